@@ -518,14 +518,26 @@ func (pdfAttachmentReader) CanRead(meta AttachmentMeta) bool {
 }
 
 func (pdfAttachmentReader) Read(data []byte, meta AttachmentMeta) (AttachmentContent, error) {
-	// Best-effort fallback only. Without a PDF parser we can only recover
-	// printable ASCII runs from the raw bytes, which may miss layout and text.
+	pages, err := readPDFText(data)
+	if err == nil {
+		if text, joinErr := joinPDFPages(pages); joinErr == nil {
+			return AttachmentContent{
+				Text:     text,
+				Metadata: map[string]string{"type": "application/pdf", "strategy": "content-streams", "pages": strconv.Itoa(len(pages))},
+			}, nil
+		}
+	}
+	// Fall back to printable ASCII runs from the raw bytes when the document
+	// cannot be parsed or carries no extractable text.
 	runs := printableASCIIRuns(data, 6)
 	content := AttachmentContent{
 		Text:     strings.Join(runs, "\n"),
 		Metadata: map[string]string{"type": "application/pdf", "strategy": "printable-ascii-runs"},
 		Warnings: []string{"PDF text extraction is best-effort only and may miss content or ordering"},
 	}
+	if err != nil {
+		content.Warnings = append(content.Warnings, "PDF could not be parsed: "+err.Error())
+	}
 	if content.Text == "" {
 		content.Warnings = append(content.Warnings, "no printable ASCII text runs were found in the PDF bytes")
 	}
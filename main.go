@@ -175,17 +175,26 @@ func main() {
 			}
 			return
 		}
-		if len(args) > 2 {
+		opts, err := parseOpenArgs(args[1:])
+		if err != nil {
 			if err := writeHelp(os.Stderr, "open"); err != nil {
 				fmt.Fprintln(os.Stderr, err)
 			}
 			os.Exit(1)
 		}
-		target := ""
-		if len(args) == 2 {
-			target = strings.TrimSpace(args[1])
+		switch {
+		case opts.listSessions:
+			err = jotOpenListSessions(os.Stdout, time.Now)
+		case opts.close:
+			err = jotOpenClose(os.Stdout, opts.target)
+		case opts.recursive:
+			err = jotOpenRecursive(opts.target)
+		case opts.edit:
+			err = jotOpenEdit(opts.target)
+		default:
+			err = jotOpen(os.Stdout, opts.target)
 		}
-		if err := jotOpen(os.Stdout, target); err != nil {
+		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -271,6 +280,36 @@ func main() {
 		return
 	}
 
+	if len(args) >= 1 && args[0] == "extract" {
+		if err := jotExtract(os.Stdout, args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				if err := writeHelp(os.Stdout, "extract"); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) >= 1 && args[0] == "render" {
+		if err := jotRender(os.Stdout, args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				if err := writeHelp(os.Stdout, "render"); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(args) >= 1 && args[0] == "timestamp" {
 		if err := jotTimestamp(os.Stdout, args[1:], time.Now); err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -303,6 +342,30 @@ func main() {
 		return
 	}
 
+	if len(args) >= 1 && args[0] == "merge" {
+		if err := jotMerge(os.Stdout, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) >= 1 && args[0] == "patch" {
+		if err := jotPatch(os.Stdout, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) >= 1 && args[0] == "pdf" {
+		if err := jotPDF(os.Stdout, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(args) >= 1 && args[0] == "rename" {
 		if err := jotRename(os.Stdout, args[1:]); err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -534,6 +597,10 @@ func renderHelp(topic string, color bool) (string, error) {
 		return renderHashHelp(color), nil
 	case "compress":
 		return renderCompressHelp(color), nil
+	case "extract":
+		return renderExtractHelp(color), nil
+	case "render":
+		return renderRenderHelp(color), nil
 	case "timestamp":
 		return renderTimestampHelp(color), nil
 	case "uuid":
@@ -542,6 +609,12 @@ func renderHelp(topic string, color bool) (string, error) {
 		return renderResizeHelp(color), nil
 	case "diff":
 		return renderDiffHelp(color), nil
+	case "merge":
+		return renderMergeHelp(color), nil
+	case "patch":
+		return renderPatchHelp(color), nil
+	case "pdf":
+		return renderPDFHelp(color), nil
 	case "rename":
 		return renderRenameHelp(color), nil
 	case "qr":
@@ -591,17 +664,22 @@ func renderMainHelp(color bool) string {
 	writeCommandSection(&b, style, []helpCommand{
 		{name: "init", description: "Open the quick prompt and append one journal entry."},
 		{name: "open", description: "Print a jot entry by id, or pick and open a local file."},
-		{name: "write", description: "Open a markdown file in jot's terminal editor with syntax highlighting."},
+		{name: "write", description: "Open a markdown file or journal entry in jot's terminal editor with syntax highlighting."},
 		{name: "capture", description: "Capture a structured note with title, tags, project, and repo context."},
 		{name: "convert", description: "Convert a local image into `.ico` or `.svg` without leaving the terminal."},
 		{name: "minify", description: "Minify or pretty-print local JSON from files, text, or stdin."},
 		{name: "encode", description: "Base64 encode or decode local files, text, or stdin."},
 		{name: "hash", description: "Compute or verify MD5, SHA1, SHA256, and SHA512 digests."},
 		{name: "compress", description: "Create local zip, tar, or tar.gz archives from files and folders."},
+		{name: "extract", description: "List or unpack zip, tar, and tar.gz archives with path-safety checks."},
+		{name: "render", description: "Export Markdown and structured files to standalone HTML or PDF."},
 		{name: "timestamp", description: "Convert Unix timestamps and human-readable dates in the terminal."},
 		{name: "uuid", description: "Generate UUIDs, nanoids, and random strings."},
 		{name: "resize", description: "Resize local images with fit, fill, or stretch modes."},
 		{name: "diff", description: "Compare two local text files with a detailed terminal render."},
+		{name: "merge", description: "Three-way merge two edited copies of a text file with conflict markers."},
+		{name: "patch", description: "Apply or reverse unified diff patches with offset and fuzz handling."},
+		{name: "pdf", description: "Extract text from, inspect, merge, split, and rotate local PDF files."},
 		{name: "rename", description: "Preview and apply safe local renames with patterns and templates."},
 		{name: "qr", description: "Generate local QR codes as PNG, SVG, or ASCII."},
 		{name: "strip", description: "Strip metadata from local image files by re-encoding them."},
@@ -662,21 +740,39 @@ func renderInitHelp(color bool) string {
 func renderWriteHelp(color bool) string {
 	style := helpStyler{color: color}
 	var b strings.Builder
-	writeHelpHeader(&b, style, "jot write", "Open a markdown file in jot's terminal editor.")
+	writeHelpHeader(&b, style, "jot write", "Open a markdown file or journal entry in jot's terminal editor.")
 	writeUsageSection(&b, style, []string{
-		"jot write <path-to-file>",
+		"jot write [--wrap] [--preview] <path-to-file>",
+		"jot write [--wrap] [--preview] --entry [entry-id]",
+		"jot write <entry-id>",
 	}, []string{
 		"Shows one full-screen markdown editor with syntax highlighting and line numbers.",
 		"Works on any .md file, whether it already exists or not.",
+		"With --entry, or given the id of a journal entry, edits a new or existing entry instead; saving stores it in the journal, titled by its first heading and tagged with its inline #hashtags.",
 		"Pair with `jot new` to create a file first.",
+		"Copies reach the system clipboard through OSC 52, so they work over SSH in terminals that allow it.",
+		"Unsaved changes are autosaved to a swap file beside the document, or under ~/.jot/swap, and offered back if the editor dies.",
 	})
 	writeFlagSection(&b, style, []helpFlag{
 		{name: "ctrl+s", description: "Save and exit."},
 		{name: "ctrl+q", description: "Quit without saving."},
+		{name: "ctrl+z", description: "Undo the last word, run of deletes, or command."},
+		{name: "ctrl+y", description: "Redo what ctrl+z undid."},
+		{name: "ctrl+f", description: "Search as you type; enter or down for the next match, up for the previous, ctrl+r for regex."},
+		{name: "alt+r", description: "Search and replace, confirming each match (y/n) or all at once (a)."},
+		{name: "ctrl+g", description: "Go to a line, or line:col."},
+		{name: "alt+z", description: "Toggle soft wrap at word boundaries; --wrap starts with it on."},
+		{name: "ctrl+p", description: "Toggle a rendered preview beside the source; --preview starts with it open."},
+		{name: "shift+arrows", description: "Select text; ctrl+shift+left/right selects by word."},
+		{name: "ctrl+w", description: "Select the word under the cursor; again to extend by a word."},
+		{name: "ctrl+a", description: "Select the current line; again to extend by a line."},
+		{name: "ctrl+c", description: "Copy the selection, or the current line, to the kill ring and system clipboard."},
+		{name: "ctrl+x", description: "Cut the selection, or the current line."},
+		{name: "ctrl+v", description: "Paste the newest kill ring entry; alt+y right after swaps in an older one."},
 		{name: "ctrl+b", description: "Insert **bold** markers at the cursor."},
 		{name: "ctrl+e", description: "Insert _italic_ markers at the cursor."},
 		{name: "ctrl+k", description: "Insert `inline code` markers at the cursor."},
-		{name: "ctrl+g", description: "Insert a fenced code block below the cursor."},
+		{name: "ctrl+t", description: "Insert a fenced code block below the cursor."},
 		{name: "ctrl+l", description: "Turn current line into a - list item."},
 		{name: "ctrl+r", description: "Insert a --- horizontal rule below the cursor."},
 		{name: `ctrl+\`, description: "Turn the current line into an # h1 heading."},
@@ -687,6 +783,7 @@ func renderWriteHelp(color bool) string {
 		"jot new --template rfc -n \"auth refactor\"",
 		"jot write 2026-03-22-rfc-auth-refactor.md",
 		"jot write README.md",
+		"jot write --entry",
 	})
 	return b.String()
 }
@@ -807,21 +904,41 @@ func renderOpenHelp(color bool) string {
 	writeUsageSection(&b, style, []string{
 		"jot open",
 		"jot open .",
+		"jot open . --recursive",
+		"jot open <file.md> --edit",
 		"jot open <id>",
 		"jot open <path-to-file>",
+		"jot open <archive>",
+		"jot open --list-sessions",
+		"jot open --close <id|all>",
 	}, []string{
 		"`jot open` with no argument shows a native file picker.",
 		"Use this when `jot list` shows a `jot open <id>` hint for a truncated preview.",
 		"Ids stay available for explicit lookup without cluttering the normal list view.",
 		"If a local `.pdf`, `.md`, `.markdown`, `.json`, `.xml`, `.yaml`, `.yml`, `.toml`, `.csv`, `.env`, `.txt`, `.log`, or `.jsonl` file is selected, jot opens it in a jot-owned viewer window when available.",
+		"Images (`.png`, `.jpg`, `.gif`, `.webp`, `.svg`) open with zoom and pan, EXIF and PNG metadata, and the dominant color palette.",
+		"Jupyter notebooks (`.ipynb`) render markdown cells, numbered code cells, and stored text, HTML, and image outputs, with an Export HTML link for a standalone copy.",
+		"SQLite databases (`.db`, `.sqlite`, `.sqlite3`) open read-only with a table list, schema, paged rows, and a query box that runs SELECT statements against one table.",
 		"If no dedicated viewer window host is found, jot falls back to the normal browser.",
+		"Files open as tabs of one viewer window, served by a single background viewer that starts with the first `jot open` and exits after 15 minutes without use. Opening a file that already has a tab reloads that tab.",
+		"`--list-sessions` lists the open tabs with their ids; `--close <id>` closes one, and `--close all` closes every tab and stops the background viewer.",
+		"Each viewer session listens on 127.0.0.1 and only answers the window opened from the URL it prints, which carries a per-session `token`; other pages and tabs are refused.",
+		"`.log` files open in a log view that detects logfmt, JSON lines, syslog, and Go log output, colors each level, adds level and source filter chips, and can follow new lines like `tail -f`.",
+		"Text, log, JSONL, and CSV files over 8 MB open in a streamed view that pages lines on demand and searches the whole file on the server.",
 		"Other existing files are opened with the system default app.",
 		// Add to notes:
-		"`jot open .` opens a folder browser for the current directory.",
+		"`jot open .` opens a folder browser for the current directory; folders with images get a thumbnail gallery.",
+		"`--recursive` (`-r`) includes every subfolder as a collapsible tree, skipping `.git` and paths matched by `.gitignore`. Press Ctrl+P to fuzzy-find a file, or use the search box to search the text of every file on the server.",
+		"`--edit` (`-e`) opens a Markdown file in a split-pane editor with a live preview. Ctrl+S saves back to disk; if the file changed on disk since it was loaded, jot asks before overwriting it. A missing file is created on the first save.",
+		"`.zip`, `.tar`, `.tar.gz`, and `.tgz` archives open as a browsable tree; Markdown, JSON, CSV, text, images, and PDFs preview in place without unpacking.",
 	})
 	writeExamplesSection(&b, style, []string{
 		"jot open",
 		"jot open .",
+		"jot open docs --recursive",
+		"jot open notes.md --edit",
+		"jot open --list-sessions",
+		"jot open --close 2",
 		"jot open dg0ftbuoqqdc-62",
 		"jot open note:2026-03-19-daily.md",
 		`jot open ".\docs\paper.pdf"`,
@@ -830,6 +947,9 @@ func renderOpenHelp(color bool) string {
 		`jot open ".\infra\docker-compose.yaml"`,
 		`jot open ".\data\report.csv"`,
 		`jot open ".\notes\todo.txt"`,
+		`jot open ".\analysis\churn.ipynb"`,
+		`jot open ".\data\app.db"`,
+		`jot open ".\downloads\bundle.zip"`,
 	})
 	return b.String()
 }
@@ -884,9 +1004,13 @@ func renderTaskHelp(color bool) string {
 		"jot task qr",
 		"jot task strip",
 		"jot task palette",
+		"jot task pdf-merge",
+		"jot task pdf-split",
+		"jot task pdf-pages",
+		"jot task pdf-rotate",
 	}, []string{
 		"`jot task` is the guided front door for jot's task layer.",
-		"Available guided tasks today include image conversion, JSON minify, base64 encode/decode, hashing, compression, timestamp conversion, ID generation, resize, diff, rename, QR generation, metadata strip, and palette extraction.",
+		"Available guided tasks today include image conversion, JSON minify, base64 encode/decode, hashing, compression, timestamp conversion, ID generation, resize, diff, rename, QR generation, metadata strip, palette extraction, and PDF merge, split, page extraction, and rotation.",
 		"After a task runs, jot prints the equivalent direct command so the terminal shortcut becomes the habit.",
 	})
 	writeExamplesSection(&b, style, []string{
@@ -1148,6 +1272,50 @@ func jotList(w io.Writer, full bool) error {
 	return writeListItemsTTY(w, items, full)
 }
 
+type openOptions struct {
+	target       string
+	recursive    bool
+	edit         bool
+	listSessions bool
+	close        bool
+}
+
+// parseOpenArgs accepts one optional target and the --recursive, --edit,
+// --list-sessions, and --close flags. With --close the target is a session id
+// or "all".
+func parseOpenArgs(args []string) (openOptions, error) {
+	var opts openOptions
+	for _, arg := range args {
+		switch {
+		case arg == "--recursive" || arg == "-r":
+			opts.recursive = true
+		case arg == "--edit" || arg == "-e":
+			opts.edit = true
+		case arg == "--list-sessions":
+			opts.listSessions = true
+		case arg == "--close":
+			opts.close = true
+		case opts.target == "":
+			opts.target = strings.TrimSpace(arg)
+		default:
+			return openOptions{}, errors.New("jot open takes a single target")
+		}
+	}
+	if opts.recursive && opts.edit {
+		return openOptions{}, errors.New("--edit opens a single file and cannot be combined with --recursive")
+	}
+	if opts.listSessions && (opts.close || opts.recursive || opts.edit || opts.target != "") {
+		return openOptions{}, errors.New("--list-sessions takes no target or other flags")
+	}
+	if opts.close && (opts.recursive || opts.edit) {
+		return openOptions{}, errors.New("--close cannot be combined with --recursive or --edit")
+	}
+	if opts.close && opts.target == "" {
+		return openOptions{}, errors.New("--close needs a session id from `jot open --list-sessions`, or all")
+	}
+	return opts, nil
+}
+
 func jotOpen(w io.Writer, target string) error {
 	return jotOpenWithHandlers(w, target, openURLInViewerWindow, openPathWithDefaultApp, pickFileInteractively)
 }
@@ -1211,13 +1379,18 @@ func openLocalPathWithViewerLauncher(target string, openURL func(string) error,
 	if info.IsDir() {
 		return true, launchViewer(absPath, openURL)
 	}
-	if viewerDocumentTypeForPath(absPath) != viewerDocumentTypeUnknown {
+	if viewerDocumentTypeForPath(absPath) != viewerDocumentTypeUnknown || archiveFormatForPath(absPath) != "" {
 		return true, launchViewer(absPath, openURL)
 	}
 	return true, openPath(absPath)
 }
 
+// launchLocalFileInViewer opens path as a tab of the viewer daemon, or in a
+// viewer process of its own when no daemon can be reached.
 func launchLocalFileInViewer(path string, openURL func(string) error) error {
+	if handled, err := openInViewerDaemon([]string{path}, openURL, spawnViewerDaemon); handled {
+		return err
+	}
 	return launchLocalFileInViewerWithProcess(path, openURL, os.Executable, startViewerProcess)
 }
 
@@ -1240,11 +1413,17 @@ func launchLocalFileInViewerWithProcess(path string, openURL func(string) error,
 }
 
 func startViewerProcess(executablePath string, filePath string) (string, error) {
+	return startViewerProcessWithArgs(executablePath, "--no-self-open", filePath)
+}
+
+// startViewerProcessWithArgs starts `jot __viewer <args>` and returns the URL
+// the child prints once its server is listening.
+func startViewerProcessWithArgs(executablePath string, args ...string) (string, error) {
 	launchPath, cleanupPath, err := prepareViewerExecutableForLaunch(executablePath, runtime.GOOS, os.TempDir, copyFile)
 	if err != nil {
 		return "", err
 	}
-	cmd := exec.Command(launchPath, "__viewer", "--no-self-open", filePath)
+	cmd := exec.Command(launchPath, append([]string{"__viewer"}, args...)...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", err
@@ -1358,22 +1537,67 @@ func parseViewerServeArgs(args []string) (path string, selfOpen bool, err error)
 }
 
 func jotServeViewer(w io.Writer, args []string, now func() time.Time) error {
-	path, selfOpen, err := parseViewerServeArgs(args)
+	if viewerServeArgsRequestDaemon(args) {
+		return jotServeViewerDaemon(w, args, now)
+	}
+	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
-	info, err := os.Stat(path)
+	session, selfOpen, err := viewerSessionForArgs(args, cwd)
 	if err != nil {
 		return err
 	}
-	if info.IsDir() {
-		return serveFolderViewer(w, path, 15*time.Minute, now, selfOpen)
+	return serveViewerHandler(w, session.newHandler, 15*time.Minute, now, selfOpen)
+}
+
+// viewerSession is one document a viewer serves: the title shown on its tab
+// and the handler that serves it.
+type viewerSession struct {
+	title      string
+	newHandler func(touch func()) http.Handler
+}
+
+// viewerSessionForArgs builds the session for `__viewer` arguments and reports
+// whether the viewer should open its own window. Relative paths resolve
+// against cwd, which is the caller's directory when the viewer daemon serves
+// the request.
+func viewerSessionForArgs(args []string, cwd string) (viewerSession, bool, error) {
+	if viewerServeArgsRequestDiff(args) {
+		return diffViewerSession(args, cwd)
+	}
+	if viewerServeArgsRequestRecursive(args) {
+		return folderTreeViewerSession(args)
+	}
+	if viewerServeArgsRequestEdit(args) {
+		return editorViewerSession(args)
 	}
-	return serveFileViewer(w, path, 15*time.Minute, now, selfOpen)
+	path, selfOpen, err := parseViewerServeArgs(args)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	var session viewerSession
+	switch {
+	case info.IsDir():
+		session, err = folderViewerSession(path)
+	case archiveFormatForPath(path) != "":
+		session, err = archiveViewerSession(path)
+	default:
+		session, err = fileViewerSession(path)
+	}
+	return session, selfOpen, err
 }
 
 type folderFile struct {
 	Name    string `json:"name"`
+	Rel     string `json:"rel,omitempty"`
 	Path    string `json:"path"`
 	DocType string `json:"docType"`
 }
@@ -1402,85 +1626,40 @@ func scanFolderFiles(dir string) ([]folderFile, error) {
 	return files, nil
 }
 
-func serveFolderViewer(w io.Writer, dir string, idleTimeout time.Duration, now func() time.Time, selfOpen bool) error {
+func folderViewerSession(dir string) (viewerSession, error) {
 	files, err := scanFolderFiles(dir)
 	if err != nil {
-		return err
+		return viewerSession{}, err
 	}
 	if len(files) == 0 {
-		return fmt.Errorf("no supported files found in %s", dir)
-	}
-
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		return err
-	}
-
-	var mu sync.Mutex
-	lastAccess := now()
-	touch := func() {
-		mu.Lock()
-		lastAccess = now()
-		mu.Unlock()
-	}
-
-	server := &http.Server{
-		Handler: newFolderViewerHandler(dir, files, touch),
-	}
-
-	serverErr := make(chan error, 1)
-	go func() {
-		err := server.Serve(listener)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			serverErr <- err
-			return
-		}
-		serverErr <- nil
-	}()
-
-	addr := listener.Addr().(*net.TCPAddr)
-	viewerURL := fmt.Sprintf("http://127.0.0.1:%d/", addr.Port)
-
-	if _, err := fmt.Fprintln(w, viewerURL); err != nil {
-		_ = server.Close()
-		<-serverErr
-		return err
-	}
-	if file, ok := w.(*os.File); ok {
-		_ = file.Sync()
-	}
-	if selfOpen {
-		_ = openURLInViewerWindow(viewerURL)
+		return viewerSession{}, fmt.Errorf("no supported files found in %s", dir)
 	}
+	return folderFilesSession(dir, files), nil
+}
 
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-	for {
-		select {
-		case err := <-serverErr:
-			return err
-		case <-ticker.C:
-			mu.Lock()
-			idle := now().Sub(lastAccess)
-			mu.Unlock()
-			if idle < idleTimeout {
-				continue
-			}
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			err := server.Shutdown(ctx)
-			cancel()
-			serveErr := <-serverErr
-			if err != nil && !errors.Is(err, context.Canceled) {
-				return err
-			}
-			return serveErr
-		}
+func folderFilesSession(dir string, files []folderFile) viewerSession {
+	return viewerSession{
+		title: filepath.Base(dir),
+		newHandler: func(touch func()) http.Handler {
+			return newFolderViewerHandler(dir, files, touch)
+		},
 	}
 }
 
 func newFolderViewerHandler(dir string, files []folderFile, touch func()) http.Handler {
 	const logoPath = "/logo.png"
 	mux := http.NewServeMux()
+	target := func(r *http.Request) (string, viewerDocumentType, bool) {
+		idx, err := strconv.Atoi(r.URL.Query().Get("i"))
+		if err != nil || idx < 0 || idx >= len(files) {
+			return "", viewerDocumentTypeUnknown, false
+		}
+		return files[idx].Path, viewerDocumentType(files[idx].DocType), true
+	}
+	registerViewerStreamRoutes(mux, newViewerStreamCache(), target, touch)
+	registerViewerLogRoutes(mux, target, touch)
+	registerViewerSQLiteRoutes(mux, target, touch)
+	registerViewerFolderSearchRoute(mux, files, touch)
 
 	// Main page — renders the folder browser shell
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -1515,6 +1694,12 @@ func newFolderViewerHandler(dir string, files []folderFile, touch func()) http.H
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if doc.image != nil {
+			doc.image.src = fmt.Sprintf("%s?i=%d", viewerImagePath, idx)
+		}
+		if doc.notebook != nil {
+			doc.notebook.exportHref = fmt.Sprintf("/export?i=%d", idx)
+		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = io.WriteString(w, renderFolderDocumentContent(doc))
 	})
@@ -1538,11 +1723,56 @@ func newFolderViewerHandler(dir string, files []folderFile, touch func()) http.H
 		http.ServeFile(w, r, f.Path)
 	})
 
+	// Standalone HTML export of a notebook
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		idx, err := strconv.Atoi(r.URL.Query().Get("i"))
+		if err != nil || idx < 0 || idx >= len(files) {
+			http.Error(w, "invalid index", http.StatusBadRequest)
+			return
+		}
+		doc, err := loadViewerDocument(files[idx].Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if doc.notebook == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", notebookExportName(doc.fileName)))
+		_, _ = io.WriteString(w, renderViewerStandaloneHTML(doc))
+	})
+
+	// Image bytes endpoint, used by the image viewer and the gallery grid
+	mux.HandleFunc(viewerImagePath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		idx, err := strconv.Atoi(r.URL.Query().Get("i"))
+		if err != nil || idx < 0 || idx >= len(files) {
+			http.Error(w, "invalid index", http.StatusBadRequest)
+			return
+		}
+		f := files[idx]
+		if f.DocType != string(viewerDocumentTypeImage) {
+			http.NotFound(w, r)
+			return
+		}
+		file, err := os.Open(f.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+		serveViewerImage(w, r, f.Path, file)
+	})
+
 	return mux
 }
 
 type folderFileJS struct {
 	Name    string `json:"name"`
+	Rel     string `json:"rel,omitempty"`
 	DocType string `json:"docType"`
 }
 
@@ -1554,7 +1784,7 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
 	// Build sidebar items JSON for the folder sidebar.
 	var jsFiles []folderFileJS
 	for _, f := range files {
-		jsFiles = append(jsFiles, folderFileJS{Name: f.Name, DocType: f.DocType})
+		jsFiles = append(jsFiles, folderFileJS{Name: f.Name, Rel: f.Rel, DocType: f.DocType})
 	}
 	filesJSONBytes, _ := json.Marshal(jsFiles)
 	filesJSON := string(filesJSONBytes)
@@ -1636,6 +1866,7 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
     .icon-json { background: rgba(184,92,26,0.1);  color: #b85c1a; }
     .icon-xml  { background: rgba(45,125,68,0.1);  color: #2d7d44; }
     .icon-pdf  { background: rgba(180,30,30,0.1);  color: #b41e1e; }
+    .icon-img  { background: rgba(120,60,170,0.1); color: #783caa; }
     .item-name {
       font-size: 12.5px; color: rgba(26,26,24,0.7);
       white-space: nowrap; overflow: hidden; text-overflow: ellipsis;
@@ -1649,6 +1880,39 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
       background: #f7f6f3;
     }
 
+    /* Gallery grid */
+    .gallery {
+      display: grid;
+      grid-template-columns: repeat(auto-fill, minmax(160px, 1fr));
+      gap: 14px;
+      padding: 18px;
+    }
+    .gallery-tile {
+      border: 0.5px solid rgba(0,0,0,0.08);
+      border-radius: 10px;
+      background: rgba(252,251,249,0.97);
+      overflow: hidden;
+      cursor: pointer;
+      transition: box-shadow 0.12s, transform 0.12s;
+    }
+    .gallery-tile:hover { box-shadow: 0 4px 14px rgba(0,0,0,0.08); transform: translateY(-1px); }
+    .gallery-thumb {
+      height: 130px;
+      display: flex; align-items: center; justify-content: center;
+      background-color: #eeece7;
+      background-image:
+        linear-gradient(45deg, rgba(0,0,0,0.05) 25%%, transparent 25%%, transparent 75%%, rgba(0,0,0,0.05) 75%%),
+        linear-gradient(45deg, rgba(0,0,0,0.05) 25%%, transparent 25%%, transparent 75%%, rgba(0,0,0,0.05) 75%%);
+      background-size: 16px 16px;
+      background-position: 0 0, 8px 8px;
+    }
+    .gallery-thumb img { max-width: 100%%; max-height: 100%%; object-fit: contain; display: block; }
+    .gallery-name {
+      padding: 7px 10px;
+      font-size: 12px; color: rgba(26,26,24,0.7);
+      white-space: nowrap; overflow: hidden; text-overflow: ellipsis;
+    }
+
     /* Loading state */
     .loading {
       display: flex; align-items: center; justify-content: center;
@@ -1663,6 +1927,7 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
     .loading-dot:nth-child(2) { animation-delay: 0.2s; }
     .loading-dot:nth-child(3) { animation-delay: 0.4s; }
     @keyframes pulse { 0%%,80%%,100%% { opacity: 0.3; } 40%% { opacity: 1; } }
+%s
 
     @media (max-width: 600px) {
       .layout { grid-template-columns: 160px 1fr; }
@@ -1683,6 +1948,7 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
   <div class="layout">
     <div class="sidebar">
       <div class="sidebar-header">Files</div>
+      <input class="sidebar-search" id="searchInput" type="search" placeholder="Search all files" autocomplete="off">
       <div class="sidebar-list" id="sidebarList"></div>
     </div>
     <div class="content-area" id="contentArea">
@@ -1693,6 +1959,12 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
       </div>
     </div>
   </div>
+  <div class="finder" id="finder" hidden>
+    <div class="finder-box">
+      <input id="finderInput" placeholder="Go to file…" autocomplete="off">
+      <div class="finder-list" id="finderList"></div>
+    </div>
+  </div>
 <script>
 (function() {
   var files = %s;
@@ -1702,40 +1974,244 @@ func renderFolderPage(dir string, files []folderFile, logoPath string) string {
   document.getElementById('fileCount').textContent =
     files.length + ' file' + (files.length !== 1 ? 's' : '');
 
-  var icons  = {markdown:'icon-md', json:'icon-json', xml:'icon-xml', yaml:'icon-json', toml:'icon-json', csv:'icon-json', env:'icon-json', text:'icon-md', pdf:'icon-pdf'};
-  var labels = {markdown:'md', json:'json', xml:'xml', yaml:'yaml', toml:'toml', csv:'csv', env:'env', text:'txt', pdf:'pdf'};
+  var icons  = {markdown:'icon-md', json:'icon-json', xml:'icon-xml', yaml:'icon-json', toml:'icon-json', csv:'icon-json', env:'icon-json', text:'icon-md', log:'icon-md', pdf:'icon-pdf', image:'icon-img', notebook:'icon-json', sqlite:'icon-json'};
+  var labels = {markdown:'md', json:'json', xml:'xml', yaml:'yaml', toml:'toml', csv:'csv', env:'env', text:'txt', log:'log', pdf:'pdf', image:'img', notebook:'nb', sqlite:'db'};
+  var images = [];
+  files.forEach(function(f, i) { if (f.docType === 'image') images.push(i); });
 
+  function esc(s) { return s.replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/"/g,'&quot;'); }
+
+  if (images.length > 0) {
+    var g = document.createElement('div');
+    g.className = 'sidebar-item';
+    g.id = 'item-gallery';
+    g.innerHTML = '<span class="item-icon icon-img">grid</span><span class="item-name">Gallery (' + images.length + ')</span>';
+    g.addEventListener('click', showGallery);
+    sl.appendChild(g);
+  }
+
+  // Files are grouped into a tree by their relative path; a flat folder has
+  // no directories and renders as a plain list.
+  var tree = {dirs: {}, files: []};
   files.forEach(function(f, i) {
-    var el = document.createElement('div');
-    el.className = 'sidebar-item';
-    el.id = 'item-' + i;
-    el.innerHTML =
-      '<span class="item-icon ' + (icons[f.docType]||'icon-md') + '">' +
-      (labels[f.docType]||'?') + '</span>' +
-      '<span class="item-name">' +
-      f.name.replace(/&/g,'&amp;').replace(/</g,'&lt;') + '</span>';
-    el.addEventListener('click', function() { load(i); });
-    sl.appendChild(el);
+    var parts = (f.rel || f.name).split('/');
+    var node = tree;
+    for (var k = 0; k < parts.length - 1; k++) {
+      node = node.dirs[parts[k]] || (node.dirs[parts[k]] = {name: parts[k], dirs: {}, files: []});
+    }
+    node.files.push(i);
   });
+  var order = [];
+  function renderTree(node, parent, depth) {
+    Object.keys(node.dirs).sort().forEach(function(key) {
+      var dir = node.dirs[key];
+      var row = document.createElement('div');
+      row.className = 'sidebar-item tree-dir' + (depth > 0 ? ' collapsed' : '');
+      row.style.paddingLeft = (14 + depth * 14) + 'px';
+      row.innerHTML = '<span class="tree-caret">▾</span><span class="item-name">' + esc(dir.name) + '</span>';
+      var kids = document.createElement('div');
+      kids.className = 'tree-children';
+      kids.hidden = depth > 0;
+      row.addEventListener('click', function() {
+        kids.hidden = !kids.hidden;
+        row.classList.toggle('collapsed', kids.hidden);
+      });
+      parent.appendChild(row);
+      parent.appendChild(kids);
+      renderTree(dir, kids, depth + 1);
+    });
+    node.files.forEach(function(i) {
+      var f = files[i];
+      var el = document.createElement('div');
+      el.className = 'sidebar-item';
+      el.id = 'item-' + i;
+      el.style.paddingLeft = (14 + depth * 14) + 'px';
+      el.innerHTML =
+        '<span class="item-icon ' + (icons[f.docType]||'icon-md') + '">' +
+        (labels[f.docType]||'?') + '</span>' +
+        '<span class="item-name">' +
+        f.name.replace(/&/g,'&amp;').replace(/</g,'&lt;') + '</span>';
+      el.addEventListener('click', function() { load(i); });
+      parent.appendChild(el);
+      order.push(i);
+    });
+  }
+  renderTree(tree, sl, 0);
+
+  function reveal(el) {
+    for (var p = el.parentElement; p && p !== sl; p = p.parentElement) {
+      if (p.classList.contains('tree-children') && p.hidden) {
+        p.hidden = false;
+        p.previousElementSibling.classList.remove('collapsed');
+      }
+    }
+  }
+
+  function showGallery() {
+    if (cur === 'gallery') return;
+    if (cur !== -1) {
+      var p = document.getElementById('item-' + cur);
+      if (p) p.classList.remove('active');
+    }
+    cur = 'gallery';
+    document.getElementById('item-gallery').classList.add('active');
+    var html = '<div class="gallery">';
+    images.forEach(function(i) {
+      html += '<div class="gallery-tile" data-i="' + i + '"><div class="gallery-thumb">' +
+        '<img loading="lazy" src="/image?i=' + i + '" alt="' + esc(files[i].name) + '"></div>' +
+        '<div class="gallery-name">' + esc(files[i].name) + '</div></div>';
+    });
+    ca.innerHTML = html + '</div>';
+    ca.querySelectorAll('.gallery-tile').forEach(function(tile) {
+      tile.addEventListener('click', function() { load(+tile.getAttribute('data-i')); });
+    });
+  }
 
   function load(i) {
     if (i === cur) return;
-    if (cur >= 0) {
+    if (cur !== -1) {
       var p = document.getElementById('item-' + cur);
       if (p) p.classList.remove('active');
     }
     cur = i;
     var n = document.getElementById('item-' + i);
-    if (n) { n.classList.add('active'); n.scrollIntoView({block:'nearest'}); }
+    if (n) { reveal(n); n.classList.add('active'); n.scrollIntoView({block:'nearest'}); }
     ca.innerHTML = '<iframe style="width:100%%;height:100%%;border:none;display:block;" src="/file?i=' + i + '"></iframe>';
+    var frame = ca.querySelector('iframe');
+    frame.addEventListener('load', function() {
+      try { frame.contentDocument.addEventListener('keydown', onKey); } catch (e) {}
+    });
   }
 
-  if (files.length > 0) load(0);
+  // Ctrl+P: fuzzy-find any file by its path.
+  var finder = document.getElementById('finder');
+  var finderInput = document.getElementById('finderInput');
+  var finderList = document.getElementById('finderList');
+  var matches = [], picked = 0;
+  function fuzzyScore(query, text) {
+    var lower = text.toLowerCase(), base = lower.lastIndexOf('/') + 1;
+    var score = 0, from = 0, run = 0, hits = [];
+    for (var k = 0; k < query.length; k++) {
+      var c = query.charAt(k);
+      if (c === ' ') continue;
+      var at = lower.indexOf(c, from);
+      if (at < 0) return null;
+      run = at === from ? run + 1 : 0;
+      score += 1 + run * 2;
+      if (at === 0 || '/_-. '.indexOf(lower.charAt(at - 1)) >= 0) score += 3;
+      if (at >= base) score += 1;
+      hits.push(at);
+      from = at + 1;
+    }
+    return {score: score * 1000 - text.length, hits: hits};
+  }
+  function highlight(text, hits) {
+    var out = '', h = 0;
+    for (var k = 0; k < text.length; k++) {
+      var ch = esc(text.charAt(k));
+      if (hits[h] === k) { out += '<b>' + ch + '</b>'; h++; } else out += ch;
+    }
+    return out;
+  }
+  function renderFinder() {
+    var query = finderInput.value.toLowerCase();
+    matches = [];
+    order.forEach(function(i) {
+      var rel = files[i].rel || files[i].name;
+      var m = query ? fuzzyScore(query, rel) : {score: 0, hits: []};
+      if (m) matches.push({i: i, rel: rel, score: m.score, hits: m.hits});
+    });
+    if (query) matches.sort(function(a, b) { return b.score - a.score; });
+    matches = matches.slice(0, 50);
+    picked = 0;
+    finderList.innerHTML = matches.map(function(m, k) {
+      var cut = m.rel.lastIndexOf('/') + 1;
+      var nameHits = m.hits.filter(function(h) { return h >= cut; }).map(function(h) { return h - cut; });
+      return '<div class="finder-item' + (k === 0 ? ' active' : '') + '" data-k="' + k + '">' +
+        '<span class="finder-name">' + highlight(m.rel.slice(cut), nameHits) + '</span>' +
+        '<span class="finder-dir">' + esc(m.rel.slice(0, cut)) + '</span></div>';
+    }).join('');
+  }
+  function pick(k) {
+    var items = finderList.querySelectorAll('.finder-item');
+    if (!items.length) return;
+    picked = (k + items.length) %% items.length;
+    items.forEach(function(el, n) { el.classList.toggle('active', n === picked); });
+    items[picked].scrollIntoView({block: 'nearest'});
+  }
+  function openFinder() {
+    finder.hidden = false;
+    finderInput.value = '';
+    renderFinder();
+    finderInput.focus();
+  }
+  function closeFinder() { finder.hidden = true; }
+  finderInput.addEventListener('input', renderFinder);
+  finderInput.addEventListener('keydown', function(e) {
+    if (e.key === 'ArrowDown') { e.preventDefault(); pick(picked + 1); }
+    else if (e.key === 'ArrowUp') { e.preventDefault(); pick(picked - 1); }
+    else if (e.key === 'Enter' && matches[picked]) { closeFinder(); load(matches[picked].i); }
+    else if (e.key === 'Escape') closeFinder();
+  });
+  finderList.addEventListener('click', function(e) {
+    var item = e.target.closest('.finder-item');
+    if (item) { closeFinder(); load(matches[+item.getAttribute('data-k')].i); }
+  });
+  finder.addEventListener('click', function(e) { if (e.target === finder) closeFinder(); });
+  function onKey(e) {
+    if ((e.ctrlKey || e.metaKey) && (e.key === 'p' || e.key === 'P')) { e.preventDefault(); openFinder(); }
+  }
+  document.addEventListener('keydown', onKey);
+
+  // Full-text search runs on the server across every listed file.
+  var searchInput = document.getElementById('searchInput');
+  function mark(text, query) {
+    var lower = text.toLowerCase(), q = query.toLowerCase(), out = '', from = 0, at;
+    while (q && (at = lower.indexOf(q, from)) >= 0) {
+      out += esc(text.slice(from, at)) + '<mark>' + esc(text.slice(at, at + q.length)) + '</mark>';
+      from = at + q.length;
+    }
+    return out + esc(text.slice(from));
+  }
+  function runSearch() {
+    var query = searchInput.value.trim();
+    if (!query) return;
+    if (cur !== -1) {
+      var p = document.getElementById('item-' + cur);
+      if (p) p.classList.remove('active');
+    }
+    cur = 'search';
+    ca.innerHTML = '<div class="search-results"><div class="search-summary">Searching…</div></div>';
+    fetch('/find?q=' + encodeURIComponent(query)).then(function(r) { return r.json(); }).then(function(data) {
+      if (cur !== 'search') return;
+      var html = '<div class="search-results"><div class="search-summary">' + data.total + ' match' + (data.total === 1 ? '' : 'es') +
+        ' in ' + data.files.length + ' file' + (data.files.length === 1 ? '' : 's') + ' for “' + esc(query) + '”' +
+        (data.truncated ? ' · showing the first ' + data.total : '') + '</div>';
+      data.files.forEach(function(f) {
+        html += '<div class="search-file"><div class="search-path" data-i="' + f.i + '">' + esc(f.path) + '</div>';
+        f.matches.forEach(function(m) {
+          html += '<div class="search-hit" data-i="' + f.i + '"><span class="search-line">' + (m.page ? 'p. ' + m.page : m.line) +
+            '</span><span>' + mark(m.text, query) + '</span></div>';
+        });
+        html += '</div>';
+      });
+      ca.innerHTML = html + '</div>';
+      ca.querySelectorAll('[data-i]').forEach(function(el) {
+        el.addEventListener('click', function() { load(+el.getAttribute('data-i')); });
+      });
+    });
+  }
+  searchInput.addEventListener('keydown', function(e) {
+    if (e.key === 'Enter') { e.preventDefault(); runSearch(); }
+  });
+
+  if (images.length > 0 && images.length === files.length) showGallery();
+  else if (order.length > 0) load(tree.files.length ? tree.files[0] : order[0]);
 })();
 </script>
 </body>
 </html>
-`, safeDir, safeLogoPath, safeLogoPath, safeDir, filesJSON)
+`, safeDir, safeLogoPath, viewerFolderTreeStyles, safeLogoPath, safeDir, filesJSON)
 }
 
 func renderFolderDocumentContent(doc viewerDocument) string {
@@ -1759,6 +2235,7 @@ const (
 	viewerDocumentTypeCSV      viewerDocumentType = "csv"
 	viewerDocumentTypeEnv      viewerDocumentType = "env"
 	viewerDocumentTypeText     viewerDocumentType = "text"
+	viewerDocumentTypeLog      viewerDocumentType = "log"
 )
 
 type viewerDocument struct {
@@ -1768,6 +2245,14 @@ type viewerDocument struct {
 	content           string
 	structuredContent string
 	csvTable          *viewerCSVTable
+	logView           *viewerLogView
+	diffView          *viewerDiffView
+	image             *viewerImageView
+	notebook          *viewerNotebook
+	sqlite            *viewerSQLiteView
+	editor            *viewerMarkdownEditor
+	size              int64
+	streamed          bool
 }
 
 type viewerCSVTable struct {
@@ -1798,8 +2283,16 @@ func viewerDocumentTypeForPath(path string) viewerDocumentType {
 		return viewerDocumentTypeTOML
 	case ".csv":
 		return viewerDocumentTypeCSV
-	case ".txt", ".log", ".jsonl":
+	case ".log":
+		return viewerDocumentTypeLog
+	case ".txt", ".jsonl":
 		return viewerDocumentTypeText
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg":
+		return viewerDocumentTypeImage
+	case ".ipynb":
+		return viewerDocumentTypeNotebook
+	case ".db", ".sqlite", ".sqlite3", ".db3":
+		return viewerDocumentTypeSQLite
 	default:
 		return viewerDocumentTypeUnknown
 	}
@@ -1823,15 +2316,66 @@ func loadViewerDocument(path string) (viewerDocument, error) {
 		path:     path,
 		fileName: filepath.Base(path),
 		docType:  docType,
+		size:     info.Size(),
 	}
 	if docType == viewerDocumentTypePDF {
 		return doc, nil
 	}
+	if docType == viewerDocumentTypeImage {
+		var content []byte
+		if info.Size() <= viewerImageAnalyzeLimit {
+			if content, err = os.ReadFile(path); err != nil {
+				return viewerDocument{}, err
+			}
+		}
+		doc.image = buildViewerImageView(path, content)
+		return doc, nil
+	}
+	if docType == viewerDocumentTypeSQLite {
+		doc.sqlite = loadViewerSQLiteFile(path)
+		return doc, nil
+	}
+	if viewerShouldStream(docType, info.Size()) {
+		doc.streamed = true
+		return doc, nil
+	}
 
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return viewerDocument{}, err
 	}
+	populateViewerDocument(&doc, content)
+	return doc, nil
+}
+
+// loadViewerDocumentFromBytes builds a document for content that only exists
+// in memory, such as an archive entry. It has no path, so the streaming and
+// log routes are not registered for it.
+func loadViewerDocumentFromBytes(name string, content []byte) (viewerDocument, error) {
+	docType := viewerDocumentTypeForPath(name)
+	if docType == viewerDocumentTypeUnknown {
+		return viewerDocument{}, fmt.Errorf("%s is not a supported jot viewer file", name)
+	}
+	doc := viewerDocument{
+		fileName: filepath.Base(name),
+		docType:  docType,
+		size:     int64(len(content)),
+	}
+	switch docType {
+	case viewerDocumentTypePDF:
+	case viewerDocumentTypeImage:
+		doc.image = buildViewerImageView(name, content)
+	case viewerDocumentTypeSQLite:
+		doc.sqlite = buildViewerSQLiteView(bytes.NewReader(content), int64(len(content)), false)
+	default:
+		populateViewerDocument(&doc, content)
+	}
+	return doc, nil
+}
+
+// populateViewerDocument fills the rendered forms of doc from its content.
+func populateViewerDocument(doc *viewerDocument, content []byte) {
+	docType := doc.docType
 	doc.content = normalizeViewerDocumentContent(docType, content)
 	switch docType {
 	case viewerDocumentTypeJSON:
@@ -1847,12 +2391,19 @@ func loadViewerDocument(path string) (viewerDocument, error) {
 			doc.structuredContent = payload
 		}
 	case viewerDocumentTypeCSV:
-		table, err := buildCSVTable(doc.content, 500)
+		table, err := buildCSVTable(doc.content, viewerCSVPreviewRows)
 		if err == nil {
 			doc.csvTable = table
 		}
+	case viewerDocumentTypeLog:
+		doc.logView = buildViewerLogView(doc.content, int64(len(content)))
+	case viewerDocumentTypeNotebook:
+		if notebook, err := parseViewerNotebook(content); err == nil {
+			doc.notebook = notebook
+		} else {
+			doc.structuredContent = doc.content
+		}
 	}
-	return doc, nil
 }
 
 func normalizeViewerDocumentContent(docType viewerDocumentType, content []byte) string {
@@ -1869,12 +2420,28 @@ func normalizeViewerDocumentContent(docType viewerDocumentType, content []byte)
 	return text
 }
 
-func serveFileViewer(w io.Writer, path string, idleTimeout time.Duration, now func() time.Time, selfOpen bool) error {
+func fileViewerSession(path string) (viewerSession, error) {
 	doc, err := loadViewerDocument(path)
 	if err != nil {
-		return err
+		return viewerSession{}, err
 	}
+	return viewerSession{
+		title: doc.fileName,
+		newHandler: func(touch func()) http.Handler {
+			return newFileViewerHandler(doc, touch)
+		},
+	}, nil
+}
 
+// serveViewerHandler runs a viewer session on a loopback port, prints its URL,
+// and shuts the server down after idleTimeout without requests.
+// Every request passes through the session guard, so only the window opened
+// from the printed URL can read the session.
+func serveViewerHandler(w io.Writer, newHandler func(touch func()) http.Handler, idleTimeout time.Duration, now func() time.Time, selfOpen bool) error {
+	token, err := newViewerSessionToken()
+	if err != nil {
+		return err
+	}
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return err
@@ -1889,7 +2456,7 @@ func serveFileViewer(w io.Writer, path string, idleTimeout time.Duration, now fu
 	}
 
 	server := &http.Server{
-		Handler: newFileViewerHandler(doc, touch),
+		Handler: newViewerSessionGuard(newHandler(touch), token),
 	}
 
 	serverErr := make(chan error, 1)
@@ -1903,7 +2470,7 @@ func serveFileViewer(w io.Writer, path string, idleTimeout time.Duration, now fu
 	}()
 
 	addr := listener.Addr().(*net.TCPAddr)
-	viewerURL := fmt.Sprintf("http://127.0.0.1:%d/", addr.Port)
+	viewerURL := fmt.Sprintf("http://127.0.0.1:%d/?token=%s", addr.Port, token)
 
 	// Always print the URL (terminal flow reads this via pipe)
 	if _, err := fmt.Fprintln(w, viewerURL); err != nil {
@@ -1954,6 +2521,14 @@ func newFileViewerHandler(doc viewerDocument, touch func()) http.Handler {
 	const documentPath = "/document.pdf"
 	const logoPath = "/logo.png"
 	mux := http.NewServeMux()
+	if doc.docType != viewerDocumentTypePDF && doc.path != "" {
+		target := func(r *http.Request) (string, viewerDocumentType, bool) {
+			return doc.path, doc.docType, true
+		}
+		registerViewerStreamRoutes(mux, newViewerStreamCache(), target, touch)
+		registerViewerLogRoutes(mux, target, touch)
+		registerViewerSQLiteRoutes(mux, target, touch)
+	}
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		touch()
 		if r.URL.Path != "/" {
@@ -1981,6 +2556,26 @@ func newFileViewerHandler(doc viewerDocument, touch func()) http.Handler {
 		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.fileName))
 		http.ServeFile(w, r, doc.path)
 	})
+	if doc.docType == viewerDocumentTypeImage {
+		mux.HandleFunc(viewerImagePath, func(w http.ResponseWriter, r *http.Request) {
+			touch()
+			file, err := os.Open(doc.path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer file.Close()
+			serveViewerImage(w, r, doc.path, file)
+		})
+	}
+	if doc.notebook != nil {
+		mux.HandleFunc(viewerNotebookExportPath, func(w http.ResponseWriter, r *http.Request) {
+			touch()
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", notebookExportName(doc.fileName)))
+			_, _ = io.WriteString(w, renderViewerStandaloneHTML(doc))
+		})
+	}
 	mux.HandleFunc(logoPath, func(w http.ResponseWriter, r *http.Request) {
 		touch()
 		if r.Method != http.MethodGet && r.Method != http.MethodHead {
@@ -2499,6 +3094,29 @@ body.toc-open .text-frame {
       main { padding: 10px; }
       iframe { height: calc(100vh - 68px); }
     }
+    @page { margin: 18mm 16mm; }
+    @media print {
+      :root { background: white; font-size: 12px; }
+      body { display: block; min-height: 0; }
+      header, .toc-trigger, .toc-panel, .viewer-meta button, .nb-export { display: none !important; }
+      main, body.toc-open main { padding: 0; overflow: visible; }
+      .viewer-surface { border: 0; border-radius: 0; background: white; overflow: visible; }
+      .text-frame, body.toc-open .text-frame { max-width: none; padding: 0; color: #1a1a18; font-size: 11pt; line-height: 1.55; }
+      .text-frame h1 { break-before: page; page-break-before: always; }
+      .text-frame h1:first-child { break-before: auto; page-break-before: auto; }
+      .text-frame h1, .text-frame h2, .text-frame h3, .text-frame h4 { break-after: avoid; page-break-after: avoid; }
+      .text-frame pre, .text-frame blockquote, .text-frame img, .text-frame tr { break-inside: avoid; page-break-inside: avoid; }
+      .text-frame pre { background: #f4f3f0; border-color: rgba(0, 0, 0, 0.12); white-space: pre-wrap; word-break: break-word; }
+      .text-frame pre code { color: #1a1a18; }
+      .text-frame pre[data-lang]::before { color: rgba(26, 26, 24, 0.45); }
+      .text-frame a { color: inherit; text-decoration: underline; }
+      .text-frame .table-wrap, .code-frame, .tree-view { overflow: visible; }
+      .text-frame thead { display: table-header-group; }
+      .code-frame .line-table { min-width: 0; }
+      .code-frame .lc { white-space: pre-wrap; word-break: break-all; }
+      iframe { height: auto; }
+    }
+%s
   </style>
 </head>
 <body class="%s">
@@ -2912,10 +3530,13 @@ if (xmlRoot) {
 </script>
 </body>
 </html>
-`, safeTitle, safeLogoPath, bodyClass, safeLogoPath, safeTitle, template.HTMLEscapeString(viewerDocumentHint(doc.docType)), contentHTML, tocShell)
+`, safeTitle, safeLogoPath, viewerDocumentStyles(doc), bodyClass, safeLogoPath, safeTitle, template.HTMLEscapeString(viewerDocumentHint(doc)), contentHTML, tocShell)
 }
 
 func renderViewerContent(doc viewerDocument, safeDocumentPath string) string {
+	if doc.streamed {
+		return renderViewerStreamContent(doc)
+	}
 	switch doc.docType {
 	case viewerDocumentTypePDF:
 		return fmt.Sprintf(`<iframe src="%s" title="%s"></iframe>`, safeDocumentPath, template.HTMLEscapeString(doc.fileName))
@@ -2944,6 +3565,36 @@ func renderViewerContent(doc viewerDocument, safeDocumentPath string) string {
 		return renderEnvHTML(doc.content)
 	case viewerDocumentTypeText:
 		return `<div class="code-frame">` + renderCodeWithLineNumbers(doc.content, "") + `</div>`
+	case viewerDocumentTypeLog:
+		if doc.logView != nil {
+			return renderLogViewHTML(*doc.logView)
+		}
+		return `<div class="code-frame">` + renderCodeWithLineNumbers(doc.content, "") + `</div>`
+	case viewerDocumentTypeDiff:
+		if doc.diffView != nil {
+			return renderDiffViewHTML(*doc.diffView)
+		}
+		return `<div class="text-frame"><p>Preview not available.</p></div>`
+	case viewerDocumentTypeImage:
+		return renderImageViewHTML(doc)
+	case viewerDocumentTypeNotebook:
+		if doc.notebook != nil {
+			return renderNotebookHTML(*doc.notebook, doc.fileName)
+		}
+		if doc.structuredContent != "" {
+			return renderStructuredViewerPayload(doc.structuredContent)
+		}
+		return `<div class="code-frame">` + renderCodeWithLineNumbers(doc.content, "") + `</div>`
+	case viewerDocumentTypeSQLite:
+		if doc.sqlite != nil {
+			return renderSQLiteViewHTML(*doc.sqlite)
+		}
+		return `<div class="text-frame"><p>Preview not available.</p></div>`
+	case viewerDocumentTypeMarkdownEdit:
+		if doc.editor != nil {
+			return renderMarkdownEditorHTML(doc)
+		}
+		return `<div class="text-frame"><p>Preview not available.</p></div>`
 	default:
 		return `<div class="text-frame"><p>Preview not available.</p></div>`
 	}
@@ -3163,8 +3814,11 @@ func highlightXMLAttrs(attrs string) string {
 	return b.String()
 }
 
-func viewerDocumentHint(docType viewerDocumentType) string {
-	switch docType {
+func viewerDocumentHint(doc viewerDocument) string {
+	if doc.streamed {
+		return "Large file · streamed"
+	}
+	switch doc.docType {
 	case viewerDocumentTypePDF:
 		return "Local PDF session"
 	case viewerDocumentTypeMarkdown:
@@ -3183,17 +3837,57 @@ func viewerDocumentHint(docType viewerDocumentType) string {
 		return "ENV preview"
 	case viewerDocumentTypeText:
 		return "Text preview"
+	case viewerDocumentTypeLog:
+		return "Log preview"
+	case viewerDocumentTypeDiff:
+		return "Diff preview"
+	case viewerDocumentTypeImage:
+		return "Image preview"
+	case viewerDocumentTypeNotebook:
+		return "Notebook preview"
+	case viewerDocumentTypeSQLite:
+		return "SQLite preview"
+	case viewerDocumentTypeMarkdownEdit:
+		return "Editing Markdown"
 	default:
 		return "Local file preview"
 	}
 }
 
+// viewerDocumentStyles returns the extra CSS a document type needs on top of
+// the shared viewer page styles.
+func viewerDocumentStyles(doc viewerDocument) string {
+	switch {
+	case doc.streamed, doc.docType == viewerDocumentTypeCSV:
+		return viewerStreamStyles
+	case doc.docType == viewerDocumentTypeLog:
+		return viewerLogStyles
+	case doc.docType == viewerDocumentTypeDiff:
+		return viewerDiffStyles
+	case doc.docType == viewerDocumentTypeImage:
+		return viewerImageStyles
+	case doc.docType == viewerDocumentTypeNotebook:
+		return viewerNotebookStyles
+	case doc.docType == viewerDocumentTypeSQLite:
+		return viewerSQLiteStyles
+	case doc.docType == viewerDocumentTypeMarkdownEdit:
+		return viewerEditorStyles
+	default:
+		return ""
+	}
+}
+
 func viewerDocumentUsesStructuredTree(doc viewerDocument) bool {
+	if doc.streamed {
+		return false
+	}
 	switch doc.docType {
 	case viewerDocumentTypeJSON, viewerDocumentTypeXML:
 		return true
 	case viewerDocumentTypeYAML, viewerDocumentTypeTOML:
 		return doc.structuredContent != ""
+	case viewerDocumentTypeNotebook:
+		return doc.notebook == nil && doc.structuredContent != ""
 	default:
 		return false
 	}
@@ -3227,7 +3921,9 @@ func renderCSVTableHTML(table viewerCSVTable) string {
 		}
 		b.WriteString(`</tr>`)
 	}
-	b.WriteString(`</tbody></table></div></div>`)
+	b.WriteString(`</tbody></table></div>`)
+	b.WriteString(renderCSVLoadMore(table))
+	b.WriteString(`</div>`)
 	return b.String()
 }
 
@@ -5806,6 +6502,8 @@ func jotTask(stdin io.Reader, w io.Writer, args []string, getwd func() string) e
 			return runStripTask(stdin, w, getwd())
 		case "palette":
 			return runPaletteTask(stdin, w, getwd())
+		case "pdf-merge", "pdf-split", "pdf-pages", "pdf-rotate":
+			return runPDFTask(stdin, w, getwd(), strings.TrimPrefix(strings.ToLower(strings.TrimSpace(args[0])), "pdf-"))
 		default:
 			return fmt.Errorf("unknown task %q", args[0])
 		}
@@ -5859,6 +6557,18 @@ func jotTask(stdin io.Reader, w io.Writer, args []string, getwd func() string) e
 	if _, err := fmt.Fprintln(w, ui.listItem(13, "extract palette", "Extract hex, swatch, or JSON palettes from images", "")); err != nil {
 		return err
 	}
+	if _, err := fmt.Fprintln(w, ui.listItem(14, "merge pdfs", "Combine local PDFs, in order, into one file", "")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ui.listItem(15, "split pdf", "Write each page, or every N pages, to its own PDF", "")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ui.listItem(16, "extract pdf pages", "Copy a page list such as 1-3,7 into a new PDF", "")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ui.listItem(17, "rotate pdf", "Rotate all or selected PDF pages by 90, 180, or 270 degrees", "")); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, ""); err != nil {
 		return err
 	}
@@ -5893,6 +6603,14 @@ func jotTask(stdin io.Reader, w io.Writer, args []string, getwd func() string) e
 		return runStripTask(reader, w, getwd())
 	case "13", "palette", "extract palette":
 		return runPaletteTask(reader, w, getwd())
+	case "14", "pdf-merge", "merge pdfs":
+		return runPDFTask(reader, w, getwd(), "merge")
+	case "15", "pdf-split", "split pdf":
+		return runPDFTask(reader, w, getwd(), "split")
+	case "16", "pdf-pages", "extract pdf pages":
+		return runPDFTask(reader, w, getwd(), "pages")
+	case "17", "pdf-rotate", "rotate pdf":
+		return runPDFTask(reader, w, getwd(), "rotate")
 	default:
 		return fmt.Errorf("unknown task selection %q", selection)
 	}
@@ -409,10 +409,12 @@ func TestJotTaskHelpWritesCommandGuide(t *testing.T) {
 		"jot task qr",
 		"jot task strip",
 		"jot task palette",
+		"jot task pdf-merge",
+		"jot task pdf-rotate",
 		"jot convert logo.png ico",
 		"Discover and run terminal-first tasks",
 		"guided front door for jot's task layer",
-		"Available guided tasks today include image conversion, JSON minify, base64 encode/decode, hashing, compression, timestamp conversion, ID generation, resize, diff, rename, QR generation, metadata strip, and palette extraction.",
+		"Available guided tasks today include image conversion, JSON minify, base64 encode/decode, hashing, compression, timestamp conversion, ID generation, resize, diff, rename, QR generation, metadata strip, palette extraction, and PDF merge, split, page extraction, and rotation.",
 	} {
 		if !strings.Contains(help, snippet) {
 			t.Fatalf("expected help to contain %q, got %q", snippet, help)
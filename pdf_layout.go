@@ -0,0 +1,1021 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// pdfPaperSize is a page size in points.
+type pdfPaperSize struct {
+	width  float64
+	height float64
+}
+
+var pdfPaperSizes = map[string]pdfPaperSize{
+	"a4":     {width: 595.28, height: 841.89},
+	"letter": {width: 612, height: 792},
+}
+
+// pdfFontStyle picks one of the standard 14 fonts every PDF reader ships,
+// so nothing has to be embedded. Resource names are F1 through F5 in order.
+type pdfFontStyle int
+
+const (
+	pdfFontRegular pdfFontStyle = iota
+	pdfFontBold
+	pdfFontItalic
+	pdfFontBoldItalic
+	pdfFontMono
+)
+
+var pdfLayoutFontNames = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique", "Courier"}
+
+// Glyph widths from the Adobe font metrics for printable ASCII, in 1/1000
+// of the font size. The oblique faces share the upright widths.
+var pdfHelveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var pdfHelveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// pdfWinAnsiCodes maps runes back to their WinAnsiEncoding byte. ASCII maps
+// to itself and is handled before the lookup.
+var pdfWinAnsiCodes = func() map[rune]byte {
+	codes := make(map[rune]byte)
+	for code, r := range pdfWinAnsiEncoding() {
+		if code >= 0x80 && r >= 0x80 {
+			codes[r] = byte(code)
+		}
+	}
+	return codes
+}()
+
+// pdfEncodeText converts s to WinAnsiEncoding. Emoji and other symbols
+// are dropped; any other character the standard fonts cannot show becomes
+// '?'.
+func pdfEncodeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case unicode.In(r, unicode.So, unicode.Mn, unicode.Cf, unicode.Sk) && pdfWinAnsiCodes[r] == 0:
+		default:
+			if code, ok := pdfWinAnsiCodes[r]; ok {
+				b.WriteByte(code)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// pdfGlyphWidth returns the width of an encoded byte. Outside ASCII only
+// the punctuation that shows up in prose is measured exactly; accented
+// letters use an average width.
+func pdfGlyphWidth(style pdfFontStyle, c byte) int {
+	bold := style == pdfFontBold || style == pdfFontBoldItalic
+	switch {
+	case style == pdfFontMono:
+		return 600
+	case c >= 0x20 && c < 0x7F && bold:
+		return pdfHelveticaBoldWidths[c-0x20]
+	case c >= 0x20 && c < 0x7F:
+		return pdfHelveticaWidths[c-0x20]
+	}
+	switch c {
+	case 0x85, 0x97, 0x89:
+		return 1000
+	case 0x95:
+		return 350
+	case 0x91, 0x92:
+		if bold {
+			return 278
+		}
+		return 222
+	case 0x93, 0x94:
+		if bold {
+			return 500
+		}
+		return 333
+	case 0xA0:
+		return 278
+	}
+	return 556
+}
+
+func pdfTextWidth(s string, style pdfFontStyle, size float64) float64 {
+	encoded := pdfEncodeText(s)
+	total := 0
+	for i := 0; i < len(encoded); i++ {
+		total += pdfGlyphWidth(style, encoded[i])
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfRun is a span of inline text in one font.
+type pdfRun struct {
+	text  string
+	style pdfFontStyle
+	link  bool
+}
+
+type pdfBlockKind int
+
+const (
+	pdfBlockHeading pdfBlockKind = iota
+	pdfBlockParagraph
+	pdfBlockItem
+	pdfBlockCode
+	pdfBlockTable
+	pdfBlockRule
+)
+
+// pdfBlock is one laid-out unit of a document: a heading, a paragraph, a
+// list item, a code block, a table, or a rule.
+type pdfBlock struct {
+	kind   pdfBlockKind
+	level  int // heading level, or list depth starting at 0
+	quote  int // blockquote nesting
+	marker string
+	runs   []pdfRun
+	code   string
+	label  string
+	header []string
+	rows   [][]string
+}
+
+const (
+	pdfMargin       = 56.0
+	pdfBodySize     = 10.5
+	pdfBodyLeading  = 15.5
+	pdfCodeSize     = 8.8
+	pdfCodeLeading  = 12.0
+	pdfTableSize    = 9.0
+	pdfTableLeading = 12.5
+	pdfQuoteIndent  = 14.0
+	pdfListIndent   = 16.0
+	pdfCodePadding  = 8.0
+	pdfCellPadding  = 5.0
+
+	pdfColorText   = "0.102 0.102 0.094"
+	pdfColorMuted  = "0.45 0.45 0.43"
+	pdfColorLink   = "0.102 0.435 0.722"
+	pdfColorFill   = "0.957 0.953 0.941"
+	pdfColorBorder = "0.82 0.82 0.80"
+)
+
+// pdfMinColumnWidth is the narrowest a table column is squeezed to.
+const pdfMinColumnWidth = 40.0
+
+// pdfLayout places blocks top to bottom, starting a new page whenever the
+// next line would run into the bottom margin.
+type pdfLayout struct {
+	paper pdfPaperSize
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+	empty bool
+}
+
+func newPDFLayout(paper pdfPaperSize) *pdfLayout {
+	l := &pdfLayout{paper: paper}
+	l.newPage()
+	return l
+}
+
+func (l *pdfLayout) width() float64 { return l.paper.width - 2*pdfMargin }
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = l.paper.height - pdfMargin
+	l.empty = true
+}
+
+// ensure starts a new page unless height still fits. A block taller than a
+// whole page is drawn from the top of an empty page rather than looping.
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height < pdfMargin && !l.empty {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) gap(height float64) {
+	if !l.empty {
+		l.y -= height
+	}
+}
+
+func (l *pdfLayout) text(x, baseline float64, style pdfFontStyle, size float64, color, s string) {
+	fmt.Fprintf(l.page, "BT %s rg /F%d %.2f Tf %.2f %.2f Td ", color, int(style)+1, size, x, baseline)
+	writePDFValue(l.page, pdfString(pdfEncodeText(s)))
+	l.page.WriteString(" Tj ET\n")
+	l.empty = false
+}
+
+func (l *pdfLayout) fill(x, y, w, h float64, color string) {
+	fmt.Fprintf(l.page, "q %s rg %.2f %.2f %.2f %.2f re f Q\n", color, x, y, w, h)
+}
+
+func (l *pdfLayout) line(x1, y1, x2, y2 float64, color string) {
+	fmt.Fprintf(l.page, "q %s RG 0.6 w %.2f %.2f m %.2f %.2f l S Q\n", color, x1, y1, x2, y2)
+	l.empty = false
+}
+
+// drawRuns writes one wrapped line whose box starts at l.y.
+func (l *pdfLayout) drawRuns(x float64, runs []pdfRun, size, leading float64, color string) {
+	baseline := l.y - (leading+size)/2 - size*0.05
+	for _, run := range runs {
+		if run.text == "" {
+			continue
+		}
+		runColor := color
+		if run.link {
+			runColor = pdfColorLink
+		}
+		l.text(x, baseline, run.style, size, runColor, run.text)
+		x += pdfTextWidth(run.text, run.style, size)
+	}
+	l.y -= leading
+}
+
+var pdfTokenPattern = regexp.MustCompile(`\n| +|[^ \n]+`)
+
+// wrapPDFRuns breaks runs into lines no wider than width, splitting on
+// spaces and, for words longer than a line, between characters.
+func wrapPDFRuns(runs []pdfRun, size, width float64) [][]pdfRun {
+	var lines [][]pdfRun
+	var line []pdfRun
+	lineWidth := 0.0
+	add := func(text string, run pdfRun, w float64) {
+		if n := len(line); n > 0 && line[n-1].style == run.style && line[n-1].link == run.link {
+			line[n-1].text += text
+		} else {
+			line = append(line, pdfRun{text: text, style: run.style, link: run.link})
+		}
+		lineWidth += w
+	}
+	flush := func() {
+		if n := len(line); n > 0 {
+			line[n-1].text = strings.TrimRight(line[n-1].text, " ")
+		}
+		lines = append(lines, line)
+		line, lineWidth = nil, 0
+	}
+	for _, run := range runs {
+		for _, token := range pdfTokenPattern.FindAllString(run.text, -1) {
+			if token == "\n" {
+				flush()
+				continue
+			}
+			w := pdfTextWidth(token, run.style, size)
+			if token[0] == ' ' {
+				if len(line) > 0 {
+					add(" ", run, pdfTextWidth(" ", run.style, size))
+				}
+				continue
+			}
+			if lineWidth+w > width && len(line) > 0 {
+				flush()
+			}
+			for w > width && token != "" {
+				cut := 1
+				for cut < len([]rune(token)) && pdfTextWidth(string([]rune(token)[:cut+1]), run.style, size) <= width {
+					cut++
+				}
+				head := string([]rune(token)[:cut])
+				add(head, run, pdfTextWidth(head, run.style, size))
+				flush()
+				token = string([]rune(token)[cut:])
+				w = pdfTextWidth(token, run.style, size)
+			}
+			add(token, run, w)
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+func (l *pdfLayout) drawBlock(block pdfBlock) {
+	indent := float64(block.quote) * pdfQuoteIndent
+	color := pdfColorText
+	if block.quote > 0 {
+		color = pdfColorMuted
+	}
+	x := pdfMargin + indent
+	switch block.kind {
+	case pdfBlockHeading:
+		// Like the print stylesheet, every top-level heading after the
+		// first starts a new page.
+		if block.level == 1 && !l.empty {
+			l.newPage()
+		}
+		size, leading := pdfHeadingSize(block.level)
+		l.gap(leading * 0.6)
+		lines := wrapPDFRuns(pdfBoldRuns(block.runs), size, l.width()-indent)
+		// Keep the heading with the first lines of what follows it.
+		l.ensure(float64(len(lines))*leading + 2*pdfBodyLeading)
+		for _, line := range lines {
+			l.drawRuns(x, line, size, leading, pdfColorText)
+		}
+		if block.level <= 2 {
+			l.y -= 3
+			l.line(x, l.y, pdfMargin+l.width(), l.y, pdfColorBorder)
+		}
+		l.y -= 5
+	case pdfBlockParagraph, pdfBlockItem:
+		textX := x
+		if block.kind == pdfBlockItem {
+			textX = x + float64(block.level)*pdfListIndent + pdfListIndent
+		}
+		lines := wrapPDFRuns(block.runs, pdfBodySize, pdfMargin+l.width()-textX)
+		for i, line := range lines {
+			l.ensure(pdfBodyLeading)
+			top := l.y
+			if i == 0 && block.marker != "" {
+				markerX := textX - pdfTextWidth(block.marker, pdfFontRegular, pdfBodySize) - 5
+				baseline := top - (pdfBodyLeading+pdfBodySize)/2 - pdfBodySize*0.05
+				l.text(markerX, baseline, pdfFontRegular, pdfBodySize, pdfColorMuted, block.marker)
+			}
+			l.drawRuns(textX, line, pdfBodySize, pdfBodyLeading, color)
+			for q := 0; q < block.quote; q++ {
+				barX := pdfMargin + float64(q)*pdfQuoteIndent + 2
+				l.line(barX, top, barX, l.y, pdfColorBorder)
+			}
+		}
+		if block.kind == pdfBlockItem {
+			l.y -= 2
+		} else {
+			l.y -= 7
+		}
+	case pdfBlockCode:
+		l.drawCode(x, block)
+	case pdfBlockTable:
+		l.drawTable(x, block)
+	case pdfBlockRule:
+		l.ensure(pdfBodyLeading)
+		l.y -= pdfBodyLeading / 2
+		l.line(x, l.y, pdfMargin+l.width(), l.y, pdfColorBorder)
+		l.y -= pdfBodyLeading / 2
+	}
+}
+
+func pdfHeadingSize(level int) (float64, float64) {
+	switch level {
+	case 1:
+		return 20, 26
+	case 2:
+		return 15.5, 21
+	case 3:
+		return 12.5, 18
+	default:
+		return 11, 16
+	}
+}
+
+func pdfBoldRuns(runs []pdfRun) []pdfRun {
+	out := make([]pdfRun, len(runs))
+	for i, run := range runs {
+		out[i] = run
+		switch run.style {
+		case pdfFontRegular:
+			out[i].style = pdfFontBold
+		case pdfFontItalic:
+			out[i].style = pdfFontBoldItalic
+		}
+	}
+	return out
+}
+
+type pdfCodeLine struct {
+	text  string
+	label bool
+}
+
+// drawCode draws a code block on a shaded box, hard-wrapping long lines and
+// continuing the box on the next page when it does not fit.
+func (l *pdfLayout) drawCode(x float64, block pdfBlock) {
+	width := pdfMargin + l.width() - x
+	perLine := int((width - 2*pdfCodePadding) / (pdfCodeSize * 0.6))
+	if perLine < 1 {
+		perLine = 1
+	}
+	var lines []pdfCodeLine
+	if block.label != "" {
+		lines = append(lines, pdfCodeLine{text: block.label, label: true})
+	}
+	source := strings.TrimRight(strings.ReplaceAll(block.code, "\r\n", "\n"), "\n")
+	for _, raw := range strings.Split(source, "\n") {
+		runes := []rune(expandPDFTabs(raw))
+		for len(runes) > perLine {
+			lines = append(lines, pdfCodeLine{text: string(runes[:perLine])})
+			runes = runes[perLine:]
+		}
+		lines = append(lines, pdfCodeLine{text: string(runes)})
+	}
+
+	l.gap(4)
+	for len(lines) > 0 {
+		fits := int((l.y - pdfMargin - 2*pdfCodePadding) / pdfCodeLeading)
+		if fits < 2 && !l.empty && len(lines) > 1 || fits < 1 && !l.empty {
+			l.newPage()
+			continue
+		}
+		if fits < 1 {
+			fits = 1
+		}
+		n := len(lines)
+		if fits < n {
+			n = fits
+		}
+		height := float64(n)*pdfCodeLeading + 2*pdfCodePadding
+		l.fill(x, l.y-height, width, height, pdfColorFill)
+		l.y -= pdfCodePadding
+		for _, line := range lines[:n] {
+			color := pdfColorText
+			if line.label {
+				color = pdfColorMuted
+			}
+			l.drawRuns(x+pdfCodePadding, []pdfRun{{text: line.text, style: pdfFontMono}}, pdfCodeSize, pdfCodeLeading, color)
+		}
+		l.y -= pdfCodePadding
+		lines = lines[n:]
+		if len(lines) > 0 {
+			l.newPage()
+		}
+	}
+	l.y -= 8
+}
+
+func expandPDFTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := 4 - col%4
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// drawTable sizes columns to their content, shrinking the widest ones when
+// the table is wider than the page, and repeats the header row after a
+// page break.
+// drawTable draws a table, split into several tables of consecutive
+// columns when squeezing every column to pdfMinColumnWidth does not fit.
+func (l *pdfLayout) drawTable(x float64, block pdfBlock) {
+	natural := pdfTableNaturalWidths(block)
+	available := pdfMargin + l.width() - x
+	start, used := 0, 0.0
+	for i, w := range natural {
+		w = math.Min(w, pdfMinColumnWidth)
+		if used+w > available && i > start {
+			l.drawTableColumns(x, pdfTableColumns(block, start, i), natural[start:i])
+			start, used = i, 0
+		}
+		used += w
+	}
+	if start < len(natural) {
+		l.drawTableColumns(x, pdfTableColumns(block, start, len(natural)), natural[start:])
+	}
+}
+
+// pdfTableNaturalWidths is the width each column needs to fit its widest
+// cell on one line, padding included.
+func pdfTableNaturalWidths(block pdfBlock) []float64 {
+	columns := len(block.header)
+	for _, row := range block.rows {
+		columns = max(columns, len(row))
+	}
+	natural := make([]float64, columns)
+	measure := func(row []string, style pdfFontStyle) {
+		for i, text := range row {
+			w := math.Ceil(pdfTextWidth(text, style, pdfTableSize)) + 2*pdfCellPadding
+			natural[i] = math.Max(natural[i], w)
+		}
+	}
+	measure(block.header, pdfFontBold)
+	for _, row := range block.rows {
+		measure(row, pdfFontRegular)
+	}
+	return natural
+}
+
+// pdfTableColumns is block with only columns [from, to).
+func pdfTableColumns(block pdfBlock, from, to int) pdfBlock {
+	slice := func(row []string) []string {
+		if from >= len(row) {
+			return nil
+		}
+		return row[from:min(to, len(row))]
+	}
+	out := block
+	if block.header != nil {
+		out.header = slice(block.header)
+	}
+	out.rows = make([][]string, len(block.rows))
+	for i, row := range block.rows {
+		out.rows[i] = slice(row)
+	}
+	return out
+}
+
+func (l *pdfLayout) drawTableColumns(x float64, block pdfBlock, natural []float64) {
+	columns := len(natural)
+	if columns == 0 {
+		return
+	}
+	cell := func(row []string, i int) string {
+		if i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	available := pdfMargin + l.width() - x
+	widths := append([]float64(nil), natural...)
+	total := 0.0
+	for _, w := range natural {
+		total += w
+	}
+	if total > available {
+		fair := available / float64(columns)
+		fixed, flexible := 0.0, 0.0
+		for _, w := range natural {
+			if w <= fair {
+				fixed += w
+			} else {
+				flexible += w
+			}
+		}
+		for i, w := range natural {
+			if w > fair {
+				// Never narrower than the padding plus some text.
+				widths[i] = math.Max(w/flexible*(available-fixed), math.Min(w, pdfMinColumnWidth))
+			}
+		}
+	}
+
+	wrapRow := func(row []string, style pdfFontStyle) ([][][]pdfRun, float64) {
+		cells := make([][][]pdfRun, columns)
+		most := 1
+		for i := 0; i < columns; i++ {
+			cells[i] = wrapPDFRuns([]pdfRun{{text: cell(row, i), style: style}}, pdfTableSize, widths[i]-2*pdfCellPadding)
+			if len(cells[i]) > most {
+				most = len(cells[i])
+			}
+		}
+		return cells, float64(most)*pdfTableLeading + 2*pdfCellPadding
+	}
+	drawRow := func(cells [][][]pdfRun, height float64, header bool) {
+		top := l.y
+		if header {
+			l.fill(x, top-height, available, height, pdfColorFill)
+		}
+		cellX := x
+		for i, lines := range cells {
+			l.y = top - pdfCellPadding
+			for _, line := range lines {
+				l.drawRuns(cellX+pdfCellPadding, line, pdfTableSize, pdfTableLeading, pdfColorText)
+			}
+			cellX += widths[i]
+		}
+		l.y = top - height
+		l.line(x, l.y, x+available, l.y, pdfColorBorder)
+	}
+
+	var headerCells [][][]pdfRun
+	headerHeight := 0.0
+	if len(block.header) > 0 {
+		headerCells, headerHeight = wrapRow(block.header, pdfFontBold)
+	}
+	l.gap(4)
+	first := true
+	for i := -1; i < len(block.rows); i++ {
+		if i < 0 {
+			if headerCells == nil {
+				continue
+			}
+			l.ensure(headerHeight + pdfTableLeading + 2*pdfCellPadding)
+			drawRow(headerCells, headerHeight, true)
+			first = false
+			continue
+		}
+		cells, height := wrapRow(block.rows[i], pdfFontRegular)
+		if l.y-height < pdfMargin && !l.empty {
+			l.newPage()
+			if headerCells != nil {
+				drawRow(headerCells, headerHeight, true)
+			}
+		}
+		if first {
+			l.line(x, l.y, x+available, l.y, pdfColorBorder)
+			first = false
+		}
+		drawRow(cells, height, false)
+	}
+	l.y -= 10
+}
+
+// write numbers the pages and writes the finished document.
+func (l *pdfLayout) write(w io.Writer, title string) error {
+	writer := &pdfWriter{}
+	catalogRef := writer.reserve()
+	pagesRef := writer.reserve()
+	fonts := pdfDict{}
+	for i, name := range pdfLayoutFontNames {
+		ref := writer.reserve()
+		writer.set(ref, pdfDict{
+			"Type":     pdfName("Font"),
+			"Subtype":  pdfName("Type1"),
+			"BaseFont": pdfName(name),
+			"Encoding": pdfName("WinAnsiEncoding"),
+		})
+		fonts[pdfName(fmt.Sprintf("F%d", i+1))] = ref
+	}
+	resources := pdfDict{"Font": fonts}
+
+	kids := make(pdfArray, 0, len(l.pages))
+	for i, page := range l.pages {
+		l.page = page
+		footer := fmt.Sprintf("%d / %d", i+1, len(l.pages))
+		footerX := (l.paper.width - pdfTextWidth(footer, pdfFontRegular, 8.5)) / 2
+		l.text(footerX, pdfMargin/2, pdfFontRegular, 8.5, pdfColorMuted, footer)
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		contentRef := writer.reserve()
+		writer.set(contentRef, pdfStream{dict: pdfDict{"Filter": pdfName("FlateDecode")}, data: compressed.Bytes()})
+		pageRef := writer.reserve()
+		writer.set(pageRef, pdfDict{
+			"Type":      pdfName("Page"),
+			"Parent":    pagesRef,
+			"MediaBox":  pdfArray{0, 0, l.paper.width, l.paper.height},
+			"Contents":  contentRef,
+			"Resources": resources,
+		})
+		kids = append(kids, pageRef)
+	}
+	writer.set(pagesRef, pdfDict{"Type": pdfName("Pages"), "Kids": kids, "Count": len(kids)})
+	writer.set(catalogRef, pdfDict{"Type": pdfName("Catalog"), "Pages": pagesRef})
+	infoRef := writer.reserve()
+	writer.set(infoRef, pdfDict{
+		"Title":    pdfString(pdfEncodeText(title)),
+		"Producer": pdfString("jot " + version),
+	})
+	return writer.write(w, pdfDict{"Root": catalogRef, "Info": infoRef})
+}
+
+// writeBlocksPDF lays blocks out on pages of the given size and writes the
+// result as a PDF.
+func writeBlocksPDF(w io.Writer, title string, blocks []pdfBlock, paper pdfPaperSize) error {
+	layout := newPDFLayout(paper)
+	for _, block := range blocks {
+		layout.drawBlock(block)
+	}
+	return layout.write(w, title)
+}
+
+// writeViewerDocumentPDF exports doc the way the viewer shows it: Markdown
+// and notebooks keep their structure, CSV becomes a table, and other
+// structured files are printed as code.
+func writeViewerDocumentPDF(w io.Writer, doc viewerDocument, paper pdfPaperSize) error {
+	return writeBlocksPDF(w, doc.fileName, viewerDocumentPDFBlocks(doc), paper)
+}
+
+func viewerDocumentPDFBlocks(doc viewerDocument) []pdfBlock {
+	if doc.docType == viewerDocumentTypeMarkdown {
+		return markdownPDFBlocks(doc.content)
+	}
+	blocks := []pdfBlock{{kind: pdfBlockHeading, level: 1, runs: []pdfRun{{text: doc.fileName}}}}
+	switch {
+	case doc.notebook != nil:
+		for _, cell := range doc.notebook.cells {
+			blocks = append(blocks, notebookCellPDFBlocks(cell)...)
+		}
+	case doc.csvTable != nil:
+		blocks = append(blocks, pdfBlock{kind: pdfBlockTable, header: doc.csvTable.Headers, rows: doc.csvTable.Rows})
+	default:
+		blocks = append(blocks, pdfBlock{kind: pdfBlockCode, code: doc.content})
+	}
+	return blocks
+}
+
+func notebookCellPDFBlocks(cell viewerNotebookCell) []pdfBlock {
+	switch cell.kind {
+	case "markdown":
+		return markdownPDFBlocks(cell.source)
+	case "code":
+	default:
+		return []pdfBlock{{kind: pdfBlockCode, code: cell.source}}
+	}
+	prompt := "In [ ]:"
+	if cell.executionCount != nil {
+		prompt = fmt.Sprintf("In [%d]:", *cell.executionCount)
+	}
+	blocks := []pdfBlock{{kind: pdfBlockCode, label: prompt, code: cell.source}}
+	for _, output := range cell.outputs {
+		switch output.kind {
+		case "image":
+			blocks = append(blocks, pdfBlock{kind: pdfBlockParagraph, runs: []pdfRun{{text: "[image output]", style: pdfFontItalic}}})
+		case "html":
+			blocks = append(blocks, htmlPDFBlocks(output.text)...)
+		case "markdown":
+			blocks = append(blocks, markdownPDFBlocks(output.text)...)
+		default:
+			blocks = append(blocks, pdfBlock{kind: pdfBlockCode, label: "Out:", code: output.text})
+		}
+	}
+	return blocks
+}
+
+// pdfHTMLNode is an element or, when tag is empty, a text node.
+type pdfHTMLNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*pdfHTMLNode
+}
+
+// parsePDFHTML reads the HTML that renderMarkdownHTML produces, and the
+// loosely formed HTML of notebook outputs, into a tree. Unclosed elements
+// are closed at the end instead of failing.
+func parsePDFHTML(fragment string) *pdfHTMLNode {
+	decoder := xml.NewDecoder(strings.NewReader(fragment))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	root := &pdfHTMLNode{tag: "root"}
+	stack := []*pdfHTMLNode{root}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		top := stack[len(stack)-1]
+		switch typed := token.(type) {
+		case xml.StartElement:
+			node := &pdfHTMLNode{tag: strings.ToLower(typed.Name.Local), attrs: make(map[string]string, len(typed.Attr))}
+			for _, attr := range typed.Attr {
+				node.attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			top.children = append(top.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			name := strings.ToLower(typed.Name.Local)
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			top.children = append(top.children, &pdfHTMLNode{text: string(typed)})
+		}
+	}
+	return root
+}
+
+func markdownPDFBlocks(content string) []pdfBlock {
+	return htmlPDFBlocks(renderMarkdownHTML(content))
+}
+
+func htmlPDFBlocks(fragment string) []pdfBlock {
+	var blocks []pdfBlock
+	appendHTMLPDFBlocks(&blocks, parsePDFHTML(fragment).children, 0)
+	return blocks
+}
+
+func appendHTMLPDFBlocks(blocks *[]pdfBlock, nodes []*pdfHTMLNode, quote int) {
+	var inline []*pdfHTMLNode
+	flush := func() {
+		runs := appendPDFRuns(nil, inline, pdfFontRegular, false)
+		if strings.TrimSpace(pdfRunsText(runs)) != "" {
+			*blocks = append(*blocks, pdfBlock{kind: pdfBlockParagraph, quote: quote, runs: runs})
+		}
+		inline = nil
+	}
+	for _, node := range nodes {
+		switch node.tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			flush()
+			*blocks = append(*blocks, pdfBlock{kind: pdfBlockHeading, level: int(node.tag[1] - '0'), quote: quote, runs: appendPDFRuns(nil, node.children, pdfFontRegular, false)})
+		case "p":
+			flush()
+			inline = node.children
+			flush()
+		case "ul", "ol":
+			flush()
+			appendListPDFBlocks(blocks, node, 0, quote)
+		case "blockquote":
+			flush()
+			appendHTMLPDFBlocks(blocks, node.children, quote+1)
+		case "pre":
+			flush()
+			*blocks = append(*blocks, pdfBlock{kind: pdfBlockCode, quote: quote, label: node.attrs["data-lang"], code: pdfNodeText(node)})
+		case "hr":
+			flush()
+			*blocks = append(*blocks, pdfBlock{kind: pdfBlockRule, quote: quote})
+		case "table":
+			flush()
+			*blocks = append(*blocks, tablePDFBlock(node, quote))
+		case "div", "section", "article", "figure", "body", "html":
+			flush()
+			appendHTMLPDFBlocks(blocks, node.children, quote)
+		case "style", "script", "head", "title":
+		default:
+			inline = append(inline, node)
+		}
+	}
+	flush()
+}
+
+func appendListPDFBlocks(blocks *[]pdfBlock, list *pdfHTMLNode, depth, quote int) {
+	number := 1
+	for _, item := range list.children {
+		if item.tag != "li" {
+			continue
+		}
+		marker := "•"
+		if depth%2 == 1 {
+			marker = "–"
+		}
+		if list.tag == "ol" {
+			marker = fmt.Sprintf("%d.", number)
+		}
+		if box := findPDFNode(item, "input"); box != nil {
+			marker = "[ ]"
+			if _, checked := box.attrs["checked"]; checked {
+				marker = "[x]"
+			}
+		}
+		var inline, nested []*pdfHTMLNode
+		for _, child := range item.children {
+			switch child.tag {
+			case "ul", "ol":
+				nested = append(nested, child)
+			case "p":
+				if len(inline) > 0 {
+					inline = append(inline, &pdfHTMLNode{tag: "br"})
+				}
+				inline = append(inline, child.children...)
+			default:
+				inline = append(inline, child)
+			}
+		}
+		*blocks = append(*blocks, pdfBlock{kind: pdfBlockItem, level: depth, quote: quote, marker: marker, runs: appendPDFRuns(nil, inline, pdfFontRegular, false)})
+		for _, child := range nested {
+			appendListPDFBlocks(blocks, child, depth+1, quote)
+		}
+		number++
+	}
+}
+
+func tablePDFBlock(table *pdfHTMLNode, quote int) pdfBlock {
+	block := pdfBlock{kind: pdfBlockTable, quote: quote}
+	var walk func(node *pdfHTMLNode, inHead bool)
+	walk = func(node *pdfHTMLNode, inHead bool) {
+		for _, child := range node.children {
+			switch child.tag {
+			case "thead":
+				walk(child, true)
+			case "tbody", "tfoot":
+				walk(child, false)
+			case "tr":
+				var row []string
+				header := inHead
+				for _, cell := range child.children {
+					if cell.tag == "td" || cell.tag == "th" {
+						row = append(row, strings.TrimSpace(pdfRunsText(appendPDFRuns(nil, cell.children, pdfFontRegular, false))))
+					}
+				}
+				if header && block.header == nil {
+					block.header = row
+				} else {
+					block.rows = append(block.rows, row)
+				}
+			}
+		}
+	}
+	walk(table, false)
+	return block
+}
+
+var pdfSpacePattern = regexp.MustCompile(`\s+`)
+
+// appendPDFRuns flattens inline HTML into runs, collapsing whitespace the
+// way a browser does.
+func appendPDFRuns(runs []pdfRun, nodes []*pdfHTMLNode, style pdfFontStyle, link bool) []pdfRun {
+	for _, node := range nodes {
+		switch node.tag {
+		case "":
+			if text := pdfSpacePattern.ReplaceAllString(node.text, " "); text != "" {
+				runs = append(runs, pdfRun{text: text, style: style, link: link})
+			}
+		case "strong", "b", "th":
+			next := pdfFontBold
+			if style == pdfFontItalic || style == pdfFontBoldItalic {
+				next = pdfFontBoldItalic
+			} else if style == pdfFontMono {
+				next = pdfFontMono
+			}
+			runs = appendPDFRuns(runs, node.children, next, link)
+		case "em", "i":
+			next := pdfFontItalic
+			if style == pdfFontBold || style == pdfFontBoldItalic {
+				next = pdfFontBoldItalic
+			} else if style == pdfFontMono {
+				next = pdfFontMono
+			}
+			runs = appendPDFRuns(runs, node.children, next, link)
+		case "code", "kbd", "samp":
+			runs = appendPDFRuns(runs, node.children, pdfFontMono, link)
+		case "a":
+			runs = appendPDFRuns(runs, node.children, style, true)
+		case "br":
+			runs = append(runs, pdfRun{text: "\n", style: style})
+		case "img":
+			if alt := node.attrs["alt"]; alt != "" {
+				runs = append(runs, pdfRun{text: "[" + alt + "]", style: pdfFontItalic, link: link})
+			}
+		case "input", "style", "script":
+		default:
+			runs = appendPDFRuns(runs, node.children, style, link)
+		}
+	}
+	return runs
+}
+
+func pdfRunsText(runs []pdfRun) string {
+	var b strings.Builder
+	for _, run := range runs {
+		b.WriteString(run.text)
+	}
+	return b.String()
+}
+
+// pdfNodeText returns the raw text under node, keeping whitespace, for
+// preformatted blocks.
+func pdfNodeText(node *pdfHTMLNode) string {
+	if node.tag == "" {
+		return node.text
+	}
+	var b strings.Builder
+	for _, child := range node.children {
+		b.WriteString(pdfNodeText(child))
+	}
+	return b.String()
+}
+
+func findPDFNode(node *pdfHTMLNode, tag string) *pdfHTMLNode {
+	for _, child := range node.children {
+		if child.tag == tag {
+			return child
+		}
+		if child.tag == "ul" || child.tag == "ol" {
+			continue
+		}
+		if found := findPDFNode(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,916 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// PDF object model. Dictionary keys and names are stored without the leading
+// slash; strings keep their raw bytes.
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  string
+	pdfArray   []any
+	pdfDict    map[pdfName]any
+)
+
+type pdfRef struct {
+	num int
+	gen int
+}
+
+// pdfStream keeps the stream dictionary and the still-encoded bytes so the
+// writer can copy it verbatim.
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+// pdfObjectLocation records where an object was defined. Objects inside an
+// object stream carry the stream's number and their index in it; pos orders
+// redefinitions from incremental updates so the latest one wins.
+type pdfObjectLocation struct {
+	offset int
+	stream int
+	index  int
+	pos    int
+}
+
+type pdfDocument struct {
+	data      []byte
+	version   string
+	locations map[int]pdfObjectLocation
+	cache     map[int]any
+	trailer   pdfDict
+}
+
+type pdfPage struct {
+	ref       pdfRef
+	dict      pdfDict
+	resources pdfDict
+	mediaBox  []float64
+	rotate    int
+}
+
+var (
+	errPDFEncrypted         = errors.New("encrypted PDFs are not supported")
+	errPDFUnsupportedFilter = errors.New("unsupported PDF stream filter")
+	pdfObjectHeaderPattern  = regexp.MustCompile(`(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+	pdfTrailerPattern       = regexp.MustCompile(`trailer[ \t\r\n\f\x00]*<<`)
+)
+
+// openPDFDocument indexes every object in data. Rather than trusting the
+// cross-reference table, which is often stale or damaged in the wild, it
+// scans for object headers and unpacks object streams, letting later
+// definitions override earlier ones the way incremental updates do.
+func openPDFDocument(data []byte) (*pdfDocument, error) {
+	// The header may follow a little junk, but must appear in the first 1 KB.
+	idx := bytes.Index(data[:min(len(data), 1024)], []byte("%PDF-"))
+	if idx < 0 {
+		return nil, errors.New("not a PDF file")
+	}
+	doc := &pdfDocument{
+		data:      data,
+		locations: make(map[int]pdfObjectLocation),
+		cache:     make(map[int]any),
+	}
+	end := idx + 5
+	for end < len(data) && end < idx+12 && (data[end] == '.' || (data[end] >= '0' && data[end] <= '9')) {
+		end++
+	}
+	doc.version = string(data[idx+5 : end])
+
+	for _, match := range pdfObjectHeaderPattern.FindAllSubmatchIndex(data, -1) {
+		start := match[0]
+		if start > 0 && !isPDFWhitespace(data[start-1]) && !isPDFDelimiter(data[start-1]) {
+			continue
+		}
+		num, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		doc.locations[num] = pdfObjectLocation{offset: start, stream: -1, pos: start}
+	}
+	if len(doc.locations) == 0 {
+		return nil, errors.New("no PDF objects found")
+	}
+
+	type trailerCandidate struct {
+		pos  int
+		dict pdfDict
+	}
+	var trailers []trailerCandidate
+	direct := make([]int, 0, len(doc.locations))
+	for num := range doc.locations {
+		direct = append(direct, num)
+	}
+	sort.Ints(direct)
+	for _, num := range direct {
+		loc := doc.locations[num]
+		stream, ok := doc.mustObject(num).(pdfStream)
+		if !ok {
+			continue
+		}
+		switch stream.dict.name("Type") {
+		case "ObjStm":
+			doc.indexObjectStream(num, loc.pos, stream)
+		case "XRef":
+			trailers = append(trailers, trailerCandidate{pos: loc.pos, dict: stream.dict})
+		}
+	}
+	for _, match := range pdfTrailerPattern.FindAllIndex(data, -1) {
+		lexer := &pdfLexer{data: data, pos: match[1] - 2}
+		value, err := lexer.readObject()
+		if err != nil {
+			continue
+		}
+		if dict, ok := value.(pdfDict); ok {
+			trailers = append(trailers, trailerCandidate{pos: match[0], dict: dict})
+		}
+	}
+	sort.SliceStable(trailers, func(i, j int) bool { return trailers[i].pos < trailers[j].pos })
+	doc.trailer = pdfDict{}
+	for _, candidate := range trailers {
+		for _, key := range []pdfName{"Root", "Info", "Encrypt", "ID"} {
+			if value, ok := candidate.dict[key]; ok {
+				doc.trailer[key] = value
+			}
+		}
+	}
+	if _, ok := doc.trailer["Root"]; !ok {
+		for _, num := range doc.objectNumbers() {
+			if dict, ok := doc.mustObject(num).(pdfDict); ok && dict.name("Type") == "Catalog" {
+				doc.trailer["Root"] = pdfRef{num: num}
+				break
+			}
+		}
+	}
+	if _, ok := doc.trailer["Root"]; !ok {
+		return nil, errors.New("PDF catalog not found")
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, errPDFEncrypted
+	}
+	return doc, nil
+}
+
+func (d *pdfDocument) indexObjectStream(num, pos int, stream pdfStream) {
+	decoded, err := d.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	count, _ := d.resolve(stream.dict["N"]).(int)
+	lexer := &pdfLexer{data: decoded}
+	for i := 0; i < count; i++ {
+		objNum, err1 := lexer.next()
+		_, err2 := lexer.next()
+		n, ok := objNum.(int)
+		if err1 != nil || err2 != nil || !ok {
+			return
+		}
+		if existing, found := d.locations[n]; found && existing.pos > pos {
+			continue
+		}
+		d.locations[n] = pdfObjectLocation{stream: num, index: i, pos: pos}
+	}
+}
+
+func (d *pdfDocument) objectNumbers() []int {
+	nums := make([]int, 0, len(d.locations))
+	for num := range d.locations {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	return nums
+}
+
+// mustObject returns the object or nil when it is missing or unreadable,
+// which PDF treats the same as null.
+func (d *pdfDocument) mustObject(num int) any {
+	value, err := d.object(num)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+func (d *pdfDocument) object(num int) (any, error) {
+	if value, ok := d.cache[num]; ok {
+		return value, nil
+	}
+	loc, ok := d.locations[num]
+	if !ok {
+		return nil, fmt.Errorf("object %d not found", num)
+	}
+	// Guard against reference cycles (for example a stream whose /Length
+	// points back at itself) while the object is being parsed.
+	d.cache[num] = nil
+	var value any
+	var err error
+	if loc.stream >= 0 {
+		value, err = d.objectFromStream(loc)
+	} else {
+		value, err = d.parseIndirectObject(loc.offset)
+	}
+	if err != nil {
+		delete(d.cache, num)
+		return nil, err
+	}
+	d.cache[num] = value
+	return value, nil
+}
+
+func (d *pdfDocument) objectFromStream(loc pdfObjectLocation) (any, error) {
+	stream, ok := d.mustObject(loc.stream).(pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("object stream %d not found", loc.stream)
+	}
+	decoded, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	first, _ := d.resolve(stream.dict["First"]).(int)
+	lexer := &pdfLexer{data: decoded}
+	offset := -1
+	for i := 0; i <= loc.index; i++ {
+		if _, err := lexer.next(); err != nil {
+			return nil, err
+		}
+		value, err := lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		offset, _ = value.(int)
+	}
+	if first < 0 || offset < 0 || first >= len(decoded) || offset >= len(decoded)-first {
+		return nil, errors.New("object stream offset out of range")
+	}
+	lexer.pos = first + offset
+	return lexer.readObject()
+}
+
+func (d *pdfDocument) parseIndirectObject(offset int) (any, error) {
+	lexer := &pdfLexer{data: d.data, pos: offset}
+	for i := 0; i < 3; i++ {
+		if _, err := lexer.next(); err != nil {
+			return nil, err
+		}
+	}
+	value, err := lexer.readObject()
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := value.(pdfDict)
+	if !ok {
+		return value, nil
+	}
+	save := lexer.pos
+	token, err := lexer.next()
+	if err != nil || token != pdfKeyword("stream") {
+		lexer.pos = save
+		return dict, nil
+	}
+	start := lexer.pos
+	if start < len(d.data) && d.data[start] == '\r' {
+		start++
+	}
+	if start < len(d.data) && d.data[start] == '\n' {
+		start++
+	}
+	length, _ := d.resolve(dict["Length"]).(int)
+	// Check the length before adding it, so a huge /Length cannot wrap.
+	if length < 0 || length > len(d.data)-start {
+		return nil, errors.New("PDF stream length out of range")
+	}
+	end := start + length
+	if length == 0 || !pdfStreamEndsAt(d.data, end) {
+		idx := bytes.Index(d.data[start:], []byte("endstream"))
+		if idx < 0 {
+			return nil, errors.New("unterminated PDF stream")
+		}
+		end = start + idx
+		for end > start && (d.data[end-1] == '\n' || d.data[end-1] == '\r') {
+			end--
+		}
+	}
+	return pdfStream{dict: dict, data: d.data[start:end]}, nil
+}
+
+func pdfStreamEndsAt(data []byte, end int) bool {
+	for end < len(data) && isPDFWhitespace(data[end]) {
+		end++
+	}
+	return bytes.HasPrefix(data[end:], []byte("endstream"))
+}
+
+// resolve follows indirect references until it reaches a direct value.
+func (d *pdfDocument) resolve(value any) any {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value = d.mustObject(ref.num)
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(value any) pdfDict {
+	switch typed := d.resolve(value).(type) {
+	case pdfDict:
+		return typed
+	case pdfStream:
+		return typed.dict
+	default:
+		return nil
+	}
+}
+
+func (d *pdfDocument) array(value any) pdfArray {
+	array, _ := d.resolve(value).(pdfArray)
+	return array
+}
+
+func (d *pdfDocument) number(value any) (float64, bool) {
+	switch typed := d.resolve(value).(type) {
+	case int:
+		return float64(typed), true
+	case float64:
+		return typed, true
+	default:
+		return 0, false
+	}
+}
+
+func (d *pdfDocument) catalog() pdfDict {
+	return d.dict(d.trailer["Root"])
+}
+
+func (d *pdfDocument) info() pdfDict {
+	return d.dict(d.trailer["Info"])
+}
+
+// pages flattens the page tree in document order, applying inherited
+// attributes along the way.
+func (d *pdfDocument) pages() ([]pdfPage, error) {
+	root, ok := d.catalog()["Pages"]
+	if !ok {
+		return nil, errors.New("PDF has no page tree")
+	}
+	var pages []pdfPage
+	visited := make(map[int]bool)
+	var walk func(node any, resources pdfDict, mediaBox []float64, rotate int, depth int) error
+	walk = func(node any, resources pdfDict, mediaBox []float64, rotate int, depth int) error {
+		if depth > 64 {
+			return errors.New("PDF page tree is too deep")
+		}
+		ref, isRef := node.(pdfRef)
+		if isRef {
+			if visited[ref.num] {
+				return nil
+			}
+			visited[ref.num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil {
+			return nil
+		}
+		if res := d.dict(dict["Resources"]); res != nil {
+			resources = res
+		}
+		if box := d.rectangle(dict["MediaBox"]); box != nil {
+			mediaBox = box
+		}
+		if value, ok := d.number(dict["Rotate"]); ok {
+			rotate = int(value)
+		}
+		if dict.name("Type") == "Pages" || (dict["Kids"] != nil && dict.name("Type") != "Page") {
+			for _, kid := range d.array(dict["Kids"]) {
+				if err := walk(kid, resources, mediaBox, rotate, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		pages = append(pages, pdfPage{ref: ref, dict: dict, resources: resources, mediaBox: mediaBox, rotate: rotate})
+		return nil
+	}
+	if err := walk(root, nil, nil, 0, 0); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func (d *pdfDocument) rectangle(value any) []float64 {
+	array := d.array(value)
+	if len(array) != 4 {
+		return nil
+	}
+	box := make([]float64, 4)
+	for i, item := range array {
+		n, ok := d.number(item)
+		if !ok {
+			return nil
+		}
+		box[i] = n
+	}
+	return box
+}
+
+// pageContent returns the decoded, concatenated content streams of a page.
+func (d *pdfDocument) pageContent(page pdfPage) ([]byte, error) {
+	var parts []any
+	switch contents := d.resolve(page.dict["Contents"]).(type) {
+	case pdfStream:
+		parts = append(parts, contents)
+	case pdfArray:
+		parts = contents
+	}
+	var out bytes.Buffer
+	for _, part := range parts {
+		stream, ok := d.resolve(part).(pdfStream)
+		if !ok {
+			continue
+		}
+		decoded, err := d.decodeStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(decoded)
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// decodeStream applies the stream's filters. Image codecs such as DCTDecode
+// are left to callers; anything else unsupported returns
+// errPDFUnsupportedFilter.
+func (d *pdfDocument) decodeStream(stream pdfStream) ([]byte, error) {
+	var filters []pdfName
+	var params []pdfDict
+	switch filter := d.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []pdfName{filter}
+		params = []pdfDict{d.dict(stream.dict["DecodeParms"])}
+	case pdfArray:
+		paramArray := d.array(stream.dict["DecodeParms"])
+		for i, item := range filter {
+			name, _ := d.resolve(item).(pdfName)
+			filters = append(filters, name)
+			var param pdfDict
+			if i < len(paramArray) {
+				param = d.dict(paramArray[i])
+			}
+			params = append(params, param)
+		}
+	}
+	data := stream.data
+	for i, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = pdfFlateDecode(data)
+			if err == nil {
+				data, err = d.applyPredictor(data, params[i])
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = pdfASCIIHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = pdfASCII85Decode(data)
+		default:
+			return nil, fmt.Errorf("%w: %s", errPDFUnsupportedFilter, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func pdfFlateDecode(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	out, err := io.ReadAll(reader)
+	// Truncated streams are common; keep whatever inflated cleanly.
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// applyPredictor undoes PNG row predictors (Predictor >= 10), which xref and
+// object streams often use.
+func (d *pdfDocument) applyPredictor(data []byte, params pdfDict) ([]byte, error) {
+	predictor, _ := d.number(params["Predictor"])
+	if predictor < 10 {
+		return data, nil
+	}
+	columns := 1
+	if value, ok := d.number(params["Columns"]); ok {
+		columns = int(value)
+	}
+	colors := 1
+	if value, ok := d.number(params["Colors"]); ok {
+		colors = int(value)
+	}
+	bits := 8
+	if value, ok := d.number(params["BitsPerComponent"]); ok {
+		bits = int(value)
+	}
+	bpp := max(1, colors*bits/8)
+	rowLen := (columns*colors*bits + 7) / 8
+	if rowLen <= 0 {
+		return nil, errors.New("invalid predictor columns")
+	}
+	var out []byte
+	prev := make([]byte, rowLen)
+	for pos := 0; pos+1+rowLen <= len(data); pos += rowLen + 1 {
+		kind := data[pos]
+		row := append([]byte(nil), data[pos+1:pos+1+rowLen]...)
+		for i := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up = prev[i]
+			switch kind {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += pdfPaeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func pdfPaeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := p-int(a), p-int(b), p-int(c)
+	if pa < 0 {
+		pa = -pa
+	}
+	if pb < 0 {
+		pb = -pb
+	}
+	if pc < 0 {
+		pc = -pc
+	}
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func pdfASCIIHexDecode(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isPDFWhitespace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func pdfASCII85Decode(data []byte) ([]byte, error) {
+	var out []byte
+	var group [5]byte
+	n := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case isPDFWhitespace(c):
+			continue
+		case c == '~':
+			i = len(data)
+			continue
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+			continue
+		case c < '!' || c > 'u':
+			return nil, fmt.Errorf("invalid ASCII85 byte %q", c)
+		}
+		group[n] = c - '!'
+		n++
+		if n == 5 {
+			var value uint32
+			for _, digit := range group {
+				value = value*85 + uint32(digit)
+			}
+			out = append(out, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+			n = 0
+		}
+	}
+	if n > 1 {
+		for i := n; i < 5; i++ {
+			group[i] = 84
+		}
+		var value uint32
+		for _, digit := range group {
+			value = value*85 + uint32(digit)
+		}
+		tail := []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+		out = append(out, tail[:n-1]...)
+	}
+	return out, nil
+}
+
+func (dict pdfDict) name(key pdfName) pdfName {
+	name, _ := dict[key].(pdfName)
+	return name
+}
+
+func isPDFWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	default:
+		return false
+	}
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	default:
+		return false
+	}
+}
+
+// pdfLexer tokenizes PDF syntax. It is shared by the object parser, object
+// streams, content streams, and CMaps.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next returns one token: a number, string, name, or keyword. Structural
+// delimiters come back as keywords ("[", "]", "<<", ">>").
+func (l *pdfLexer) next() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return l.readLiteralString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.readHexString()
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}' || c == ')':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == '/':
+		return l.readName(), nil
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if number, ok := parsePDFNumber(word); ok {
+		return number, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func parsePDFNumber(word string) (any, bool) {
+	if word == "" {
+		return nil, false
+	}
+	c := word[0]
+	if c != '+' && c != '-' && c != '.' && (c < '0' || c > '9') {
+		return nil, false
+	}
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) || isPDFDelimiter(c) {
+			break
+		}
+		if c == '#' && l.pos+2 < len(l.data) {
+			if decoded, err := hex.DecodeString(string(l.data[l.pos+1 : l.pos+3])); err == nil {
+				b = append(b, decoded[0])
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+func (l *pdfLexer) readLiteralString() (pdfString, error) {
+	l.pos++
+	depth := 1
+	var b []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(b), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = append(b, byte(value))
+					continue
+				}
+				b = append(b, e)
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+	return pdfString(b), errors.New("unterminated PDF string")
+}
+
+func (l *pdfLexer) readHexString() (pdfString, error) {
+	l.pos++
+	start := l.pos
+	end := bytes.IndexByte(l.data[start:], '>')
+	if end < 0 {
+		return "", errors.New("unterminated PDF hex string")
+	}
+	l.pos = start + end + 1
+	decoded, err := pdfASCIIHexDecode(l.data[start : start+end])
+	return pdfString(decoded), err
+}
+
+// readObject parses one complete object, including arrays, dictionaries, and
+// `num gen R` references.
+func (l *pdfLexer) readObject() (any, error) {
+	token, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	return l.completeObject(token)
+}
+
+func (l *pdfLexer) completeObject(token any) (any, error) {
+	switch typed := token.(type) {
+	case pdfKeyword:
+		switch typed {
+		case "[":
+			var array pdfArray
+			for {
+				item, err := l.next()
+				if err != nil {
+					return nil, err
+				}
+				if item == pdfKeyword("]") {
+					return array, nil
+				}
+				value, err := l.completeObject(item)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				item, err := l.next()
+				if err != nil {
+					return nil, err
+				}
+				if item == pdfKeyword(">>") {
+					return dict, nil
+				}
+				key, ok := item.(pdfName)
+				if !ok {
+					continue
+				}
+				value, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if value != nil {
+					dict[key] = value
+				}
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return typed, nil
+	case int:
+		save := l.pos
+		gen, err := l.next()
+		if genNum, ok := gen.(int); err == nil && ok {
+			if keyword, err := l.next(); err == nil && keyword == pdfKeyword("R") {
+				return pdfRef{num: typed, gen: genNum}, nil
+			}
+		}
+		l.pos = save
+		return typed, nil
+	default:
+		return token, nil
+	}
+}
@@ -0,0 +1,657 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// pdfTextMaxFormDepth bounds Form XObject recursion; nested forms can refer
+// back to themselves.
+const pdfTextMaxFormDepth = 8
+
+// pdfFont decodes shown strings into text. Composite (Type0) fonts read two
+// bytes per code unless their ToUnicode CMap declares otherwise; simple fonts
+// fall back to a single-byte encoding.
+type pdfFont struct {
+	composite bool
+	toUnicode *pdfCMap
+	encoding  [256]rune
+}
+
+type pdfCodespace struct {
+	low  []byte
+	high []byte
+}
+
+// pdfCMap is a parsed ToUnicode CMap: codespace ranges decide how many bytes
+// make up each character code, and mappings turn codes into text.
+type pdfCMap struct {
+	codespaces []pdfCodespace
+	mappings   map[string]string
+}
+
+type pdfTextExtractor struct {
+	doc   *pdfDocument
+	fonts map[string]*pdfFont
+	out   strings.Builder
+	// lastY is the baseline of the most recent shown text, used to start a
+	// new line when text moves vertically.
+	lastY float64
+	hasY  bool
+}
+
+// extractPDFText returns the text of every page, in page order.
+func extractPDFText(doc *pdfDocument) ([]string, error) {
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+	extractor := &pdfTextExtractor{doc: doc, fonts: make(map[string]*pdfFont)}
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		text, err := extractor.pageText(page)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return texts, nil
+}
+
+func (e *pdfTextExtractor) pageText(page pdfPage) (string, error) {
+	content, err := e.doc.pageContent(page)
+	if err != nil {
+		return "", err
+	}
+	e.out.Reset()
+	e.hasY = false
+	e.runContent(content, page.resources, 0)
+	return cleanPDFText(e.out.String()), nil
+}
+
+// runContent interprets the text-related operators of a content stream.
+// Graphics state that does not affect reading order is ignored.
+func (e *pdfTextExtractor) runContent(content []byte, resources pdfDict, depth int) {
+	lexer := &pdfLexer{data: content}
+	var operands []any
+	var font *pdfFont
+	var tm, tlm [6]float64
+	var leading float64
+	identity := [6]float64{1, 0, 0, 1, 0, 0}
+	tm, tlm = identity, identity
+
+	moveTo := func(tx, ty float64) {
+		tlm[4] += tx*tlm[0] + ty*tlm[2]
+		tlm[5] += tx*tlm[1] + ty*tlm[3]
+		tm = tlm
+		if ty == 0 && tx != 0 {
+			e.separateWords()
+		}
+	}
+	for {
+		token, err := lexer.readObject()
+		if err != nil {
+			return
+		}
+		op, ok := token.(pdfKeyword)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+		switch op {
+		case "BT":
+			tm, tlm = identity, identity
+		case "ET":
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = e.font(resources, name)
+				}
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				leading = pdfOperandNumber(operands[len(operands)-1])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx := pdfOperandNumber(operands[len(operands)-2])
+				ty := pdfOperandNumber(operands[len(operands)-1])
+				if op == "TD" {
+					leading = -ty
+				}
+				moveTo(tx, ty)
+			}
+		case "T*":
+			moveTo(0, -leading)
+			e.newLine()
+		case "Tm":
+			if len(operands) >= 6 {
+				for i := 0; i < 6; i++ {
+					tlm[i] = pdfOperandNumber(operands[len(operands)-6+i])
+				}
+				tm = tlm
+				if e.hasY && tm[5] == e.lastY {
+					e.separateWords()
+				}
+			}
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				moveTo(0, -leading)
+				e.newLine()
+			}
+			if len(operands) >= 1 {
+				if text, ok := operands[len(operands)-1].(pdfString); ok {
+					e.show(font, tm[5], text)
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if array, ok := operands[len(operands)-1].(pdfArray); ok {
+					for _, item := range array {
+						switch typed := item.(type) {
+						case pdfString:
+							e.show(font, tm[5], typed)
+						case int, float64:
+							// Large negative adjustments move right by
+							// roughly a space width.
+							if pdfOperandNumber(typed) < -200 {
+								e.separateWords()
+							}
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < pdfTextMaxFormDepth {
+				if name, ok := operands[len(operands)-1].(pdfName); ok {
+					e.runForm(resources, name, depth)
+				}
+			}
+		case "BI":
+			skipPDFInlineImage(lexer)
+		}
+		operands = operands[:0]
+	}
+}
+
+func (e *pdfTextExtractor) runForm(resources pdfDict, name pdfName, depth int) {
+	xobjects := e.doc.dict(resources["XObject"])
+	stream, ok := e.doc.resolve(xobjects[name]).(pdfStream)
+	if !ok || stream.dict.name("Subtype") != "Form" {
+		return
+	}
+	data, err := e.doc.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	formResources := e.doc.dict(stream.dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	e.runContent(data, formResources, depth+1)
+}
+
+// skipPDFInlineImage moves past BI ... ID <binary> EI, whose binary payload
+// would otherwise be tokenized as content.
+func skipPDFInlineImage(lexer *pdfLexer) {
+	for {
+		token, err := lexer.next()
+		if err != nil {
+			return
+		}
+		if token == pdfKeyword("ID") {
+			break
+		}
+	}
+	data := lexer.data
+	for i := lexer.pos; i+2 < len(data); i++ {
+		if data[i] == 'E' && data[i+1] == 'I' && isPDFWhitespace(data[i-1]) && (i+2 == len(data) || isPDFWhitespace(data[i+2])) {
+			lexer.pos = i + 2
+			return
+		}
+	}
+	lexer.pos = len(data)
+}
+
+func pdfOperandNumber(value any) float64 {
+	switch typed := value.(type) {
+	case int:
+		return float64(typed)
+	case float64:
+		return typed
+	default:
+		return 0
+	}
+}
+
+func (e *pdfTextExtractor) show(font *pdfFont, y float64, text pdfString) {
+	if font == nil {
+		return
+	}
+	if e.hasY && math.Abs(y-e.lastY) > 1 {
+		e.newLine()
+	}
+	e.lastY, e.hasY = y, true
+	e.out.WriteString(font.decode([]byte(text)))
+}
+
+func (e *pdfTextExtractor) newLine() {
+	if text := e.out.String(); text != "" && !strings.HasSuffix(text, "\n") {
+		e.out.WriteByte('\n')
+	}
+}
+
+func (e *pdfTextExtractor) separateWords() {
+	text := e.out.String()
+	if text != "" && !strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\n") {
+		e.out.WriteByte(' ')
+	}
+}
+
+// font loads and caches the font named in resources. Fonts are keyed by the
+// identity of their resolved dictionary, which the document caches, so pages
+// sharing a font parse its CMap once.
+func (e *pdfTextExtractor) font(resources pdfDict, name pdfName) *pdfFont {
+	dict := e.doc.dict(e.doc.dict(resources["Font"])[name])
+	if dict == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%p", dict)
+	if font, ok := e.fonts[key]; ok {
+		return font
+	}
+	font := e.loadFont(dict)
+	e.fonts[key] = font
+	return font
+}
+
+func (e *pdfTextExtractor) loadFont(dict pdfDict) *pdfFont {
+	if dict == nil {
+		return nil
+	}
+	font := &pdfFont{composite: dict.name("Subtype") == "Type0"}
+	if stream, ok := e.doc.resolve(dict["ToUnicode"]).(pdfStream); ok {
+		if data, err := e.doc.decodeStream(stream); err == nil {
+			font.toUnicode = parsePDFCMap(data)
+		}
+	}
+	if font.composite && font.toUnicode == nil {
+		// Without ToUnicode, glyph IDs in a composite font have no reliable
+		// mapping back to text.
+		return nil
+	}
+	font.encoding = pdfWinAnsiEncoding()
+	switch encoding := e.doc.resolve(dict["Encoding"]).(type) {
+	case pdfName:
+		if encoding == "MacRomanEncoding" {
+			font.encoding = pdfMacRomanEncoding()
+		}
+	case pdfDict:
+		if encoding.name("BaseEncoding") == "MacRomanEncoding" {
+			font.encoding = pdfMacRomanEncoding()
+		}
+		code := 0
+		for _, item := range e.doc.array(encoding["Differences"]) {
+			switch typed := e.doc.resolve(item).(type) {
+			case int:
+				code = typed
+			case pdfName:
+				if code >= 0 && code < 256 {
+					if r, ok := pdfGlyphRune(string(typed)); ok {
+						font.encoding[code] = r
+					}
+				}
+				code++
+			}
+		}
+	}
+	return font
+}
+
+func (f *pdfFont) decode(data []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(data); {
+		if f.toUnicode != nil {
+			n := f.toUnicode.codeLength(data[i:], f.composite)
+			if text, ok := f.toUnicode.mappings[string(data[i:i+n])]; ok {
+				b.WriteString(text)
+				i += n
+				continue
+			}
+			if f.composite {
+				i += n
+				continue
+			}
+		}
+		if r := f.encoding[data[i]]; r != 0 {
+			b.WriteRune(r)
+		}
+		i++
+	}
+	return b.String()
+}
+
+func (c *pdfCMap) codeLength(data []byte, composite bool) int {
+	for _, space := range c.codespaces {
+		n := len(space.low)
+		if n == 0 || n > len(data) {
+			continue
+		}
+		inside := true
+		for j := 0; j < n; j++ {
+			if data[j] < space.low[j] || data[j] > space.high[j] {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			return n
+		}
+	}
+	if composite && len(data) >= 2 {
+		return 2
+	}
+	return 1
+}
+
+// parsePDFCMap reads codespace, bfchar, and bfrange sections, including the
+// array form of bfrange. Destinations are UTF-16BE.
+func parsePDFCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{mappings: make(map[string]string)}
+	lexer := &pdfLexer{data: data}
+	var operands []any
+	for {
+		token, err := lexer.readObject()
+		if err != nil {
+			break
+		}
+		keyword, ok := token.(pdfKeyword)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+		switch keyword {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				low, ok1 := operands[i].(pdfString)
+				high, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 && len(low) == len(high) {
+					cmap.codespaces = append(cmap.codespaces, pdfCodespace{low: []byte(low), high: []byte(high)})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					cmap.mappings[string(src)] = decodeUTF16BE([]byte(dst))
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				low, ok1 := operands[i].(pdfString)
+				high, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(low) != len(high) || len(low) == 0 {
+					continue
+				}
+				cmap.addRange([]byte(low), []byte(high), operands[i+2])
+			}
+		}
+		operands = operands[:0]
+	}
+	return cmap
+}
+
+func (c *pdfCMap) addRange(low, high []byte, dst any) {
+	start := pdfCodeValue(low)
+	end := pdfCodeValue(high)
+	if end < start || end-start > 0xFFFF {
+		return
+	}
+	// Count on a wider type: with end at 0xFFFFFFFF a uint32 would wrap to
+	// zero and never pass end.
+	for wide := uint64(start); wide <= uint64(end); wide++ {
+		code := uint32(wide)
+		key := pdfCodeBytes(code, len(low))
+		offset := int(code - start)
+		switch typed := dst.(type) {
+		case pdfString:
+			base := []byte(typed)
+			if len(base) == 0 {
+				continue
+			}
+			// The last byte increments across the range.
+			value := append([]byte(nil), base...)
+			last := int(value[len(value)-1]) + offset
+			value[len(value)-1] = byte(last)
+			if last > 0xFF && len(value) >= 2 {
+				value[len(value)-2] += byte(last >> 8)
+			}
+			c.mappings[key] = decodeUTF16BE(value)
+		case pdfArray:
+			if offset < len(typed) {
+				if text, ok := typed[offset].(pdfString); ok {
+					c.mappings[key] = decodeUTF16BE([]byte(text))
+				}
+			}
+		}
+	}
+}
+
+func pdfCodeValue(code []byte) uint32 {
+	var value uint32
+	for _, b := range code {
+		value = value<<8 | uint32(b)
+	}
+	return value
+}
+
+func pdfCodeBytes(value uint32, n int) string {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = byte(value)
+		value >>= 8
+	}
+	return string(out)
+}
+
+func decodeUTF16BE(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// pdfTextString decodes a PDF text string from the document information
+// dictionary or outlines: UTF-16BE with a BOM, UTF-8 with a BOM, or
+// PDFDocEncoding, which matches Latin-1 for the characters seen in practice.
+func pdfTextString(value any) string {
+	s, ok := value.(pdfString)
+	if !ok {
+		return ""
+	}
+	data := []byte(s)
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16BE(data[2:])
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) && utf8.Valid(data[3:]):
+		return string(data[3:])
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// parsePDFDate parses `D:YYYYMMDDHHmmSSOHH'mm'`, where every field after the
+// year is optional.
+func parsePDFDate(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	if len(value) < 4 {
+		return time.Time{}, false
+	}
+	fields := []int{0, 1, 1, 0, 0, 0}
+	widths := []int{4, 2, 2, 2, 2, 2}
+	pos := 0
+	for i, width := range widths {
+		if pos+width > len(value) {
+			break
+		}
+		n, err := strconv.Atoi(value[pos : pos+width])
+		if err != nil {
+			break
+		}
+		fields[i] = n
+		pos += width
+	}
+	location := time.UTC
+	if pos < len(value) && (value[pos] == '+' || value[pos] == '-') {
+		zone := strings.NewReplacer("'", "").Replace(value[pos+1:])
+		hours, minutes := 0, 0
+		if len(zone) >= 2 {
+			hours, _ = strconv.Atoi(zone[:2])
+		}
+		if len(zone) >= 4 {
+			minutes, _ = strconv.Atoi(zone[2:4])
+		}
+		offset := hours*3600 + minutes*60
+		if value[pos] == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, location), true
+}
+
+// cleanPDFText trims trailing spaces and collapses runs of blank lines.
+func cleanPDFText(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func pdfWinAnsiEncoding() [256]rune {
+	var table [256]rune
+	for i := 0x20; i < 0x7F; i++ {
+		table[i] = rune(i)
+	}
+	for i := 0xA0; i <= 0xFF; i++ {
+		table[i] = rune(i)
+	}
+	table['\t'], table['\n'], table['\r'] = '\t', '\n', '\r'
+	high := map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	}
+	for code, r := range high {
+		table[code] = r
+	}
+	table[0xAD] = '-'
+	return table
+}
+
+func pdfMacRomanEncoding() [256]rune {
+	var table [256]rune
+	for i := 0x20; i < 0x7F; i++ {
+		table[i] = rune(i)
+	}
+	high := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range high {
+		table[0x80+i] = r
+	}
+	return table
+}
+
+// pdfGlyphNames covers the glyph names that commonly appear in /Differences
+// arrays; single letters and uniXXXX names are handled in pdfGlyphRune.
+var pdfGlyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "quoteright": '’',
+	"parenleft": '(', "parenright": ')', "asterisk": '*', "plus": '+', "comma": ',',
+	"hyphen": '-', "minus": '−', "period": '.', "slash": '/', "zero": '0', "one": '1',
+	"two": '2', "three": '3', "four": '4', "five": '5', "six": '6', "seven": '7',
+	"eight": '8', "nine": '9', "colon": ':', "semicolon": ';', "less": '<',
+	"equal": '=', "greater": '>', "question": '?', "at": '@', "bracketleft": '[',
+	"backslash": '\\', "bracketright": ']', "asciicircum": '^', "underscore": '_',
+	"grave": '`', "quoteleft": '‘', "braceleft": '{', "bar": '|', "braceright": '}',
+	"asciitilde": '~', "bullet": '•', "endash": '–', "emdash": '—', "ellipsis": '…',
+	"quotedblleft": '“', "quotedblright": '”', "quotesinglbase": '‚',
+	"quotedblbase": '„', "dagger": '†', "daggerdbl": '‡', "trademark": '™',
+	"copyright": '©', "registered": '®', "degree": '°', "section": '§',
+	"paragraph": '¶', "fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ',
+	"Euro": '€', "sterling": '£', "yen": '¥', "cent": '¢', "dotlessi": 'ı',
+	"germandbls": 'ß', "eacute": 'é', "egrave": 'è', "ecircumflex": 'ê',
+	"aacute": 'á', "agrave": 'à', "acircumflex": 'â', "adieresis": 'ä',
+	"odieresis": 'ö', "udieresis": 'ü', "ccedilla": 'ç', "ntilde": 'ñ',
+	"Adieresis": 'Ä', "Odieresis": 'Ö', "Udieresis": 'Ü', "Eacute": 'É',
+	"nbspace": ' ', "multiply": '×', "divide": '÷', "periodcentered": '·',
+}
+
+func pdfGlyphRune(name string) (rune, bool) {
+	if r, ok := pdfGlyphNames[name]; ok {
+		return r, true
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return r, true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) >= 7 {
+		if n, err := strconv.ParseUint(name[3:7], 16, 32); err == nil {
+			return rune(n), true
+		}
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if n, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return rune(n), true
+		}
+	}
+	return 0, false
+}
+
+// readPDFText is the shared entry point for commands and attachments.
+func readPDFText(data []byte) ([]string, error) {
+	doc, err := openPDFDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return extractPDFText(doc)
+}
+
+var errPDFNoText = errors.New("no extractable text found")
+
+func joinPDFPages(pages []string) (string, error) {
+	var nonEmpty []string
+	for _, page := range pages {
+		if strings.TrimSpace(page) != "" {
+			nonEmpty = append(nonEmpty, page)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return "", errPDFNoText
+	}
+	return strings.Join(nonEmpty, "\n\n"), nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// pdfOutputPage is one page to write: a page from an opened document plus
+// any extra clockwise rotation, in degrees, to apply on top of its own.
+type pdfOutputPage struct {
+	doc    *pdfDocument
+	page   pdfPage
+	rotate int
+}
+
+// pdfWriter collects objects for a new file. Object i+1 lives at
+// objects[i]; slots can be reserved first so pages and their parent can
+// refer to each other.
+type pdfWriter struct {
+	objects []any
+}
+
+func (pw *pdfWriter) reserve() pdfRef {
+	pw.objects = append(pw.objects, nil)
+	return pdfRef{num: len(pw.objects)}
+}
+
+func (pw *pdfWriter) set(ref pdfRef, value any) {
+	pw.objects[ref.num-1] = value
+}
+
+// pdfCopier copies objects from one source document into a writer,
+// renumbering references and copying each source object at most once.
+type pdfCopier struct {
+	doc    *pdfDocument
+	writer *pdfWriter
+	refs   map[int]pdfRef
+}
+
+func (c *pdfCopier) copyValue(value any) any {
+	switch typed := value.(type) {
+	case pdfRef:
+		return c.copyRef(typed.num)
+	case pdfDict:
+		out := make(pdfDict, len(typed))
+		for key, item := range typed {
+			if copied := c.copyValue(item); copied != nil {
+				out[key] = copied
+			}
+		}
+		return out
+	case pdfArray:
+		out := make(pdfArray, len(typed))
+		for i, item := range typed {
+			out[i] = c.copyValue(item)
+		}
+		return out
+	case pdfStream:
+		// Length is rewritten from the data, so an indirect length object
+		// does not need copying.
+		dict := make(pdfDict, len(typed.dict))
+		for key, item := range typed.dict {
+			if key == "Length" {
+				continue
+			}
+			if copied := c.copyValue(item); copied != nil {
+				dict[key] = copied
+			}
+		}
+		return pdfStream{dict: dict, data: typed.data}
+	default:
+		return value
+	}
+}
+
+// copyRef copies the referenced object. Pages that were not selected, and
+// the source page tree itself, become null so that links such as an
+// annotation's /P do not drag the whole source document along.
+func (c *pdfCopier) copyRef(num int) any {
+	if ref, ok := c.refs[num]; ok {
+		return ref
+	}
+	value := c.doc.mustObject(num)
+	if dict, ok := value.(pdfDict); ok {
+		if kind := dict.name("Type"); kind == "Page" || kind == "Pages" {
+			return nil
+		}
+	}
+	ref := c.writer.reserve()
+	c.refs[num] = ref
+	c.writer.set(ref, c.copyValue(value))
+	return ref
+}
+
+// copyPage writes the page with its inherited attributes made explicit,
+// since the new page tree has no intermediate nodes to inherit from.
+func (c *pdfCopier) copyPage(out pdfOutputPage, parent pdfRef) pdfDict {
+	dict := make(pdfDict, len(out.page.dict)+4)
+	for key, value := range out.page.dict {
+		if key == "Parent" {
+			continue
+		}
+		if copied := c.copyValue(value); copied != nil {
+			dict[key] = copied
+		}
+	}
+	if _, ok := dict["Resources"]; !ok && out.page.resources != nil {
+		dict["Resources"] = c.copyValue(out.page.resources)
+	}
+	if out.page.mediaBox != nil {
+		box := make(pdfArray, len(out.page.mediaBox))
+		for i, n := range out.page.mediaBox {
+			box[i] = n
+		}
+		dict["MediaBox"] = box
+	}
+	rotate := ((out.page.rotate+out.rotate)%360 + 360) % 360
+	if rotate != 0 {
+		dict["Rotate"] = rotate
+	} else {
+		delete(dict, "Rotate")
+	}
+	dict["Parent"] = parent
+	return dict
+}
+
+// writePDFPages writes a new PDF containing pages in order. Document
+// information is carried over from the first page's source document;
+// document-level features such as outlines and forms are dropped because
+// they refer to pages that may no longer exist.
+func writePDFPages(w io.Writer, pages []pdfOutputPage) error {
+	writer := &pdfWriter{}
+	catalogRef := writer.reserve()
+	pagesRef := writer.reserve()
+	copiers := make(map[*pdfDocument]*pdfCopier)
+	copierFor := func(doc *pdfDocument) *pdfCopier {
+		if c, ok := copiers[doc]; ok {
+			return c
+		}
+		c := &pdfCopier{doc: doc, writer: writer, refs: make(map[int]pdfRef)}
+		copiers[doc] = c
+		return c
+	}
+
+	kids := make(pdfArray, len(pages))
+	pageRefs := make([]pdfRef, len(pages))
+	for i, out := range pages {
+		ref := writer.reserve()
+		pageRefs[i] = ref
+		kids[i] = ref
+		c := copierFor(out.doc)
+		if out.page.ref.num > 0 {
+			if _, seen := c.refs[out.page.ref.num]; !seen {
+				c.refs[out.page.ref.num] = ref
+			}
+		}
+	}
+	for i, out := range pages {
+		writer.set(pageRefs[i], copierFor(out.doc).copyPage(out, pagesRef))
+	}
+	writer.set(pagesRef, pdfDict{"Type": pdfName("Pages"), "Kids": kids, "Count": len(pages)})
+	writer.set(catalogRef, pdfDict{"Type": pdfName("Catalog"), "Pages": pagesRef})
+
+	trailer := pdfDict{"Root": catalogRef}
+	if len(pages) > 0 {
+		if info := pages[0].doc.info(); info != nil {
+			infoRef := writer.reserve()
+			writer.set(infoRef, copierFor(pages[0].doc).copyValue(info))
+			trailer["Info"] = infoRef
+		}
+	}
+	return writer.write(w, trailer)
+}
+
+func (pw *pdfWriter) write(w io.Writer, trailer pdfDict) error {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(pw.objects))
+	for i, value := range pw.objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		if stream, ok := value.(pdfStream); ok {
+			dict := make(pdfDict, len(stream.dict))
+			for key, item := range stream.dict {
+				dict[key] = item
+			}
+			dict["Length"] = len(stream.data)
+			writePDFValue(&b, dict)
+			b.WriteString("\nstream\n")
+			b.Write(stream.data)
+			b.WriteString("\nendstream")
+		} else {
+			writePDFValue(&b, value)
+		}
+		b.WriteString("\nendobj\n")
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(pw.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	trailer["Size"] = len(pw.objects) + 1
+	b.WriteString("trailer\n")
+	writePDFValue(&b, trailer)
+	fmt.Fprintf(&b, "\nstartxref\n%d\n%%%%EOF\n", xref)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writePDFValue serializes a direct object. Dictionary keys are sorted so
+// output is deterministic.
+func writePDFValue(b *bytes.Buffer, value any) {
+	switch typed := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(typed))
+	case int:
+		b.WriteString(strconv.Itoa(typed))
+	case float64:
+		b.WriteString(strconv.FormatFloat(typed, 'f', -1, 64))
+	case pdfName:
+		writePDFName(b, typed)
+	case pdfString:
+		writePDFString(b, typed)
+	case pdfRef:
+		fmt.Fprintf(b, "%d %d R", typed.num, typed.gen)
+	case pdfArray:
+		b.WriteByte('[')
+		for i, item := range typed {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writePDFValue(b, item)
+		}
+		b.WriteByte(']')
+	case pdfDict:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+		b.WriteString("<<")
+		for _, key := range keys {
+			b.WriteByte(' ')
+			writePDFName(b, pdfName(key))
+			b.WriteByte(' ')
+			writePDFValue(b, typed[pdfName(key)])
+		}
+		b.WriteString(" >>")
+	case pdfKeyword:
+		b.WriteString(string(typed))
+	default:
+		b.WriteString("null")
+	}
+}
+
+func writePDFName(b *bytes.Buffer, name pdfName) {
+	b.WriteByte('/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 0x21 || c > 0x7E || c == '#' || isPDFDelimiter(c) {
+			fmt.Fprintf(b, "#%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
+
+// writePDFString uses a literal string for printable ASCII and a hex string
+// for anything else, which keeps binary strings intact.
+func writePDFString(b *bytes.Buffer, s pdfString) {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			fmt.Fprintf(b, "<%X>", []byte(s))
+			return
+		}
+	}
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '(' || s[i] == ')' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte(')')
+}
@@ -0,0 +1,1425 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The viewer's query box runs a read-only subset of SQL against one table:
+//
+//	SELECT *|expr [AS name], ... | count(*) FROM table
+//	  [WHERE expr] [ORDER BY expr|n [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+//
+// Expressions cover comparisons, AND/OR/NOT, LIKE, IN, BETWEEN, IS NULL,
+// arithmetic, ||, and a handful of scalar functions.
+
+const (
+	sqliteQueryMaxRows     = 1000
+	sqliteQueryMaxSortRows = 200000
+)
+
+var errSQLiteSelectOnly = errors.New("only SELECT statements are allowed")
+
+type sqlTokenKind int
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenQuoted
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenBlob
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+func (t sqlToken) is(symbol string) bool {
+	return t.kind == sqlTokenSymbol && t.text == symbol
+}
+
+func (t sqlToken) keyword(word string) bool {
+	return t.kind == sqlTokenIdent && strings.EqualFold(t.text, word)
+}
+
+func (t sqlToken) name() bool {
+	return t.kind == sqlTokenIdent || t.kind == sqlTokenQuoted
+}
+
+func tokenizeSQL(src string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		next := byte(0)
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && next == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && next == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 4
+			}
+		case (c == 'x' || c == 'X') && next == '\'':
+			text, n, err := readSQLQuoted(src[i+1:], '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenBlob, text: text})
+			i += 1 + n
+		case c == '\'':
+			text, n, err := readSQLQuoted(src[i:], '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenString, text: text})
+			i += n
+		case c == '"' || c == '`':
+			text, n, err := readSQLQuoted(src[i:], c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenQuoted, text: text})
+			i += n
+		case c == '[':
+			end := strings.IndexByte(src[i:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated [identifier]")
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenQuoted, text: src[i+1 : i+end]})
+			i += end + 1
+		case c >= '0' && c <= '9' || c == '.' && next >= '0' && next <= '9':
+			start := i
+			if c == '0' && (next == 'x' || next == 'X') {
+				i += 2
+				for i < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[i]) >= 0 {
+					i++
+				}
+			} else {
+				for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+					i++
+				}
+				if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+					i++
+					if i < len(src) && (src[i] == '+' || src[i] == '-') {
+						i++
+					}
+					for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: src[start:i]})
+		case c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || src[i] >= 0x80 || unicode.IsLetter(rune(src[i])) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: src[start:i]})
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "<=", ">=", "<>", "!=", "==", "||":
+					tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: two})
+					i += 2
+					continue
+				}
+			}
+			if strings.IndexByte("(),;*=<>+-/%.", c) < 0 {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// readSQLQuoted reads a quoted run where a doubled quote stands for one.
+func readSQLQuoted(src string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		if src[i] != quote {
+			b.WriteByte(src[i])
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated %c quote", quote)
+}
+
+type sqliteQuery struct {
+	table   string
+	star    bool
+	count   bool
+	columns []sqliteResultColumn
+	where   sqlExpr
+	orderBy []sqliteOrderTerm
+	limit   int
+	offset  int
+}
+
+type sqliteResultColumn struct {
+	expr sqlExpr
+	name string
+}
+
+type sqliteOrderTerm struct {
+	expr     sqlExpr
+	position int
+	desc     bool
+}
+
+// sqliteResult is what the viewer renders: display strings, with nil for NULL.
+type sqliteResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Total     int      `json:"total"`
+	Truncated bool     `json:"truncated"`
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func (p *sqlParser) peek() sqlToken {
+	if p.pos >= len(p.tokens) {
+		return sqlToken{kind: sqlTokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	token := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *sqlParser) acceptKeyword(words ...string) bool {
+	for i, word := range words {
+		if p.pos+i >= len(p.tokens) || !p.tokens[p.pos+i].keyword(word) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *sqlParser) acceptSymbol(symbol string) bool {
+	if p.peek().is(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return fmt.Errorf("expected %q near %s", symbol, p.describe())
+	}
+	return nil
+}
+
+func (p *sqlParser) describe() string {
+	token := p.peek()
+	if token.kind == sqlTokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(token.text)
+}
+
+func parseSQLiteSelect(text string) (*sqliteQuery, error) {
+	tokens, err := tokenizeSQL(text)
+	if err != nil {
+		return nil, err
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].is(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("enter a SELECT statement")
+	}
+	for _, token := range tokens {
+		if token.is(";") {
+			return nil, errors.New("run one statement at a time")
+		}
+	}
+	p := &sqlParser{tokens: tokens}
+	if !p.acceptKeyword("SELECT") {
+		return nil, errSQLiteSelectOnly
+	}
+	if p.peek().keyword("DISTINCT") {
+		return nil, errors.New("SELECT DISTINCT is not supported in the query box")
+	}
+
+	q := &sqliteQuery{limit: -1}
+	if p.acceptSymbol("*") {
+		q.star = true
+	} else {
+		for {
+			start := p.pos
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			column := sqliteResultColumn{expr: expr, name: sqlTokensText(p.tokens[start:p.pos])}
+			if ref, ok := expr.(*sqlColumn); ok {
+				column.name = ref.name
+			}
+			if p.acceptKeyword("AS") || p.peek().name() && !p.peek().keyword("FROM") {
+				alias := p.next()
+				if !alias.name() {
+					return nil, fmt.Errorf("expected a column alias near %s", p.describe())
+				}
+				column.name = alias.text
+			}
+			if _, ok := expr.(*sqlCountStar); ok {
+				q.count = true
+			}
+			q.columns = append(q.columns, column)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if q.count && len(q.columns) > 1 {
+			return nil, errors.New("count(*) cannot be combined with other columns; GROUP BY is not supported")
+		}
+	}
+
+	if !p.acceptKeyword("FROM") {
+		return nil, fmt.Errorf("expected FROM near %s", p.describe())
+	}
+	table := p.next()
+	if !table.name() {
+		return nil, fmt.Errorf("expected a table name near %s", strconv.Quote(table.text))
+	}
+	q.table = table.text
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER", "BY") {
+		for {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			term := sqliteOrderTerm{expr: expr}
+			if literal, ok := expr.(*sqlLiteral); ok {
+				if n, ok := literal.value.(int64); ok {
+					term.position = int(n)
+				}
+			}
+			if p.acceptKeyword("DESC") {
+				term.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, term)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+		if p.acceptKeyword("OFFSET") {
+			if q.offset, err = p.parseCount("OFFSET"); err != nil {
+				return nil, err
+			}
+		} else if p.acceptSymbol(",") {
+			q.offset = q.limit
+			if q.limit, err = p.parseCount("LIMIT"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.peek().kind != sqlTokenEOF {
+		return nil, fmt.Errorf("unsupported clause near %s; the query box runs SELECT ... FROM one table with WHERE, ORDER BY, and LIMIT", p.describe())
+	}
+	return q, nil
+}
+
+func (p *sqlParser) parseCount(clause string) (int, error) {
+	token := p.next()
+	n, err := strconv.Atoi(token.text)
+	if token.kind != sqlTokenNumber || err != nil || n < 0 {
+		return 0, fmt.Errorf("%s needs a whole number", clause)
+	}
+	return n, nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, error) {
+	return p.parseOr()
+}
+
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		switch {
+		case token.kind == sqlTokenSymbol && strings.Contains("= == != <> < <= > >=", token.text) && token.text != "":
+			p.next()
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			op := token.text
+			switch op {
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			}
+			left = &sqlBinary{op: op, left: left, right: right}
+		case token.keyword("IS"):
+			p.next()
+			not := p.acceptKeyword("NOT")
+			if !p.acceptKeyword("NULL") {
+				return nil, fmt.Errorf("expected NULL after IS near %s", p.describe())
+			}
+			left = &sqlIsNull{x: left, not: not}
+		case token.keyword("ISNULL"), token.keyword("NOTNULL"):
+			p.next()
+			left = &sqlIsNull{x: left, not: token.keyword("NOTNULL")}
+		case token.keyword("NOT") || token.keyword("LIKE") || token.keyword("IN") || token.keyword("BETWEEN"):
+			not := p.acceptKeyword("NOT")
+			switch {
+			case p.acceptKeyword("LIKE"):
+				pattern, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				left = &sqlLike{x: left, pattern: pattern, not: not}
+			case p.acceptKeyword("IN"):
+				if err := p.expectSymbol("("); err != nil {
+					return nil, err
+				}
+				in := &sqlIn{x: left, not: not}
+				for !p.acceptSymbol(")") {
+					item, err := p.parseExpr()
+					if err != nil {
+						return nil, err
+					}
+					in.list = append(in.list, item)
+					if !p.acceptSymbol(",") {
+						if err := p.expectSymbol(")"); err != nil {
+							return nil, err
+						}
+						break
+					}
+				}
+				left = in
+			case p.acceptKeyword("BETWEEN"):
+				low, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				if !p.acceptKeyword("AND") {
+					return nil, fmt.Errorf("expected AND in BETWEEN near %s", p.describe())
+				}
+				high, err := p.parseAdditive()
+				if err != nil {
+					return nil, err
+				}
+				left = &sqlBetween{x: left, low: low, high: high, not: not}
+			default:
+				return nil, fmt.Errorf("expected LIKE, IN, or BETWEEN after NOT near %s", p.describe())
+			}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("+") || p.peek().is("-") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("*") || p.peek().is("/") || p.peek().is("%") {
+		op := p.next().text
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseConcat() (sqlExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptSymbol("||") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &sqlBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.peek().is("-") || p.peek().is("+") {
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	token := p.next()
+	switch token.kind {
+	case sqlTokenNumber:
+		value, err := parseSQLNumber(token.text)
+		if err != nil {
+			return nil, err
+		}
+		return &sqlLiteral{value: value}, nil
+	case sqlTokenString:
+		return &sqlLiteral{value: token.text}, nil
+	case sqlTokenBlob:
+		data, err := hex.DecodeString(token.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blob literal x'%s'", token.text)
+		}
+		return &sqlLiteral{value: data}, nil
+	case sqlTokenQuoted:
+		return &sqlColumn{name: token.text}, nil
+	case sqlTokenIdent:
+		if token.keyword("NULL") {
+			return &sqlLiteral{}, nil
+		}
+		if token.keyword("SELECT") {
+			return nil, errors.New("subqueries are not supported in the query box")
+		}
+		if p.acceptSymbol("(") {
+			return p.parseCall(strings.ToLower(token.text))
+		}
+		if p.acceptSymbol(".") {
+			column := p.next()
+			if !column.name() {
+				return nil, fmt.Errorf("expected a column name after %s.", token.text)
+			}
+			return &sqlColumn{name: column.text}, nil
+		}
+		return &sqlColumn{name: token.text}, nil
+	case sqlTokenSymbol:
+		if token.is("(") {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expectSymbol(")")
+		}
+	}
+	p.pos--
+	return nil, fmt.Errorf("unexpected %s", p.describe())
+}
+
+func (p *sqlParser) parseCall(name string) (sqlExpr, error) {
+	if name == "count" && p.acceptSymbol("*") {
+		return &sqlCountStar{}, p.expectSymbol(")")
+	}
+	arity, ok := sqliteFunctionArity[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported function %s()", name)
+	}
+	call := &sqlCall{name: name}
+	for !p.acceptSymbol(")") {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if !p.acceptSymbol(",") {
+			if err := p.expectSymbol(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if arity >= 0 && len(call.args) != arity || arity < 0 && len(call.args) < 1 {
+		return nil, fmt.Errorf("wrong number of arguments to %s()", name)
+	}
+	return call, nil
+}
+
+// sqliteFunctionArity lists the scalar functions the query box knows; -1
+// means one or more arguments.
+var sqliteFunctionArity = map[string]int{
+	"lower":    1,
+	"upper":    1,
+	"length":   1,
+	"abs":      1,
+	"typeof":   1,
+	"coalesce": -1,
+	"ifnull":   2,
+}
+
+func parseSQLNumber(text string) (sqliteValue, error) {
+	if strings.HasPrefix(strings.ToLower(text), "0x") {
+		n, err := strconv.ParseUint(text[2:], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", text)
+		}
+		return int64(n), nil
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", text)
+	}
+	return f, nil
+}
+
+func sqlTokensText(tokens []sqlToken) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		word := token.kind != sqlTokenSymbol
+		if i > 0 && word && tokens[i-1].kind != sqlTokenSymbol {
+			b.WriteByte(' ')
+		}
+		switch token.kind {
+		case sqlTokenString:
+			b.WriteString("'" + strings.ReplaceAll(token.text, "'", "''") + "'")
+		case sqlTokenQuoted:
+			b.WriteString(`"` + token.text + `"`)
+		case sqlTokenBlob:
+			b.WriteString("x'" + token.text + "'")
+		default:
+			b.WriteString(token.text)
+		}
+	}
+	return b.String()
+}
+
+// sqliteRow is the row an expression is evaluated against.
+type sqliteRow struct {
+	rowid  int64
+	values []sqliteValue
+}
+
+type sqlExpr interface {
+	eval(row *sqliteRow) sqliteValue
+	bind(table *sqliteTable, aliases []sqliteResultColumn) error
+}
+
+type sqlLiteral struct{ value sqliteValue }
+
+type sqlColumn struct {
+	name  string
+	index int
+	alias sqlExpr
+}
+
+type sqlUnary struct {
+	op string
+	x  sqlExpr
+}
+
+type sqlBinary struct {
+	op          string
+	left, right sqlExpr
+}
+
+type sqlIsNull struct {
+	x   sqlExpr
+	not bool
+}
+
+type sqlLike struct {
+	x, pattern sqlExpr
+	not        bool
+}
+
+type sqlIn struct {
+	x    sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+type sqlBetween struct {
+	x, low, high sqlExpr
+	not          bool
+}
+
+type sqlCall struct {
+	name string
+	args []sqlExpr
+}
+
+type sqlCountStar struct{}
+
+func (e *sqlLiteral) bind(*sqliteTable, []sqliteResultColumn) error   { return nil }
+func (e *sqlCountStar) bind(*sqliteTable, []sqliteResultColumn) error { return nil }
+
+// bind resolves a column name to its index; rowid and its aliases map to -1,
+// and result-column aliases are accepted in ORDER BY.
+func (e *sqlColumn) bind(table *sqliteTable, aliases []sqliteResultColumn) error {
+	for i, column := range table.columns {
+		if strings.EqualFold(column.name, e.name) {
+			e.index = i
+			return nil
+		}
+	}
+	switch strings.ToLower(e.name) {
+	case "rowid", "_rowid_", "oid":
+		e.index = -1
+		return nil
+	}
+	for _, alias := range aliases {
+		if strings.EqualFold(alias.name, e.name) {
+			if _, self := alias.expr.(*sqlColumn); !self {
+				e.alias = alias.expr
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no such column: %s", e.name)
+}
+
+func (e *sqlUnary) bind(t *sqliteTable, a []sqliteResultColumn) error { return e.x.bind(t, a) }
+
+func (e *sqlBinary) bind(t *sqliteTable, a []sqliteResultColumn) error {
+	if err := e.left.bind(t, a); err != nil {
+		return err
+	}
+	return e.right.bind(t, a)
+}
+
+func (e *sqlIsNull) bind(t *sqliteTable, a []sqliteResultColumn) error { return e.x.bind(t, a) }
+
+func (e *sqlLike) bind(t *sqliteTable, a []sqliteResultColumn) error {
+	if err := e.x.bind(t, a); err != nil {
+		return err
+	}
+	return e.pattern.bind(t, a)
+}
+
+func (e *sqlIn) bind(t *sqliteTable, a []sqliteResultColumn) error {
+	if err := e.x.bind(t, a); err != nil {
+		return err
+	}
+	for _, item := range e.list {
+		if err := item.bind(t, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *sqlBetween) bind(t *sqliteTable, a []sqliteResultColumn) error {
+	for _, x := range []sqlExpr{e.x, e.low, e.high} {
+		if err := x.bind(t, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *sqlCall) bind(t *sqliteTable, a []sqliteResultColumn) error {
+	for _, arg := range e.args {
+		if err := arg.bind(t, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *sqlLiteral) eval(*sqliteRow) sqliteValue { return e.value }
+
+func (e *sqlCountStar) eval(*sqliteRow) sqliteValue { return nil }
+
+func (e *sqlColumn) eval(row *sqliteRow) sqliteValue {
+	switch {
+	case e.alias != nil:
+		return e.alias.eval(row)
+	case e.index < 0:
+		return row.rowid
+	case e.index < len(row.values):
+		return row.values[e.index]
+	default:
+		return nil
+	}
+}
+
+func (e *sqlUnary) eval(row *sqliteRow) sqliteValue {
+	x := e.x.eval(row)
+	if x == nil {
+		return nil
+	}
+	switch e.op {
+	case "NOT":
+		return sqliteBool(!sqliteTruth(x))
+	case "-":
+		switch v := sqliteNumeric(x).(type) {
+		case int64:
+			return -v
+		case float64:
+			return -v
+		}
+	}
+	return sqliteNumeric(x)
+}
+
+func (e *sqlBinary) eval(row *sqliteRow) sqliteValue {
+	switch e.op {
+	case "AND":
+		left, right := e.left.eval(row), e.right.eval(row)
+		if left != nil && !sqliteTruth(left) || right != nil && !sqliteTruth(right) {
+			return int64(0)
+		}
+		if left == nil || right == nil {
+			return nil
+		}
+		return int64(1)
+	case "OR":
+		left, right := e.left.eval(row), e.right.eval(row)
+		if left != nil && sqliteTruth(left) || right != nil && sqliteTruth(right) {
+			return int64(1)
+		}
+		if left == nil || right == nil {
+			return nil
+		}
+		return int64(0)
+	}
+	left, right := e.left.eval(row), e.right.eval(row)
+	if left == nil || right == nil {
+		return nil
+	}
+	switch e.op {
+	case "||":
+		return sqliteValueText(left) + sqliteValueText(right)
+	case "=", "!=", "<", "<=", ">", ">=":
+		cmp := compareSQLiteValues(left, right)
+		switch e.op {
+		case "=":
+			return sqliteBool(cmp == 0)
+		case "!=":
+			return sqliteBool(cmp != 0)
+		case "<":
+			return sqliteBool(cmp < 0)
+		case "<=":
+			return sqliteBool(cmp <= 0)
+		case ">":
+			return sqliteBool(cmp > 0)
+		default:
+			return sqliteBool(cmp >= 0)
+		}
+	}
+	return sqliteArithmetic(e.op, sqliteNumeric(left), sqliteNumeric(right))
+}
+
+func (e *sqlIsNull) eval(row *sqliteRow) sqliteValue {
+	return sqliteBool((e.x.eval(row) == nil) != e.not)
+}
+
+func (e *sqlLike) eval(row *sqliteRow) sqliteValue {
+	x, pattern := e.x.eval(row), e.pattern.eval(row)
+	if x == nil || pattern == nil {
+		return nil
+	}
+	return sqliteBool(sqliteLikeMatch(sqliteValueText(pattern), sqliteValueText(x)) != e.not)
+}
+
+func (e *sqlIn) eval(row *sqliteRow) sqliteValue {
+	x := e.x.eval(row)
+	if x == nil {
+		return nil
+	}
+	sawNull := false
+	for _, item := range e.list {
+		value := item.eval(row)
+		if value == nil {
+			sawNull = true
+			continue
+		}
+		if compareSQLiteValues(x, value) == 0 {
+			return sqliteBool(!e.not)
+		}
+	}
+	if sawNull {
+		return nil
+	}
+	return sqliteBool(e.not)
+}
+
+func (e *sqlBetween) eval(row *sqliteRow) sqliteValue {
+	x, low, high := e.x.eval(row), e.low.eval(row), e.high.eval(row)
+	if x == nil || low == nil || high == nil {
+		return nil
+	}
+	inside := compareSQLiteValues(x, low) >= 0 && compareSQLiteValues(x, high) <= 0
+	return sqliteBool(inside != e.not)
+}
+
+func (e *sqlCall) eval(row *sqliteRow) sqliteValue {
+	args := make([]sqliteValue, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.eval(row)
+	}
+	switch e.name {
+	case "coalesce", "ifnull":
+		for _, arg := range args {
+			if arg != nil {
+				return arg
+			}
+		}
+		return nil
+	case "typeof":
+		switch args[0].(type) {
+		case nil:
+			return "null"
+		case int64:
+			return "integer"
+		case float64:
+			return "real"
+		case []byte:
+			return "blob"
+		default:
+			return "text"
+		}
+	}
+	if args[0] == nil {
+		return nil
+	}
+	switch e.name {
+	case "lower":
+		return strings.ToLower(sqliteValueText(args[0]))
+	case "upper":
+		return strings.ToUpper(sqliteValueText(args[0]))
+	case "length":
+		if data, ok := args[0].([]byte); ok {
+			return int64(len(data))
+		}
+		return int64(utf8.RuneCountInString(formatSQLiteValue(args[0])))
+	case "abs":
+		switch v := sqliteNumeric(args[0]).(type) {
+		case int64:
+			if v < 0 {
+				return -v
+			}
+			return v
+		case float64:
+			return math.Abs(v)
+		}
+	}
+	return nil
+}
+
+func sqliteBool(v bool) sqliteValue {
+	if v {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+func sqliteTruth(value sqliteValue) bool {
+	switch v := sqliteNumeric(value).(type) {
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	}
+	return false
+}
+
+// sqliteNumeric converts text and blobs the way SQLite does for arithmetic:
+// the longest numeric prefix, or 0.
+func sqliteNumeric(value sqliteValue) sqliteValue {
+	switch v := value.(type) {
+	case nil, int64, float64:
+		return v
+	case []byte:
+		return sqliteNumeric(string(v))
+	case string:
+		text := strings.TrimSpace(v)
+		for end := len(text); end > 0; end-- {
+			if n, err := strconv.ParseInt(text[:end], 10, 64); err == nil {
+				return n
+			}
+			if f, err := strconv.ParseFloat(text[:end], 64); err == nil {
+				return f
+			}
+		}
+	}
+	return int64(0)
+}
+
+func sqliteArithmetic(op string, left, right sqliteValue) sqliteValue {
+	if a, ok := left.(int64); ok {
+		if b, ok := right.(int64); ok {
+			switch op {
+			case "+":
+				return a + b
+			case "-":
+				return a - b
+			case "*":
+				return a * b
+			case "/":
+				if b == 0 {
+					return nil
+				}
+				return a / b
+			case "%":
+				if b == 0 {
+					return nil
+				}
+				return a % b
+			}
+		}
+	}
+	a, b := sqliteFloat(left), sqliteFloat(right)
+	switch op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		if b == 0 {
+			return nil
+		}
+		return a / b
+	case "%":
+		if int64(b) == 0 {
+			return nil
+		}
+		return float64(int64(a) % int64(b))
+	}
+	return nil
+}
+
+func sqliteFloat(value sqliteValue) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// compareSQLiteValues orders non-NULL values: numbers before text before
+// blobs. Text that looks like a number compares numerically against numbers,
+// which stands in for SQLite's column affinity.
+func compareSQLiteValues(a, b sqliteValue) int {
+	if text, ok := a.(string); ok && sqliteIsNumber(b) {
+		if n, ok := sqliteParseNumber(text); ok {
+			a = n
+		}
+	}
+	if text, ok := b.(string); ok && sqliteIsNumber(a) {
+		if n, ok := sqliteParseNumber(text); ok {
+			b = n
+		}
+	}
+	ca, cb := sqliteTypeClass(a), sqliteTypeClass(b)
+	if ca != cb {
+		return ca - cb
+	}
+	switch ca {
+	case 1:
+		if x, ok := a.(int64); ok {
+			if y, ok := b.(int64); ok {
+				switch {
+				case x < y:
+					return -1
+				case x > y:
+					return 1
+				}
+				return 0
+			}
+		}
+		x, y := sqliteFloat(a), sqliteFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	default:
+		return bytes.Compare(a.([]byte), b.([]byte))
+	}
+}
+
+func sqliteIsNumber(value sqliteValue) bool {
+	return sqliteTypeClass(value) == 1
+}
+
+func sqliteTypeClass(value sqliteValue) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case int64, float64:
+		return 1
+	case string:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func sqliteParseNumber(text string) (sqliteValue, bool) {
+	text = strings.TrimSpace(text)
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// sqliteLikeMatch implements LIKE: % matches any run, _ one character, and
+// ASCII letters match case-insensitively.
+func sqliteLikeMatch(pattern, text string) bool {
+	p, t := []rune(pattern), []rune(text)
+	fold := func(r rune) rune {
+		if r < utf8.RuneSelf {
+			return unicode.ToLower(r)
+		}
+		return r
+	}
+	pi, ti, starP, starT := 0, 0, -1, 0
+	for ti < len(t) {
+		switch {
+		case pi < len(p) && p[pi] == '%':
+			starP, starT = pi, ti
+			pi++
+		case pi < len(p) && (p[pi] == '_' || fold(p[pi]) == fold(t[ti])):
+			pi++
+			ti++
+		case starP >= 0:
+			pi = starP + 1
+			starT++
+			ti = starT
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func formatSQLiteValue(value sqliteValue) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		text := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".eEnN") {
+			text += ".0"
+		}
+		return text
+	case string:
+		return v
+	case []byte:
+		if len(v) > 32 {
+			return fmt.Sprintf("x'%s…' (%s)", hex.EncodeToString(v[:32]), formatViewerByteSize(int64(len(v))))
+		}
+		return fmt.Sprintf("x'%s'", hex.EncodeToString(v))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sqliteDisplayRow converts values for JSON, keeping NULL as null.
+func sqliteDisplayRow(values []sqliteValue) []any {
+	row := make([]any, len(values))
+	for i, value := range values {
+		if value != nil {
+			row[i] = formatSQLiteValue(value)
+		}
+	}
+	return row
+}
+
+func lookupSQLiteTable(db *sqliteDB, name string) (sqliteTable, error) {
+	if strings.EqualFold(name, "sqlite_schema") || strings.EqualFold(name, "sqlite_master") {
+		return sqliteSchemaTable, nil
+	}
+	objects, err := db.schema()
+	if err != nil {
+		return sqliteTable{}, err
+	}
+	for _, object := range objects {
+		if !strings.EqualFold(object.name, name) {
+			continue
+		}
+		switch {
+		case object.kind == "view":
+			return sqliteTable{}, fmt.Errorf("%s is a view; views cannot be queried yet", object.name)
+		case object.kind == "table" && object.root == 0:
+			return sqliteTable{}, fmt.Errorf("%s is a virtual table, which jot cannot read", object.name)
+		case object.kind == "table":
+			columns, rowidColumn, withoutRowid := parseSQLiteCreateTable(object.sql)
+			return sqliteTable{name: object.name, root: object.root, sql: object.sql, columns: columns, rowidColumn: rowidColumn, withoutRowid: withoutRowid}, nil
+		}
+	}
+	return sqliteTable{}, fmt.Errorf("no such table: %s", name)
+}
+
+// runSQLiteQuery parses and runs one SELECT. Results past sqliteQueryMaxRows
+// are dropped and reported as truncated.
+func runSQLiteQuery(db *sqliteDB, text string) (sqliteResult, error) {
+	q, err := parseSQLiteSelect(text)
+	if err != nil {
+		return sqliteResult{}, err
+	}
+	table, err := lookupSQLiteTable(db, q.table)
+	if err != nil {
+		return sqliteResult{}, err
+	}
+	if q.star {
+		for _, column := range table.columns {
+			q.columns = append(q.columns, sqliteResultColumn{expr: &sqlColumn{name: column.name}, name: column.name})
+		}
+	}
+	for _, column := range q.columns {
+		if err := column.expr.bind(&table, nil); err != nil {
+			return sqliteResult{}, err
+		}
+	}
+	if q.where != nil {
+		if err := q.where.bind(&table, nil); err != nil {
+			return sqliteResult{}, err
+		}
+	}
+	for i, term := range q.orderBy {
+		if term.position != 0 {
+			if term.position < 1 || term.position > len(q.columns) {
+				return sqliteResult{}, fmt.Errorf("ORDER BY term %d is out of range", term.position)
+			}
+			q.orderBy[i].expr = q.columns[term.position-1].expr
+			continue
+		}
+		if err := term.expr.bind(&table, q.columns); err != nil {
+			return sqliteResult{}, err
+		}
+	}
+
+	result := sqliteResult{}
+	for _, column := range q.columns {
+		result.Columns = append(result.Columns, column.name)
+	}
+	matches := func(row *sqliteRow) bool {
+		return q.where == nil || sqliteTruth(q.where.eval(row))
+	}
+
+	if q.count {
+		count := int64(0)
+		err := db.tableRows(table, 0, func(rowid int64, values []sqliteValue) (bool, error) {
+			if matches(&sqliteRow{rowid: rowid, values: values}) {
+				count++
+			}
+			return true, nil
+		})
+		if err != nil {
+			return sqliteResult{}, err
+		}
+		result.Rows = [][]any{{strconv.FormatInt(count, 10)}}
+		result.Total = 1
+		return result, nil
+	}
+
+	project := func(row *sqliteRow) []any {
+		values := make([]sqliteValue, len(q.columns))
+		for i, column := range q.columns {
+			values[i] = column.expr.eval(row)
+		}
+		return sqliteDisplayRow(values)
+	}
+	keep := func(n int) bool {
+		return q.limit < 0 || n < q.limit
+	}
+
+	if len(q.orderBy) == 0 {
+		skip := q.offset
+		err := db.tableRows(table, 0, func(rowid int64, values []sqliteValue) (bool, error) {
+			row := &sqliteRow{rowid: rowid, values: values}
+			if !matches(row) {
+				return true, nil
+			}
+			if skip > 0 {
+				skip--
+				return true, nil
+			}
+			if !keep(result.Total) {
+				return false, nil
+			}
+			result.Total++
+			if len(result.Rows) == sqliteQueryMaxRows {
+				result.Truncated = true
+				return false, nil
+			}
+			result.Rows = append(result.Rows, project(row))
+			return true, nil
+		})
+		return result, err
+	}
+
+	var rows []*sqliteRow
+	err = db.tableRows(table, 0, func(rowid int64, values []sqliteValue) (bool, error) {
+		row := &sqliteRow{rowid: rowid, values: values}
+		if !matches(row) {
+			return true, nil
+		}
+		if len(rows) == sqliteQueryMaxSortRows {
+			return false, fmt.Errorf("more than %d rows match; narrow the WHERE clause before sorting", sqliteQueryMaxSortRows)
+		}
+		rows = append(rows, row)
+		return true, nil
+	})
+	if err != nil {
+		return sqliteResult{}, err
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, term := range q.orderBy {
+			a, b := term.expr.eval(rows[i]), term.expr.eval(rows[j])
+			cmp := 0
+			switch {
+			case a == nil && b == nil:
+			case a == nil:
+				cmp = -1
+			case b == nil:
+				cmp = 1
+			default:
+				cmp = compareSQLiteValues(a, b)
+			}
+			if term.desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	if q.offset < len(rows) {
+		rows = rows[q.offset:]
+	} else {
+		rows = nil
+	}
+	for _, row := range rows {
+		if !keep(result.Total) {
+			break
+		}
+		result.Total++
+		if len(result.Rows) == sqliteQueryMaxRows {
+			result.Truncated = true
+			break
+		}
+		result.Rows = append(result.Rows, project(row))
+	}
+	return result, nil
+}
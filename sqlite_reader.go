@@ -0,0 +1,636 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// sqliteDB reads the SQLite 3 file format directly, without cgo. It only
+// ever reads: pages come from an io.ReaderAt and nothing is written back.
+type sqliteDB struct {
+	r         io.ReaderAt
+	size      int64
+	pageSize  int
+	usable    int
+	pageCount int
+	encoding  int
+	cache     map[int][]byte
+}
+
+// sqliteSchemaObject is one row of sqlite_schema.
+type sqliteSchemaObject struct {
+	kind  string
+	name  string
+	table string
+	root  int
+	sql   string
+}
+
+type sqliteTable struct {
+	name         string
+	root         int
+	sql          string
+	columns      []sqliteColumn
+	rowidColumn  int
+	withoutRowid bool
+}
+
+type sqliteColumn struct {
+	name         string
+	typ          string
+	primaryKey   bool
+	defaultValue sqliteValue
+}
+
+const sqliteMaxCachedPages = 4096
+
+var errSQLiteNotDatabase = errors.New("not a SQLite 3 database")
+
+// sqliteSchemaTable describes sqlite_schema itself so it can be browsed and
+// queried like any other table.
+var sqliteSchemaTable = sqliteTable{
+	name: "sqlite_schema",
+	root: 1,
+	columns: []sqliteColumn{
+		{name: "type", typ: "TEXT"},
+		{name: "name", typ: "TEXT"},
+		{name: "tbl_name", typ: "TEXT"},
+		{name: "rootpage", typ: "INT"},
+		{name: "sql", typ: "TEXT"},
+	},
+	rowidColumn: -1,
+}
+
+func openSQLiteDB(r io.ReaderAt, size int64) (*sqliteDB, error) {
+	header := make([]byte, 100)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, errSQLiteNotDatabase
+	}
+	if string(header[:16]) != "SQLite format 3\x00" {
+		return nil, errSQLiteNotDatabase
+	}
+	pageSize := int(binary.BigEndian.Uint16(header[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+	db := &sqliteDB{
+		r:         r,
+		size:      size,
+		pageSize:  pageSize,
+		usable:    pageSize - int(header[20]),
+		pageCount: int(size / int64(pageSize)),
+		encoding:  int(binary.BigEndian.Uint32(header[56:])),
+		cache:     map[int][]byte{},
+	}
+	if db.usable < 480 {
+		return nil, fmt.Errorf("invalid SQLite reserved space %d", header[20])
+	}
+	if db.encoding == 0 {
+		db.encoding = 1
+	}
+	return db, nil
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	if n < 1 || n > db.pageCount {
+		return nil, fmt.Errorf("SQLite page %d is out of range", n)
+	}
+	if data, ok := db.cache[n]; ok {
+		return data, nil
+	}
+	data := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(data, int64(n-1)*int64(db.pageSize)); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(db.cache) >= sqliteMaxCachedPages {
+		db.cache = map[int][]byte{}
+	}
+	db.cache[n] = data
+	return data, nil
+}
+
+// walkTable visits the rows of a table b-tree in rowid order, skipping the
+// first skip rows without decoding them. visit returns false to stop.
+func (db *sqliteDB) walkTable(root int, skip int, visit func(rowid int64, payload []byte) (bool, error)) error {
+	if skip < 0 {
+		skip = 0
+	}
+	_, err := db.walkTablePage(root, 0, &skip, visit)
+	return err
+}
+
+func (db *sqliteDB) walkTablePage(pageNo int, depth int, skip *int, visit func(int64, []byte) (bool, error)) (bool, error) {
+	if depth > 40 {
+		return false, errors.New("SQLite b-tree is too deep; the file may be corrupt")
+	}
+	data, err := db.page(pageNo)
+	if err != nil {
+		return false, err
+	}
+	hdr := 0
+	if pageNo == 1 {
+		hdr = 100
+	}
+	if hdr+12 > len(data) {
+		return false, fmt.Errorf("SQLite page %d is truncated", pageNo)
+	}
+	cells := int(binary.BigEndian.Uint16(data[hdr+3:]))
+	switch data[hdr] {
+	case 0x0d:
+		if *skip >= cells {
+			*skip -= cells
+			return true, nil
+		}
+		for i := *skip; i < cells; i++ {
+			ptr := hdr + 8 + 2*i
+			if ptr+2 > len(data) {
+				return false, fmt.Errorf("SQLite page %d has a bad cell pointer", pageNo)
+			}
+			rowid, payload, err := db.tableLeafCell(data, int(binary.BigEndian.Uint16(data[ptr:])))
+			if err != nil {
+				return false, fmt.Errorf("SQLite page %d: %w", pageNo, err)
+			}
+			if more, err := visit(rowid, payload); err != nil || !more {
+				return false, err
+			}
+		}
+		*skip = 0
+		return true, nil
+	case 0x05:
+		for i := 0; i < cells; i++ {
+			ptr := hdr + 12 + 2*i
+			if ptr+2 > len(data) {
+				return false, fmt.Errorf("SQLite page %d has a bad cell pointer", pageNo)
+			}
+			off := int(binary.BigEndian.Uint16(data[ptr:]))
+			if off+4 > len(data) {
+				return false, fmt.Errorf("SQLite page %d has a bad cell offset", pageNo)
+			}
+			if more, err := db.walkTablePage(int(binary.BigEndian.Uint32(data[off:])), depth+1, skip, visit); err != nil || !more {
+				return false, err
+			}
+		}
+		return db.walkTablePage(int(binary.BigEndian.Uint32(data[hdr+8:])), depth+1, skip, visit)
+	default:
+		return false, fmt.Errorf("SQLite page %d is not a table page", pageNo)
+	}
+}
+
+// tableLeafCell reads one table leaf cell, following overflow pages when the
+// payload does not fit on the page.
+func (db *sqliteDB) tableLeafCell(data []byte, off int) (int64, []byte, error) {
+	if off >= len(data) {
+		return 0, nil, errors.New("cell offset out of range")
+	}
+	size, n := sqliteVarint(data[off:])
+	if n == 0 {
+		return 0, nil, errors.New("truncated cell")
+	}
+	off += n
+	rowid, n := sqliteVarint(data[off:])
+	if n == 0 {
+		return 0, nil, errors.New("truncated cell")
+	}
+	off += n
+	total := int(size)
+	if size > uint64(db.size) {
+		return 0, nil, errors.New("cell payload is larger than the file")
+	}
+	maxLocal := db.usable - 35
+	local := total
+	if total > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(data) {
+		return 0, nil, errors.New("cell payload out of range")
+	}
+	payload := make([]byte, 0, total)
+	payload = append(payload, data[off:off+local]...)
+	if local == total {
+		return int64(rowid), payload, nil
+	}
+	if off+local+4 > len(data) {
+		return 0, nil, errors.New("missing overflow pointer")
+	}
+	next := int(binary.BigEndian.Uint32(data[off+local:]))
+	for hops := 0; len(payload) < total; hops++ {
+		if next == 0 || hops > db.pageCount {
+			return 0, nil, errors.New("overflow chain ends early")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		take := total - len(payload)
+		if take > db.usable-4 {
+			take = db.usable - 4
+		}
+		payload = append(payload, overflow[4:4+take]...)
+		next = int(binary.BigEndian.Uint32(overflow))
+	}
+	return int64(rowid), payload, nil
+}
+
+// countRows counts the cells on every leaf page of a table without decoding
+// any of them.
+func (db *sqliteDB) countRows(root int) (int, error) {
+	count := 0
+	var walk func(pageNo, depth int) error
+	walk = func(pageNo, depth int) error {
+		if depth > 40 {
+			return errors.New("SQLite b-tree is too deep; the file may be corrupt")
+		}
+		data, err := db.page(pageNo)
+		if err != nil {
+			return err
+		}
+		hdr := 0
+		if pageNo == 1 {
+			hdr = 100
+		}
+		cells := int(binary.BigEndian.Uint16(data[hdr+3:]))
+		switch data[hdr] {
+		case 0x0d:
+			count += cells
+			return nil
+		case 0x05:
+			for i := 0; i < cells; i++ {
+				ptr := hdr + 12 + 2*i
+				if ptr+2 > len(data) {
+					return fmt.Errorf("SQLite page %d has a bad cell pointer", pageNo)
+				}
+				off := int(binary.BigEndian.Uint16(data[ptr:]))
+				if off+4 > len(data) {
+					return fmt.Errorf("SQLite page %d has a bad cell offset", pageNo)
+				}
+				if err := walk(int(binary.BigEndian.Uint32(data[off:])), depth+1); err != nil {
+					return err
+				}
+			}
+			return walk(int(binary.BigEndian.Uint32(data[hdr+8:])), depth+1)
+		default:
+			return fmt.Errorf("SQLite page %d is not a table page", pageNo)
+		}
+	}
+	return count, walk(root, 0)
+}
+
+func (db *sqliteDB) schema() ([]sqliteSchemaObject, error) {
+	var objects []sqliteSchemaObject
+	err := db.walkTable(1, 0, func(_ int64, payload []byte) (bool, error) {
+		values, err := decodeSQLiteRecord(payload, db.encoding)
+		if err != nil {
+			return false, err
+		}
+		for len(values) < 5 {
+			values = append(values, nil)
+		}
+		object := sqliteSchemaObject{
+			kind:  sqliteValueText(values[0]),
+			name:  sqliteValueText(values[1]),
+			table: sqliteValueText(values[2]),
+			sql:   sqliteValueText(values[4]),
+		}
+		if root, ok := values[3].(int64); ok {
+			object.root = int(root)
+		}
+		objects = append(objects, object)
+		return true, nil
+	})
+	return objects, err
+}
+
+// tables returns the ordinary rowid tables, skipping virtual tables whose
+// rows live elsewhere.
+func (db *sqliteDB) tables() ([]sqliteTable, error) {
+	objects, err := db.schema()
+	if err != nil {
+		return nil, err
+	}
+	var tables []sqliteTable
+	for _, object := range objects {
+		if object.kind != "table" || object.root == 0 {
+			continue
+		}
+		columns, rowidColumn, withoutRowid := parseSQLiteCreateTable(object.sql)
+		tables = append(tables, sqliteTable{
+			name:         object.name,
+			root:         object.root,
+			sql:          object.sql,
+			columns:      columns,
+			rowidColumn:  rowidColumn,
+			withoutRowid: withoutRowid,
+		})
+	}
+	return tables, nil
+}
+
+// tableRows decodes rows in rowid order, filling in the INTEGER PRIMARY KEY
+// column from the rowid and padding rows written before ALTER TABLE ADD
+// COLUMN with the column default.
+func (db *sqliteDB) tableRows(table sqliteTable, skip int, visit func(rowid int64, row []sqliteValue) (bool, error)) error {
+	if table.withoutRowid {
+		return fmt.Errorf("%s is a WITHOUT ROWID table, which jot cannot read yet", table.name)
+	}
+	return db.walkTable(table.root, skip, func(rowid int64, payload []byte) (bool, error) {
+		values, err := decodeSQLiteRecord(payload, db.encoding)
+		if err != nil {
+			return false, err
+		}
+		for len(values) < len(table.columns) {
+			values = append(values, table.columns[len(values)].defaultValue)
+		}
+		if table.rowidColumn >= 0 && table.rowidColumn < len(values) && values[table.rowidColumn] == nil {
+			values[table.rowidColumn] = rowid
+		}
+		return visit(rowid, values)
+	})
+}
+
+// sqliteValue is nil, int64, float64, string, or []byte.
+type sqliteValue any
+
+func decodeSQLiteRecord(payload []byte, encoding int) ([]sqliteValue, error) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, errors.New("bad record header")
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		serial, k := sqliteVarint(payload[pos:headerSize])
+		if k == 0 {
+			return nil, errors.New("bad record header")
+		}
+		types = append(types, serial)
+		pos += k
+	}
+	values := make([]sqliteValue, 0, len(types))
+	body := payload[headerSize:]
+	for _, serial := range types {
+		size := sqliteSerialSize(serial)
+		if size > len(body) {
+			return nil, errors.New("record is shorter than its header")
+		}
+		field := body[:size]
+		body = body[size:]
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial >= 1 && serial <= 6:
+			var v int64
+			if field[0]&0x80 != 0 {
+				v = -1
+			}
+			for _, b := range field {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case serial == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case serial == 8:
+			values = append(values, int64(0))
+		case serial == 9:
+			values = append(values, int64(1))
+		case serial >= 12 && serial%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		case serial >= 13:
+			values = append(values, decodeSQLiteText(field, encoding))
+		default:
+			return nil, fmt.Errorf("unsupported record serial type %d", serial)
+		}
+	}
+	return values, nil
+}
+
+func sqliteSerialSize(serial uint64) int {
+	switch {
+	case serial <= 4:
+		return [...]int{0, 1, 2, 3, 4}[serial]
+	case serial == 5:
+		return 6
+	case serial == 6 || serial == 7:
+		return 8
+	case serial < 12:
+		return 0
+	case serial > 1<<40:
+		return 1 << 40
+	default:
+		return int((serial - 12) / 2)
+	}
+}
+
+func decodeSQLiteText(data []byte, encoding int) string {
+	if encoding == 1 || len(data) < 2 {
+		return string(data)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if encoding == 2 {
+			units[i] = binary.LittleEndian.Uint16(data[2*i:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(data[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// sqliteVarint decodes SQLite's big-endian variable-length integer. It
+// returns 0 bytes read when the input is truncated.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	if len(b) < 9 {
+		return 0, 0
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+// parseSQLiteCreateTable pulls column names and types out of a CREATE TABLE
+// statement, and finds the INTEGER PRIMARY KEY column that aliases the rowid.
+func parseSQLiteCreateTable(sql string) ([]sqliteColumn, int, bool) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return nil, -1, false
+	}
+	start := -1
+	for i, token := range tokens {
+		if token.is("(") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, -1, false
+	}
+	var definitions [][]sqlToken
+	var current []sqlToken
+	depth, end := 1, len(tokens)
+	for i := start; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token.is("("):
+			depth++
+		case token.is(")"):
+			depth--
+		}
+		if depth == 0 {
+			end = i
+			break
+		}
+		if depth == 1 && token.is(",") {
+			definitions = append(definitions, current)
+			current = nil
+			continue
+		}
+		current = append(current, token)
+	}
+	definitions = append(definitions, current)
+
+	withoutRowid := false
+	for i := end; i+1 < len(tokens); i++ {
+		if tokens[i].keyword("WITHOUT") && tokens[i+1].keyword("ROWID") {
+			withoutRowid = true
+		}
+	}
+
+	var columns []sqliteColumn
+	var tablePrimaryKey []string
+	for _, def := range definitions {
+		if len(def) == 0 {
+			continue
+		}
+		if def[0].keyword("CONSTRAINT") && len(def) > 2 {
+			def = def[2:]
+		}
+		switch {
+		case def[0].keyword("PRIMARY"):
+			depth := 0
+			for _, token := range def {
+				switch {
+				case token.is("("):
+					depth++
+				case token.is(")"):
+					depth--
+				case depth == 1 && token.name() && !token.keyword("ASC") && !token.keyword("DESC"):
+					tablePrimaryKey = append(tablePrimaryKey, token.text)
+				}
+			}
+			continue
+		case def[0].keyword("UNIQUE"), def[0].keyword("CHECK"), def[0].keyword("FOREIGN"):
+			continue
+		}
+		column := sqliteColumn{name: def[0].text}
+		var typeParts []string
+		i := 1
+		for ; i < len(def); i++ {
+			if def[i].kind == sqlTokenIdent && !sqliteColumnConstraintWords[strings.ToUpper(def[i].text)] {
+				typeParts = append(typeParts, def[i].text)
+				continue
+			}
+			if def[i].is("(") && len(typeParts) > 0 {
+				for ; i < len(def) && !def[i].is(")"); i++ {
+				}
+				continue
+			}
+			break
+		}
+		column.typ = strings.Join(typeParts, " ")
+		for j := i; j+1 < len(def); j++ {
+			if def[j].keyword("PRIMARY") && def[j+1].keyword("KEY") {
+				column.primaryKey = true
+			}
+			if def[j].keyword("DEFAULT") {
+				column.defaultValue = sqliteDefaultValue(def[j+1:])
+			}
+		}
+		columns = append(columns, column)
+	}
+
+	rowidColumn := -1
+	if len(tablePrimaryKey) == 1 {
+		for i := range columns {
+			if strings.EqualFold(columns[i].name, tablePrimaryKey[0]) {
+				columns[i].primaryKey = true
+			}
+		}
+	}
+	primaryKeys := 0
+	for _, column := range columns {
+		if column.primaryKey {
+			primaryKeys++
+		}
+	}
+	if !withoutRowid && primaryKeys == 1 {
+		for i, column := range columns {
+			if column.primaryKey && strings.EqualFold(column.typ, "INTEGER") {
+				rowidColumn = i
+			}
+		}
+	}
+	return columns, rowidColumn, withoutRowid
+}
+
+// sqliteDefaultValue reads a literal DEFAULT; expression defaults are left
+// as NULL.
+func sqliteDefaultValue(tokens []sqlToken) sqliteValue {
+	if len(tokens) == 0 {
+		return nil
+	}
+	sign := ""
+	if tokens[0].is("-") || tokens[0].is("+") {
+		sign, tokens = tokens[0].text, tokens[1:]
+		if len(tokens) == 0 {
+			return nil
+		}
+	}
+	switch tokens[0].kind {
+	case sqlTokenString:
+		return tokens[0].text
+	case sqlTokenNumber:
+		value, err := parseSQLNumber(sign + tokens[0].text)
+		if err != nil {
+			return nil
+		}
+		return value
+	}
+	return nil
+}
+
+var sqliteColumnConstraintWords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "NOT": true, "NULL": true, "UNIQUE": true,
+	"CHECK": true, "DEFAULT": true, "COLLATE": true, "REFERENCES": true, "GENERATED": true,
+	"AS": true,
+}
+
+func sqliteValueText(value sqliteValue) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -18,12 +18,19 @@ type diffOptions struct {
 	leftPath         string
 	rightPath        string
 	viewer           bool
+	open             bool
 	summaryOnly      bool
 	context          int
 	ignoreWhitespace bool
 	ignoreEOL        bool
 	wordDiff         bool
 	noColor          bool
+	structural       bool
+	patch            bool
+	includes         []string
+	excludes         []string
+	includeHidden    bool
+	ignoreArrayOrder bool
 }
 
 type diffOpKind int
@@ -75,22 +82,38 @@ func renderDiffHelp(color bool) string {
 		"jot diff before.txt after.txt",
 		"jot diff before.txt after.txt --viewer",
 		"jot diff before.txt after.txt --summary-only",
+		"jot diff before.txt after.txt --open",
+		"jot diff before.yaml after.yaml --structural",
+		"jot diff before.txt after.txt --patch > change.patch",
+		"jot diff ./release-1 ./release-2 --exclude node_modules",
 	}, []string{
 		"`jot diff` rejects binary files and stays local.",
 		"`--viewer` renders a detailed terminal diff instead of opening a browser.",
+		"`--open` shows a side-by-side or unified diff in the jot viewer window.",
+		"`--structural` compares parsed JSON, YAML, or TOML values by key path instead of lines.",
+		"`--patch` prints a standard unified diff that `jot patch apply` or git can apply.",
+		"Two folders list added, removed, and modified files by content hash; pick a modified file to see its diff.",
 	})
 	writeFlagSection(&b, style, []helpFlag{
 		{name: "--viewer", description: "Show the detailed local diff render after the summary."},
+		{name: "--open", description: "Open the diff in the local viewer window."},
 		{name: "--summary-only", description: "Skip the detailed render and print only the terminal summary."},
 		{name: "--context N", description: "Show N context lines around each changed block."},
 		{name: "--ignore-whitespace", description: "Treat whitespace-only changes as unchanged."},
 		{name: "--ignore-eol", description: "Treat line-ending differences as unchanged."},
 		{name: "--word-diff", description: "Show inline word changes inside modified lines."},
 		{name: "--no-color", description: "Force plain text output."},
+		{name: "--patch", description: "Print a unified diff using --context lines instead of the summary."},
+		{name: "--structural", description: "Report added, removed, and changed values by key path."},
+		{name: "--include PATTERN", description: "When comparing folders, only compare files matching a glob. Repeatable."},
+		{name: "--exclude PATTERN", description: "When comparing folders, skip entries matching a glob. Repeatable."},
+		{name: "--include-hidden", description: "When comparing folders, include dot files and folders."},
+		{name: "--ignore-array-order", description: "With --structural, treat arrays as unordered collections."},
 	})
 	writeExamplesSection(&b, style, []string{
 		"jot diff README.md README.new.md",
 		"jot diff before.txt after.txt --viewer",
+		"jot diff deploy.yaml deploy.json --structural --ignore-array-order",
 		"jot task diff",
 	})
 	return b.String()
@@ -132,6 +155,8 @@ func parseDiffArgs(args []string) (diffOptions, bool, error) {
 			switch name {
 			case "--viewer":
 				opts.viewer = true
+			case "--open":
+				opts.open = true
 			case "--summary-only":
 				opts.summaryOnly = true
 			case "--ignore-whitespace":
@@ -142,6 +167,30 @@ func parseDiffArgs(args []string) (diffOptions, bool, error) {
 				opts.wordDiff = true
 			case "--no-color":
 				opts.noColor = true
+			case "--structural":
+				opts.structural = true
+			case "--patch":
+				opts.patch = true
+			case "--include-hidden":
+				opts.includeHidden = true
+			case "--include", "--exclude":
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, false, fmt.Errorf("missing value for %s", name)
+					}
+					i++
+					value = args[i]
+				}
+				if strings.TrimSpace(value) == "" {
+					return opts, false, fmt.Errorf("%s pattern must not be empty", strings.TrimPrefix(name, "--"))
+				}
+				if name == "--include" {
+					opts.includes = append(opts.includes, value)
+				} else {
+					opts.excludes = append(opts.excludes, value)
+				}
+			case "--ignore-array-order":
+				opts.ignoreArrayOrder = true
 			case "--context":
 				if !hasValue {
 					if i+1 >= len(args) {
@@ -166,6 +215,19 @@ func parseDiffArgs(args []string) (diffOptions, bool, error) {
 	if opts.viewer && opts.summaryOnly {
 		return opts, false, errors.New("--viewer cannot be combined with --summary-only")
 	}
+	if opts.ignoreArrayOrder && !opts.structural {
+		return opts, false, errors.New("--ignore-array-order requires --structural")
+	}
+	if opts.structural && opts.open {
+		return opts, false, errors.New("--structural cannot be combined with --open")
+	}
+	if opts.patch && (opts.viewer || opts.summaryOnly || opts.open || opts.structural) {
+		return opts, false, errors.New("--patch cannot be combined with --viewer, --summary-only, --open, or --structural")
+	}
+	if opts.patch && opts.ignoreWhitespace {
+		// A patch that skips whitespace changes would not apply to the original.
+		return opts, false, errors.New("--patch cannot be combined with --ignore-whitespace")
+	}
 	if len(positional) != 2 {
 		return opts, false, errors.New("usage: jot diff <left-path> <right-path>")
 	}
@@ -175,8 +237,6 @@ func parseDiffArgs(args []string) (diffOptions, bool, error) {
 }
 
 func executeDiff(stdin io.Reader, w io.Writer, cwd string, opts diffOptions) error {
-	_ = stdin
-
 	leftPath, err := resolveDiffPath(cwd, opts.leftPath)
 	if err != nil {
 		return err
@@ -189,6 +249,25 @@ func executeDiff(stdin io.Reader, w io.Writer, cwd string, opts diffOptions) err
 		return errors.New("cannot diff the same file against itself")
 	}
 
+	ui := newTermUI(w)
+	if opts.noColor {
+		ui.color = false
+	}
+	leftInfo, leftErr := os.Stat(leftPath)
+	rightInfo, rightErr := os.Stat(rightPath)
+	if leftErr == nil && rightErr == nil && (leftInfo.IsDir() || rightInfo.IsDir()) {
+		if !leftInfo.IsDir() || !rightInfo.IsDir() {
+			return errors.New("cannot diff a folder against a file")
+		}
+		if opts.structural || opts.open {
+			return errors.New("--structural and --open compare files, not folders")
+		}
+		return executeDirectoryDiff(stdin, w, ui, leftPath, rightPath, opts)
+	}
+	if opts.structural {
+		return executeStructuralDiff(w, ui, leftPath, rightPath, opts)
+	}
+
 	leftDoc, err := loadDiffDocument(leftPath, opts.ignoreEOL)
 	if err != nil {
 		return err
@@ -198,13 +277,16 @@ func executeDiff(stdin io.Reader, w io.Writer, cwd string, opts diffOptions) err
 		return err
 	}
 
-	ui := newTermUI(w)
-	if opts.noColor {
-		ui.color = false
-	}
-
 	result := buildDiffResult(leftDoc, rightDoc, opts)
+	if opts.patch {
+		return writeUnifiedPatch(w, result, opts.context, filepath.ToSlash(opts.leftPath), filepath.ToSlash(opts.rightPath))
+	}
 	renderDiffSummary(w, ui, result)
+	if opts.open {
+		if err := diffViewerLauncher(leftPath, rightPath, opts); err != nil {
+			return err
+		}
+	}
 	if opts.viewer && !opts.summaryOnly {
 		if _, err := fmt.Fprintln(w, ""); err != nil {
 			return err
@@ -639,17 +721,31 @@ func diffNumber(n int) string {
 func diffWordMarkup(oldLine, newLine string) (string, string) {
 	oldTokens := strings.Fields(oldLine)
 	newTokens := strings.Fields(newLine)
-	type tokenOp int
-	const (
-		tokenEqual tokenOp = iota
-		tokenDelete
-		tokenAdd
-	)
-	type tokenStep struct {
-		op   tokenOp
-		text string
+	var oldParts []string
+	var newParts []string
+	i, j := 0, 0
+	for _, kind := range diffTokenSteps(oldTokens, newTokens) {
+		switch kind {
+		case diffEqual:
+			oldParts = append(oldParts, oldTokens[i])
+			newParts = append(newParts, newTokens[j])
+			i++
+			j++
+		case diffAdd:
+			newParts = append(newParts, "{+"+newTokens[j]+"+}")
+			j++
+		default:
+			oldParts = append(oldParts, "[-"+oldTokens[i]+"-]")
+			i++
+		}
 	}
+	return strings.Join(oldParts, " "), strings.Join(newParts, " ")
+}
 
+// diffTokenSteps aligns two token lists by their longest common subsequence
+// and returns one step per token: diffEqual consumes a token from each side,
+// diffDelete one old token, and diffAdd one new token.
+func diffTokenSteps(oldTokens, newTokens []string) []diffOpKind {
 	n := len(oldTokens)
 	m := len(newTokens)
 	dp := make([][]int, n+1)
@@ -669,25 +765,23 @@ func diffWordMarkup(oldLine, newLine string) (string, string) {
 			}
 		}
 	}
-	var oldParts []string
-	var newParts []string
+	var steps []diffOpKind
 	i, j := 0, 0
 	for i < n || j < m {
 		switch {
 		case i < n && j < m && oldTokens[i] == newTokens[j]:
-			oldParts = append(oldParts, oldTokens[i])
-			newParts = append(newParts, newTokens[j])
+			steps = append(steps, diffEqual)
 			i++
 			j++
 		case j < m && (i == n || dp[i][j+1] >= dp[i+1][j]):
-			newParts = append(newParts, "{+"+newTokens[j]+"+}")
+			steps = append(steps, diffAdd)
 			j++
 		default:
-			oldParts = append(oldParts, "[-"+oldTokens[i]+"-]")
+			steps = append(steps, diffDelete)
 			i++
 		}
 	}
-	return strings.Join(oldParts, " "), strings.Join(newParts, " ")
+	return steps
 }
 
 func resolveDiffPath(cwd, input string) (string, error) {
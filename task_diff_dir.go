@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// dirDiffMaxStatBytes caps the files whose +/- line counts are computed for
+// the modified list; larger files are still reported, just without counts.
+const dirDiffMaxStatBytes = 4 << 20
+
+type dirDiffEntry struct {
+	rel       string
+	leftPath  string
+	rightPath string
+	size      int64
+}
+
+type dirDiffReport struct {
+	added     []dirDiffEntry
+	removed   []dirDiffEntry
+	modified  []dirDiffEntry
+	unchanged int
+}
+
+func executeDirectoryDiff(stdin io.Reader, w io.Writer, ui termUI, leftDir, rightDir string, opts diffOptions) error {
+	leftFiles, err := collectDirDiffFiles(leftDir, opts)
+	if err != nil {
+		return err
+	}
+	rightFiles, err := collectDirDiffFiles(rightDir, opts)
+	if err != nil {
+		return err
+	}
+	report, err := compareDirDiffFiles(leftDir, rightDir, leftFiles, rightFiles)
+	if err != nil {
+		return err
+	}
+	if opts.patch {
+		return writeDirectoryPatch(w, report, opts)
+	}
+
+	renderDirDiffSummary(w, ui, leftDir, rightDir, report)
+	if opts.summaryOnly || len(report.added)+len(report.removed)+len(report.modified) == 0 {
+		return nil
+	}
+	if err := renderDirDiffLists(w, ui, report, opts); err != nil {
+		return err
+	}
+	if !dirDiffCanPrompt(stdin) {
+		return nil
+	}
+	return drillIntoDirDiff(bufio.NewReader(stdin), w, ui, report, opts)
+}
+
+// dirDiffCanPrompt reports whether stdin can answer the drill-in prompt;
+// tests replace it.
+var dirDiffCanPrompt = stdinIsTerminal
+
+// stdinIsTerminal asks the terminal itself: a character device such as
+// /dev/null is not enough.
+func stdinIsTerminal(stdin io.Reader) bool {
+	file, ok := stdin.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// collectDirDiffFiles walks root and returns the slash-separated relative
+// paths of regular files, honoring the same hidden and glob rules as
+// `jot compress`.
+func collectDirDiffFiles(root string, opts diffOptions) (map[string]int64, error) {
+	files := make(map[string]int64)
+	err := filepath.WalkDir(root, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if current == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !opts.includeHidden && compressPathHasHiddenSegment(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if dirDiffPathMatches(rel, opts.excludes) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if len(opts.includes) > 0 && !dirDiffPathMatches(rel, opts.includes) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[rel] = info.Size()
+		return nil
+	})
+	return files, err
+}
+
+func dirDiffPathMatches(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+		if pattern != "" && compressPatternMatch(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareDirDiffFiles(leftDir, rightDir string, leftFiles, rightFiles map[string]int64) (dirDiffReport, error) {
+	var report dirDiffReport
+	for rel, size := range leftFiles {
+		entry := dirDiffEntry{
+			rel:       rel,
+			leftPath:  filepath.Join(leftDir, filepath.FromSlash(rel)),
+			rightPath: filepath.Join(rightDir, filepath.FromSlash(rel)),
+			size:      size,
+		}
+		rightSize, ok := rightFiles[rel]
+		if !ok {
+			report.removed = append(report.removed, entry)
+			continue
+		}
+		same := false
+		if size == rightSize {
+			var err error
+			same, err = sameDirDiffContent(entry.leftPath, entry.rightPath)
+			if err != nil {
+				return report, err
+			}
+		}
+		if same {
+			report.unchanged++
+			continue
+		}
+		if rightSize > size {
+			entry.size = rightSize
+		}
+		report.modified = append(report.modified, entry)
+	}
+	for rel, size := range rightFiles {
+		if _, ok := leftFiles[rel]; ok {
+			continue
+		}
+		report.added = append(report.added, dirDiffEntry{
+			rel:       rel,
+			leftPath:  filepath.Join(leftDir, filepath.FromSlash(rel)),
+			rightPath: filepath.Join(rightDir, filepath.FromSlash(rel)),
+			size:      size,
+		})
+	}
+	for _, list := range [][]dirDiffEntry{report.added, report.removed, report.modified} {
+		sort.Slice(list, func(i, j int) bool { return list[i].rel < list[j].rel })
+	}
+	return report, nil
+}
+
+// sameDirDiffContent compares two files of equal size by their SHA-256
+// content hash.
+func sameDirDiffContent(leftPath, rightPath string) (bool, error) {
+	leftSum, err := hashDirDiffFile(leftPath)
+	if err != nil {
+		return false, err
+	}
+	rightSum, err := hashDirDiffFile(rightPath)
+	if err != nil {
+		return false, err
+	}
+	return leftSum == rightSum, nil
+}
+
+func hashDirDiffFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return hashDigestForAlgo("sha256", file)
+}
+
+func renderDirDiffSummary(w io.Writer, ui termUI, leftDir, rightDir string, report dirDiffReport) {
+	changed := len(report.added) + len(report.removed) + len(report.modified)
+	if changed == 0 {
+		_, _ = fmt.Fprintln(w, ui.success(fmt.Sprintf("%s and %s are identical (%d file(s))", filepath.Base(leftDir), filepath.Base(rightDir), report.unchanged)))
+		return
+	}
+	_, _ = fmt.Fprintln(w, ui.listItem(1, "folders", fmt.Sprintf("%s -> %s", filepath.Base(leftDir), filepath.Base(rightDir)), ""))
+	_, _ = fmt.Fprintln(w, ui.listItem(2, "summary", fmt.Sprintf("+%d added  -%d removed  ~%d modified  %d unchanged", len(report.added), len(report.removed), len(report.modified), report.unchanged), ""))
+}
+
+func renderDirDiffLists(w io.Writer, ui termUI, report dirDiffReport, opts diffOptions) error {
+	if len(report.added) > 0 {
+		if _, err := fmt.Fprint(w, ui.sectionLabel("added")); err != nil {
+			return err
+		}
+		for _, entry := range report.added {
+			if _, err := fmt.Fprintf(w, "  %s %s\n", ui.tgreen("+"), entry.rel); err != nil {
+				return err
+			}
+		}
+	}
+	if len(report.removed) > 0 {
+		if _, err := fmt.Fprint(w, ui.sectionLabel("removed")); err != nil {
+			return err
+		}
+		for _, entry := range report.removed {
+			if _, err := fmt.Fprintf(w, "  %s %s\n", ui.tmagenta("-"), entry.rel); err != nil {
+				return err
+			}
+		}
+	}
+	if len(report.modified) > 0 {
+		if _, err := fmt.Fprint(w, ui.sectionLabel("modified")); err != nil {
+			return err
+		}
+		for i, entry := range report.modified {
+			if _, err := fmt.Fprintln(w, ui.listItem(i+1, entry.rel, dirDiffEntryStats(entry, opts), "")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func dirDiffEntryStats(entry dirDiffEntry, opts diffOptions) string {
+	if entry.size > dirDiffMaxStatBytes {
+		return "large file"
+	}
+	result, err := loadDirDiffResult(entry, opts)
+	if err != nil {
+		return "binary"
+	}
+	return fmt.Sprintf("+%d  -%d", result.additions, result.deletions)
+}
+
+func loadDirDiffResult(entry dirDiffEntry, opts diffOptions) (diffResult, error) {
+	left := diffDocument{name: entry.rel, isText: true}
+	right := diffDocument{name: entry.rel, isText: true}
+	var err error
+	if entry.leftPath != "" {
+		if left, err = loadDiffDocument(entry.leftPath, opts.ignoreEOL); err != nil {
+			return diffResult{}, err
+		}
+	}
+	if entry.rightPath != "" {
+		if right, err = loadDiffDocument(entry.rightPath, opts.ignoreEOL); err != nil {
+			return diffResult{}, err
+		}
+	}
+	return buildDiffResult(left, right, opts), nil
+}
+
+// drillIntoDirDiff lets the user open modified files, by number or relative
+// path, in the regular terminal diff renderer until they press Enter.
+func drillIntoDirDiff(reader *bufio.Reader, w io.Writer, ui termUI, report dirDiffReport, opts diffOptions) error {
+	if len(report.modified) == 0 {
+		return nil
+	}
+	for {
+		if _, err := fmt.Fprintln(w, ""); err != nil {
+			return err
+		}
+		selection, err := promptLine(reader, w, ui.styledPrompt("Open modified file", "Enter to finish"))
+		if errors.Is(err, io.EOF) || (err == nil && selection == "") {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entry, ok := findDirDiffEntry(report.modified, selection)
+		if !ok {
+			if _, err := fmt.Fprintln(w, ui.warnLine(fmt.Sprintf("no modified file matches %q", selection))); err != nil {
+				return err
+			}
+			continue
+		}
+		result, err := loadDirDiffResult(entry, opts)
+		if err != nil {
+			if _, writeErr := fmt.Fprintln(w, ui.warnLine(fmt.Sprintf("%s: %v", entry.rel, err))); writeErr != nil {
+				return writeErr
+			}
+			continue
+		}
+		if err := renderDiffViewer(w, ui, result, opts); err != nil {
+			return err
+		}
+	}
+}
+
+func findDirDiffEntry(entries []dirDiffEntry, selection string) (dirDiffEntry, bool) {
+	if n, err := strconv.Atoi(selection); err == nil {
+		if n >= 1 && n <= len(entries) {
+			return entries[n-1], true
+		}
+		return dirDiffEntry{}, false
+	}
+	selection = filepath.ToSlash(selection)
+	for _, entry := range entries {
+		if entry.rel == selection {
+			return entry, true
+		}
+	}
+	return dirDiffEntry{}, false
+}
+
+// writeDirectoryPatch emits one git-style unified diff covering every added,
+// removed, and modified text file. Binary files are listed but skipped.
+func writeDirectoryPatch(w io.Writer, report dirDiffReport, opts diffOptions) error {
+	type patchEntry struct {
+		entry      dirDiffEntry
+		leftLabel  string
+		rightLabel string
+	}
+	var entries []patchEntry
+	for _, entry := range report.modified {
+		entries = append(entries, patchEntry{entry, "a/" + entry.rel, "b/" + entry.rel})
+	}
+	for _, entry := range report.added {
+		entry.leftPath = ""
+		entries = append(entries, patchEntry{entry, "/dev/null", "b/" + entry.rel})
+	}
+	for _, entry := range report.removed {
+		entry.rightPath = ""
+		entries = append(entries, patchEntry{entry, "a/" + entry.rel, "/dev/null"})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].entry.rel < entries[j].entry.rel })
+
+	for _, item := range entries {
+		result, err := loadDirDiffResult(item.entry, opts)
+		if err != nil {
+			if _, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", item.leftLabel, item.rightLabel); err != nil {
+				return err
+			}
+			continue
+		}
+		if !patchHasChanges(result) {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "diff --git a/%s b/%s\n", item.entry.rel, item.entry.rel); err != nil {
+			return err
+		}
+		if err := writeUnifiedPatch(w, result, opts.context, item.leftLabel, item.rightLabel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+type structuralChangeKind int
+
+const (
+	structuralAdded structuralChangeKind = iota
+	structuralRemoved
+	structuralChanged
+)
+
+// structuralChange is one value that differs between two parsed documents,
+// addressed by its key path (for example `spec.replicas`).
+type structuralChange struct {
+	kind     structuralChangeKind
+	path     string
+	oldValue any
+	newValue any
+}
+
+var structuralPlainKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func executeStructuralDiff(w io.Writer, ui termUI, leftPath, rightPath string, opts diffOptions) error {
+	left, err := loadStructuredDiffValue(leftPath)
+	if err != nil {
+		return err
+	}
+	right, err := loadStructuredDiffValue(rightPath)
+	if err != nil {
+		return err
+	}
+	changes := compareStructuredValues("", left, right, opts.ignoreArrayOrder, nil)
+	return renderStructuralDiff(w, ui, leftPath, rightPath, changes)
+}
+
+// loadStructuredDiffValue parses JSON, YAML, or TOML into the same generic
+// JSON value shape so files of different formats can be compared.
+func loadStructuredDiffValue(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := normalizeDiffContent(string(data), true)
+	payload := content
+	switch viewerDocumentTypeForPath(path) {
+	case viewerDocumentTypeJSON:
+	case viewerDocumentTypeYAML:
+		payload, err = yamlToStructuredJSON(content)
+	case viewerDocumentTypeTOML:
+		payload, err = tomlToStructuredJSON(content)
+	default:
+		return nil, fmt.Errorf("%s: --structural supports JSON, YAML, and TOML files", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var value any
+	if err := json.Unmarshal([]byte(payload), &value); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return value, nil
+}
+
+func compareStructuredValues(path string, left, right any, ignoreArrayOrder bool, changes []structuralChange) []structuralChange {
+	switch l := left.(type) {
+	case map[string]any:
+		r, ok := right.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(l)+len(r))
+		for key := range l {
+			keys = append(keys, key)
+		}
+		for key := range r {
+			if _, seen := l[key]; !seen {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := structuralKeyPath(path, key)
+			lv, inLeft := l[key]
+			rv, inRight := r[key]
+			switch {
+			case !inRight:
+				changes = append(changes, structuralChange{kind: structuralRemoved, path: childPath, oldValue: lv})
+			case !inLeft:
+				changes = append(changes, structuralChange{kind: structuralAdded, path: childPath, newValue: rv})
+			default:
+				changes = compareStructuredValues(childPath, lv, rv, ignoreArrayOrder, changes)
+			}
+		}
+		return changes
+	case []any:
+		r, ok := right.([]any)
+		if !ok {
+			break
+		}
+		if ignoreArrayOrder {
+			return compareStructuredArraysUnordered(path, l, r, changes)
+		}
+		for i := 0; i < len(l) || i < len(r); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(r):
+				changes = append(changes, structuralChange{kind: structuralRemoved, path: childPath, oldValue: l[i]})
+			case i >= len(l):
+				changes = append(changes, structuralChange{kind: structuralAdded, path: childPath, newValue: r[i]})
+			default:
+				changes = compareStructuredValues(childPath, l[i], r[i], ignoreArrayOrder, changes)
+			}
+		}
+		return changes
+	}
+	if structuralValueKey(left) != structuralValueKey(right) {
+		changes = append(changes, structuralChange{kind: structuralChanged, path: path, oldValue: left, newValue: right})
+	}
+	return changes
+}
+
+// compareStructuredArraysUnordered treats both arrays as multisets: elements
+// present on both sides cancel out regardless of position, and whatever is
+// left over is reported at its original index.
+func compareStructuredArraysUnordered(path string, left, right []any, changes []structuralChange) []structuralChange {
+	pending := make(map[string][]int, len(right))
+	for i, item := range right {
+		key := structuralValueKey(item)
+		pending[key] = append(pending[key], i)
+	}
+	matched := make([]bool, len(right))
+	for i, item := range left {
+		key := structuralValueKey(item)
+		if indexes := pending[key]; len(indexes) > 0 {
+			matched[indexes[0]] = true
+			pending[key] = indexes[1:]
+			continue
+		}
+		changes = append(changes, structuralChange{kind: structuralRemoved, path: fmt.Sprintf("%s[%d]", path, i), oldValue: item})
+	}
+	for i, item := range right {
+		if !matched[i] {
+			changes = append(changes, structuralChange{kind: structuralAdded, path: fmt.Sprintf("%s[%d]", path, i), newValue: item})
+		}
+	}
+	return changes
+}
+
+// structuralValueKey is a canonical encoding used for equality checks;
+// encoding/json sorts map keys so equal values always encode the same way.
+func structuralValueKey(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
+
+func structuralKeyPath(parent, key string) string {
+	if !structuralPlainKeyPattern.MatchString(key) {
+		quoted, _ := json.Marshal(key)
+		return parent + "[" + string(quoted) + "]"
+	}
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func formatStructuralValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	text := string(data)
+	if len([]rune(text)) > 120 {
+		text = string([]rune(text)[:117]) + "..."
+	}
+	return text
+}
+
+func formatStructuralChange(ui termUI, change structuralChange) string {
+	path := change.path
+	if path == "" {
+		path = "(root)"
+	}
+	switch change.kind {
+	case structuralAdded:
+		return fmt.Sprintf("  %s %s: %s", ui.tgreen("+"), path, formatStructuralValue(change.newValue))
+	case structuralRemoved:
+		return fmt.Sprintf("  %s %s: %s", ui.tmagenta("-"), path, formatStructuralValue(change.oldValue))
+	default:
+		return fmt.Sprintf("  %s %s: %s → %s", ui.tyellow("~"), path, formatStructuralValue(change.oldValue), formatStructuralValue(change.newValue))
+	}
+}
+
+func renderStructuralDiff(w io.Writer, ui termUI, leftPath, rightPath string, changes []structuralChange) error {
+	leftName := filepath.Base(leftPath)
+	rightName := filepath.Base(rightPath)
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, ui.success(fmt.Sprintf("%s and %s are structurally identical", leftName, rightName)))
+		return err
+	}
+	added, removed, changed := 0, 0, 0
+	for _, change := range changes {
+		switch change.kind {
+		case structuralAdded:
+			added++
+		case structuralRemoved:
+			removed++
+		default:
+			changed++
+		}
+	}
+	if _, err := fmt.Fprintln(w, ui.listItem(1, "files", fmt.Sprintf("%s -> %s", leftName, rightName), "")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ui.listItem(2, "summary", fmt.Sprintf("+%d  -%d  ~%d", added, removed, changed), "")); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, ui.sectionLabel("changes")); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if _, err := fmt.Fprintln(w, formatStructuralChange(ui, change)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
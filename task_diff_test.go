@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -167,3 +168,138 @@ func TestRunDiffTaskGuidedFlow(t *testing.T) {
 		}
 	}
 }
+
+func TestJotDiffStructuralReportsKeyPathsAcrossFormats(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "deploy.yaml")
+	right := filepath.Join(dir, "deploy.json")
+	if err := os.WriteFile(left, []byte("spec:\n  replicas: 2\n  image: api:v1\n  ports:\n    - 80\n    - 443\nmetadata:\n  name: api\n"), 0o600); err != nil {
+		t.Fatalf("write left failed: %v", err)
+	}
+	if err := os.WriteFile(right, []byte(`{"metadata":{"name":"api","labels":{"tier":"web"}},"spec":{"ports":[443,80],"replicas":3}}`), 0o600); err != nil {
+		t.Fatalf("write right failed: %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := jotDiffWithInput(strings.NewReader(""), &out, append([]string{left, right, "--structural", "--no-color"}, args...), func() (string, error) {
+			return dir, nil
+		}); err != nil {
+			t.Fatalf("jotDiffWithInput returned error: %v", err)
+		}
+		return out.String()
+	}
+
+	ordered := run()
+	for _, snippet := range []string{
+		"spec.replicas: 2 → 3",
+		`- spec.image: "api:v1"`,
+		`+ metadata.labels: {"tier":"web"}`,
+		"spec.ports[0]: 80 → 443",
+		"+1  -1  ~3",
+	} {
+		if !strings.Contains(ordered, snippet) {
+			t.Fatalf("expected structural diff to contain %q, got %q", snippet, ordered)
+		}
+	}
+
+	unordered := run("--ignore-array-order")
+	if strings.Contains(unordered, "spec.ports") || !strings.Contains(unordered, "+1  -1  ~1") {
+		t.Fatalf("expected array order to be ignored, got %q", unordered)
+	}
+}
+
+func TestParseDiffArgsRejectsArrayOrderWithoutStructural(t *testing.T) {
+	if _, _, err := parseDiffArgs([]string{"a.json", "b.json", "--ignore-array-order"}); err == nil || !strings.Contains(err.Error(), "--structural") {
+		t.Fatalf("expected --ignore-array-order to require --structural, got %v", err)
+	}
+}
+
+func TestParseDiffArgsRejectsPatchIgnoringWhitespace(t *testing.T) {
+	if _, _, err := parseDiffArgs([]string{"a.txt", "b.txt", "--patch", "--ignore-whitespace"}); err == nil || !strings.Contains(err.Error(), "--ignore-whitespace") {
+		t.Fatalf("expected --patch with --ignore-whitespace to be rejected, got %v", err)
+	}
+}
+
+func TestJotDiffDirectoriesListsChangesAndDrillsIntoFiles(t *testing.T) {
+	previous := dirDiffCanPrompt
+	dirDiffCanPrompt = func(io.Reader) bool { return true }
+	t.Cleanup(func() { dirDiffCanPrompt = previous })
+	root := t.TempDir()
+	left := filepath.Join(root, "v1")
+	right := filepath.Join(root, "v2")
+	files := map[string]string{
+		"v1/README.md":         "title\nold line\n",
+		"v2/README.md":         "title\nnew line\n",
+		"v1/same.txt":          "unchanged\n",
+		"v2/same.txt":          "unchanged\n",
+		"v1/gone.txt":          "bye\n",
+		"v2/src/new.go":        "package main\n",
+		"v1/node_modules/x.js": "a",
+		"v2/node_modules/x.js": "b",
+		"v2/.cache/state":      "hidden",
+		"v1/sizes/equal.txt":   "abcd\n",
+		"v2/sizes/equal.txt":   "abce\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s failed: %v", rel, err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := jotDiffWithInput(strings.NewReader("README.md\n\n"), &out, []string{left, right, "--exclude", "node_modules", "--viewer", "--no-color"}, func() (string, error) {
+		return root, nil
+	})
+	if err != nil {
+		t.Fatalf("jotDiffWithInput returned error: %v", err)
+	}
+	text := out.String()
+	for _, snippet := range []string{
+		"+1 added  -1 removed  ~2 modified  1 unchanged",
+		"+ src/new.go",
+		"- gone.txt",
+		"README.md",
+		"sizes/equal.txt",
+		"Diff Viewer",
+		"new line",
+	} {
+		if !strings.Contains(text, snippet) {
+			t.Fatalf("expected folder diff to contain %q, got %q", snippet, text)
+		}
+	}
+	if strings.Contains(text, "node_modules") || strings.Contains(text, ".cache") {
+		t.Fatalf("expected excluded and hidden entries to be skipped, got %q", text)
+	}
+
+	out.Reset()
+	if err := jotDiffWithInput(strings.NewReader(""), &out, []string{left, right, "--include", "*.md", "--patch"}, func() (string, error) {
+		return root, nil
+	}); err != nil {
+		t.Fatalf("folder --patch returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "--- a/README.md\n+++ b/README.md\n") || strings.Contains(out.String(), "new.go") {
+		t.Fatalf("expected README-only git-style patch, got %q", out.String())
+	}
+
+	dirDiffCanPrompt = previous
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	out.Reset()
+	if err := jotDiffWithInput(devNull, &out, []string{left, right, "--viewer", "--no-color"}, func() (string, error) {
+		return root, nil
+	}); err != nil {
+		t.Fatalf("folder diff without a terminal returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "MODIFIED") || strings.Contains(out.String(), "Open modified file") {
+		t.Fatalf("expected the summary without the drill-in prompt, got %q", out.String())
+	}
+}
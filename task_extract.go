@@ -0,0 +1,472 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type extractOptions struct {
+	archivePath string
+	to          string
+	list        bool
+	force       bool
+}
+
+// archiveEntry describes one member of a zip or tar archive. name is the
+// path exactly as stored, which may be unsafe to extract.
+type archiveEntry struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	isDir   bool
+	isLink  bool
+}
+
+// errArchiveStop ends a walkArchive early without reporting an error.
+var errArchiveStop = errors.New("stop archive walk")
+
+// jotExtract is the direct command entry point for unpacking archives.
+func jotExtract(w io.Writer, args []string) error {
+	return jotExtractWithInput(w, args, os.Getwd)
+}
+
+func jotExtractWithInput(w io.Writer, args []string, getwd func() (string, error)) error {
+	opts, helpRequested, err := parseExtractArgs(args)
+	if err != nil {
+		return err
+	}
+	if helpRequested {
+		_, writeErr := io.WriteString(w, renderExtractHelp(isTTY(w)))
+		return writeErr
+	}
+	cwd, err := getwd()
+	if err != nil {
+		return err
+	}
+	return executeExtract(w, cwd, opts)
+}
+
+func renderExtractHelp(color bool) string {
+	style := helpStyler{color: color}
+	var b strings.Builder
+	writeHelpHeader(&b, style, "jot extract", "Unpack local zip, tar, or tar.gz archives without leaving the terminal.")
+	writeUsageSection(&b, style, []string{
+		"jot extract <archive>",
+		"jot extract <archive> --to <dir>",
+		"jot extract <archive> --list",
+	}, []string{
+		"Archives extract into a folder named after the archive unless `--to` is given.",
+		"Entries whose paths would land outside the destination are refused before anything is written.",
+		"Symbolic and hard links are skipped.",
+		"`jot open <archive>` browses the same entries in the viewer.",
+	})
+	writeFlagSection(&b, style, []helpFlag{
+		{name: "--to DIR", description: "Extract into DIR instead of a sibling folder named after the archive."},
+		{name: "--list", description: "List entries with their sizes without extracting."},
+		{name: "--force", description: "Replace files that already exist in the destination."},
+	})
+	writeExamplesSection(&b, style, []string{
+		"jot extract bundle.zip",
+		"jot extract release.tar.gz --to ./release",
+		"jot extract backup.tar --list",
+	})
+	return b.String()
+}
+
+func parseExtractArgs(args []string) (extractOptions, bool, error) {
+	var opts extractOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if arg == "" {
+			continue
+		}
+		if isHelpFlag(arg) {
+			return opts, true, nil
+		}
+		if strings.HasPrefix(arg, "-") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "--list":
+				opts.list = true
+			case "--force":
+				opts.force = true
+			case "--to":
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, false, fmt.Errorf("missing value for %s", name)
+					}
+					i++
+					value = args[i]
+				}
+				opts.to = strings.TrimSpace(value)
+			default:
+				return opts, false, fmt.Errorf("unknown flag %q", arg)
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) == 0 {
+		return opts, true, nil
+	}
+	if len(positional) != 1 {
+		return opts, false, errors.New("usage: jot extract <archive> [--to dir] [--list]")
+	}
+	opts.archivePath = positional[0]
+	if archiveFormatForPath(opts.archivePath) == "" {
+		return opts, false, fmt.Errorf("%s: jot extract supports .zip, .tar, .tar.gz, and .tgz archives", opts.archivePath)
+	}
+	if opts.list && (opts.to != "" || opts.force) {
+		return opts, false, errors.New("--list cannot be combined with --to or --force")
+	}
+	return opts, false, nil
+}
+
+func executeExtract(w io.Writer, cwd string, opts extractOptions) error {
+	archivePath := opts.archivePath
+	if !filepath.IsAbs(archivePath) {
+		archivePath = filepath.Join(cwd, archivePath)
+	}
+	entries, err := listArchiveEntries(archivePath)
+	if err != nil {
+		return err
+	}
+	ui := newTermUI(w)
+	if opts.list {
+		return renderArchiveList(w, ui, entries)
+	}
+
+	dest := opts.to
+	if dest == "" {
+		dest = archiveBaseName(archivePath)
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(cwd, dest)
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+
+	// Validate every entry before writing so a hostile archive leaves the
+	// destination untouched.
+	var skipped []string
+	for _, entry := range entries {
+		if entry.isLink {
+			skipped = append(skipped, entry.name)
+			continue
+		}
+		target, err := archiveExtractTarget(root, entry.name)
+		if err != nil {
+			return err
+		}
+		if entry.isDir || opts.force {
+			continue
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("%s already exists; rerun with --force or choose --to", target)
+		}
+	}
+
+	files := 0
+	err = walkArchive(archivePath, func(entry archiveEntry, body io.Reader) error {
+		if entry.isLink {
+			return nil
+		}
+		target, err := archiveExtractTarget(root, entry.name)
+		if err != nil {
+			return err
+		}
+		if entry.isDir {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			return archiveEnsureWithinRoot(root, target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := archiveEnsureWithinRoot(root, filepath.Dir(target)); err != nil {
+			return err
+		}
+		if err := writeArchiveFile(target, body, entry.mode, opts.force); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w, ui.success(fmt.Sprintf("extracted %d file(s) to %s", files, dest))); err != nil {
+		return err
+	}
+	for _, name := range skipped {
+		if _, err := fmt.Fprintln(w, ui.warnLine("skipped link "+name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderArchiveList(w io.Writer, ui termUI, entries []archiveEntry) error {
+	var total int64
+	files := 0
+	for _, entry := range entries {
+		size := ""
+		switch {
+		case entry.isDir:
+			size = "dir"
+		case entry.isLink:
+			size = "link"
+		default:
+			size = formatViewerByteSize(entry.size)
+			total += entry.size
+			files++
+		}
+		if _, err := fmt.Fprintf(w, "  %s  %s  %s\n", ui.tdim(fmt.Sprintf("%10s", size)), ui.tdim(entry.modTime.Format("2006-01-02 15:04")), entry.name); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", ui.success(fmt.Sprintf("%d file(s), %s uncompressed", files, formatViewerByteSize(total))))
+	return err
+}
+
+// archiveExtractTarget maps an entry name to a path under root, rejecting
+// absolute paths, drive letters, and any `..` that would escape root.
+func archiveExtractTarget(root, name string) (string, error) {
+	clean := strings.ReplaceAll(name, `\`, "/")
+	unsafe := clean == "" || strings.HasPrefix(clean, "/") || filepath.IsAbs(name) ||
+		(len(clean) >= 2 && clean[1] == ':')
+	if !unsafe {
+		clean = path.Clean(clean)
+		unsafe = clean == ".." || strings.HasPrefix(clean, "../")
+	}
+	if unsafe {
+		return "", fmt.Errorf("refusing to extract %q: path escapes the destination", name)
+	}
+	target := filepath.Join(root, filepath.FromSlash(clean))
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to extract %q: path escapes the destination", name)
+	}
+	return target, nil
+}
+
+// archiveEnsureWithinRoot guards against symlinks already present in the
+// destination that would redirect a write elsewhere.
+func archiveEnsureWithinRoot(root, dir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("refusing to write through %s: it resolves outside the destination", dir)
+	}
+	return nil
+}
+
+func writeArchiveFile(target string, body io.Reader, mode fs.FileMode, force bool) error {
+	if force {
+		// Replace the entry rather than truncating it, so --force never
+		// writes through a symlink or hard link to a file outside the
+		// destination.
+		if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+	}
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0o644
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// archiveFormatForPath returns "zip", "tar", or "tar.gz" for supported
+// archive names and "" otherwise.
+func archiveFormatForPath(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	default:
+		return ""
+	}
+}
+
+func archiveBaseName(archivePath string) string {
+	base := filepath.Base(archivePath)
+	lower := strings.ToLower(base)
+	for _, suffix := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, suffix) {
+			return base[:len(base)-len(suffix)]
+		}
+	}
+	return base
+}
+
+// walkArchive calls visit for every entry in order. body is only valid until
+// visit returns and is empty for directories and links; returning
+// errArchiveStop ends the walk early.
+func walkArchive(archivePath string, visit func(entry archiveEntry, body io.Reader) error) error {
+	var err error
+	if archiveFormatForPath(archivePath) == "zip" {
+		err = walkZipArchive(archivePath, visit)
+	} else {
+		err = walkTarArchive(archivePath, visit)
+	}
+	if errors.Is(err, errArchiveStop) {
+		return nil
+	}
+	return err
+}
+
+func walkZipArchive(archivePath string, visit func(entry archiveEntry, body io.Reader) error) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, file := range reader.File {
+		info := file.FileInfo()
+		entry := archiveEntry{
+			name:    file.Name,
+			size:    int64(file.UncompressedSize64),
+			mode:    info.Mode(),
+			modTime: file.Modified,
+			isDir:   info.IsDir() || strings.HasSuffix(file.Name, "/"),
+			isLink:  info.Mode()&fs.ModeSymlink != 0,
+		}
+		if entry.isDir || entry.isLink {
+			if err := visit(entry, strings.NewReader("")); err != nil {
+				return err
+			}
+			continue
+		}
+		body, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = visit(entry, body)
+		_ = body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTarArchive(archivePath string, visit func(entry archiveEntry, body io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var source io.Reader = file
+	if archiveFormatForPath(archivePath) == "tar.gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		source = gz
+	}
+	reader := tar.NewReader(source)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeDir, tar.TypeSymlink, tar.TypeLink:
+		default:
+			// Device nodes, FIFOs, and PAX metadata carry no file content.
+			continue
+		}
+		entry := archiveEntry{
+			name:    header.Name,
+			size:    header.Size,
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+			isDir:   header.Typeflag == tar.TypeDir,
+			isLink:  header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink,
+		}
+		if err := visit(entry, reader); err != nil {
+			return err
+		}
+	}
+}
+
+func listArchiveEntries(archivePath string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := walkArchive(archivePath, func(entry archiveEntry, _ io.Reader) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// readArchiveEntry returns the content of the named entry, refusing entries
+// larger than limit so previews stay in memory comfortably.
+func readArchiveEntry(archivePath, name string, limit int64) ([]byte, error) {
+	var data []byte
+	found := false
+	err := walkArchive(archivePath, func(entry archiveEntry, body io.Reader) error {
+		if entry.name != name || entry.isDir || entry.isLink {
+			return nil
+		}
+		found = true
+		var err error
+		data, err = io.ReadAll(io.LimitReader(body, limit+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > limit {
+			return fmt.Errorf("%s is larger than %s and cannot be previewed", name, formatViewerByteSize(limit))
+		}
+		return errArchiveStop
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s not found in archive", name)
+	}
+	return data, nil
+}
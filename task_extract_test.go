@@ -0,0 +1,184 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testArchiveFile struct {
+	name string
+	body string
+}
+
+func writeTestZip(t *testing.T, path string, files ...testArchiveFile) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			t.Fatalf("zip Create failed: %v", err)
+		}
+		if _, err := fw.Write([]byte(file.body)); err != nil {
+			t.Fatalf("zip Write failed: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close failed: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func writeTestTarGz(t *testing.T, path string, files ...testArchiveFile) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("tar WriteHeader failed: %v", err)
+		}
+		if _, err := tw.Write([]byte(file.body)); err != nil {
+			t.Fatalf("tar Write failed: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close failed: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close failed: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestJotExtractTarGzIntoArchiveNamedFolder(t *testing.T) {
+	workdir := t.TempDir()
+	archivePath := filepath.Join(workdir, "bundle.tar.gz")
+	writeTestTarGz(t, archivePath,
+		testArchiveFile{name: "README.md", body: "# hi\n"},
+		testArchiveFile{name: "data/rows.csv", body: "a,b\n1,2\n"},
+	)
+
+	var out bytes.Buffer
+	if err := jotExtractWithInput(&out, []string{"bundle.tar.gz"}, func() (string, error) { return workdir, nil }); err != nil {
+		t.Fatalf("jotExtract returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(workdir, "bundle", "data", "rows.csv"))
+	if err != nil {
+		t.Fatalf("expected extracted file: %v", err)
+	}
+	if string(got) != "a,b\n1,2\n" {
+		t.Fatalf("unexpected extracted content %q", got)
+	}
+	if !strings.Contains(out.String(), "extracted 2 file(s)") {
+		t.Fatalf("expected success summary, got %q", out.String())
+	}
+}
+
+func TestJotExtractListPrintsEntriesWithoutWriting(t *testing.T) {
+	workdir := t.TempDir()
+	archivePath := filepath.Join(workdir, "bundle.zip")
+	writeTestZip(t, archivePath,
+		testArchiveFile{name: "notes/a.txt", body: "alpha"},
+		testArchiveFile{name: "b.json", body: "{}"},
+	)
+
+	var out bytes.Buffer
+	if err := jotExtractWithInput(&out, []string{archivePath, "--list"}, func() (string, error) { return workdir, nil }); err != nil {
+		t.Fatalf("jotExtract --list returned error: %v", err)
+	}
+	for _, want := range []string{"notes/a.txt", "b.json", "2 file(s)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected list output to contain %q, got %q", want, out.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workdir, "bundle")); !os.IsNotExist(err) {
+		t.Fatalf("expected --list not to create a folder, stat err=%v", err)
+	}
+}
+
+func TestJotExtractRefusesZipSlipBeforeWritingAnything(t *testing.T) {
+	workdir := t.TempDir()
+	archivePath := filepath.Join(workdir, "evil.zip")
+	writeTestZip(t, archivePath,
+		testArchiveFile{name: "ok.txt", body: "fine"},
+		testArchiveFile{name: "../evil.txt", body: "pwned"},
+	)
+	dest := filepath.Join(workdir, "out")
+
+	err := jotExtractWithInput(&bytes.Buffer{}, []string{archivePath, "--to", dest}, func() (string, error) { return workdir, nil })
+	if err == nil || !strings.Contains(err.Error(), "path escapes the destination") {
+		t.Fatalf("expected zip-slip refusal, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workdir, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected escaping entry not to be written, stat err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "ok.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected no partial extraction, stat err=%v", err)
+	}
+}
+
+func TestJotExtractRequiresForceToOverwrite(t *testing.T) {
+	workdir := t.TempDir()
+	archivePath := filepath.Join(workdir, "bundle.zip")
+	writeTestZip(t, archivePath, testArchiveFile{name: "a.txt", body: "new"})
+	dest := filepath.Join(workdir, "out")
+	writeTestFile(t, filepath.Join(dest, "a.txt"), "old")
+	getwd := func() (string, error) { return workdir, nil }
+
+	err := jotExtractWithInput(&bytes.Buffer{}, []string{archivePath, "--to", dest}, getwd)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected overwrite refusal, got %v", err)
+	}
+	if err := jotExtractWithInput(&bytes.Buffer{}, []string{archivePath, "--to", dest, "--force"}, getwd); err != nil {
+		t.Fatalf("jotExtract --force returned error: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(dest, "a.txt"))
+	if string(got) != "new" {
+		t.Fatalf("expected --force to overwrite, got %q", got)
+	}
+}
+
+func TestJotExtractForceReplacesSymlinksInsteadOfFollowingThem(t *testing.T) {
+	workdir := t.TempDir()
+	archivePath := filepath.Join(workdir, "bundle.zip")
+	writeTestZip(t, archivePath, testArchiveFile{name: "a.txt", body: "new"})
+	outside := filepath.Join(workdir, "outside.txt")
+	writeTestFile(t, outside, "keep me")
+	dest := filepath.Join(workdir, "out")
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "a.txt")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	getwd := func() (string, error) { return workdir, nil }
+
+	if err := jotExtractWithInput(&bytes.Buffer{}, []string{archivePath, "--to", dest, "--force"}, getwd); err != nil {
+		t.Fatalf("jotExtract --force returned error: %v", err)
+	}
+	if got, _ := os.ReadFile(outside); string(got) != "keep me" {
+		t.Fatalf("expected the symlink target untouched, got %q", got)
+	}
+	info, err := os.Lstat(filepath.Join(dest, "a.txt"))
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("expected a.txt replaced by a regular file, got %v, %v", info, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "a.txt")); string(got) != "new" {
+		t.Fatalf("expected the extracted content, got %q", got)
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type mergeOptions struct {
+	basePath    string
+	oursPath    string
+	theirsPath  string
+	outputPath  string
+	stdout      bool
+	interactive bool
+	noColor     bool
+}
+
+// mergeRegion is one stretch of a three-way merge. Resolved regions carry
+// their final lines; conflicts keep all three sides for markers or the
+// interactive resolver.
+type mergeRegion struct {
+	conflict bool
+	lines    []string
+	base     []string
+	ours     []string
+	theirs   []string
+}
+
+type mergeLabels struct {
+	base   string
+	ours   string
+	theirs string
+}
+
+// jotMerge is the direct command entry point for three-way text merges.
+func jotMerge(w io.Writer, args []string) error {
+	return jotMergeWithInput(os.Stdin, w, args, os.Getwd)
+}
+
+func jotMergeWithInput(stdin io.Reader, w io.Writer, args []string, getwd func() (string, error)) error {
+	opts, helpRequested, err := parseMergeArgs(args)
+	if err != nil {
+		return err
+	}
+	if helpRequested {
+		_, writeErr := io.WriteString(w, renderMergeHelp(isTTY(w)))
+		return writeErr
+	}
+	cwd, err := getwd()
+	if err != nil {
+		return err
+	}
+	return executeMerge(stdin, w, cwd, opts)
+}
+
+func renderMergeHelp(color bool) string {
+	style := helpStyler{color: color}
+	var b strings.Builder
+	writeHelpHeader(&b, style, "jot merge", "Three-way merge two edited copies of a local text file.")
+	writeUsageSection(&b, style, []string{
+		"jot merge base.txt ours.txt theirs.txt",
+		"jot merge base.txt ours.txt theirs.txt --output merged.txt",
+		"jot merge base.txt ours.txt theirs.txt --interactive",
+	}, []string{
+		"Changes made on only one side are applied automatically; overlapping changes become conflicts.",
+		"The result replaces `ours` unless `--output` or `--stdout` is given.",
+		"Exits non-zero while conflicts remain, so it can be used as a git mergetool.",
+	})
+	writeFlagSection(&b, style, []helpFlag{
+		{name: "--output PATH", description: "Write the merged result to PATH instead of ours."},
+		{name: "--stdout", description: "Print the merged result instead of writing a file."},
+		{name: "--interactive", description: "Resolve conflicts one hunk at a time in the terminal."},
+		{name: "--no-color", description: "Force plain text output."},
+	})
+	writeExamplesSection(&b, style, []string{
+		"jot merge base.md mine.md yours.md --stdout",
+		`git config mergetool.jot.cmd 'jot merge "$BASE" "$LOCAL" "$REMOTE" --output "$MERGED" --interactive'`,
+		"git config mergetool.jot.trustExitCode true",
+	})
+	return b.String()
+}
+
+func parseMergeArgs(args []string) (mergeOptions, bool, error) {
+	var opts mergeOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if arg == "" {
+			continue
+		}
+		if isHelpFlag(arg) {
+			return opts, true, nil
+		}
+		if strings.HasPrefix(arg, "-") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "--output", "-o":
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, false, fmt.Errorf("missing value for %s", name)
+					}
+					i++
+					value = args[i]
+				}
+				opts.outputPath = strings.TrimSpace(value)
+				if opts.outputPath == "" {
+					return opts, false, errors.New("--output must not be empty")
+				}
+			case "--stdout":
+				opts.stdout = true
+			case "--interactive", "-i":
+				opts.interactive = true
+			case "--no-color":
+				opts.noColor = true
+			default:
+				return opts, false, fmt.Errorf("unknown flag %q", arg)
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if opts.stdout && opts.outputPath != "" {
+		return opts, false, errors.New("--stdout cannot be combined with --output")
+	}
+	if opts.stdout && opts.interactive {
+		return opts, false, errors.New("--stdout cannot be combined with --interactive")
+	}
+	if len(positional) != 3 {
+		return opts, false, errors.New("usage: jot merge <base> <ours> <theirs>")
+	}
+	opts.basePath = positional[0]
+	opts.oursPath = positional[1]
+	opts.theirsPath = positional[2]
+	return opts, false, nil
+}
+
+func executeMerge(stdin io.Reader, w io.Writer, cwd string, opts mergeOptions) error {
+	var docs [3]diffDocument
+	for i, input := range []string{opts.basePath, opts.oursPath, opts.theirsPath} {
+		path, err := resolveDiffPath(cwd, input)
+		if err != nil {
+			return err
+		}
+		docs[i], err = loadDiffDocument(path, true)
+		if err != nil {
+			return err
+		}
+	}
+	base, ours, theirs := docs[0], docs[1], docs[2]
+
+	regions := mergeThreeWay(base.display, ours.display, theirs.display)
+	labels := mergeLabels{base: base.name, ours: ours.name, theirs: theirs.name}
+	ui := newTermUI(w)
+	if opts.noColor {
+		ui.color = false
+	}
+
+	if opts.interactive {
+		if err := resolveMergeInteractively(bufio.NewReader(stdin), w, ui, regions, labels); err != nil {
+			return err
+		}
+	}
+
+	trailingNewline := strings.HasSuffix(ours.original, "\n") || (ours.original == "" && strings.HasSuffix(theirs.original, "\n"))
+	merged := renderMergeResult(regions, labels, trailingNewline)
+	conflicts := countMergeConflicts(regions)
+
+	if opts.stdout {
+		if _, err := io.WriteString(w, merged); err != nil {
+			return err
+		}
+	} else {
+		target := ours.path
+		if opts.outputPath != "" {
+			resolved, err := resolveDiffPath(cwd, opts.outputPath)
+			if err != nil {
+				return err
+			}
+			target = resolved
+		}
+		mode := os.FileMode(0o644)
+		if info, err := os.Stat(ours.path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(target, []byte(merged), mode); err != nil {
+			return err
+		}
+		if conflicts == 0 {
+			if _, err := fmt.Fprintln(w, ui.success(fmt.Sprintf("merged cleanly into %s", filepath.Base(target)))); err != nil {
+				return err
+			}
+		} else if _, err := fmt.Fprintln(w, ui.warnLine(fmt.Sprintf("wrote %s with %d conflict(s) marked", filepath.Base(target), conflicts))); err != nil {
+			return err
+		}
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d merge conflict(s) remain", conflicts)
+	}
+	return nil
+}
+
+// mergeThreeWay performs a diff3-style merge. Lines of base that both sides
+// kept unchanged act as sync points; the stretch between sync points is taken
+// from whichever side changed it, or becomes a conflict when both did so
+// differently.
+func mergeThreeWay(base, ours, theirs []string) []mergeRegion {
+	oursMatch := mergeBaseMatches(base, ours)
+	theirsMatch := mergeBaseMatches(base, theirs)
+
+	var regions []mergeRegion
+	appendResolved := func(lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if n := len(regions); n > 0 && !regions[n-1].conflict {
+			regions[n-1].lines = append(regions[n-1].lines, lines...)
+			return
+		}
+		regions = append(regions, mergeRegion{lines: append([]string(nil), lines...)})
+	}
+
+	i, o, t := 0, 0, 0
+	for i < len(base) || o < len(ours) || t < len(theirs) {
+		if i < len(base) && oursMatch[i] == o && theirsMatch[i] == t {
+			appendResolved(base[i : i+1])
+			i++
+			o++
+			t++
+			continue
+		}
+		k := i
+		for k < len(base) && (oursMatch[k] < 0 || theirsMatch[k] < 0) {
+			k++
+		}
+		oEnd, tEnd := len(ours), len(theirs)
+		if k < len(base) {
+			oEnd, tEnd = oursMatch[k], theirsMatch[k]
+		}
+		baseChunk, oursChunk, theirsChunk := base[i:k], ours[o:oEnd], theirs[t:tEnd]
+		switch {
+		case equalLines(oursChunk, baseChunk):
+			appendResolved(theirsChunk)
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			appendResolved(oursChunk)
+		default:
+			regions = append(regions, mergeRegion{
+				conflict: true,
+				base:     append([]string(nil), baseChunk...),
+				ours:     append([]string(nil), oursChunk...),
+				theirs:   append([]string(nil), theirsChunk...),
+			})
+		}
+		i, o, t = k, oEnd, tEnd
+	}
+	return regions
+}
+
+// mergeBaseMatches maps each base line to the line it is kept as in other,
+// or -1 when the two-way diff deletes it.
+func mergeBaseMatches(base, other []string) []int {
+	matches := make([]int, len(base))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for _, op := range buildDiffOps(base, other, base, other) {
+		if op.kind == diffEqual {
+			matches[op.leftNo-1] = op.rightNo - 1
+		}
+	}
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func countMergeConflicts(regions []mergeRegion) int {
+	count := 0
+	for _, region := range regions {
+		if region.conflict {
+			count++
+		}
+	}
+	return count
+}
+
+// renderMergeResult joins the merged regions, writing diff3-style conflict
+// markers (with the base section) for anything left unresolved.
+func renderMergeResult(regions []mergeRegion, labels mergeLabels, trailingNewline bool) string {
+	var lines []string
+	for _, region := range regions {
+		if !region.conflict {
+			lines = append(lines, region.lines...)
+			continue
+		}
+		lines = append(lines, "<<<<<<< "+labels.ours)
+		lines = append(lines, region.ours...)
+		lines = append(lines, "||||||| "+labels.base)
+		lines = append(lines, region.base...)
+		lines = append(lines, "=======")
+		lines = append(lines, region.theirs...)
+		lines = append(lines, ">>>>>>> "+labels.theirs)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	text := strings.Join(lines, "\n")
+	if trailingNewline {
+		text += "\n"
+	}
+	return text
+}
+
+// resolveMergeInteractively walks the conflicts one at a time and replaces
+// each one the user decides on with a resolved region. Skipped conflicts keep
+// their markers.
+func resolveMergeInteractively(reader *bufio.Reader, w io.Writer, ui termUI, regions []mergeRegion, labels mergeLabels) error {
+	total := countMergeConflicts(regions)
+	if total == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, ui.header("Merge")); err != nil {
+		return err
+	}
+	seen := 0
+	for idx := range regions {
+		region := &regions[idx]
+		if !region.conflict {
+			continue
+		}
+		seen++
+		if _, err := fmt.Fprint(w, ui.sectionLabel(fmt.Sprintf("conflict %d of %d", seen, total))); err != nil {
+			return err
+		}
+		writeMergeSide(w, ui.tgreen("ours"), labels.ours, region.ours)
+		writeMergeSide(w, ui.tdim("base"), labels.base, region.base)
+		writeMergeSide(w, ui.tmagenta("theirs"), labels.theirs, region.theirs)
+
+		for {
+			choice, err := promptLine(reader, w, ui.styledPrompt("Keep [o]urs, [t]heirs, [b]oth, b[a]se, [s]kip, [q]uit", "s"))
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			resolved, valid, quit := mergeChoiceLines(*region, choice)
+			if quit {
+				return nil
+			}
+			if !valid {
+				if _, err := fmt.Fprintln(w, ui.warnLine("choose o, t, b, a, s, or q")); err != nil {
+					return err
+				}
+				continue
+			}
+			if resolved != nil {
+				*region = mergeRegion{lines: resolved}
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// mergeChoiceLines applies one resolver answer. It returns the replacement
+// lines (nil to leave the conflict marked), whether the answer was valid, and
+// whether the user asked to stop.
+func mergeChoiceLines(region mergeRegion, choice string) (lines []string, valid bool, quit bool) {
+	switch strings.ToLower(choice) {
+	case "o", "ours":
+		return append([]string{}, region.ours...), true, false
+	case "t", "theirs":
+		return append([]string{}, region.theirs...), true, false
+	case "b", "both":
+		return append(append([]string{}, region.ours...), region.theirs...), true, false
+	case "a", "base":
+		return append([]string{}, region.base...), true, false
+	case "", "s", "skip":
+		return nil, true, false
+	case "q", "quit":
+		return nil, true, true
+	default:
+		return nil, false, false
+	}
+}
+
+func writeMergeSide(w io.Writer, label, name string, lines []string) {
+	_, _ = fmt.Fprintf(w, "  %s %s\n", label, name)
+	if len(lines) == 0 {
+		_, _ = fmt.Fprintln(w, "    (empty)")
+		return
+	}
+	width := len(strconv.Itoa(len(lines)))
+	for i, line := range lines {
+		_, _ = fmt.Fprintf(w, "    %*d  %s\n", width, i+1, line)
+	}
+}
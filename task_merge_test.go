@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMergeInputs(t *testing.T, base, ours, theirs string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"base.txt": base, "ours.txt": ours, "theirs.txt": theirs} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestMergeThreeWayAppliesNonOverlappingChanges(t *testing.T) {
+	base := []string{"a", "b", "c", "d", "e"}
+	ours := []string{"a", "B", "c", "d", "e"}
+	theirs := []string{"a", "b", "c", "d", "E", "f"}
+
+	regions := mergeThreeWay(base, ours, theirs)
+	if countMergeConflicts(regions) != 0 {
+		t.Fatalf("expected a clean merge, got %#v", regions)
+	}
+	got := renderMergeResult(regions, mergeLabels{}, false)
+	if got != "a\nB\nc\nd\nE\nf" {
+		t.Fatalf("unexpected merge result %q", got)
+	}
+}
+
+func TestJotMergeWritesDiff3MarkersAndFailsOnConflict(t *testing.T) {
+	dir := writeMergeInputs(t, "one\ntwo\nthree\n", "one\nours\nthree\n", "one\ntheirs\nthree\n")
+
+	var out bytes.Buffer
+	err := jotMergeWithInput(strings.NewReader(""), &out, []string{"base.txt", "ours.txt", "theirs.txt", "--output", "merged.txt", "--no-color"}, func() (string, error) {
+		return dir, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 merge conflict(s) remain") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	merged, readErr := os.ReadFile(filepath.Join(dir, "merged.txt"))
+	if readErr != nil {
+		t.Fatalf("read merged failed: %v", readErr)
+	}
+	want := "one\n<<<<<<< ours.txt\nours\n||||||| base.txt\ntwo\n=======\ntheirs\n>>>>>>> theirs.txt\nthree\n"
+	if string(merged) != want {
+		t.Fatalf("expected diff3 markers %q, got %q", want, merged)
+	}
+	if ours, _ := os.ReadFile(filepath.Join(dir, "ours.txt")); string(ours) != "one\nours\nthree\n" {
+		t.Fatalf("expected ours to stay untouched with --output, got %q", ours)
+	}
+}
+
+func TestJotMergeInteractiveResolvesEachConflict(t *testing.T) {
+	dir := writeMergeInputs(t,
+		"title\nalpha\nmiddle\nomega\n",
+		"title\nALPHA\nmiddle\nOMEGA ours\n",
+		"title\nAlpha\nmiddle\nomega theirs\n")
+
+	var out bytes.Buffer
+	err := jotMergeWithInput(strings.NewReader("x\nt\nb\n"), &out, []string{"base.txt", "ours.txt", "theirs.txt", "--interactive", "--no-color"}, func() (string, error) {
+		return dir, nil
+	})
+	if err != nil {
+		t.Fatalf("expected all conflicts resolved, got %v\n%s", err, out.String())
+	}
+	merged, _ := os.ReadFile(filepath.Join(dir, "ours.txt"))
+	if string(merged) != "title\nAlpha\nmiddle\nOMEGA ours\nomega theirs\n" {
+		t.Fatalf("unexpected resolved file %q", merged)
+	}
+	if !strings.Contains(out.String(), "CONFLICT 2 OF 2") || !strings.Contains(out.String(), "choose o, t, b, a, s, or q") {
+		t.Fatalf("expected resolver prompts, got %q", out.String())
+	}
+}
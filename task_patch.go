@@ -0,0 +1,733 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const patchNoNewlineMarker = `\ No newline at end of file`
+
+type patchOptions struct {
+	patchPath string
+	dir       string
+	dryRun    bool
+	reverse   bool
+	fuzz      int
+	strip     int
+}
+
+type patchLine struct {
+	op   byte
+	text string
+}
+
+type patchHunk struct {
+	oldStart int
+	oldCount int
+	newStart int
+	newCount int
+	lines    []patchLine
+	// oldNoNewline and newNoNewline record a "\ No newline at end of file"
+	// marker following the last line of that side.
+	oldNoNewline bool
+	newNoNewline bool
+}
+
+type patchFile struct {
+	oldPath string
+	newPath string
+	hunks   []patchHunk
+}
+
+// writeUnifiedPatch renders result as a standard unified diff using the same
+// blocks and context as the terminal viewer.
+func writeUnifiedPatch(w io.Writer, result diffResult, context int, leftLabel, rightLabel string) error {
+	allOps := patchOps(result)
+	blocks := diffBlocks(allOps, context)
+	if len(blocks) == 0 {
+		return nil
+	}
+	leftNoNewline, rightNoNewline := patchNoNewline(result)
+	leftTotal := len(result.left.display)
+	rightTotal := len(result.right.display)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", leftLabel, rightLabel)
+	for _, block := range blocks {
+		ops := allOps[block.start:block.end]
+		oldStart, oldCount, newStart, newCount := patchHunkRange(allOps, block)
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", formatPatchRange(oldStart, oldCount), formatPatchRange(newStart, newCount))
+		for _, op := range orderPatchOps(ops) {
+			switch op.kind {
+			case diffEqual:
+				b.WriteString(" " + op.text + "\n")
+				if (leftNoNewline && op.leftNo == leftTotal) || (rightNoNewline && op.rightNo == rightTotal) {
+					b.WriteString(patchNoNewlineMarker + "\n")
+				}
+			case diffDelete:
+				b.WriteString("-" + op.text + "\n")
+				if leftNoNewline && op.leftNo == leftTotal {
+					b.WriteString(patchNoNewlineMarker + "\n")
+				}
+			case diffAdd:
+				b.WriteString("+" + op.text + "\n")
+				if rightNoNewline && op.rightNo == rightTotal {
+					b.WriteString(patchNoNewlineMarker + "\n")
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// patchNoNewline reports which side's text ends without a newline.
+func patchNoNewline(result diffResult) (left, right bool) {
+	left = result.left.original != "" && !strings.HasSuffix(result.left.original, "\n")
+	right = result.right.original != "" && !strings.HasSuffix(result.right.original, "\n")
+	return left, right
+}
+
+// patchOps returns the ops to write as a patch. When only one side ends
+// without a newline, a last line the two sides share is written as deleted
+// and added again, so the newline marker has a change to attach to, as
+// diff -u does.
+func patchOps(result diffResult) []diffOp {
+	ops := result.ops
+	leftNoNewline, rightNoNewline := patchNoNewline(result)
+	if leftNoNewline == rightNoNewline || len(ops) == 0 {
+		return ops
+	}
+	last := ops[len(ops)-1]
+	if last.kind != diffEqual || last.leftNo != len(result.left.display) || last.rightNo != len(result.right.display) {
+		return ops
+	}
+	n := len(ops) - 1
+	return append(ops[:n:n],
+		diffOp{kind: diffDelete, leftNo: last.leftNo, text: result.left.display[last.leftNo-1]},
+		diffOp{kind: diffAdd, rightNo: last.rightNo, text: result.right.display[last.rightNo-1]},
+	)
+}
+
+// patchHasChanges reports whether writeUnifiedPatch has anything to write.
+func patchHasChanges(result diffResult) bool {
+	for _, op := range patchOps(result) {
+		if op.kind != diffEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// orderPatchOps lists each run of changes as its deletions followed by its
+// additions, the order patch tools expect.
+func orderPatchOps(ops []diffOp) []diffOp {
+	ordered := make([]diffOp, 0, len(ops))
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			ordered = append(ordered, ops[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(ops) && ops[end].kind != diffEqual {
+			end++
+		}
+		for _, kind := range []diffOpKind{diffDelete, diffAdd} {
+			for _, op := range ops[i:end] {
+				if op.kind == kind {
+					ordered = append(ordered, op)
+				}
+			}
+		}
+		i = end
+	}
+	return ordered
+}
+
+// patchHunkRange returns the 1-based start line and line count of a block on
+// each side. Empty sides point at the line before the change, as diff -u does.
+func patchHunkRange(ops []diffOp, block diffBlock) (oldStart, oldCount, newStart, newCount int) {
+	oldBefore, newBefore := 0, 0
+	for _, op := range ops[:block.start] {
+		if op.leftNo > 0 {
+			oldBefore = op.leftNo
+		}
+		if op.rightNo > 0 {
+			newBefore = op.rightNo
+		}
+	}
+	for _, op := range ops[block.start:block.end] {
+		if op.kind != diffAdd {
+			oldCount++
+		}
+		if op.kind != diffDelete {
+			newCount++
+		}
+	}
+	oldStart, newStart = oldBefore, newBefore
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	return oldStart, oldCount, newStart, newCount
+}
+
+func formatPatchRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// jotPatch is the direct command entry point for applying unified diffs.
+func jotPatch(w io.Writer, args []string) error {
+	return jotPatchWithInput(w, args, os.Getwd)
+}
+
+func jotPatchWithInput(w io.Writer, args []string, getwd func() (string, error)) error {
+	opts, helpRequested, err := parsePatchArgs(args)
+	if err != nil {
+		return err
+	}
+	if helpRequested {
+		_, writeErr := io.WriteString(w, renderPatchHelp(isTTY(w)))
+		return writeErr
+	}
+	cwd, err := getwd()
+	if err != nil {
+		return err
+	}
+	return executePatchApply(w, cwd, opts)
+}
+
+func renderPatchHelp(color bool) string {
+	style := helpStyler{color: color}
+	var b strings.Builder
+	writeHelpHeader(&b, style, "jot patch", "Apply unified diff patches to local files without GNU patch.")
+	writeUsageSection(&b, style, []string{
+		"jot patch apply changes.patch",
+		"jot patch apply changes.patch --dry-run",
+		"jot patch apply changes.patch --reverse",
+	}, []string{
+		"Hunks that moved are found by searching outward from their recorded line (offset).",
+		"`--fuzz N` lets a hunk match after ignoring up to N lines of leading and trailing context.",
+		"Files are only written when every hunk for that file applies.",
+	})
+	writeFlagSection(&b, style, []helpFlag{
+		{name: "--dry-run", description: "Report what would change without writing files."},
+		{name: "--reverse", description: "Undo a previously applied patch (also -R)."},
+		{name: "--fuzz N", description: "Allow up to N context lines to mismatch at each hunk edge (default 2)."},
+		{name: "--strip N", description: "Remove N leading path components from patch paths (also -pN)."},
+		{name: "--directory DIR", description: "Resolve patch paths relative to DIR."},
+	})
+	writeExamplesSection(&b, style, []string{
+		"jot diff before.txt after.txt --patch > change.patch",
+		"jot patch apply change.patch --dry-run",
+		"jot patch apply change.patch -R",
+	})
+	return b.String()
+}
+
+func parsePatchArgs(args []string) (patchOptions, bool, error) {
+	opts := patchOptions{fuzz: 2, strip: -1}
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if arg == "" {
+			continue
+		}
+		if isHelpFlag(arg) {
+			return opts, true, nil
+		}
+		if strings.HasPrefix(arg, "-p") && len(arg) > 2 && !strings.HasPrefix(arg, "--") {
+			strip, err := strconv.Atoi(arg[2:])
+			if err != nil || strip < 0 {
+				return opts, false, errors.New("-p must be followed by a non-negative integer")
+			}
+			opts.strip = strip
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "--dry-run":
+				opts.dryRun = true
+			case "--reverse", "-R":
+				opts.reverse = true
+			case "--fuzz", "--strip", "--directory", "-d":
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, false, fmt.Errorf("missing value for %s", name)
+					}
+					i++
+					value = args[i]
+				}
+				value = strings.TrimSpace(value)
+				if name == "--directory" || name == "-d" {
+					opts.dir = value
+					continue
+				}
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return opts, false, fmt.Errorf("%s must be a non-negative integer", name)
+				}
+				if name == "--fuzz" {
+					opts.fuzz = n
+				} else {
+					opts.strip = n
+				}
+			default:
+				return opts, false, fmt.Errorf("unknown flag %q", arg)
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) != 2 || positional[0] != "apply" {
+		return opts, false, errors.New("usage: jot patch apply <file.patch>")
+	}
+	opts.patchPath = positional[1]
+	return opts, false, nil
+}
+
+func executePatchApply(w io.Writer, cwd string, opts patchOptions) error {
+	patchPath := opts.patchPath
+	if !filepath.IsAbs(patchPath) {
+		patchPath = filepath.Join(cwd, patchPath)
+	}
+	data, err := os.ReadFile(patchPath)
+	if err != nil {
+		return err
+	}
+	files, err := parseUnifiedPatch(string(data))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no hunks found in patch")
+	}
+	root := cwd
+	if opts.dir != "" {
+		root = opts.dir
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(cwd, root)
+		}
+	}
+	strip := opts.strip
+	if strip < 0 {
+		strip = detectPatchStrip(files)
+	}
+
+	ui := newTermUI(w)
+	failed := 0
+	for _, file := range files {
+		if err := applyPatchFile(w, ui, root, file, strip, opts); err != nil {
+			failed++
+			if _, writeErr := fmt.Fprintln(w, ui.warnLine(err.Error())); writeErr != nil {
+				return writeErr
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be patched", failed)
+	}
+	return nil
+}
+
+func parseUnifiedPatch(text string) ([]patchFile, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	var files []patchFile
+	var current *patchFile
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			files = append(files, patchFile{
+				oldPath: parsePatchHeaderPath(line[4:]),
+				newPath: parsePatchHeaderPath(lines[i+1][4:]),
+			})
+			current = &files[len(files)-1]
+			i++
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				return nil, fmt.Errorf("patch line %d: hunk before file header", i+1)
+			}
+			hunk, consumed, err := parsePatchHunk(lines[i:])
+			if err != nil {
+				return nil, fmt.Errorf("patch line %d: %w", i+1, err)
+			}
+			current.hunks = append(current.hunks, hunk)
+			i += consumed - 1
+		}
+	}
+	return files, nil
+}
+
+func parsePatchHeaderPath(text string) string {
+	if tab := strings.IndexByte(text, '\t'); tab >= 0 {
+		text = text[:tab]
+	}
+	text = strings.TrimSpace(text)
+	if unquoted, err := strconv.Unquote(text); err == nil && strings.HasPrefix(text, `"`) {
+		return unquoted
+	}
+	return text
+}
+
+// parsePatchHunk reads one hunk starting at its @@ header and returns how
+// many lines it consumed.
+func parsePatchHunk(lines []string) (patchHunk, int, error) {
+	var hunk patchHunk
+	header := lines[0]
+	end := strings.Index(header[3:], " @@")
+	if end < 0 {
+		return hunk, 0, fmt.Errorf("malformed hunk header %q", header)
+	}
+	fields := strings.Fields(header[3 : 3+end])
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "-") || !strings.HasPrefix(fields[1], "+") {
+		return hunk, 0, fmt.Errorf("malformed hunk header %q", header)
+	}
+	var err error
+	if hunk.oldStart, hunk.oldCount, err = parsePatchRange(fields[0][1:]); err != nil {
+		return hunk, 0, err
+	}
+	if hunk.newStart, hunk.newCount, err = parsePatchRange(fields[1][1:]); err != nil {
+		return hunk, 0, err
+	}
+
+	oldSeen, newSeen := 0, 0
+	i := 1
+	for ; i < len(lines) && (oldSeen < hunk.oldCount || newSeen < hunk.newCount); i++ {
+		line := lines[i]
+		if line == "" {
+			// Some editors strip the single space from blank context lines.
+			line = " "
+		}
+		op := line[0]
+		switch op {
+		case ' ':
+			oldSeen++
+			newSeen++
+		case '-':
+			oldSeen++
+		case '+':
+			newSeen++
+		case '\\':
+			hunk.markNoNewline()
+			continue
+		default:
+			return hunk, 0, fmt.Errorf("unexpected line %q in hunk", line)
+		}
+		hunk.lines = append(hunk.lines, patchLine{op: op, text: line[1:]})
+	}
+	if oldSeen != hunk.oldCount || newSeen != hunk.newCount {
+		return hunk, 0, errors.New("hunk is shorter than its header says")
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		hunk.markNoNewline()
+		i++
+	}
+	return hunk, i, nil
+}
+
+func (h *patchHunk) markNoNewline() {
+	if len(h.lines) == 0 {
+		return
+	}
+	switch h.lines[len(h.lines)-1].op {
+	case '-':
+		h.oldNoNewline = true
+	case '+':
+		h.newNoNewline = true
+	default:
+		h.oldNoNewline = true
+		h.newNoNewline = true
+	}
+}
+
+func parsePatchRange(text string) (int, int, error) {
+	startText, countText, hasCount := strings.Cut(text, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed hunk range %q", text)
+	}
+	count := 1
+	if hasCount {
+		count, err = strconv.Atoi(countText)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed hunk range %q", text)
+		}
+	}
+	return start, count, nil
+}
+
+// detectPatchStrip recognizes git-style a/ and b/ prefixes.
+func detectPatchStrip(files []patchFile) int {
+	for _, file := range files {
+		oldOK := file.oldPath == "/dev/null" || strings.HasPrefix(file.oldPath, "a/")
+		newOK := file.newPath == "/dev/null" || strings.HasPrefix(file.newPath, "b/")
+		if !oldOK || !newOK {
+			return 0
+		}
+	}
+	return 1
+}
+
+func reversePatchFile(file patchFile) patchFile {
+	reversed := patchFile{oldPath: file.newPath, newPath: file.oldPath}
+	for _, hunk := range file.hunks {
+		flipped := patchHunk{
+			oldStart:     hunk.newStart,
+			oldCount:     hunk.newCount,
+			newStart:     hunk.oldStart,
+			newCount:     hunk.oldCount,
+			oldNoNewline: hunk.newNoNewline,
+			newNoNewline: hunk.oldNoNewline,
+		}
+		for _, line := range hunk.lines {
+			switch line.op {
+			case '-':
+				line.op = '+'
+			case '+':
+				line.op = '-'
+			}
+			flipped.lines = append(flipped.lines, line)
+		}
+		reversed.hunks = append(reversed.hunks, flipped)
+	}
+	return reversed
+}
+
+// resolvePatchTarget strips path components and refuses paths that would
+// leave root.
+func resolvePatchTarget(root, patchPath string, strip int) (string, error) {
+	parts := strings.Split(filepath.ToSlash(patchPath), "/")
+	if strip >= len(parts) {
+		return "", fmt.Errorf("cannot strip %d component(s) from %s", strip, patchPath)
+	}
+	rel := filepath.FromSlash(strings.Join(parts[strip:], "/"))
+	if filepath.IsAbs(rel) || rel == "" {
+		return "", fmt.Errorf("refusing absolute patch path %s", patchPath)
+	}
+	target := filepath.Join(root, rel)
+	within, err := filepath.Rel(root, target)
+	if err != nil || within == ".." || strings.HasPrefix(within, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing patch path outside %s: %s", root, patchPath)
+	}
+	return target, nil
+}
+
+func applyPatchFile(w io.Writer, ui termUI, root string, file patchFile, strip int, opts patchOptions) error {
+	target, err := choosePatchTarget(root, file, strip)
+	if err != nil {
+		return err
+	}
+	display, _ := filepath.Rel(root, target)
+	if opts.reverse {
+		file = reversePatchFile(file)
+	}
+	creating := file.oldPath == "/dev/null"
+	deleting := file.newPath == "/dev/null"
+
+	var lines []string
+	trailingNewline := true
+	crlf := false
+	data, err := os.ReadFile(target)
+	switch {
+	case err == nil:
+		if creating && len(data) > 0 {
+			return fmt.Errorf("%s already exists", display)
+		}
+		text := string(data)
+		crlf = strings.Contains(text, "\r\n")
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		trailingNewline = text == "" || strings.HasSuffix(text, "\n")
+		lines = splitDiffLines(text)
+	case errors.Is(err, os.ErrNotExist) && creating:
+	default:
+		return err
+	}
+
+	var reports []string
+	offset := 0
+	floor := 0
+	for n, hunk := range file.hunks {
+		oldLines, newLines := hunk.sides()
+		expected := hunk.oldStart - 1 + offset
+		if hunk.oldCount == 0 {
+			expected = hunk.oldStart + offset
+		}
+		at, fuzz, lead, trail := locatePatchHunk(lines, oldLines, hunk, expected, floor, opts.fuzz)
+		if at < 0 {
+			return fmt.Errorf("%s: hunk #%d failed to apply", display, n+1)
+		}
+		matched := oldLines[lead : len(oldLines)-trail]
+		replacement := newLines[lead : len(newLines)-trail]
+		updated := make([]string, 0, len(lines)-len(matched)+len(replacement))
+		updated = append(updated, lines[:at]...)
+		updated = append(updated, replacement...)
+		updated = append(updated, lines[at+len(matched):]...)
+		if at+len(matched) == len(lines) {
+			switch {
+			case hunk.newNoNewline:
+				trailingNewline = false
+			case hunk.oldNoNewline:
+				trailingNewline = true
+			}
+		}
+		lines = updated
+
+		report := fmt.Sprintf("hunk #%d applied", n+1)
+		if delta := at - lead - expected; hunk.oldCount > 0 && delta != 0 {
+			report += fmt.Sprintf(" at %d (offset %d line(s))", at-lead+1, delta)
+		}
+		if fuzz > 0 {
+			report += fmt.Sprintf(" with fuzz %d", fuzz)
+		}
+		reports = append(reports, report)
+		offset += len(newLines) - len(oldLines)
+		floor = at + len(replacement)
+	}
+
+	verb := "patched"
+	if opts.dryRun {
+		verb = "would patch"
+	}
+	if deleting && len(lines) > 0 {
+		return fmt.Errorf("%s: file still has content after removing hunks", display)
+	}
+	if !opts.dryRun {
+		if err := writePatchedFile(target, lines, trailingNewline, crlf, deleting); err != nil {
+			return err
+		}
+	}
+	switch {
+	case creating:
+		verb += " (new file)"
+	case deleting:
+		verb += " (deleted)"
+	}
+	if _, err := fmt.Fprintln(w, ui.success(fmt.Sprintf("%s %s", verb, display))); err != nil {
+		return err
+	}
+	for _, report := range reports {
+		if _, err := fmt.Fprintf(w, "    %s\n", report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// choosePatchTarget picks the file a patch entry applies to. `jot diff old new
+// --patch` names two different files; the old one is patched when it exists,
+// as GNU patch does, in either direction.
+func choosePatchTarget(root string, file patchFile, strip int) (string, error) {
+	switch {
+	case file.oldPath == "/dev/null":
+		return resolvePatchTarget(root, file.newPath, strip)
+	case file.newPath == "/dev/null":
+		return resolvePatchTarget(root, file.oldPath, strip)
+	}
+	target, err := resolvePatchTarget(root, file.newPath, strip)
+	if err != nil {
+		return "", err
+	}
+	if file.oldPath != file.newPath {
+		if oldTarget, oldErr := resolvePatchTarget(root, file.oldPath, strip); oldErr == nil {
+			if _, statErr := os.Stat(oldTarget); statErr == nil {
+				return oldTarget, nil
+			}
+		}
+	}
+	return target, nil
+}
+
+func (h patchHunk) sides() (oldLines, newLines []string) {
+	for _, line := range h.lines {
+		if line.op != '+' {
+			oldLines = append(oldLines, line.text)
+		}
+		if line.op != '-' {
+			newLines = append(newLines, line.text)
+		}
+	}
+	return oldLines, newLines
+}
+
+// locatePatchHunk finds where a hunk's old side matches, searching outward
+// from the expected line. With fuzz it retries while ignoring up to fuzz
+// context lines at each edge. It returns -1 when nothing matches.
+func locatePatchHunk(lines, oldLines []string, hunk patchHunk, expected, floor, maxFuzz int) (at, fuzz, lead, trail int) {
+	leadContext, trailContext := 0, 0
+	for _, line := range hunk.lines {
+		if line.op != ' ' {
+			break
+		}
+		leadContext++
+	}
+	for i := len(hunk.lines) - 1; i >= 0 && hunk.lines[i].op == ' '; i-- {
+		trailContext++
+	}
+	for fuzz = 0; fuzz <= maxFuzz; fuzz++ {
+		lead = min(fuzz, leadContext)
+		trail = min(fuzz, trailContext)
+		if fuzz > 0 && lead == 0 && trail == 0 {
+			break
+		}
+		want := oldLines[lead : len(oldLines)-trail]
+		if at = searchPatchLines(lines, want, expected+lead, floor); at >= 0 {
+			return at, fuzz, lead, trail
+		}
+	}
+	return -1, 0, 0, 0
+}
+
+func searchPatchLines(lines, want []string, expected, floor int) int {
+	last := len(lines) - len(want)
+	if last < floor {
+		return -1
+	}
+	expected = max(floor, min(expected, last))
+	for delta := 0; expected-delta >= floor || expected+delta <= last; delta++ {
+		if pos := expected - delta; pos >= floor && equalLines(lines[pos:pos+len(want)], want) {
+			return pos
+		}
+		if pos := expected + delta; delta > 0 && pos <= last && equalLines(lines[pos:pos+len(want)], want) {
+			return pos
+		}
+	}
+	return -1
+}
+
+func writePatchedFile(target string, lines []string, trailingNewline, crlf, remove bool) error {
+	if remove {
+		return os.Remove(target)
+	}
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	text := strings.Join(lines, eol)
+	if len(lines) > 0 && trailingNewline {
+		text += eol
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	} else if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, []byte(text), mode)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePatchFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s failed: %v", name, err)
+	}
+	return path
+}
+
+func runDiffPatch(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := jotDiffWithInput(strings.NewReader(""), &out, append(args, "--patch"), func() (string, error) {
+		return dir, nil
+	}); err != nil {
+		t.Fatalf("jot diff --patch returned error: %v", err)
+	}
+	return out.String()
+}
+
+func TestJotDiffPatchWritesUnifiedHunks(t *testing.T) {
+	dir := t.TempDir()
+	writePatchFixture(t, dir, "old.txt", "a\nb\nc\nd\ne\nf\ng\nh\n")
+	writePatchFixture(t, dir, "new.txt", "a\nb\nC\nd\ne\nf\ng\nh\ni")
+
+	got := runDiffPatch(t, dir, "old.txt", "new.txt", "--context", "1")
+	want := "--- old.txt\n+++ new.txt\n" +
+		"@@ -2,3 +2,3 @@\n b\n-c\n+C\n d\n" +
+		"@@ -8 +8,2 @@\n h\n+i\n" + patchNoNewlineMarker + "\n"
+	if got != want {
+		t.Fatalf("unexpected patch:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJotPatchCarriesATrailingNewlineChange(t *testing.T) {
+	dir := t.TempDir()
+	target := writePatchFixture(t, dir, "notes.txt", "a\nb\nc")
+	writePatchFixture(t, dir, "notes.new", "a\nb\nc\n")
+
+	patch := runDiffPatch(t, dir, "notes.txt", "notes.new")
+	want := "--- notes.txt\n+++ notes.new\n" +
+		"@@ -1,3 +1,3 @@\n a\n b\n-c\n" + patchNoNewlineMarker + "\n+c\n"
+	if patch != want {
+		t.Fatalf("unexpected patch:\n%s\nwant:\n%s", patch, want)
+	}
+	writePatchFixture(t, dir, "eol.patch", patch)
+
+	getwd := func() (string, error) { return dir, nil }
+	var out bytes.Buffer
+	if err := jotPatchWithInput(&out, []string{"apply", "eol.patch"}, getwd); err != nil {
+		t.Fatalf("apply returned error: %v\n%s", err, out.String())
+	}
+	if data, _ := os.ReadFile(target); string(data) != "a\nb\nc\n" {
+		t.Fatalf("expected the newline to be added, got %q", data)
+	}
+	if err := jotPatchWithInput(&out, []string{"apply", "eol.patch", "-R"}, getwd); err != nil {
+		t.Fatalf("reverse returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "a\nb\nc" {
+		t.Fatalf("expected reverse to drop the newline again, got %q", data)
+	}
+}
+
+func TestJotPatchApplyRoundTripsWithOffsetFuzzAndReverse(t *testing.T) {
+	dir := t.TempDir()
+	writePatchFixture(t, dir, "config.txt", "one\ntwo\nthree\nfour\nfive\nsix\n")
+	writePatchFixture(t, dir, "config.new", "one\ntwo\nTHREE\nfour\nfive\nsix\n")
+	patch := runDiffPatch(t, dir, "config.txt", "config.new")
+	writePatchFixture(t, dir, "change.patch", patch)
+
+	// Shift the target down and edit a context line so the hunk needs both an
+	// offset and one line of fuzz.
+	original := "header\nextra\none\ntwo\nthree\nfour\nfive\nSIX\n"
+	target := writePatchFixture(t, dir, "config.txt", original)
+
+	getwd := func() (string, error) { return dir, nil }
+	var out bytes.Buffer
+	if err := jotPatchWithInput(&out, []string{"apply", "change.patch", "--dry-run"}, getwd); err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != original || !strings.Contains(out.String(), "would patch config.txt") {
+		t.Fatalf("expected dry run to leave the file alone, got %q / %q", data, out.String())
+	}
+
+	out.Reset()
+	if err := jotPatchWithInput(&out, []string{"apply", "change.patch"}, getwd); err != nil {
+		t.Fatalf("apply returned error: %v\n%s", err, out.String())
+	}
+	if data, _ := os.ReadFile(target); string(data) != "header\nextra\none\ntwo\nTHREE\nfour\nfive\nSIX\n" {
+		t.Fatalf("unexpected patched file %q", data)
+	}
+	if !strings.Contains(out.String(), "offset 2 line(s)") || !strings.Contains(out.String(), "with fuzz 1") {
+		t.Fatalf("expected offset and fuzz report, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := jotPatchWithInput(&out, []string{"apply", "change.patch", "-R"}, getwd); err != nil {
+		t.Fatalf("reverse returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != original {
+		t.Fatalf("expected reverse to restore the file, got %q", data)
+	}
+}
+
+func TestJotPatchApplyCreatesGitStyleFilesAndRefusesEscapes(t *testing.T) {
+	dir := t.TempDir()
+	writePatchFixture(t, dir, "new.patch", "diff --git a/docs/new.md b/docs/new.md\nnew file mode 100644\n--- /dev/null\n+++ b/docs/new.md\n@@ -0,0 +1,2 @@\n+# Title\n+body\n")
+	getwd := func() (string, error) { return dir, nil }
+	var out bytes.Buffer
+	if err := jotPatchWithInput(&out, []string{"apply", "new.patch"}, getwd); err != nil {
+		t.Fatalf("apply returned error: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "docs", "new.md")); err != nil || string(data) != "# Title\nbody\n" {
+		t.Fatalf("expected created file, got %q (%v)", data, err)
+	}
+
+	writePatchFixture(t, dir, "escape.patch", "--- ../outside.txt\n+++ ../outside.txt\n@@ -0,0 +1 @@\n+owned\n")
+	out.Reset()
+	err := jotPatchWithInput(&out, []string{"apply", "escape.patch"}, getwd)
+	if err == nil || !strings.Contains(out.String(), "refusing patch path outside") {
+		t.Fatalf("expected escape to be refused, got %v / %q", err, out.String())
+	}
+}
@@ -0,0 +1,752 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type pdfOptions struct {
+	action     string
+	path       string
+	inputs     []string
+	term       string
+	pages      string
+	rangeSpec  string
+	angle      int
+	every      int
+	outputPath string
+	outDir     string
+	overwrite  bool
+}
+
+// jotPDF is the direct command entry point for reading and rearranging PDF
+// files.
+func jotPDF(w io.Writer, args []string) error {
+	return jotPDFWithInput(w, args, os.Getwd)
+}
+
+func jotPDFWithInput(w io.Writer, args []string, getwd func() (string, error)) error {
+	opts, helpRequested, err := parsePDFArgs(args)
+	if err != nil {
+		return err
+	}
+	if helpRequested {
+		_, writeErr := io.WriteString(w, renderPDFHelp(isTTY(w)))
+		return writeErr
+	}
+	cwd, err := getwd()
+	if err != nil {
+		return err
+	}
+	return executePDF(w, cwd, opts)
+}
+
+func renderPDFHelp(color bool) string {
+	style := helpStyler{color: color}
+	var b strings.Builder
+	writeHelpHeader(&b, style, "jot pdf", "Read, combine, and rearrange local PDF files without external tools.")
+	writeUsageSection(&b, style, []string{
+		"jot pdf text report.pdf",
+		"jot pdf search report.pdf \"term\"",
+		"jot pdf info report.pdf",
+		"jot pdf merge a.pdf b.pdf -o out.pdf",
+		"jot pdf split report.pdf [--every N] [--to DIR]",
+		"jot pdf pages report.pdf 1-3,7 [-o out.pdf]",
+		"jot pdf rotate report.pdf 90 [--pages LIST] [-o out.pdf]",
+	}, []string{
+		"Text is decoded from page content streams, including compressed streams and fonts with ToUnicode maps.",
+		"Scanned PDFs contain images rather than text, so they have nothing to extract.",
+		"Page operations copy pages as-is; outlines and form fields are not carried over.",
+		"Encrypted PDFs are not supported.",
+	})
+	writeCommandSection(&b, style, []helpCommand{
+		{name: "text", description: "Print the text of every page, or only the pages selected with --pages."},
+		{name: "search", description: "List the lines that contain a term, with their page numbers."},
+		{name: "info", description: "Show the PDF version, page count, page size, and document metadata."},
+		{name: "merge", description: "Combine two or more PDFs, in order, into one file."},
+		{name: "split", description: "Write each page, or every N pages, to its own file."},
+		{name: "pages", description: "Extract a page list such as 1-3,7 into a new file, in that order."},
+		{name: "rotate", description: "Rotate pages clockwise by 90, 180, or 270 degrees (negative turns counterclockwise)."},
+	})
+	writeFlagSection(&b, style, []helpFlag{
+		{name: "--pages LIST", description: "Limit text, search, or rotate to pages such as 1-3,5 (1-based)."},
+		{name: "--output PATH", description: "Write merge, pages, or rotate output to PATH (also -o)."},
+		{name: "--every N", description: "Put N pages in each file when splitting (default 1)."},
+		{name: "--to DIR", description: "Write split files into DIR instead of next to the source."},
+		{name: "--overwrite", description: "Replace output files that already exist."},
+	})
+	writeExamplesSection(&b, style, []string{
+		"jot pdf text invoice.pdf > invoice.txt",
+		"jot pdf text slides.pdf --pages 2-4",
+		"jot pdf search contract.pdf \"termination\"",
+		"jot pdf info paper.pdf",
+		"jot pdf merge scan-1.pdf scan-2.pdf -o scans.pdf",
+		"jot pdf pages report.pdf 1-3,7 -o summary.pdf",
+		"jot pdf rotate scan.pdf 90 --pages 2",
+	})
+	return b.String()
+}
+
+func parsePDFArgs(args []string) (pdfOptions, bool, error) {
+	opts := pdfOptions{every: 1}
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if arg == "" {
+			continue
+		}
+		if isHelpFlag(arg) {
+			return opts, true, nil
+		}
+		// Negative rotation angles look like flags.
+		if _, err := strconv.Atoi(arg); err == nil {
+			positional = append(positional, arg)
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "--overwrite":
+				opts.overwrite = true
+				continue
+			case "--pages", "--output", "-o", "--every", "--to":
+			default:
+				return opts, false, fmt.Errorf("unknown flag %q", arg)
+			}
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, false, fmt.Errorf("missing value for %s", name)
+				}
+				i++
+				value = args[i]
+			}
+			value = strings.TrimSpace(value)
+			switch name {
+			case "--pages":
+				opts.pages = value
+			case "--output", "-o":
+				opts.outputPath = value
+			case "--to":
+				opts.outDir = value
+			case "--every":
+				every, err := strconv.Atoi(value)
+				if err != nil || every < 1 {
+					return opts, false, errors.New("--every must be a positive integer")
+				}
+				opts.every = every
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) == 0 {
+		return opts, true, nil
+	}
+	opts.action = strings.ToLower(positional[0])
+	positional = positional[1:]
+	switch opts.action {
+	case "text", "info", "split":
+		if len(positional) != 1 {
+			return opts, false, fmt.Errorf("usage: jot pdf %s <file.pdf>", opts.action)
+		}
+	case "search":
+		if len(positional) != 2 {
+			return opts, false, errors.New(`usage: jot pdf search <file.pdf> "term"`)
+		}
+		opts.term = positional[1]
+	case "merge":
+		if len(positional) < 2 {
+			return opts, false, errors.New("usage: jot pdf merge a.pdf b.pdf [...] -o out.pdf")
+		}
+		if opts.outputPath == "" {
+			return opts, false, errors.New("merge needs an output file; pass -o out.pdf")
+		}
+		opts.inputs = positional
+	case "pages":
+		if len(positional) != 2 {
+			return opts, false, errors.New("usage: jot pdf pages <file.pdf> 1-3,7 [-o out.pdf]")
+		}
+		opts.rangeSpec = positional[1]
+	case "rotate":
+		if len(positional) != 2 {
+			return opts, false, errors.New("usage: jot pdf rotate <file.pdf> 90 [--pages LIST] [-o out.pdf]")
+		}
+		angle, err := strconv.Atoi(positional[1])
+		if err != nil || angle%90 != 0 || angle == 0 {
+			return opts, false, errors.New("rotation must be a non-zero multiple of 90 degrees")
+		}
+		opts.angle = angle
+	default:
+		return opts, false, fmt.Errorf("unknown pdf command %q (use text, search, info, merge, split, pages, or rotate)", opts.action)
+	}
+	if opts.action != "merge" {
+		opts.path = positional[0]
+	}
+	switch {
+	case opts.pages != "" && opts.action != "text" && opts.action != "search" && opts.action != "rotate":
+		return opts, false, errors.New("--pages only applies to text, search, and rotate")
+	case opts.outputPath != "" && opts.action != "merge" && opts.action != "pages" && opts.action != "rotate":
+		return opts, false, errors.New("--output only applies to merge, pages, and rotate")
+	case (opts.outDir != "" || opts.every != 1) && opts.action != "split":
+		return opts, false, errors.New("--every and --to only apply to split")
+	}
+	return opts, false, nil
+}
+
+func executePDF(w io.Writer, cwd string, opts pdfOptions) error {
+	switch opts.action {
+	case "merge", "split", "pages", "rotate":
+		return executePDFPageOperation(w, newTermUI(w), cwd, opts)
+	}
+	path := resolvePDFPath(cwd, opts.path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err := openPDFDocument(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opts.path, err)
+	}
+	if opts.action == "info" {
+		return renderPDFInfo(w, newTermUI(w), path, int64(len(data)), doc)
+	}
+
+	texts, err := extractPDFText(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opts.path, err)
+	}
+	pages, err := parsePDFPageSelection(opts.pages, len(texts))
+	if err != nil {
+		return err
+	}
+	if opts.action == "search" {
+		return renderPDFSearch(w, newTermUI(w), texts, pages, opts.term)
+	}
+
+	var parts []string
+	for _, index := range pages {
+		if texts[index] != "" {
+			parts = append(parts, texts[index])
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("%s: %w", opts.path, errPDFNoText)
+	}
+	_, err = fmt.Fprintln(w, strings.Join(parts, "\n\n"))
+	return err
+}
+
+func resolvePDFPath(cwd, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+func loadPDFPages(cwd, path string) (*pdfDocument, []pdfPage, error) {
+	data, err := os.ReadFile(resolvePDFPath(cwd, path))
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err := openPDFDocument(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(pages) == 0 {
+		return nil, nil, fmt.Errorf("%s: PDF has no pages", path)
+	}
+	return doc, pages, nil
+}
+
+// executePDFPageOperation runs merge, split, pages, and rotate. Every source
+// is read fully before anything is written, so an output may replace its own
+// input when --overwrite is given.
+func executePDFPageOperation(w io.Writer, ui termUI, cwd string, opts pdfOptions) error {
+	if opts.action == "merge" {
+		var out []pdfOutputPage
+		for _, input := range opts.inputs {
+			doc, pages, err := loadPDFPages(cwd, input)
+			if err != nil {
+				return err
+			}
+			for _, page := range pages {
+				out = append(out, pdfOutputPage{doc: doc, page: page})
+			}
+		}
+		return writePDFOutput(w, ui, resolvePDFPath(cwd, opts.outputPath), out, opts.overwrite)
+	}
+
+	doc, pages, err := loadPDFPages(cwd, opts.path)
+	if err != nil {
+		return err
+	}
+	source := resolvePDFPath(cwd, opts.path)
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	switch opts.action {
+	case "pages":
+		selection, err := parsePDFPageList(opts.rangeSpec, len(pages))
+		if err != nil {
+			return err
+		}
+		out := make([]pdfOutputPage, len(selection))
+		for i, index := range selection {
+			out[i] = pdfOutputPage{doc: doc, page: pages[index]}
+		}
+		return writePDFOutput(w, ui, pdfOutputPath(cwd, opts.outputPath, source, base+"-pages.pdf"), out, opts.overwrite)
+	case "rotate":
+		selection, err := parsePDFPageSelection(opts.pages, len(pages))
+		if err != nil {
+			return err
+		}
+		rotated := make(map[int]bool, len(selection))
+		for _, index := range selection {
+			rotated[index] = true
+		}
+		out := make([]pdfOutputPage, len(pages))
+		for i, page := range pages {
+			out[i] = pdfOutputPage{doc: doc, page: page}
+			if rotated[i] {
+				out[i].rotate = opts.angle
+			}
+		}
+		return writePDFOutput(w, ui, pdfOutputPath(cwd, opts.outputPath, source, base+"-rotated.pdf"), out, opts.overwrite)
+	default:
+		dir := filepath.Dir(source)
+		if opts.outDir != "" {
+			dir = resolvePDFPath(cwd, opts.outDir)
+		}
+		for start := 0; start < len(pages); start += opts.every {
+			end := min(start+opts.every, len(pages))
+			name := fmt.Sprintf("%s-%d.pdf", base, start+1)
+			if end-start > 1 {
+				name = fmt.Sprintf("%s-%d-%d.pdf", base, start+1, end)
+			}
+			out := make([]pdfOutputPage, 0, end-start)
+			for _, page := range pages[start:end] {
+				out = append(out, pdfOutputPage{doc: doc, page: page})
+			}
+			if err := writePDFOutput(w, ui, filepath.Join(dir, name), out, opts.overwrite); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func pdfOutputPath(cwd, outputPath, source, defaultName string) string {
+	if outputPath != "" {
+		return resolvePDFPath(cwd, outputPath)
+	}
+	return filepath.Join(filepath.Dir(source), defaultName)
+}
+
+func writePDFOutput(w io.Writer, ui termUI, path string, pages []pdfOutputPage, overwrite bool) error {
+	var buf bytes.Buffer
+	if err := writePDFPages(&buf, pages); err != nil {
+		return err
+	}
+	if err := writePDFFile(path, buf.Bytes(), overwrite); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, ui.success(fmt.Sprintf("wrote %s (%d page(s), %s)", filepath.Base(path), len(pages), formatViewerByteSize(int64(buf.Len())))))
+	return err
+}
+
+// writePDFFile writes through a temporary file in the target folder so a
+// failed write never leaves a truncated PDF behind.
+func writePDFFile(path string, data []byte, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists; rerun with --overwrite or choose -o", path)
+		}
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".jot-pdf-*")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	if overwrite {
+		_ = os.Remove(path)
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	return nil
+}
+
+// parsePDFPageList is like parsePDFPageSelection but keeps the listed order
+// and repeats, so `pages 3,1,1` produces exactly those pages.
+func parsePDFPageList(spec string, total int) ([]int, error) {
+	var pages []int
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		selection, err := parsePDFPageSelection(part, total)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, selection...)
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("page list did not select any pages")
+	}
+	return pages, nil
+}
+
+// parsePDFPageSelection turns a list such as "1-3,5" into sorted 0-based page
+// indexes. An empty selection means every page.
+func parsePDFPageSelection(spec string, total int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		pages := make([]int, total)
+		for i := range pages {
+			pages[i] = i
+		}
+		return pages, nil
+	}
+	seen := make(map[int]bool)
+	var pages []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		startText, endText, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid page %q", part)
+		}
+		end := start
+		if isRange {
+			if strings.TrimSpace(endText) == "" {
+				end = total
+			} else if end, err = strconv.Atoi(strings.TrimSpace(endText)); err != nil {
+				return nil, fmt.Errorf("invalid page range %q", part)
+			}
+		}
+		if start < 1 || end < start || end > total {
+			return nil, fmt.Errorf("page %q is out of range (document has %d page(s))", part, total)
+		}
+		for page := start; page <= end; page++ {
+			if !seen[page] {
+				seen[page] = true
+				pages = append(pages, page-1)
+			}
+		}
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("--pages did not select any pages")
+	}
+	sort.Ints(pages)
+	return pages, nil
+}
+
+func renderPDFSearch(w io.Writer, ui termUI, texts []string, pages []int, term string) error {
+	needle := strings.ToLower(term)
+	if strings.TrimSpace(needle) == "" {
+		return errors.New("search term cannot be empty")
+	}
+	matches := 0
+	matchedPages := 0
+	for _, index := range pages {
+		pageMatched := false
+		for _, line := range strings.Split(texts[index], "\n") {
+			if !strings.Contains(strings.ToLower(line), needle) {
+				continue
+			}
+			matches++
+			pageMatched = true
+			if _, err := fmt.Fprintf(w, "  %s  %s\n", ui.tdim(fmt.Sprintf("p.%d", index+1)), highlightPDFMatch(ui, strings.TrimSpace(line), needle)); err != nil {
+				return err
+			}
+		}
+		if pageMatched {
+			matchedPages++
+		}
+	}
+	if matches == 0 {
+		_, err := fmt.Fprintln(w, ui.warnLine(fmt.Sprintf("no matches for %q", term)))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", ui.success(fmt.Sprintf("%d match(es) on %d page(s)", matches, matchedPages)))
+	return err
+}
+
+// highlightPDFMatch marks every case-insensitive occurrence of needle. The
+// lowered line is only used for positions when lowering keeps byte lengths.
+func highlightPDFMatch(ui termUI, line, needle string) string {
+	lower := strings.ToLower(line)
+	if !ui.color || len(lower) != len(line) {
+		return line
+	}
+	var b strings.Builder
+	for {
+		idx := strings.Index(lower, needle)
+		if idx < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:idx])
+		b.WriteString(ui.tyellow(line[idx : idx+len(needle)]))
+		line, lower = line[idx+len(needle):], lower[idx+len(needle):]
+	}
+}
+
+func renderPDFInfo(w io.Writer, ui termUI, path string, size int64, doc *pdfDocument) error {
+	pages, err := doc.pages()
+	if err != nil {
+		return err
+	}
+	rows := [][2]string{
+		{"file", filepath.Base(path)},
+		{"size", formatViewerByteSize(size)},
+		{"version", "PDF " + doc.version},
+		{"pages", strconv.Itoa(len(pages))},
+	}
+	if len(pages) > 0 && pages[0].mediaBox != nil {
+		rows = append(rows, [2]string{"page size", formatPDFPageSize(pages[0].mediaBox, pages[0].rotate)})
+	}
+	info := doc.info()
+	for _, field := range []struct{ key, label string }{
+		{"Title", "title"},
+		{"Author", "author"},
+		{"Subject", "subject"},
+		{"Keywords", "keywords"},
+		{"Creator", "creator"},
+		{"Producer", "producer"},
+		{"CreationDate", "created"},
+		{"ModDate", "modified"},
+	} {
+		value := strings.TrimSpace(pdfTextString(doc.resolve(info[pdfName(field.key)])))
+		if value == "" {
+			continue
+		}
+		if strings.HasSuffix(field.key, "Date") {
+			if parsed, ok := parsePDFDate(value); ok {
+				value = parsed.Format("2006-01-02 15:04:05 -07:00")
+			}
+		}
+		rows = append(rows, [2]string{field.label, value})
+	}
+	for i, row := range rows {
+		if _, err := fmt.Fprintln(w, ui.listItem(i+1, row[0], row[1], "")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatPDFPageSize reports the page size in points and millimetres, naming
+// common paper sizes.
+func formatPDFPageSize(box []float64, rotate int) string {
+	width := math.Abs(box[2] - box[0])
+	height := math.Abs(box[3] - box[1])
+	if rotate%180 != 0 {
+		width, height = height, width
+	}
+	label := fmt.Sprintf("%.0f x %.0f pt (%.0f x %.0f mm)", width, height, width*25.4/72, height*25.4/72)
+	short, long := math.Min(width, height), math.Max(width, height)
+	for _, paper := range []struct {
+		name        string
+		short, long float64
+	}{
+		{"A4", 595, 842},
+		{"A3", 842, 1191},
+		{"A5", 420, 595},
+		{"Letter", 612, 792},
+		{"Legal", 612, 1008},
+	} {
+		if math.Abs(short-paper.short) <= 2 && math.Abs(long-paper.long) <= 2 {
+			orientation := "portrait"
+			if width > height {
+				orientation = "landscape"
+			}
+			return fmt.Sprintf("%s, %s %s", label, paper.name, orientation)
+		}
+	}
+	return label
+}
+
+// pdfTaskTitles names the guided `jot task` entries for page operations.
+var pdfTaskTitles = map[string]string{
+	"merge":  "Merge PDFs",
+	"split":  "Split PDF",
+	"pages":  "Extract PDF Pages",
+	"rotate": "Rotate PDF",
+}
+
+// runPDFTask is the guided flow behind the PDF entries in `jot task`. It
+// collects the same options as `jot pdf <action>` and prints that command
+// afterwards.
+func runPDFTask(stdin io.Reader, w io.Writer, dir, action string) error {
+	ui := newTermUI(w)
+	reader := bufio.NewReader(stdin)
+
+	if _, err := fmt.Fprint(w, ui.header(pdfTaskTitles[action])); err != nil {
+		return err
+	}
+	files, err := listPDFFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		if _, err := fmt.Fprint(w, ui.sectionLabel("pdfs in this folder")); err != nil {
+			return err
+		}
+		for i, path := range files {
+			meta := ""
+			if info, statErr := os.Stat(path); statErr == nil {
+				meta = formatViewerByteSize(info.Size())
+			}
+			if _, err := fmt.Fprintln(w, ui.listItem(i+1, filepath.Base(path), "", meta)); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+
+	opts := pdfOptions{action: action, every: 1}
+	command := []string{"jot", "pdf", action}
+	if action == "merge" {
+		selection, err := promptLine(reader, w, ui.styledPrompt("Files to merge, in order", "e.g. 1 2"))
+		if err != nil {
+			return err
+		}
+		for _, field := range strings.FieldsFunc(selection, func(r rune) bool { return r == ' ' || r == ',' }) {
+			opts.inputs = append(opts.inputs, pickPDFTaskFile(files, field))
+		}
+		if len(opts.inputs) < 2 {
+			return errors.New("choose at least two PDFs to merge")
+		}
+		output, err := promptLine(reader, w, ui.styledPrompt("Output file", "merged.pdf"))
+		if err != nil {
+			return err
+		}
+		opts.outputPath = firstNonEmpty(output, "merged.pdf")
+		for _, input := range opts.inputs {
+			command = append(command, filepath.Base(input))
+		}
+		command = append(command, "-o", opts.outputPath)
+	} else {
+		selection, err := promptLine(reader, w, ui.styledPrompt("Source PDF", hintForStripSelection(files)))
+		if err != nil {
+			return err
+		}
+		if selection == "" {
+			if len(files) != 1 {
+				return errors.New("source PDF must be provided")
+			}
+			selection = "1"
+		}
+		opts.path = pickPDFTaskFile(files, selection)
+		command = append(command, filepath.Base(opts.path))
+	}
+
+	switch action {
+	case "split":
+		every, err := promptLine(reader, w, ui.styledPrompt("Pages per file", "1"))
+		if err != nil {
+			return err
+		}
+		if every != "" {
+			if opts.every, err = strconvAtoi(every); err != nil || opts.every < 1 {
+				return errors.New("pages per file must be a positive integer")
+			}
+			if opts.every > 1 {
+				command = append(command, "--every", every)
+			}
+		}
+	case "pages":
+		pages, err := promptLine(reader, w, ui.styledPrompt("Pages to keep", "e.g. 1-3,7"))
+		if err != nil {
+			return err
+		}
+		if pages == "" {
+			return errors.New("choose the pages to keep")
+		}
+		opts.rangeSpec = pages
+		command = append(command, pages)
+	case "rotate":
+		angle, err := promptLine(reader, w, ui.styledPrompt("Degrees clockwise", "90"))
+		if err != nil {
+			return err
+		}
+		angle = firstNonEmpty(angle, "90")
+		if opts.angle, err = strconvAtoi(angle); err != nil || opts.angle%90 != 0 || opts.angle == 0 {
+			return errors.New("rotation must be a non-zero multiple of 90 degrees")
+		}
+		command = append(command, angle)
+		pages, err := promptLine(reader, w, ui.styledPrompt("Pages to rotate", "all"))
+		if err != nil {
+			return err
+		}
+		if pages != "" && !strings.EqualFold(pages, "all") {
+			opts.pages = pages
+			command = append(command, "--pages", pages)
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, ""); err != nil {
+		return err
+	}
+	if err := executePDFPageOperation(w, ui, dir, opts); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, ui.tip("next time: "+strings.Join(command, " "))); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, "")
+	return err
+}
+
+func pickPDFTaskFile(files []string, selection string) string {
+	if idx, err := strconvAtoi(selection); err == nil && idx >= 1 && idx <= len(files) {
+		return files[idx-1]
+	}
+	return selection
+}
+
+func listPDFFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".pdf") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildTestPDF assembles a PDF from object bodies (object i+1 is objects[i])
+// with a correct cross-reference table. Streams are written with their
+// bodies flate-compressed when the body starts with "FLATE:".
+func buildTestPDF(t testing.TB, trailer string, objects ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		if dict, content, ok := strings.Cut(body, "FLATE:"); ok {
+			var compressed bytes.Buffer
+			zw := zlib.NewWriter(&compressed)
+			if _, err := zw.Write([]byte(content)); err != nil {
+				t.Fatalf("compress stream: %v", err)
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatalf("compress stream: %v", err)
+			}
+			fmt.Fprintf(&b, "<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+			b.Write(compressed.Bytes())
+			b.WriteString("\nendstream")
+		} else {
+			b.WriteString(body)
+		}
+		b.WriteString("\nendobj\n")
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+	return b.Bytes()
+}
+
+// samplePDF has two pages: the first uses a composite font whose glyph IDs
+// only make sense through its ToUnicode CMap, the second a simple font with
+// a /Differences encoding and TJ kerning.
+func samplePDF(t testing.TB) []byte {
+	t.Helper()
+	cmap := strings.Join([]string{
+		"/CIDInit /ProcSet findresource begin",
+		"12 dict begin begincmap",
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange",
+		"2 beginbfchar <0001> <0048> <0002> <0069> endbfchar",
+		"1 beginbfrange <0010> <0012> <0041> endbfrange",
+		"1 beginbfrange <0020> <0021> [<00660069> <00200057>] endbfrange",
+		"endcmap CMapName currentdict /CMap defineresource pop end end",
+	}, "\n")
+	page1 := "BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -14 Td <001000110012> Tj <00200021> Tj ET"
+	page2 := "BT /F2 11 Tf 1 0 0 1 72 700 Tm [(Quarterly)-250(report)] TJ T* 0 -13 Td (Net \\(ex. tax\\) \\221up\\222 \\001) Tj ET"
+	return buildTestPDF(t, "/Root 1 0 R /Info 10 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 595 842] >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F2 8 0 R >> >> /Contents 9 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Sample /Encoding /Identity-H /ToUnicode 7 0 R >>",
+		"FLATE:"+page1,
+		"FLATE:"+cmap,
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [1 /ff] >> >>",
+		"FLATE:"+page2,
+		"<< /Title <FEFF004E006F007400650073> /Author (Ada) /CreationDate (D:20240102030405+01'00') >>",
+	)
+}
+
+func writePDFFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sample.pdf"), samplePDF(t), 0o600); err != nil {
+		t.Fatalf("write sample.pdf failed: %v", err)
+	}
+	return dir
+}
+
+func runJotPDF(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := jotPDFWithInput(&out, args, func() (string, error) { return dir, nil }); err != nil {
+		t.Fatalf("jot pdf %v returned error: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestJotPDFTextDecodesToUnicodeAndSimpleFonts(t *testing.T) {
+	dir := writePDFFixture(t)
+
+	got := runJotPDF(t, dir, "text", "sample.pdf")
+	want := "Hi\nABCfi W\n\nQuarterly report\nNet (ex. tax) ‘up’ ﬀ\n"
+	if got != want {
+		t.Fatalf("unexpected text:\n%q\nwant:\n%q", got, want)
+	}
+
+	got = runJotPDF(t, dir, "text", "sample.pdf", "--pages", "2")
+	if !strings.HasPrefix(got, "Quarterly report") || strings.Contains(got, "Hi") {
+		t.Fatalf("--pages 2 returned %q", got)
+	}
+
+	content, err := (pdfAttachmentReader{}).Read(samplePDF(t), AttachmentMeta{Filename: "sample.pdf"})
+	if err != nil {
+		t.Fatalf("attachment read failed: %v", err)
+	}
+	if content.Metadata["strategy"] != "content-streams" || !strings.Contains(content.Text, "Quarterly report") {
+		t.Fatalf("unexpected attachment content: %#v", content)
+	}
+}
+
+func TestJotPDFSearchReportsPageNumbers(t *testing.T) {
+	dir := writePDFFixture(t)
+
+	got := runJotPDF(t, dir, "search", "sample.pdf", "REPORT")
+	if !strings.Contains(got, "p.2  Quarterly report") {
+		t.Fatalf("expected page 2 match, got:\n%s", got)
+	}
+	if !strings.Contains(got, "1 match(es) on 1 page(s)") {
+		t.Fatalf("expected match summary, got:\n%s", got)
+	}
+
+	got = runJotPDF(t, dir, "search", "sample.pdf", "missing")
+	if !strings.Contains(got, `no matches for "missing"`) {
+		t.Fatalf("expected no-match warning, got:\n%s", got)
+	}
+
+	var out bytes.Buffer
+	err := jotPDFWithInput(&out, []string{"text", "sample.pdf", "--pages", "3"}, func() (string, error) { return dir, nil })
+	if err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("expected out of range error, got %v", err)
+	}
+}
+
+func TestJotPDFInfoShowsMetadata(t *testing.T) {
+	dir := writePDFFixture(t)
+
+	got := runJotPDF(t, dir, "info", "sample.pdf")
+	for _, want := range []string{
+		"version   PDF 1.7",
+		"pages   2",
+		"page size   595 x 842 pt (210 x 297 mm), A4 portrait",
+		"title   Notes",
+		"author   Ada",
+		"created   2024-01-02 03:04:05 +01:00",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in info output:\n%s", want, got)
+		}
+	}
+}
+
+func readPDFFixturePages(t *testing.T, path string) (*pdfDocument, []pdfPage) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s failed: %v", path, err)
+	}
+	doc, err := openPDFDocument(data)
+	if err != nil {
+		t.Fatalf("open %s failed: %v", path, err)
+	}
+	pages, err := doc.pages()
+	if err != nil {
+		t.Fatalf("pages of %s failed: %v", path, err)
+	}
+	return doc, pages
+}
+
+func TestJotPDFMergePagesAndRotateRoundTrip(t *testing.T) {
+	dir := writePDFFixture(t)
+
+	runJotPDF(t, dir, "merge", "sample.pdf", "sample.pdf", "-o", "merged.pdf")
+	doc, pages := readPDFFixturePages(t, filepath.Join(dir, "merged.pdf"))
+	if len(pages) != 4 {
+		t.Fatalf("expected 4 merged pages, got %d", len(pages))
+	}
+	texts, err := extractPDFText(doc)
+	if err != nil {
+		t.Fatalf("extract merged text: %v", err)
+	}
+	if texts[0] != "Hi\nABCfi W" || texts[3] != "Quarterly report\nNet (ex. tax) ‘up’ ﬀ" {
+		t.Fatalf("unexpected merged text: %q", texts)
+	}
+	if title := pdfTextString(doc.resolve(doc.info()["Title"])); title != "Notes" {
+		t.Fatalf("expected info to carry over, got title %q", title)
+	}
+
+	runJotPDF(t, dir, "pages", "merged.pdf", "4,1")
+	doc, pages = readPDFFixturePages(t, filepath.Join(dir, "merged-pages.pdf"))
+	texts, _ = extractPDFText(doc)
+	if len(pages) != 2 || !strings.HasPrefix(texts[0], "Quarterly") || !strings.HasPrefix(texts[1], "Hi") {
+		t.Fatalf("pages did not keep the listed order: %q", texts)
+	}
+
+	runJotPDF(t, dir, "rotate", "sample.pdf", "-90", "--pages", "2", "-o", "turned.pdf")
+	_, pages = readPDFFixturePages(t, filepath.Join(dir, "turned.pdf"))
+	if pages[0].rotate != 0 || pages[1].rotate != 270 || len(pages[1].mediaBox) != 4 {
+		t.Fatalf("unexpected rotation: %d, %d", pages[0].rotate, pages[1].rotate)
+	}
+
+	var out bytes.Buffer
+	err = jotPDFWithInput(&out, []string{"rotate", "sample.pdf", "90", "-o", "turned.pdf"}, func() (string, error) { return dir, nil })
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected existing output to be refused, got %v", err)
+	}
+}
+
+func TestJotTaskPDFSplitWritesOneFilePerChunk(t *testing.T) {
+	dir := writePDFFixture(t)
+
+	var out bytes.Buffer
+	if err := jotTask(strings.NewReader("15\n1\n\n"), &out, nil, func() string { return dir }); err != nil {
+		t.Fatalf("jot task split returned error: %v\n%s", err, out.String())
+	}
+	for _, name := range []string{"sample-1.pdf", "sample-2.pdf"} {
+		_, pages := readPDFFixturePages(t, filepath.Join(dir, name))
+		if len(pages) != 1 {
+			t.Fatalf("%s: expected 1 page, got %d", name, len(pages))
+		}
+	}
+	if !strings.Contains(out.String(), "next time: jot pdf split sample.pdf") {
+		t.Fatalf("expected direct command tip, got:\n%s", out.String())
+	}
+}
+
+// hostilePDFs are well-formed enough to reach the stream and CMap parsers
+// with values chosen to overflow them.
+func hostilePDFs(t testing.TB) [][]byte {
+	page := func(cmap string) []byte {
+		return buildTestPDF(t, "/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+			"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /ToUnicode 6 0 R >>",
+			"FLATE:BT /F1 12 Tf <FFFFFFFF> Tj ET",
+			"FLATE:"+cmap,
+		)
+	}
+	return [][]byte{
+		page("1 beginbfrange <FFFF0000> <FFFFFFFF> <0041> endbfrange"),
+		buildTestPDF(t, "/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+			"<< /Length 9223372036854775807 >>\nstream\nBT (x) Tj ET\nendstream",
+		),
+		buildTestPDF(t, "/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+			"<< /Type /ObjStm /N 1 /First -9223372036854775807 >>\nstream\n5 9223372036854775807 (x)\nendstream",
+		),
+	}
+}
+
+func TestReadPDFTextSurvivesHostileInput(t *testing.T) {
+	for i, data := range hostilePDFs(t) {
+		// An error is fine; a panic or a hang is not.
+		_, _ = readPDFText(data)
+		t.Logf("hostile PDF %d handled", i)
+	}
+}
+
+func FuzzReadPDFText(f *testing.F) {
+	f.Add(samplePDF(f))
+	for _, data := range hostilePDFs(f) {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = readPDFText(data)
+	})
+}
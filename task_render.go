@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type renderOptions struct {
+	inputPath  string
+	to         string
+	outputPath string
+	paper      string
+	overwrite  bool
+}
+
+// jotRender is the direct command entry point for exporting a document to a
+// self-contained HTML page or a PDF.
+func jotRender(w io.Writer, args []string) error {
+	return jotRenderWithInput(w, args, os.Getwd)
+}
+
+func jotRenderWithInput(w io.Writer, args []string, getwd func() (string, error)) error {
+	opts, helpRequested, err := parseRenderArgs(args)
+	if err != nil {
+		return err
+	}
+	if helpRequested {
+		_, writeErr := io.WriteString(w, renderRenderHelp(isTTY(w)))
+		return writeErr
+	}
+	cwd, err := getwd()
+	if err != nil {
+		return err
+	}
+	return executeRender(w, cwd, opts)
+}
+
+func renderRenderHelp(color bool) string {
+	style := helpStyler{color: color}
+	var b strings.Builder
+	writeHelpHeader(&b, style, "jot render", "Export Markdown and structured documents to a standalone HTML page or a PDF.")
+	writeUsageSection(&b, style, []string{
+		"jot render <file> --to html",
+		"jot render <file> --to pdf [--paper a4|letter]",
+		"jot render <file> --output <path> [--overwrite]",
+	}, []string{
+		"Supports Markdown, JSON, YAML, TOML, XML, CSV, text, log, and Jupyter notebook files.",
+		"HTML output is the viewer page with its CSS and logo inlined, so it opens anywhere without jot.",
+		"PDF output is laid out by jot itself using the standard PDF fonts, with headings, lists, tables, and code blocks.",
+		"The output lands next to the input with a .html or .pdf extension unless `--output` is given; `--output -` writes to stdout.",
+	})
+	writeFlagSection(&b, style, []helpFlag{
+		{name: "--to FORMAT", description: "html or pdf. Defaults to the extension of --output, then html."},
+		{name: "--output PATH", description: "Write to PATH instead of next to the input."},
+		{name: "--paper SIZE", description: "PDF page size: a4 (default) or letter."},
+		{name: "--overwrite", description: "Replace an existing output file."},
+	})
+	writeExamplesSection(&b, style, []string{
+		"jot render README.md --to html",
+		"jot render notes.md --to pdf --paper letter",
+		"jot render report.ipynb --output report.pdf",
+	})
+	return b.String()
+}
+
+func parseRenderArgs(args []string) (renderOptions, bool, error) {
+	var opts renderOptions
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if arg == "" {
+			continue
+		}
+		if isHelpFlag(arg) {
+			return opts, true, nil
+		}
+		if arg == "--overwrite" {
+			opts.overwrite = true
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			name, value, hasValue := strings.Cut(arg, "=")
+			switch name {
+			case "--to", "--output", "-o", "--paper":
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, false, fmt.Errorf("missing value for %s", name)
+					}
+					i++
+					value = args[i]
+				}
+				value = strings.TrimSpace(value)
+				switch name {
+				case "--to":
+					opts.to = strings.ToLower(value)
+				case "--paper":
+					opts.paper = strings.ToLower(value)
+				default:
+					opts.outputPath = value
+				}
+			default:
+				return opts, false, fmt.Errorf("unknown flag %q", arg)
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+
+	if len(positional) == 0 {
+		return opts, true, nil
+	}
+	if len(positional) != 1 {
+		return opts, false, errors.New("usage: jot render <file> --to html|pdf [--output path]")
+	}
+	opts.inputPath = positional[0]
+	if opts.to == "" {
+		switch strings.ToLower(filepath.Ext(opts.outputPath)) {
+		case ".pdf":
+			opts.to = "pdf"
+		default:
+			opts.to = "html"
+		}
+	}
+	if opts.to != "html" && opts.to != "pdf" {
+		return opts, false, fmt.Errorf("unsupported --to %q: use html or pdf", opts.to)
+	}
+	if opts.paper == "" {
+		opts.paper = "a4"
+	}
+	if _, ok := pdfPaperSizes[opts.paper]; !ok {
+		return opts, false, fmt.Errorf("unsupported --paper %q: use a4 or letter", opts.paper)
+	}
+	if opts.paper != "a4" && opts.to != "pdf" {
+		return opts, false, errors.New("--paper only applies to --to pdf")
+	}
+	return opts, false, nil
+}
+
+func executeRender(w io.Writer, cwd string, opts renderOptions) error {
+	inputPath := opts.inputPath
+	if !filepath.IsAbs(inputPath) {
+		inputPath = filepath.Join(cwd, inputPath)
+	}
+	doc, err := loadRenderDocument(inputPath)
+	if err != nil {
+		return err
+	}
+
+	if opts.outputPath == "-" {
+		return writeRenderedDocument(w, doc, opts)
+	}
+	outputPath := opts.outputPath
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + "." + opts.to
+	} else if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(cwd, outputPath)
+	}
+	if filepath.Clean(outputPath) == filepath.Clean(inputPath) {
+		return fmt.Errorf("%s: refusing to overwrite the input file", outputPath)
+	}
+	if err := writeRenderFile(outputPath, doc, opts); err != nil {
+		return err
+	}
+	ui := newTermUI(w)
+	_, err = fmt.Fprintln(w, ui.success(fmt.Sprintf("rendered %s to %s", doc.fileName, outputPath)))
+	return err
+}
+
+// writeRenderFile renders through a temporary file in the target folder so
+// a failed render never leaves a truncated export behind.
+func writeRenderFile(path string, doc viewerDocument, opts renderOptions) error {
+	if !opts.overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists; rerun with --overwrite or choose --output", path)
+		}
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".jot-render-*")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	if err := writeRenderedDocument(temp, doc, opts); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	if opts.overwrite {
+		_ = os.Remove(path)
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	return nil
+}
+
+func writeRenderedDocument(w io.Writer, doc viewerDocument, opts renderOptions) error {
+	if opts.to == "pdf" {
+		return writeViewerDocumentPDF(w, doc, pdfPaperSizes[opts.paper])
+	}
+	_, err := io.WriteString(w, renderViewerStandaloneHTML(doc))
+	return err
+}
+
+// loadRenderDocument reads the whole file regardless of size, since an
+// export has no server to stream the rest from.
+func loadRenderDocument(path string) (viewerDocument, error) {
+	switch viewerDocumentTypeForPath(path) {
+	case viewerDocumentTypeMarkdown, viewerDocumentTypeJSON, viewerDocumentTypeYAML, viewerDocumentTypeTOML,
+		viewerDocumentTypeXML, viewerDocumentTypeCSV, viewerDocumentTypeText, viewerDocumentTypeLog, viewerDocumentTypeNotebook:
+	default:
+		return viewerDocument{}, fmt.Errorf("%s: jot render supports Markdown, JSON, YAML, TOML, XML, CSV, text, log, and .ipynb files", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	if info.IsDir() {
+		return viewerDocument{}, fmt.Errorf("%s is a directory, expected a file", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	doc, err := loadViewerDocumentFromBytes(path, content)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	// The log view's filters and the CSV "load more" button call back into
+	// the viewer server, so exports show every line and row up front.
+	doc.logView = nil
+	if doc.csvTable != nil {
+		if table, err := buildCSVTable(doc.content, math.MaxInt); err == nil {
+			doc.csvTable = table
+		}
+	}
+	return doc, nil
+}
+
+// renderViewerStandaloneHTML renders the same page the viewer shows, with
+// the logo inlined and no links back to the viewer server, so it opens
+// anywhere without jot.
+func renderViewerStandaloneHTML(doc viewerDocument) string {
+	if doc.notebook != nil {
+		nb := *doc.notebook
+		nb.exportHref = ""
+		doc.notebook = &nb
+	}
+	logo := "data:image/png;base64," + base64.StdEncoding.EncodeToString(viewerLogoPNG)
+	return renderViewerPage(doc, "", logo)
+}
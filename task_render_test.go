@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRenderMarkdown = "# Release notes\n\nShipped **faster** sync and a `--dry-run` flag.\n\n" +
+	"- fixed crash\n  - on empty input\n- [x] docs\n\n" +
+	"| Name | Status |\n|---|---|\n| sync | done |\n| export | café |\n\n" +
+	"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n# Appendix\n\n> Quoted text.\n"
+
+func TestRenderMarkdownToPDFLaysOutBlocks(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "notes.md"), testRenderMarkdown)
+
+	var out bytes.Buffer
+	if err := jotRenderWithInput(&out, []string{"notes.md", "--to", "pdf", "--paper", "letter"}, func() (string, error) { return dir, nil }); err != nil {
+		t.Fatalf("jotRenderWithInput returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "notes.pdf") {
+		t.Fatalf("expected the output path to be reported, got %q", out.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "notes.pdf"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	doc, err := openPDFDocument(data)
+	if err != nil {
+		t.Fatalf("openPDFDocument returned error: %v", err)
+	}
+	pages, err := doc.pages()
+	if err != nil || len(pages) != 2 {
+		t.Fatalf("expected the second h1 to start page 2, got %d pages, %v", len(pages), err)
+	}
+	if pages[0].mediaBox[2] != 612.0 || pages[0].mediaBox[3] != 792.0 {
+		t.Fatalf("expected a letter page, got %v", pages[0].mediaBox)
+	}
+	text, err := readPDFText(data)
+	if err != nil {
+		t.Fatalf("readPDFText returned error: %v", err)
+	}
+	for _, want := range []string{"Release notes", "Shipped faster sync and a --dry-run flag.", "fixed crash", "[x]", "export", "café", `fmt.Println("hi")`, "1 / 2"} {
+		if !strings.Contains(text[0], want) {
+			t.Fatalf("expected page 1 to contain %q, got:\n%s", want, text[0])
+		}
+	}
+	if !strings.Contains(text[1], "Appendix") || !strings.Contains(text[1], "Quoted text.") {
+		t.Fatalf("unexpected page 2:\n%s", text[1])
+	}
+}
+
+func TestRenderHTMLIsSelfContained(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "notes.md"), testRenderMarkdown)
+	rows := "name,count\n"
+	for i := 0; i < viewerCSVPreviewRows+20; i++ {
+		rows += "row,1\n"
+	}
+	writeTestFile(t, filepath.Join(dir, "data.csv"), rows)
+	getwd := func() (string, error) { return dir, nil }
+
+	var out bytes.Buffer
+	if err := jotRenderWithInput(&out, []string{"notes.md", "--output", "-"}, getwd); err != nil {
+		t.Fatalf("jotRenderWithInput returned error: %v", err)
+	}
+	page := out.String()
+	for _, want := range []string{"<style>", "@media print", "break-before: page", `src="data:image/png;base64,`, `<h1 id="release-notes">`} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected export to contain %q", want)
+		}
+	}
+	if strings.Contains(page, `href="/`) || strings.Contains(page, `src="/`) {
+		t.Fatal("expected no links back to a viewer server")
+	}
+
+	out.Reset()
+	if err := jotRenderWithInput(&out, []string{"data.csv", "-o", "table.html"}, getwd); err != nil {
+		t.Fatalf("jotRenderWithInput returned error: %v", err)
+	}
+	table, err := os.ReadFile(filepath.Join(dir, "table.html"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if got := strings.Count(string(table), "<td>row</td>"); got != viewerCSVPreviewRows+20 {
+		t.Fatalf("expected every CSV row in the export, got %d", got)
+	}
+}
+
+func TestParseRenderArgs(t *testing.T) {
+	opts, help, err := parseRenderArgs([]string{"doc.md", "--output=out/doc.pdf"})
+	if err != nil || help || opts.to != "pdf" || opts.paper != "a4" {
+		t.Fatalf("parseRenderArgs = %+v, %v, %v", opts, help, err)
+	}
+	if _, help, _ := parseRenderArgs(nil); !help {
+		t.Fatal("expected no arguments to show help")
+	}
+	for _, args := range [][]string{
+		{"doc.md", "--to", "docx"},
+		{"doc.md", "--to", "html", "--paper", "letter"},
+		{"doc.md", "--to", "pdf", "--paper", "a3"},
+		{"a.md", "b.md"},
+	} {
+		if _, _, err := parseRenderArgs(args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "photo.png"), "png")
+	err = jotRenderWithInput(&bytes.Buffer{}, []string{"photo.png"}, func() (string, error) { return dir, nil })
+	if err == nil || !strings.Contains(err.Error(), "jot render supports") {
+		t.Fatalf("expected images to be refused, got %v", err)
+	}
+}
+
+func TestWrapPDFRunsBreaksOnSpacesAndLongWords(t *testing.T) {
+	runs := []pdfRun{{text: "alpha beta "}, {text: "gamma", style: pdfFontBold}, {text: " " + strings.Repeat("x", 40)}}
+	lines := wrapPDFRuns(runs, 10, 60)
+	var got []string
+	for _, line := range lines {
+		got = append(got, pdfRunsText(line))
+		if width := pdfTextWidth(pdfRunsText(line), pdfFontBold, 10); width > 60 && len(line) > 1 {
+			t.Fatalf("line %q is wider than the limit", pdfRunsText(line))
+		}
+	}
+	if got[0] != "alpha beta" || got[1] != "gamma" || strings.Join(got[2:], "") != strings.Repeat("x", 40) {
+		t.Fatalf("unexpected wrapping: %q", got)
+	}
+}
+
+func TestRenderWideCSVToPDFSplitsTheTable(t *testing.T) {
+	dir := t.TempDir()
+	var header, row []string
+	for i := 0; i < 120; i++ {
+		header = append(header, fmt.Sprintf("h%d", i))
+		row = append(row, fmt.Sprintf("v%d", i))
+	}
+	writeTestFile(t, filepath.Join(dir, "wide.csv"), strings.Join(header, ",")+"\n"+strings.Join(row, ",")+"\n")
+
+	if err := jotRenderWithInput(&bytes.Buffer{}, []string{"wide.csv", "--to", "pdf"}, func() (string, error) { return dir, nil }); err != nil {
+		t.Fatalf("jotRenderWithInput returned error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "wide.pdf"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	text, err := readPDFText(data)
+	if err != nil {
+		t.Fatalf("readPDFText returned error: %v", err)
+	}
+	all := strings.Join(text, "\n")
+	for _, want := range []string{"h0", "h119", "v119"} {
+		if !strings.Contains(all, want) {
+			t.Fatalf("expected the PDF to contain %q, got:\n%s", want, all)
+		}
+	}
+
+	if lines := wrapPDFRuns([]pdfRun{{text: "abc"}}, 9, -4); len(lines) == 0 {
+		t.Fatal("expected a negative width to still produce a line")
+	}
+}
+
+func TestRenderKeepsAnExistingOutputWithoutOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "notes.md"), testRenderMarkdown)
+	writeTestFile(t, filepath.Join(dir, "notes.html"), "keep me")
+	getwd := func() (string, error) { return dir, nil }
+
+	err := jotRenderWithInput(&bytes.Buffer{}, []string{"notes.md"}, getwd)
+	if err == nil || !strings.Contains(err.Error(), "--overwrite") {
+		t.Fatalf("expected an existing output to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.html")); string(data) != "keep me" {
+		t.Fatalf("expected the existing output untouched, got %q", data)
+	}
+
+	if err := jotRenderWithInput(&bytes.Buffer{}, []string{"notes.md", "--overwrite"}, getwd); err != nil {
+		t.Fatalf("jotRenderWithInput returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "notes.html")); !strings.Contains(string(data), "Release notes") {
+		t.Fatal("expected --overwrite to replace the output")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".jot-render-") {
+			t.Fatalf("expected no temporary file left behind, found %s", entry.Name())
+		}
+	}
+}
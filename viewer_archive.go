@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// viewerArchivePreviewLimit caps how much of a single entry is read into
+// memory for a preview.
+const viewerArchivePreviewLimit = 8 << 20
+
+// archiveViewerEntry is one file in the archive tree. Kind is the viewer
+// document type, or "" when the entry has no preview.
+type archiveViewerEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Kind string `json:"kind"`
+	name string
+}
+
+func archiveViewerSession(archivePath string) (viewerSession, error) {
+	entries, err := listArchiveViewerEntries(archivePath)
+	if err != nil {
+		return viewerSession{}, err
+	}
+	if len(entries) == 0 {
+		return viewerSession{}, fmt.Errorf("%s has no files to browse", archivePath)
+	}
+	return viewerSession{
+		title: filepath.Base(archivePath),
+		newHandler: func(touch func()) http.Handler {
+			return newArchiveViewerHandler(archivePath, entries, touch)
+		},
+	}, nil
+}
+
+func listArchiveViewerEntries(archivePath string) ([]archiveViewerEntry, error) {
+	entries, err := listArchiveEntries(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	var files []archiveViewerEntry
+	for _, entry := range entries {
+		if entry.isDir || entry.isLink {
+			continue
+		}
+		display := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(entry.name, `\`, "/")), "/")
+		files = append(files, archiveViewerEntry{
+			Path: display,
+			Size: entry.size,
+			Kind: string(viewerDocumentTypeForPath(display)),
+			name: entry.name,
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func newArchiveViewerHandler(archivePath string, entries []archiveViewerEntry, touch func()) http.Handler {
+	const logoPath = "/logo.png"
+	mux := http.NewServeMux()
+	entryFor := func(r *http.Request) (archiveViewerEntry, bool) {
+		idx, err := strconv.Atoi(r.URL.Query().Get("i"))
+		if err != nil || idx < 0 || idx >= len(entries) {
+			return archiveViewerEntry{}, false
+		}
+		return entries[idx], true
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, renderArchivePage(filepath.Base(archivePath), entries, logoPath))
+	})
+
+	mux.HandleFunc(logoPath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		_, _ = w.Write(viewerLogoPNG)
+	})
+
+	// Rendered preview of an entry, decoded from memory.
+	mux.HandleFunc("/entry", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		entry, ok := entryFor(r)
+		if !ok {
+			http.Error(w, "invalid index", http.StatusBadRequest)
+			return
+		}
+		doc := viewerDocument{fileName: path.Base(entry.Path), size: entry.Size}
+		if entry.Kind != "" && entry.Kind != string(viewerDocumentTypePDF) {
+			data, err := readArchiveEntry(archivePath, entry.name, viewerArchivePreviewLimit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if doc, err = loadViewerDocumentFromBytes(entry.Path, data); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if doc.image != nil {
+				doc.image.src = "/raw?i=" + r.URL.Query().Get("i")
+			}
+			if doc.notebook != nil {
+				doc.notebook.exportHref = ""
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, renderFolderDocumentContent(doc))
+	})
+
+	// Raw bytes, only for images and PDFs, which the browser renders itself.
+	mux.HandleFunc("/raw", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		entry, ok := entryFor(r)
+		if !ok {
+			http.Error(w, "invalid index", http.StatusBadRequest)
+			return
+		}
+		if entry.Kind != string(viewerDocumentTypeImage) && entry.Kind != string(viewerDocumentTypePDF) {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := readArchiveEntry(archivePath, entry.name, viewerArchivePreviewLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entry.Kind == string(viewerDocumentTypeImage) {
+			serveViewerImage(w, r, entry.Path, bytes.NewReader(data))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", path.Base(entry.Path)))
+		_, _ = w.Write(data)
+	})
+
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func renderArchivePage(archiveName string, entries []archiveViewerEntry, logoPath string) string {
+	entriesJSON, _ := json.Marshal(entries)
+	safeName := template.HTMLEscapeString(archiveName)
+	safeLogoPath := template.HTMLEscapeString(logoPath)
+	return fmt.Sprintf(`<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>jot · %s</title>
+  <link rel="icon" type="image/png" href="%s">
+  <style>%s</style>
+</head>
+<body>
+  <header>
+    <div class="brand">
+      <img class="brand-mark" src="%s" alt="jot">
+      <span class="brand-name">jot</span>
+      <div class="brand-sep"></div>
+      <span class="dir-name">%s</span>
+    </div>
+    <span class="file-count" id="fileCount"></span>
+  </header>
+  <div class="layout">
+    <div class="sidebar">
+      <div class="sidebar-header">Archive</div>
+      <div class="sidebar-list" id="tree"></div>
+    </div>
+    <div class="content-area" id="contentArea"></div>
+  </div>
+<script>var archiveEntries = %s;</script>
+<script>%s</script>
+</body>
+</html>
+`, safeName, safeLogoPath, viewerArchiveStyles, safeLogoPath, safeName, strings.ReplaceAll(string(entriesJSON), "</", `<\/`), viewerArchiveScript)
+}
+
+const viewerArchiveStyles = `
+*, *::before, *::after { box-sizing: border-box; margin: 0; padding: 0; }
+:root {
+  font-family: -apple-system, BlinkMacSystemFont, "Inter", "Segoe UI", sans-serif;
+  -webkit-font-smoothing: antialiased;
+  background: #f7f6f3; color: #1a1a18; font-size: 14px;
+}
+body { min-height: 100vh; display: grid; grid-template-rows: 48px 1fr; }
+header {
+  display: flex; align-items: center; justify-content: space-between;
+  padding: 0 14px; height: 48px;
+  background: rgba(252,251,249,0.92);
+  border-bottom: 0.5px solid rgba(0,0,0,0.08);
+}
+.brand { display: flex; align-items: center; gap: 10px; min-width: 0; }
+.brand-mark { width: 26px; height: 26px; border-radius: 7px; object-fit: cover; flex: none; }
+.brand-name { font-size: 11px; font-weight: 500; letter-spacing: 0.05em; text-transform: uppercase; color: rgba(26,26,24,0.38); }
+.brand-sep { width: 0.5px; height: 14px; background: rgba(0,0,0,0.12); }
+.dir-name { font-size: 13px; font-weight: 500; }
+.file-count { font-size: 11px; font-weight: 500; padding: 2px 8px; border-radius: 5px; background: rgba(26,26,24,0.06); color: rgba(26,26,24,0.45); }
+.layout { display: grid; grid-template-columns: 260px 1fr; height: calc(100vh - 48px); overflow: hidden; }
+.sidebar { border-right: 0.5px solid rgba(0,0,0,0.08); background: rgba(250,249,246,0.97); display: flex; flex-direction: column; overflow: hidden; }
+.sidebar-header {
+  padding: 10px 14px 8px; border-bottom: 0.5px solid rgba(0,0,0,0.06);
+  font-size: 10px; font-weight: 600; letter-spacing: 0.08em; text-transform: uppercase;
+  color: rgba(26,26,24,0.35);
+}
+.sidebar-list { flex: 1; overflow-y: auto; padding: 6px 0; }
+.tree-row {
+  display: flex; align-items: center; gap: 7px; padding: 5px 14px;
+  cursor: pointer; border-left: 2px solid transparent; min-width: 0;
+  font-size: 12.5px; color: rgba(26,26,24,0.7);
+}
+.tree-row:hover { background: rgba(26,26,24,0.05); }
+.tree-row.active { background: rgba(26,26,24,0.06); border-left-color: #1a1a18; color: #1a1a18; font-weight: 500; }
+.tree-row.unsupported { color: rgba(26,26,24,0.38); }
+.tree-toggle { width: 10px; flex: none; font-size: 9px; color: rgba(26,26,24,0.4); }
+.tree-name { white-space: nowrap; overflow: hidden; text-overflow: ellipsis; flex: 1; }
+.tree-size { font-size: 10.5px; color: rgba(26,26,24,0.35); flex: none; }
+.item-icon {
+  font-size: 9.5px; font-weight: 600; padding: 1px 4px; border-radius: 4px; flex: none;
+  font-family: "SF Mono", Consolas, monospace; background: rgba(26,26,24,0.06);
+}
+.tree-children.collapsed { display: none; }
+.content-area { overflow: hidden; }
+.content-area iframe { width: 100%; height: 100%; border: none; display: block; background: #f7f6f3; }
+`
+
+const viewerArchiveScript = `
+(function() {
+  var tree = document.getElementById('tree');
+  var area = document.getElementById('contentArea');
+  var count = archiveEntries.length;
+  document.getElementById('fileCount').textContent = count + ' file' + (count !== 1 ? 's' : '');
+  var labels = {markdown:'md', json:'json', xml:'xml', yaml:'yaml', toml:'toml', csv:'csv', env:'env', text:'txt', log:'log', pdf:'pdf', image:'img', notebook:'nb', sqlite:'db'};
+  var active = null;
+
+  function esc(s) { return s.replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/>/g,'&gt;'); }
+  function size(n) {
+    if (n < 1024) return n + ' B';
+    var units = ['KB','MB','GB'], i = -1;
+    do { n /= 1024; i++; } while (n >= 1024 && i < units.length - 1);
+    return n.toFixed(1) + ' ' + units[i];
+  }
+
+  var root = {dirs: {}, files: []};
+  archiveEntries.forEach(function(entry, index) {
+    var parts = entry.path.split('/');
+    var node = root;
+    for (var i = 0; i < parts.length - 1; i++) {
+      node = node.dirs[parts[i]] || (node.dirs[parts[i]] = {dirs: {}, files: []});
+    }
+    node.files.push({name: parts[parts.length - 1], entry: entry, index: index});
+  });
+
+  function render(node, container, depth) {
+    Object.keys(node.dirs).sort().forEach(function(name) {
+      var row = document.createElement('div');
+      row.className = 'tree-row';
+      row.style.paddingLeft = (14 + depth * 14) + 'px';
+      var open = depth < 1;
+      row.innerHTML = '<span class="tree-toggle">' + (open ? '▾' : '▸') + '</span><span class="tree-name">' + esc(name) + '/</span>';
+      var children = document.createElement('div');
+      children.className = 'tree-children' + (open ? '' : ' collapsed');
+      row.addEventListener('click', function() {
+        var collapsed = children.classList.toggle('collapsed');
+        row.firstChild.textContent = collapsed ? '▸' : '▾';
+      });
+      container.appendChild(row);
+      container.appendChild(children);
+      render(node.dirs[name], children, depth + 1);
+    });
+    node.files.forEach(function(file) {
+      var row = document.createElement('div');
+      row.className = 'tree-row' + (file.entry.kind ? '' : ' unsupported');
+      row.style.paddingLeft = (14 + depth * 14) + 'px';
+      row.innerHTML = '<span class="tree-toggle"></span>' +
+        '<span class="item-icon">' + (labels[file.entry.kind] || '—') + '</span>' +
+        '<span class="tree-name">' + esc(file.name) + '</span>' +
+        '<span class="tree-size">' + size(file.entry.size) + '</span>';
+      row.addEventListener('click', function() { load(file, row); });
+      container.appendChild(row);
+      if (!active && file.entry.kind) load(file, row);
+    });
+  }
+
+  function load(file, row) {
+    if (active) active.classList.remove('active');
+    active = row;
+    row.classList.add('active');
+    var kind = file.entry.kind;
+    var src = (kind === 'pdf' ? '/raw?i=' : '/entry?i=') + file.index;
+    area.innerHTML = '<iframe title="' + esc(file.name) + '" src="' + src + '"></iframe>';
+  }
+
+  render(root, tree, 0);
+})();
+`
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestArchiveViewerListsEntriesAndPreviewsInPlace(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "bundle.zip")
+	writeTestZip(t, archivePath,
+		testArchiveFile{name: "docs/guide.md", body: "# Guide\n\nHello **archive**.\n"},
+		testArchiveFile{name: "logo.png", body: "\x89PNG\r\n\x1a\n"},
+		testArchiveFile{name: "blob.bin", body: "\x00\x01"},
+	)
+	entries, err := listArchiveViewerEntries(archivePath)
+	if err != nil {
+		t.Fatalf("listArchiveViewerEntries returned error: %v", err)
+	}
+	index := map[string]int{}
+	for i, entry := range entries {
+		index[entry.Path] = i
+	}
+
+	server := httptest.NewServer(newArchiveViewerHandler(archivePath, entries, func() {}))
+	defer server.Close()
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, page := get("/")
+	for _, want := range []string{"bundle.zip", `"docs/guide.md"`, `"kind":"image"`} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected tree page to contain %q", want)
+		}
+	}
+
+	_, guide := get("/entry?i=" + strconv.Itoa(index["docs/guide.md"]))
+	if !strings.Contains(guide, "<strong>archive</strong>") {
+		t.Fatalf("expected markdown entry to render as HTML, got %q", guide)
+	}
+
+	resp, _ := get("/raw?i=" + strconv.Itoa(index["logo.png"]))
+	if resp.Header.Get("Content-Type") != "image/png" || resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("unexpected image headers: %v", resp.Header)
+	}
+
+	resp, _ = get("/raw?i=" + strconv.Itoa(index["docs/guide.md"]))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected raw markdown to be refused, got %d", resp.StatusCode)
+	}
+
+	_, blob := get("/entry?i=" + strconv.Itoa(index["blob.bin"]))
+	if !strings.Contains(blob, "blob.bin") {
+		t.Fatalf("expected unsupported entry page to name the file, got %q", blob)
+	}
+}
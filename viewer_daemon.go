@@ -0,0 +1,788 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The viewer daemon is one long-lived `jot __viewer --daemon` process per
+// user. `jot open` hands it the same arguments it would pass to a
+// `jot __viewer` child, one JSON line per request on a local socket, and the
+// daemon shows each document as a tab of a single window. Every tab is an
+// ordinary viewer session on a port of its own, so the existing handlers
+// serve it unchanged; the window frames them.
+
+const (
+	viewerDaemonIdleTimeout = 15 * time.Minute
+	// viewerDaemonWindowTimeout is how long the window may go without
+	// polling before the daemon assumes it was closed.
+	viewerDaemonWindowTimeout = 5 * time.Second
+	// viewerDaemonWindowGrace covers the time a freshly opened window takes
+	// to load and start polling.
+	viewerDaemonWindowGrace = 15 * time.Second
+	viewerDaemonTabsPath    = "/tabs"
+	viewerDaemonClosePath   = "/close"
+)
+
+// viewerDaemonShellPolicy lets the window frame the tabs, which are served
+// from other loopback ports.
+var viewerDaemonShellPolicy = strings.Replace(viewerContentSecurityPolicy, "frame-src 'self'", "frame-src 'self' http://127.0.0.1:*", 1)
+
+// viewerDaemonSocketPath locates the control socket; tests point it at a
+// temporary folder.
+var viewerDaemonSocketPath = defaultViewerDaemonSocketPath
+
+// errViewerDaemonUnavailable means no daemon answered on the socket.
+var errViewerDaemonUnavailable = errors.New("viewer daemon is not running")
+
+func defaultViewerDaemonSocketPath() (string, error) {
+	dir, err := assistantConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "viewer", "viewer.sock"), nil
+}
+
+// viewerDaemonRequest is one line on the control socket. Op is "open",
+// "list", or "close"; Args are `jot __viewer` arguments and Dir is the
+// caller's working directory.
+type viewerDaemonRequest struct {
+	Op   string   `json:"op"`
+	Args []string `json:"args,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+	ID   int      `json:"id,omitempty"`
+	All  bool     `json:"all,omitempty"`
+}
+
+// viewerDaemonResponse answers a request. Window is set when the caller
+// should open the viewer window because none is showing.
+type viewerDaemonResponse struct {
+	Tabs   []viewerDaemonTab `json:"tabs,omitempty"`
+	Window string            `json:"window,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+type viewerDaemonTab struct {
+	ID     int       `json:"id"`
+	Title  string    `json:"title"`
+	Target string    `json:"target"`
+	URL    string    `json:"url"`
+	Opened time.Time `json:"opened"`
+	key    string
+	server *http.Server
+}
+
+type viewerDaemon struct {
+	mu           sync.Mutex
+	now          func() time.Time
+	shellOrigin  string
+	windowURL    string
+	tabs         []*viewerDaemonTab
+	nextID       int
+	focus        int
+	rev          int
+	lastAccess   time.Time
+	windowSeen   time.Time
+	windowOpened time.Time
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+// viewerDaemonErrorLog keeps net/http from writing to stderr, which is a
+// pipe the launching process stops reading once the daemon is up.
+var viewerDaemonErrorLog = log.New(io.Discard, "", 0)
+
+func viewerServeArgsRequestDaemon(args []string) bool {
+	for _, arg := range args {
+		if arg == "--daemon" {
+			return true
+		}
+	}
+	return false
+}
+
+// jotServeViewerDaemon handles `__viewer [--no-self-open] --daemon`. It prints
+// the window URL once the socket is listening, like every `__viewer` child,
+// and exits after viewerDaemonIdleTimeout without requests.
+func jotServeViewerDaemon(w io.Writer, args []string, now func() time.Time) error {
+	selfOpen := true
+	for _, arg := range args {
+		switch arg {
+		case "--daemon":
+		case "--no-self-open":
+			selfOpen = false
+		default:
+			return errors.New("usage: jot __viewer --daemon")
+		}
+	}
+	socketPath, err := viewerDaemonSocketPath()
+	if err != nil {
+		return err
+	}
+	control, err := listenViewerDaemonSocket(socketPath)
+	if err != nil {
+		return err
+	}
+	defer control.Close()
+
+	daemon, shell, err := startViewerDaemon(now)
+	if err != nil {
+		return err
+	}
+	defer daemon.closeAll()
+	defer shell.Close()
+
+	if _, err := fmt.Fprintln(w, daemon.windowURL); err != nil {
+		return err
+	}
+	if file, ok := w.(*os.File); ok {
+		_ = file.Sync()
+	}
+	if selfOpen {
+		daemon.mu.Lock()
+		daemon.windowOpened = now()
+		daemon.mu.Unlock()
+		_ = openURLInViewerWindow(daemon.windowURL)
+	}
+	// The daemon outlives the terminal that started it.
+	signal.Ignore(syscall.SIGHUP)
+
+	go daemon.acceptControl(control)
+	return daemon.run(viewerDaemonIdleTimeout)
+}
+
+// listenViewerDaemonSocket listens on path, replacing a socket left behind by
+// a daemon that did not exit cleanly.
+func listenViewerDaemonSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, errors.New("a viewer daemon is already running")
+	}
+	_ = os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Chmod(path, 0o600)
+	return listener, nil
+}
+
+// startViewerDaemon starts the window server and returns the daemon that
+// owns it.
+func startViewerDaemon(now func() time.Time) (*viewerDaemon, *http.Server, error) {
+	token, err := newViewerSessionToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	daemon := &viewerDaemon{
+		now:         now,
+		shellOrigin: fmt.Sprintf("http://127.0.0.1:%d", port),
+		windowURL:   fmt.Sprintf("http://127.0.0.1:%d/?token=%s", port, token),
+		lastAccess:  now(),
+		done:        make(chan struct{}),
+	}
+	server := &http.Server{
+		Handler:  newViewerSessionGuard(newViewerDaemonShellHandler(daemon), token),
+		ErrorLog: viewerDaemonErrorLog,
+	}
+	go func() { _ = server.Serve(listener) }()
+	return daemon, server, nil
+}
+
+func (d *viewerDaemon) touch() {
+	d.mu.Lock()
+	d.lastAccess = d.now()
+	d.mu.Unlock()
+}
+
+// run waits until every tab is closed with `jot open --close all` or no
+// request arrives for idleTimeout.
+func (d *viewerDaemon) run(idleTimeout time.Duration) error {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return nil
+		case <-ticker.C:
+			d.mu.Lock()
+			idle := d.now().Sub(d.lastAccess)
+			d.mu.Unlock()
+			if idle >= idleTimeout {
+				return nil
+			}
+		}
+	}
+}
+
+func (d *viewerDaemon) stop() {
+	d.stopOnce.Do(func() { close(d.done) })
+}
+
+func (d *viewerDaemon) acceptControl(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.serveControl(conn)
+	}
+}
+
+func (d *viewerDaemon) serveControl(conn net.Conn) {
+	defer conn.Close()
+	var req viewerDaemonRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+	d.touch()
+	var resp viewerDaemonResponse
+	switch req.Op {
+	case "open":
+		tab, window, err := d.open(req.Args, req.Dir)
+		if err != nil {
+			resp.Error = err.Error()
+			break
+		}
+		resp.Tabs = []viewerDaemonTab{tab}
+		resp.Window = window
+	case "list":
+		resp.Tabs = d.list()
+	case "close":
+		if req.All {
+			resp.Tabs = d.closeAll()
+			d.stop()
+			break
+		}
+		tab, ok := d.close(req.ID)
+		if !ok {
+			resp.Error = fmt.Sprintf("no viewer session %d; run `jot open --list-sessions` to see open sessions", req.ID)
+			break
+		}
+		resp.Tabs = []viewerDaemonTab{tab}
+	default:
+		resp.Error = fmt.Sprintf("unknown viewer daemon request %q", req.Op)
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// open serves args as a tab. Opening a document that already has a tab
+// reloads that tab instead of adding another.
+func (d *viewerDaemon) open(args []string, cwd string) (viewerDaemonTab, string, error) {
+	var rest []string
+	for _, arg := range args {
+		if arg != "--no-self-open" {
+			rest = append(rest, arg)
+		}
+	}
+	session, _, err := viewerSessionForArgs(rest, cwd)
+	if err != nil {
+		return viewerDaemonTab{}, "", err
+	}
+	token, err := newViewerSessionToken()
+	if err != nil {
+		return viewerDaemonTab{}, "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return viewerDaemonTab{}, "", err
+	}
+	server := &http.Server{
+		Handler:  newFramedViewerSessionGuard(session.newHandler(d.touch), token, d.shellOrigin),
+		ErrorLog: viewerDaemonErrorLog,
+	}
+	go func() { _ = server.Serve(listener) }()
+	url := fmt.Sprintf("http://127.0.0.1:%d/?token=%s", listener.Addr().(*net.TCPAddr).Port, token)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.now()
+	key := strings.Join(rest, "\x00")
+	var tab *viewerDaemonTab
+	for _, existing := range d.tabs {
+		if existing.key == key {
+			tab = existing
+			_ = tab.server.Close()
+			break
+		}
+	}
+	if tab == nil {
+		d.nextID++
+		tab = &viewerDaemonTab{ID: d.nextID, key: key}
+		d.tabs = append(d.tabs, tab)
+	}
+	tab.Title = session.title
+	tab.Target = strings.Join(rest, " ")
+	tab.URL = url
+	tab.Opened = current
+	tab.server = server
+	d.focus = tab.ID
+	d.rev++
+
+	window := ""
+	if current.Sub(d.windowSeen) > viewerDaemonWindowTimeout && current.Sub(d.windowOpened) > viewerDaemonWindowGrace {
+		d.windowOpened = current
+		window = d.windowURL
+	}
+	return *tab, window, nil
+}
+
+func (d *viewerDaemon) list() []viewerDaemonTab {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tabs := make([]viewerDaemonTab, 0, len(d.tabs))
+	for _, tab := range d.tabs {
+		tabs = append(tabs, *tab)
+	}
+	return tabs
+}
+
+func (d *viewerDaemon) close(id int) (viewerDaemonTab, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, tab := range d.tabs {
+		if tab.ID != id {
+			continue
+		}
+		_ = tab.server.Close()
+		d.tabs = append(d.tabs[:i], d.tabs[i+1:]...)
+		d.rev++
+		return *tab, true
+	}
+	return viewerDaemonTab{}, false
+}
+
+func (d *viewerDaemon) closeAll() []viewerDaemonTab {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	closed := make([]viewerDaemonTab, 0, len(d.tabs))
+	for _, tab := range d.tabs {
+		_ = tab.server.Close()
+		closed = append(closed, *tab)
+	}
+	d.tabs = nil
+	d.rev++
+	return closed
+}
+
+// viewerDaemonState is what the window polls: the tabs in order and the one
+// most recently opened, which the window brings to the front.
+type viewerDaemonState struct {
+	Rev   int               `json:"rev"`
+	Focus int               `json:"focus"`
+	Tabs  []viewerDaemonTab `json:"tabs"`
+}
+
+func newViewerDaemonShellHandler(d *viewerDaemon) http.Handler {
+	const logoPath = "/logo.png"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		d.touch()
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Security-Policy", viewerDaemonShellPolicy)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = io.WriteString(w, renderViewerDaemonPage(logoPath))
+	})
+	mux.HandleFunc(viewerDaemonTabsPath, func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		d.lastAccess = d.now()
+		d.windowSeen = d.lastAccess
+		state := viewerDaemonState{Rev: d.rev, Focus: d.focus, Tabs: []viewerDaemonTab{}}
+		for _, tab := range d.tabs {
+			state.Tabs = append(state.Tabs, *tab)
+		}
+		d.mu.Unlock()
+		writeViewerJSON(w, state)
+	})
+	mux.HandleFunc(viewerDaemonClosePath, func(w http.ResponseWriter, r *http.Request) {
+		d.touch()
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			ID int `json:"id"`
+		}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<10)).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		if _, ok := d.close(req.ID); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeViewerJSON(w, map[string]bool{"closed": true})
+	})
+	mux.HandleFunc(logoPath, func(w http.ResponseWriter, r *http.Request) {
+		d.touch()
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		_, _ = w.Write(viewerLogoPNG)
+	})
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func renderViewerDaemonPage(logoPath string) string {
+	var b strings.Builder
+	safeLogoPath := template.HTMLEscapeString(logoPath)
+	b.WriteString(`<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>jot viewer</title>
+  <link rel="icon" type="image/png" href="` + safeLogoPath + `">
+  <style>` + viewerDaemonStyles + `</style>
+</head>
+<body>
+  <header>
+    <img class="brand-mark" src="` + safeLogoPath + `" alt="jot">
+    <div class="viewer-tabs" id="viewer-tabs" role="tablist"></div>
+  </header>
+  <div class="viewer-offline" id="viewer-offline" hidden>The viewer has stopped. Run <code>jot open</code> to start it again.</div>
+  <main class="viewer-frames" id="viewer-frames">
+    <div class="viewer-empty" id="viewer-empty" hidden>No documents are open. Run <code>jot open &lt;file&gt;</code> to add one.</div>
+  </main>
+  <script>` + viewerDaemonScript + `</script>
+</body>
+</html>
+`)
+	return b.String()
+}
+
+const viewerDaemonStyles = `
+    *, *::before, *::after { box-sizing: border-box; margin: 0; padding: 0; }
+    :root {
+      font-family: -apple-system, BlinkMacSystemFont, "Inter", "Segoe UI", sans-serif;
+      -webkit-font-smoothing: antialiased;
+      background: #f7f6f3;
+      color: #1a1a18;
+      font-size: 14px;
+    }
+    body { height: 100vh; display: grid; grid-template-rows: 40px auto 1fr; overflow: hidden; }
+    header {
+      display: flex; align-items: flex-end; gap: 10px;
+      padding: 0 10px; height: 40px;
+      background: rgba(252,251,249,0.92);
+      border-bottom: 0.5px solid rgba(0,0,0,0.08);
+    }
+    .brand-mark { width: 22px; height: 22px; border-radius: 6px; object-fit: cover; flex: none; align-self: center; }
+    .viewer-tabs { display: flex; align-items: flex-end; gap: 2px; flex: 1; min-width: 0; height: 100%; overflow-x: auto; scrollbar-width: none; }
+    .viewer-tab {
+      display: flex; align-items: center; gap: 6px;
+      max-width: 220px; height: 32px; padding: 0 6px 0 12px;
+      border: 0.5px solid transparent; border-bottom: 0;
+      border-radius: 8px 8px 0 0;
+      font-size: 12.5px; color: rgba(26,26,24,0.6);
+      cursor: pointer; user-select: none; flex: none;
+    }
+    .viewer-tab:hover { background: rgba(26,26,24,0.05); }
+    .viewer-tab.active { background: #f7f6f3; color: #1a1a18; font-weight: 500; border-color: rgba(0,0,0,0.08); margin-bottom: -0.5px; }
+    .viewer-tab-label { white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+    .viewer-tab-close {
+      width: 18px; height: 18px; border: 0; border-radius: 4px;
+      background: none; color: rgba(26,26,24,0.4);
+      font: inherit; font-size: 14px; line-height: 1; cursor: pointer; flex: none;
+    }
+    .viewer-tab-close:hover { background: rgba(26,26,24,0.08); color: #1a1a18; }
+    .viewer-offline {
+      padding: 8px 14px; font-size: 12.5px;
+      background: rgba(184,92,26,0.08); color: #b85c1a;
+      border-bottom: 0.5px solid rgba(184,92,26,0.2);
+    }
+    .viewer-frames { position: relative; min-height: 0; }
+    .viewer-frame { position: absolute; inset: 0; width: 100%; height: 100%; border: 0; display: block; background: #f7f6f3; }
+    .viewer-frame[hidden], .viewer-empty[hidden], .viewer-offline[hidden] { display: none; }
+    .viewer-empty {
+      height: 100%; display: flex; align-items: center; justify-content: center;
+      font-size: 13px; color: rgba(26,26,24,0.45);
+    }
+    code { font-family: "SF Mono", Consolas, monospace; font-size: 12px; }
+`
+
+const viewerDaemonScript = `
+(function() {
+  var strip = document.getElementById('viewer-tabs');
+  var frames = document.getElementById('viewer-frames');
+  var empty = document.getElementById('viewer-empty');
+  var offline = document.getElementById('viewer-offline');
+  var views = {};
+  var active = 0, focus = 0, rev = -1;
+
+  function activate(id) {
+    active = id;
+    Object.keys(views).forEach(function(key) {
+      var view = views[key];
+      var on = view.id === id;
+      view.tab.classList.toggle('active', on);
+      view.tab.setAttribute('aria-selected', on ? 'true' : 'false');
+      view.frame.hidden = !on;
+      if (on) document.title = view.title + ' · jot';
+    });
+  }
+  function closeTab(id) {
+    fetch('` + viewerDaemonClosePath + `', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ id: id })
+    }).then(poll, poll);
+  }
+  function addView(tab) {
+    var button = document.createElement('div');
+    button.className = 'viewer-tab';
+    button.setAttribute('role', 'tab');
+    var label = document.createElement('span');
+    label.className = 'viewer-tab-label';
+    var close = document.createElement('button');
+    close.type = 'button';
+    close.className = 'viewer-tab-close';
+    close.title = 'Close tab';
+    close.textContent = '×';
+    button.appendChild(label);
+    button.appendChild(close);
+    button.addEventListener('click', function() { activate(tab.id); });
+    button.addEventListener('auxclick', function(e) { if (e.button === 1) closeTab(tab.id); });
+    close.addEventListener('click', function(e) { e.stopPropagation(); closeTab(tab.id); });
+    var frame = document.createElement('iframe');
+    frame.className = 'viewer-frame';
+    frame.hidden = true;
+    frame.src = tab.url;
+    frames.appendChild(frame);
+    views[tab.id] = { id: tab.id, tab: button, label: label, frame: frame, url: tab.url, title: tab.title };
+  }
+  function apply(state) {
+    var seen = {};
+    state.tabs.forEach(function(tab) {
+      seen[tab.id] = true;
+      var view = views[tab.id];
+      if (!view) {
+        addView(tab);
+        view = views[tab.id];
+      } else if (view.url !== tab.url) {
+        view.url = tab.url;
+        view.frame.src = tab.url;
+      }
+      view.title = tab.title;
+      view.label.textContent = tab.title;
+      view.tab.title = tab.target;
+      strip.appendChild(view.tab);
+    });
+    Object.keys(views).forEach(function(key) {
+      if (seen[key]) return;
+      views[key].tab.remove();
+      views[key].frame.remove();
+      delete views[key];
+    });
+    if (state.focus !== focus && views[state.focus]) {
+      focus = state.focus;
+      activate(focus);
+      views[focus].tab.scrollIntoView({ inline: 'nearest' });
+      window.focus();
+    }
+    if (!views[active]) {
+      var ids = Object.keys(views);
+      activate(ids.length ? views[ids[ids.length - 1]].id : 0);
+    }
+    empty.hidden = state.tabs.length > 0;
+    if (!state.tabs.length) document.title = 'jot viewer';
+  }
+  function poll() {
+    return fetch('` + viewerDaemonTabsPath + `', { cache: 'no-store' }).then(function(r) {
+      if (!r.ok) throw new Error(r.statusText);
+      return r.json();
+    }).then(function(state) {
+      offline.hidden = true;
+      if (state.rev === rev) return;
+      rev = state.rev;
+      apply(state);
+    }).catch(function() {
+      offline.hidden = false;
+    });
+  }
+  poll();
+  setInterval(poll, 1000);
+})();
+`
+
+// callViewerDaemon sends one request to the running daemon. Errors that mean
+// no daemon answered wrap errViewerDaemonUnavailable.
+func callViewerDaemon(req viewerDaemonRequest) (viewerDaemonResponse, error) {
+	socketPath, err := viewerDaemonSocketPath()
+	if err != nil {
+		return viewerDaemonResponse{}, err
+	}
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return viewerDaemonResponse{}, fmt.Errorf("%w: %v", errViewerDaemonUnavailable, err)
+	}
+	defer conn.Close()
+	// Loading a large document can take a while; a stuck daemon should not
+	// hang the terminal forever.
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return viewerDaemonResponse{}, fmt.Errorf("%w: %v", errViewerDaemonUnavailable, err)
+	}
+	var resp viewerDaemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return viewerDaemonResponse{}, fmt.Errorf("%w: %v", errViewerDaemonUnavailable, err)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// launchViewerArgs shows a `jot __viewer` session in a tab of the viewer
+// daemon, starting the daemon when none is running. When no daemon can be
+// reached it falls back to a viewer process of its own.
+func launchViewerArgs(args ...string) error {
+	if handled, err := openInViewerDaemon(args, openURLInViewerWindow, spawnViewerDaemon); handled {
+		return err
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return err
+	}
+	viewerURL, err := startViewerProcessWithArgs(exePath, append([]string{"--no-self-open"}, args...)...)
+	if err != nil {
+		return err
+	}
+	return openURLInViewerWindow(viewerURL)
+}
+
+// openInViewerDaemon reports false when no daemon answered even after spawn
+// tried to start one.
+func openInViewerDaemon(args []string, openURL func(string) error, spawn func() error) (bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	req := viewerDaemonRequest{Op: "open", Args: args, Dir: cwd}
+	resp, err := callViewerDaemon(req)
+	if errors.Is(err, errViewerDaemonUnavailable) {
+		// Another `jot open` may have started the daemon meanwhile, so the
+		// request is retried even when spawning fails.
+		_ = spawn()
+		resp, err = callViewerDaemon(req)
+	}
+	if errors.Is(err, errViewerDaemonUnavailable) {
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	if resp.Window != "" {
+		return true, openURL(resp.Window)
+	}
+	return true, nil
+}
+
+// spawnViewerDaemon starts `jot __viewer --daemon` and returns once its socket
+// is listening.
+func spawnViewerDaemon() error {
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exePath, err = filepath.Abs(exePath)
+	if err != nil {
+		return err
+	}
+	_, err = startViewerProcessWithArgs(exePath, "--no-self-open", "--daemon")
+	return err
+}
+
+// jotOpenListSessions prints the tabs of the running viewer daemon.
+func jotOpenListSessions(w io.Writer, now func() time.Time) error {
+	ui := newTermUI(w)
+	resp, err := callViewerDaemon(viewerDaemonRequest{Op: "list"})
+	if err != nil && !errors.Is(err, errViewerDaemonUnavailable) {
+		return err
+	}
+	if len(resp.Tabs) == 0 {
+		_, err := fmt.Fprintln(w, ui.tip("No viewer sessions are open."))
+		return err
+	}
+	sort.Slice(resp.Tabs, func(i, j int) bool { return resp.Tabs[i].ID < resp.Tabs[j].ID })
+	if _, err := fmt.Fprint(w, ui.header("Viewer sessions")); err != nil {
+		return err
+	}
+	for _, tab := range resp.Tabs {
+		opened := "opened " + humanDuration(now().Sub(tab.Opened)) + " ago"
+		if _, err := fmt.Fprintln(w, ui.listItem(tab.ID, tab.Title, tab.Target, opened)); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w, "\n"+ui.tip("Close one with `jot open --close <id>`, or all with `jot open --close all`."))
+	return err
+}
+
+// jotOpenClose closes one viewer daemon tab by id, or every tab with "all",
+// which also stops the daemon.
+func jotOpenClose(w io.Writer, target string) error {
+	ui := newTermUI(w)
+	req := viewerDaemonRequest{Op: "close"}
+	if strings.EqualFold(target, "all") {
+		req.All = true
+	} else {
+		id, err := strconv.Atoi(target)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("--close takes a session id from `jot open --list-sessions` or all, got %q", target)
+		}
+		req.ID = id
+	}
+	resp, err := callViewerDaemon(req)
+	if errors.Is(err, errViewerDaemonUnavailable) {
+		if req.All {
+			_, err := fmt.Fprintln(w, ui.tip("No viewer sessions are open."))
+			return err
+		}
+		return errors.New("no viewer sessions are open")
+	}
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Closed %d viewer sessions", len(resp.Tabs))
+	if !req.All && len(resp.Tabs) == 1 {
+		message = "Closed " + resp.Tabs[0].Title
+	} else if len(resp.Tabs) == 1 {
+		message = "Closed 1 viewer session"
+	}
+	_, err = fmt.Fprintln(w, ui.success(message))
+	return err
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startTestViewerDaemon(t *testing.T) <-chan error {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "viewer.sock")
+	previous := viewerDaemonSocketPath
+	viewerDaemonSocketPath = func() (string, error) { return socketPath, nil }
+	t.Cleanup(func() { viewerDaemonSocketPath = previous })
+
+	done := make(chan error, 1)
+	go func() { done <- jotServeViewerDaemon(io.Discard, []string{"--no-self-open", "--daemon"}, time.Now) }()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("viewer daemon did not start listening")
+	return nil
+}
+
+func TestViewerDaemonOpensDocumentsAsTabs(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	writeTestFile(t, notes, "# Notes\n\nfirst\n")
+	writeTestFile(t, filepath.Join(dir, "data.json"), `{"a":1}`)
+	done := startTestViewerDaemon(t)
+
+	first, err := callViewerDaemon(viewerDaemonRequest{Op: "open", Args: []string{"--no-self-open", notes}, Dir: dir})
+	if err != nil || len(first.Tabs) != 1 || first.Tabs[0].ID != 1 || first.Tabs[0].Title != "notes.md" {
+		t.Fatalf("open notes.md = %+v, %v", first, err)
+	}
+	if first.Window == "" {
+		t.Fatal("expected the first tab to ask the caller to open the window")
+	}
+	second, err := callViewerDaemon(viewerDaemonRequest{Op: "open", Args: []string{"data.json"}, Dir: dir})
+	if err != nil || second.Tabs[0].ID != 2 || second.Window != "" {
+		t.Fatalf("expected a second tab in the window being opened, got %+v, %v", second, err)
+	}
+	if _, err := callViewerDaemon(viewerDaemonRequest{Op: "open", Args: []string{"missing.md"}, Dir: dir}); err == nil || errors.Is(err, errViewerDaemonUnavailable) {
+		t.Fatalf("expected a missing file to be reported by the daemon, got %v", err)
+	}
+
+	writeTestFile(t, notes, "# Notes\n\nsecond\n")
+	reopened, err := callViewerDaemon(viewerDaemonRequest{Op: "open", Args: []string{notes}, Dir: dir})
+	if err != nil || reopened.Tabs[0].ID != 1 || reopened.Tabs[0].URL == first.Tabs[0].URL {
+		t.Fatalf("expected reopening notes.md to reload tab 1, got %+v, %v", reopened, err)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	browser := &http.Client{Jar: jar}
+	resp, err := browser.Get(reopened.Tabs[0].URL)
+	if err != nil {
+		t.Fatalf("GET tab failed: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "second") {
+		t.Fatalf("expected the reloaded document, got %d", resp.StatusCode)
+	}
+	shellOrigin := strings.SplitN(first.Window, "/?", 2)[0]
+	if policy := resp.Header.Get("Content-Security-Policy"); !strings.HasSuffix(policy, "frame-ancestors 'self' "+shellOrigin) {
+		t.Fatalf("expected the window to be allowed to frame the tab, got %q", policy)
+	}
+	if _, err := browser.Get(first.Tabs[0].URL); err == nil {
+		t.Fatal("expected the replaced tab server to be closed")
+	}
+
+	resp, err = browser.Get(first.Window)
+	if err != nil {
+		t.Fatalf("GET window failed: %v", err)
+	}
+	shell, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(shell), `id="viewer-tabs"`) || !strings.Contains(resp.Header.Get("Content-Security-Policy"), "frame-src 'self' http://127.0.0.1:*") {
+		t.Fatalf("unexpected window page: %d %q", resp.StatusCode, resp.Header.Get("Content-Security-Policy"))
+	}
+	resp, err = browser.Get(shellOrigin + viewerDaemonTabsPath)
+	if err != nil {
+		t.Fatalf("GET tabs failed: %v", err)
+	}
+	var state viewerDaemonState
+	_ = json.NewDecoder(resp.Body).Decode(&state)
+	resp.Body.Close()
+	if len(state.Tabs) != 2 || state.Focus != 1 {
+		t.Fatalf("unexpected window state: %+v", state)
+	}
+	if resp, err := http.Get(shellOrigin + viewerDaemonTabsPath); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the window state to need the session token, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := jotOpenClose(&out, "2"); err != nil || !strings.Contains(out.String(), "Closed data.json") {
+		t.Fatalf("jotOpenClose = %q, %v", out.String(), err)
+	}
+	if err := jotOpenClose(&out, "2"); err == nil || !strings.Contains(err.Error(), "no viewer session 2") {
+		t.Fatalf("expected closing a closed tab to fail, got %v", err)
+	}
+	if _, err := browser.Get(second.Tabs[0].URL); err == nil {
+		t.Fatal("expected the closed tab server to stop")
+	}
+	out.Reset()
+	if err := jotOpenListSessions(&out, time.Now); err != nil {
+		t.Fatalf("jotOpenListSessions returned error: %v", err)
+	}
+	listing := stripANSI(out.String())
+	if !strings.Contains(listing, "1  notes.md") || strings.Contains(listing, "data.json") {
+		t.Fatalf("unexpected session list:\n%s", listing)
+	}
+
+	out.Reset()
+	if err := jotOpenClose(&out, "all"); err != nil || !strings.Contains(out.String(), "Closed 1 viewer session") {
+		t.Fatalf("jotOpenClose all = %q, %v", out.String(), err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("daemon returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected closing every tab to stop the daemon")
+	}
+	out.Reset()
+	if err := jotOpenListSessions(&out, time.Now); err != nil || !strings.Contains(out.String(), "No viewer sessions are open") {
+		t.Fatalf("expected no sessions once the daemon stopped, got %q, %v", out.String(), err)
+	}
+}
+
+func TestOpenInViewerDaemonFallsBackWhenNoDaemonStarts(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "viewer.sock")
+	previous := viewerDaemonSocketPath
+	viewerDaemonSocketPath = func() (string, error) { return socketPath, nil }
+	defer func() { viewerDaemonSocketPath = previous }()
+
+	spawned := 0
+	handled, err := openInViewerDaemon([]string{"notes.md"}, func(string) error {
+		t.Fatal("expected no window to open")
+		return nil
+	}, func() error {
+		spawned++
+		return errors.New("cannot start")
+	})
+	if handled || err != nil || spawned != 1 {
+		t.Fatalf("openInViewerDaemon = %v, %v after %d spawns", handled, err, spawned)
+	}
+	if _, err := os.Stat(socketPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no socket to be created, got %v", err)
+	}
+}
+
+func TestParseOpenArgsSessionFlags(t *testing.T) {
+	opts, err := parseOpenArgs([]string{"--list-sessions"})
+	if err != nil || !opts.listSessions {
+		t.Fatalf("parseOpenArgs = %+v, %v", opts, err)
+	}
+	opts, err = parseOpenArgs([]string{"--close", "3"})
+	if err != nil || !opts.close || opts.target != "3" {
+		t.Fatalf("parseOpenArgs = %+v, %v", opts, err)
+	}
+	for _, args := range [][]string{
+		{"--close"},
+		{"--list-sessions", "notes.md"},
+		{"--close", "1", "--edit"},
+	} {
+		if _, err := parseOpenArgs(args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+	if err := jotOpenClose(io.Discard, "first"); err == nil || !strings.Contains(err.Error(), "session id") {
+		t.Fatalf("expected a non-numeric id to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const viewerDocumentTypeDiff viewerDocumentType = "diff"
+
+// viewerDiffView is the browser-side rendering of a jot diff result.
+type viewerDiffView struct {
+	result  diffResult
+	context int
+	lang    string
+}
+
+// diffViewerLauncher opens a diff in a viewer window; tests replace it so no
+// child process is spawned.
+var diffViewerLauncher = launchDiffInViewer
+
+func launchDiffInViewer(leftPath, rightPath string, opts diffOptions) error {
+	return launchViewerArgs(append([]string{"--diff"}, diffViewerArgs(leftPath, rightPath, opts)...)...)
+}
+
+// diffViewerArgs rebuilds the jot diff arguments the viewer child needs to
+// reproduce the same comparison.
+func diffViewerArgs(leftPath, rightPath string, opts diffOptions) []string {
+	args := []string{leftPath, rightPath, "--context", strconv.Itoa(opts.context)}
+	if opts.ignoreWhitespace {
+		args = append(args, "--ignore-whitespace")
+	}
+	return args
+}
+
+func viewerServeArgsRequestDiff(args []string) bool {
+	for _, arg := range args {
+		if arg == "--diff" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseViewerDiffServeArgs accepts `__viewer [--no-self-open] --diff <left> <right> [diff flags]`.
+func parseViewerDiffServeArgs(args []string) (diffOptions, bool, error) {
+	selfOpen := true
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--no-self-open":
+			selfOpen = false
+		case "--diff":
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	opts, helpRequested, err := parseDiffArgs(rest)
+	if err != nil {
+		return opts, false, err
+	}
+	if helpRequested {
+		return opts, false, errors.New("usage: jot __viewer --diff <left-path> <right-path>")
+	}
+	return opts, selfOpen, nil
+}
+
+func diffViewerSession(args []string, cwd string) (viewerSession, bool, error) {
+	opts, selfOpen, err := parseViewerDiffServeArgs(args)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	doc, err := loadViewerDiffDocument(cwd, opts)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	return viewerSession{
+		title: filepath.Base(opts.leftPath) + " ↔ " + filepath.Base(opts.rightPath),
+		newHandler: func(touch func()) http.Handler {
+			return newFileViewerHandler(doc, touch)
+		},
+	}, selfOpen, nil
+}
+
+// loadViewerDiffDocument compares the two files in opts and wraps the result
+// as a viewer document so it shares the regular viewer chrome.
+func loadViewerDiffDocument(cwd string, opts diffOptions) (viewerDocument, error) {
+	leftPath, err := resolveDiffPath(cwd, opts.leftPath)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	rightPath, err := resolveDiffPath(cwd, opts.rightPath)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	leftDoc, err := loadDiffDocument(leftPath, opts.ignoreEOL)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	rightDoc, err := loadDiffDocument(rightPath, opts.ignoreEOL)
+	if err != nil {
+		return viewerDocument{}, err
+	}
+	result := buildDiffResult(leftDoc, rightDoc, opts)
+	return viewerDocument{
+		fileName: leftDoc.name + " ↔ " + rightDoc.name,
+		docType:  viewerDocumentTypeDiff,
+		diffView: &viewerDiffView{
+			result:  result,
+			context: opts.context,
+			lang:    viewerDiffLanguage(leftPath, rightPath),
+		},
+	}, nil
+}
+
+// viewerDiffLanguage enables syntax highlighting only when both sides share a
+// highlighted type, so a JSON-vs-text diff stays plain.
+func viewerDiffLanguage(leftPath, rightPath string) string {
+	left := viewerDocumentTypeForPath(leftPath)
+	if left != viewerDocumentTypeForPath(rightPath) {
+		return ""
+	}
+	switch left {
+	case viewerDocumentTypeJSON:
+		return "json"
+	case viewerDocumentTypeXML:
+		return "xml"
+	default:
+		return ""
+	}
+}
+
+// viewerDiffRow is one aligned row of the side-by-side table. Either side may
+// be missing for pure additions or deletions.
+type viewerDiffRow struct {
+	kind      diffOpKind
+	left      *diffOp
+	right     *diffOp
+	leftHTML  string
+	rightHTML string
+}
+
+func renderDiffViewHTML(view viewerDiffView) string {
+	result := view.result
+	var b strings.Builder
+	b.WriteString(`<div class="diff-root" id="diff-root">`)
+	b.WriteString(`<div class="diff-bar">`)
+	fmt.Fprintf(&b, `<span class="diff-files">%s <span class="diff-arrow">→</span> %s</span>`,
+		template.HTMLEscapeString(result.left.name), template.HTMLEscapeString(result.right.name))
+	fmt.Fprintf(&b, `<span class="diff-stat add">+%d</span><span class="diff-stat del">-%d</span><span class="diff-stat">%d hunk(s)</span>`,
+		result.additions, result.deletions, result.hunks)
+	b.WriteString(`<span class="diff-spacer"></span>`)
+	b.WriteString(`<button type="button" class="diff-mode active" data-mode="split">Side by side</button>`)
+	b.WriteString(`<button type="button" class="diff-mode" data-mode="unified">Unified</button>`)
+	b.WriteString(`<button type="button" id="diff-expand">Expand all</button>`)
+	b.WriteString(`</div>`)
+
+	if result.additions == 0 && result.deletions == 0 {
+		fmt.Fprintf(&b, `<div class="diff-empty">%s and %s are identical.</div></div>`,
+			template.HTMLEscapeString(result.left.name), template.HTMLEscapeString(result.right.name))
+		return b.String()
+	}
+
+	var split, unified strings.Builder
+	split.WriteString(`<table class="diff-table diff-split">`)
+	unified.WriteString(`<table class="diff-table diff-unified">`)
+	pos := 0
+	for _, block := range diffBlocks(result.ops, view.context) {
+		if block.start > pos {
+			writeViewerDiffFold(&split, &unified, result.ops[pos:block.start], view.lang)
+		}
+		rows := buildViewerDiffRows(result.ops[block.start:block.end], view.lang)
+		writeViewerDiffHunkHeader(&split, &unified, result.ops[block.start:block.end])
+		split.WriteString(`<tbody>`)
+		unified.WriteString(`<tbody>`)
+		for _, row := range rows {
+			writeViewerDiffSplitRow(&split, row)
+		}
+		writeViewerDiffUnifiedRows(&unified, rows)
+		split.WriteString(`</tbody>`)
+		unified.WriteString(`</tbody>`)
+		pos = block.end
+	}
+	if pos < len(result.ops) {
+		writeViewerDiffFold(&split, &unified, result.ops[pos:], view.lang)
+	}
+	split.WriteString(`</table>`)
+	unified.WriteString(`</table>`)
+
+	b.WriteString(split.String())
+	b.WriteString(unified.String())
+	b.WriteString(`</div><script>` + viewerDiffScript + `</script>`)
+	return b.String()
+}
+
+// buildViewerDiffRows pairs each run of deletions with the additions that
+// follow or precede it so modified lines sit next to each other.
+func buildViewerDiffRows(ops []diffOp, lang string) []viewerDiffRow {
+	var rows []viewerDiffRow
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			op := &ops[i]
+			text := highlightViewerDiffLine(op.text, lang)
+			rows = append(rows, viewerDiffRow{kind: diffEqual, left: op, right: op, leftHTML: text, rightHTML: text})
+			i++
+			continue
+		}
+		var deleted, added []*diffOp
+		for i < len(ops) && ops[i].kind != diffEqual {
+			if ops[i].kind == diffDelete {
+				deleted = append(deleted, &ops[i])
+			} else {
+				added = append(added, &ops[i])
+			}
+			i++
+		}
+		for k := 0; k < len(deleted) || k < len(added); k++ {
+			row := viewerDiffRow{kind: diffDelete}
+			switch {
+			case k < len(deleted) && k < len(added):
+				row.left, row.right = deleted[k], added[k]
+				row.leftHTML, row.rightHTML = diffWordHTML(row.left.text, row.right.text)
+			case k < len(deleted):
+				row.left = deleted[k]
+				row.leftHTML = highlightViewerDiffLine(row.left.text, lang)
+			default:
+				row.kind = diffAdd
+				row.right = added[k]
+				row.rightHTML = highlightViewerDiffLine(row.right.text, lang)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func highlightViewerDiffLine(line, lang string) string {
+	switch lang {
+	case "json":
+		return highlightJSONLine(line)
+	case "xml":
+		return highlightXMLLine(line)
+	default:
+		return template.HTMLEscapeString(line)
+	}
+}
+
+// diffWordHTML marks the words that differ between two lines with <del>
+// and <ins>. The whitespace before each word is kept as it was, so
+// indentation and runs of spaces survive.
+func diffWordHTML(oldLine, newLine string) (string, string) {
+	oldSpaces, oldWords := splitDiffWords(oldLine)
+	newSpaces, newWords := splitDiffWords(newLine)
+	var oldHTML, newHTML strings.Builder
+	i, j := 0, 0
+	for _, kind := range diffTokenSteps(oldWords, newWords) {
+		switch kind {
+		case diffEqual:
+			oldHTML.WriteString(template.HTMLEscapeString(oldSpaces[i] + oldWords[i]))
+			newHTML.WriteString(template.HTMLEscapeString(newSpaces[j] + newWords[j]))
+			i++
+			j++
+		case diffAdd:
+			newHTML.WriteString(newSpaces[j] + `<ins>` + template.HTMLEscapeString(newWords[j]) + `</ins>`)
+			j++
+		default:
+			oldHTML.WriteString(oldSpaces[i] + `<del>` + template.HTMLEscapeString(oldWords[i]) + `</del>`)
+			i++
+		}
+	}
+	oldHTML.WriteString(oldSpaces[len(oldWords)])
+	newHTML.WriteString(newSpaces[len(newWords)])
+	return oldHTML.String(), newHTML.String()
+}
+
+// splitDiffWords splits line into its words and the whitespace before each
+// one; spaces has one more entry than words, for whatever trails the last
+// word.
+func splitDiffWords(line string) (spaces, words []string) {
+	rest := line
+	for {
+		word := strings.TrimLeftFunc(rest, unicode.IsSpace)
+		spaces = append(spaces, rest[:len(rest)-len(word)])
+		if word == "" {
+			return spaces, words
+		}
+		end := strings.IndexFunc(word, unicode.IsSpace)
+		if end < 0 {
+			end = len(word)
+		}
+		words = append(words, word[:end])
+		rest = word[end:]
+	}
+}
+
+func writeViewerDiffHunkHeader(split, unified *strings.Builder, ops []diffOp) {
+	leftStart, rightStart := 0, 0
+	for _, op := range ops {
+		if leftStart == 0 && op.leftNo > 0 {
+			leftStart = op.leftNo
+		}
+		if rightStart == 0 && op.rightNo > 0 {
+			rightStart = op.rightNo
+		}
+	}
+	label := fmt.Sprintf("@@ -%d +%d @@", leftStart, rightStart)
+	fmt.Fprintf(split, `<tbody><tr class="diff-hunk"><td colspan="4">%s</td></tr></tbody>`, label)
+	fmt.Fprintf(unified, `<tbody><tr class="diff-hunk"><td colspan="4">%s</td></tr></tbody>`, label)
+}
+
+// writeViewerDiffFold emits a collapsed run of unchanged lines with a toggle
+// row in front of it.
+func writeViewerDiffFold(split, unified *strings.Builder, ops []diffOp, lang string) {
+	label := fmt.Sprintf("⋯ %d unchanged line(s)", len(ops))
+	if len(ops) == 1 {
+		label = "⋯ 1 unchanged line"
+	}
+	rows := buildViewerDiffRows(ops, lang)
+	for _, out := range []*strings.Builder{split, unified} {
+		fmt.Fprintf(out, `<tbody class="diff-fold"><tr class="diff-fold-toggle"><td colspan="4">%s</td></tr></tbody><tbody class="diff-fold-body" hidden>`, label)
+		if out == split {
+			for _, row := range rows {
+				writeViewerDiffSplitRow(out, row)
+			}
+		} else {
+			writeViewerDiffUnifiedRows(out, rows)
+		}
+		out.WriteString(`</tbody>`)
+	}
+}
+
+func writeViewerDiffSplitRow(b *strings.Builder, row viewerDiffRow) {
+	leftClass, rightClass := "ctx", "ctx"
+	if row.kind != diffEqual {
+		leftClass, rightClass = "empty", "empty"
+		if row.left != nil {
+			leftClass = "del"
+		}
+		if row.right != nil {
+			rightClass = "add"
+		}
+	}
+	fmt.Fprintf(b, `<tr><td class="ln">%s</td><td class="lc %s">%s</td><td class="ln">%s</td><td class="lc %s">%s</td></tr>`,
+		viewerDiffLineNo(row.left, true), leftClass, row.leftHTML,
+		viewerDiffLineNo(row.right, false), rightClass, row.rightHTML)
+}
+
+// writeViewerDiffUnifiedRows lists a paired run as all of its deletions
+// followed by all of its additions, like a unified patch.
+func writeViewerDiffUnifiedRows(b *strings.Builder, rows []viewerDiffRow) {
+	for i := 0; i < len(rows); {
+		if rows[i].kind == diffEqual {
+			fmt.Fprintf(b, `<tr><td class="ln">%d</td><td class="ln">%d</td><td class="sign"> </td><td class="lc ctx">%s</td></tr>`,
+				rows[i].left.leftNo, rows[i].right.rightNo, rows[i].leftHTML)
+			i++
+			continue
+		}
+		end := i
+		for end < len(rows) && rows[end].kind != diffEqual {
+			end++
+		}
+		for _, row := range rows[i:end] {
+			if row.left != nil {
+				fmt.Fprintf(b, `<tr><td class="ln">%d</td><td class="ln"></td><td class="sign">-</td><td class="lc del">%s</td></tr>`, row.left.leftNo, row.leftHTML)
+			}
+		}
+		for _, row := range rows[i:end] {
+			if row.right != nil {
+				fmt.Fprintf(b, `<tr><td class="ln"></td><td class="ln">%d</td><td class="sign">+</td><td class="lc add">%s</td></tr>`, row.right.rightNo, row.rightHTML)
+			}
+		}
+		i = end
+	}
+}
+
+func viewerDiffLineNo(op *diffOp, left bool) string {
+	if op == nil {
+		return ""
+	}
+	if left {
+		return strconv.Itoa(op.leftNo)
+	}
+	return strconv.Itoa(op.rightNo)
+}
+
+const viewerDiffStyles = `
+.diff-root{display:flex;flex-direction:column;gap:12px}
+.diff-bar{display:flex;flex-wrap:wrap;align-items:center;gap:10px;font-size:13px}
+.diff-files{font-weight:600}
+.diff-arrow{opacity:.5;margin:0 4px}
+.diff-stat{padding:2px 8px;border-radius:999px;background:rgba(127,127,127,.14);font-variant-numeric:tabular-nums}
+.diff-stat.add{color:#1a7f37}
+.diff-stat.del{color:#cf222e}
+.diff-spacer{flex:1}
+.diff-bar button{font:inherit;padding:4px 10px;border-radius:6px;border:1px solid rgba(127,127,127,.35);background:transparent;color:inherit;cursor:pointer}
+.diff-bar button.active{background:rgba(127,127,127,.18)}
+.diff-empty{padding:24px;text-align:center;opacity:.7}
+.diff-table{width:100%;border-collapse:collapse;table-layout:fixed;font-family:ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;font-size:12.5px}
+.diff-table td{padding:1px 8px;vertical-align:top;white-space:pre-wrap;word-break:break-word}
+.diff-table td.ln{width:52px;text-align:right;opacity:.5;user-select:none;font-variant-numeric:tabular-nums}
+.diff-table td.sign{width:14px;user-select:none;opacity:.7}
+.diff-table td.del{background:rgba(207,34,46,.12)}
+.diff-table td.add{background:rgba(26,127,55,.12)}
+.diff-table td.empty{background:rgba(127,127,127,.06)}
+.diff-table del{background:rgba(207,34,46,.3);text-decoration:none;border-radius:2px}
+.diff-table ins{background:rgba(26,127,55,.3);text-decoration:none;border-radius:2px}
+.diff-hunk td{padding:4px 8px;opacity:.6;background:rgba(84,130,255,.08)}
+.diff-fold-toggle td{padding:4px 8px;text-align:center;cursor:pointer;opacity:.65;background:rgba(127,127,127,.08)}
+.diff-fold-toggle td:hover{opacity:1}
+.diff-unified{display:none}
+.diff-root.mode-unified .diff-split{display:none}
+.diff-root.mode-unified .diff-unified{display:table}
+`
+
+const viewerDiffScript = `
+(function(){
+  var root = document.getElementById('diff-root');
+  if (!root) return;
+  root.querySelectorAll('.diff-mode').forEach(function(btn){
+    btn.addEventListener('click', function(){
+      root.classList.toggle('mode-unified', btn.dataset.mode === 'unified');
+      root.querySelectorAll('.diff-mode').forEach(function(other){ other.classList.toggle('active', other === btn); });
+    });
+  });
+  function toggle(fold, open){
+    var body = fold.nextElementSibling;
+    if (!body) return;
+    body.hidden = !open;
+    fold.hidden = open;
+  }
+  root.querySelectorAll('.diff-fold').forEach(function(fold){
+    fold.addEventListener('click', function(){ toggle(fold, true); });
+  });
+  var expand = document.getElementById('diff-expand');
+  if (expand) {
+    expand.addEventListener('click', function(){
+      root.querySelectorAll('.diff-fold').forEach(function(fold){ toggle(fold, true); });
+    });
+  }
+})();
+`
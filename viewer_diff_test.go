@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiffViewHTMLFoldsUnchangedRegionsAndMarksWords(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "left.txt")
+	right := filepath.Join(dir, "right.txt")
+	var before, after strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&before, "line %d\n", i)
+		if i == 15 {
+			after.WriteString("line fifteen <changed>\n")
+			continue
+		}
+		fmt.Fprintf(&after, "line %d\n", i)
+	}
+	if err := os.WriteFile(left, []byte(before.String()), 0o600); err != nil {
+		t.Fatalf("write left failed: %v", err)
+	}
+	if err := os.WriteFile(right, []byte(after.String()), 0o600); err != nil {
+		t.Fatalf("write right failed: %v", err)
+	}
+
+	doc, err := loadViewerDiffDocument(dir, diffOptions{leftPath: "left.txt", rightPath: "right.txt", context: 2, ignoreEOL: true})
+	if err != nil {
+		t.Fatalf("loadViewerDiffDocument returned error: %v", err)
+	}
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+
+	page := fetchViewerBody(t, server.URL+"/")
+	for _, snippet := range []string{
+		`class="diff-table diff-split"`,
+		`class="diff-table diff-unified"`,
+		"⋯ 12 unchanged line(s)",
+		"⋯ 13 unchanged line(s)",
+		"<del>15</del>",
+		"<ins>fifteen</ins> <ins>&lt;changed&gt;</ins>",
+		"@@ -13 +13 @@",
+		"Diff preview",
+	} {
+		if !strings.Contains(page, snippet) {
+			t.Fatalf("expected diff page to contain %q, got %q", snippet, page)
+		}
+	}
+	if strings.Contains(page, "<changed>") {
+		t.Fatalf("expected changed text to be escaped, got %q", page)
+	}
+}
+
+func TestDiffWordHTMLKeepsIndentationAndSpaceRuns(t *testing.T) {
+	oldHTML, newHTML := diffWordHTML("\t    return  a < b", "\t    return  a <= b  ")
+	if oldHTML != "\t    return  a <del>&lt;</del> b" {
+		t.Fatalf("unexpected old line %q", oldHTML)
+	}
+	if newHTML != "\t    return  a <ins>&lt;=</ins> b  " {
+		t.Fatalf("unexpected new line %q", newHTML)
+	}
+}
+
+func TestRenderDiffViewHTMLHighlightsWhenBothSidesAreJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte("{\n  \"replicas\": 2,\n  \"name\": \"api\"\n}\n"), 0o600); err != nil {
+		t.Fatalf("write a failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.json"), []byte("{\n  \"replicas\": 3,\n  \"name\": \"api\"\n}\n"), 0o600); err != nil {
+		t.Fatalf("write b failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("{\n  \"replicas\": 3,\n  \"name\": \"api\"\n}\n"), 0o600); err != nil {
+		t.Fatalf("write b.txt failed: %v", err)
+	}
+
+	doc, err := loadViewerDiffDocument(dir, diffOptions{leftPath: "a.json", rightPath: "b.json", context: 3, ignoreEOL: true})
+	if err != nil {
+		t.Fatalf("loadViewerDiffDocument returned error: %v", err)
+	}
+	highlighted := renderDiffViewHTML(*doc.diffView)
+	if doc.diffView.lang != "json" || !strings.Contains(highlighted, highlightJSONLine(`  "name": "api"`)) {
+		t.Fatalf("expected JSON highlighting, got %q", highlighted)
+	}
+
+	mixed, err := loadViewerDiffDocument(dir, diffOptions{leftPath: "a.json", rightPath: "b.txt", context: 3, ignoreEOL: true})
+	if err != nil {
+		t.Fatalf("loadViewerDiffDocument returned error: %v", err)
+	}
+	if mixed.diffView.lang != "" {
+		t.Fatalf("expected mixed types to stay plain, got %q", mixed.diffView.lang)
+	}
+}
+
+func TestJotDiffOpenLaunchesViewerWithResolvedPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o600); err != nil {
+		t.Fatalf("write a failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("two\n"), 0o600); err != nil {
+		t.Fatalf("write b failed: %v", err)
+	}
+
+	var launched []string
+	previous := diffViewerLauncher
+	diffViewerLauncher = func(leftPath, rightPath string, opts diffOptions) error {
+		launched = diffViewerArgs(leftPath, rightPath, opts)
+		return nil
+	}
+	defer func() { diffViewerLauncher = previous }()
+
+	var out bytes.Buffer
+	if err := jotDiffWithInput(strings.NewReader(""), &out, []string{"a.txt", "b.txt", "--open", "--context=1"}, func() (string, error) {
+		return dir, nil
+	}); err != nil {
+		t.Fatalf("jotDiffWithInput returned error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), "--context", "1"}
+	if strings.Join(launched, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected launcher args %q, got %q", want, launched)
+	}
+
+	opts, selfOpen, err := parseViewerDiffServeArgs(append([]string{"--no-self-open", "--diff"}, launched...))
+	if err != nil || selfOpen || opts.context != 1 || opts.leftPath != want[0] {
+		t.Fatalf("unexpected viewer diff args: %#v selfOpen=%v err=%v", opts, selfOpen, err)
+	}
+}
@@ -0,0 +1,562 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const viewerDocumentTypeMarkdownEdit viewerDocumentType = "markdown-edit"
+
+const (
+	viewerEditorSavePath    = "/save"
+	viewerEditorPreviewPath = "/preview"
+	viewerEditorSourcePath  = "/source"
+	viewerEditorMaxBytes    = 8 << 20
+)
+
+// viewerMarkdownEditor is the state the editor page needs to talk back to
+// its session. version is the SHA-256 of the file as loaded, or empty when
+// the file does not exist yet.
+type viewerMarkdownEditor struct {
+	version  string
+	sections []string
+	missing  bool
+}
+
+// markdownEditorLauncher opens a file in the viewer's editor; tests replace it
+// so no child process is spawned.
+var markdownEditorLauncher = launchMarkdownEditorInViewer
+
+// jotOpenEdit opens target in a split-pane Markdown editor with live preview.
+func jotOpenEdit(target string) error {
+	return jotOpenEditWithInput(target, os.Getwd, markdownEditorLauncher)
+}
+
+func jotOpenEditWithInput(target string, getwd func() (string, error), launch func(string) error) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return errors.New("--edit needs a Markdown file")
+	}
+	if !filepath.IsAbs(target) {
+		wd, err := getwd()
+		if err != nil {
+			return err
+		}
+		target = filepath.Join(wd, target)
+	}
+	if viewerDocumentTypeForPath(target) != viewerDocumentTypeMarkdown {
+		return fmt.Errorf("--edit supports Markdown files, got %s", target)
+	}
+	info, err := os.Stat(target)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("%s is a directory, expected a file", target)
+	case errors.Is(err, os.ErrNotExist):
+		// A missing file is created on the first save, as long as its
+		// folder exists.
+		if _, dirErr := os.Stat(filepath.Dir(target)); dirErr != nil {
+			return dirErr
+		}
+	case err != nil:
+		return err
+	}
+	return launch(target)
+}
+
+func launchMarkdownEditorInViewer(path string) error {
+	return launchViewerArgs("--edit", path)
+}
+
+func viewerServeArgsRequestEdit(args []string) bool {
+	for _, arg := range args {
+		if arg == "--edit" {
+			return true
+		}
+	}
+	return false
+}
+
+// editorViewerSession handles `__viewer [--no-self-open] --edit <file>`.
+func editorViewerSession(args []string) (viewerSession, bool, error) {
+	var rest []string
+	for _, arg := range args {
+		if arg != "--edit" {
+			rest = append(rest, arg)
+		}
+	}
+	path, selfOpen, err := parseViewerServeArgs(rest)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	return viewerSession{
+		title: filepath.Base(path),
+		newHandler: func(touch func()) http.Handler {
+			return newEditorViewerHandler(path, touch)
+		},
+	}, selfOpen, nil
+}
+
+// readEditorFile returns the file's content and version. A file that does
+// not exist yet reads as empty with an empty version.
+func readEditorFile(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:]), nil
+}
+
+// markdownSections splits content before each ATX heading outside fenced
+// code. The preview renders and patches one section at a time, so a
+// keystroke only re-renders the section it lands in.
+func markdownSections(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	var sections []string
+	start := 0
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 {
+			continue
+		}
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") && i > start {
+			sections = append(sections, strings.Join(lines[start:i], "\n"))
+			start = i
+		}
+	}
+	return append(sections, strings.Join(lines[start:], "\n"))
+}
+
+// markdownSectionRenderer caches rendered sections between preview requests.
+// Only sections present in the latest request are kept.
+type markdownSectionRenderer struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func (r *markdownSectionRenderer) render(content string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := make(map[string]string)
+	var out []string
+	for _, section := range markdownSections(content) {
+		html, ok := r.cache[section]
+		if !ok {
+			html = renderMarkdownHTML(section)
+		}
+		next[section] = html
+		out = append(out, html)
+	}
+	r.cache = next
+	return out
+}
+
+// newEditorViewerHandler serves the editor. Like every viewer handler it
+// relies on the session guard in serveViewerHandler to refuse requests
+// without the session token or from another origin.
+func newEditorViewerHandler(path string, touch func()) http.Handler {
+	const logoPath = "/logo.png"
+	mux := http.NewServeMux()
+	renderer := &markdownSectionRenderer{}
+	var saveMu sync.Mutex
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		data, version, err := readEditorFile(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		doc := viewerDocument{
+			fileName: filepath.Base(path),
+			docType:  viewerDocumentTypeMarkdownEdit,
+			content:  strings.ReplaceAll(string(data), "\r\n", "\n"),
+			size:     int64(len(data)),
+		}
+		doc.editor = &viewerMarkdownEditor{
+			version:  version,
+			sections: renderer.render(doc.content),
+			missing:  version == "",
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = io.WriteString(w, renderViewerPage(doc, "", logoPath))
+	})
+
+	mux.HandleFunc(viewerEditorPreviewPath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, viewerEditorMaxBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeViewerJSON(w, map[string]any{"sections": renderer.render(string(body))})
+	})
+
+	mux.HandleFunc(viewerEditorSourcePath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		data, version, err := readEditorFile(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeViewerJSON(w, map[string]any{"content": strings.ReplaceAll(string(data), "\r\n", "\n"), "version": version})
+	})
+
+	mux.HandleFunc(viewerEditorSavePath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Content string `json:"content"`
+			Base    string `json:"base"`
+			Force   bool   `json:"force"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, viewerEditorMaxBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid save request", http.StatusBadRequest)
+			return
+		}
+		saveMu.Lock()
+		defer saveMu.Unlock()
+		current, version, err := readEditorFile(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if version != req.Base && !req.Force {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":   filepath.Base(path) + " changed on disk since it was loaded",
+				"version": version,
+			})
+			return
+		}
+		content := req.Content
+		if strings.Contains(string(current), "\r\n") {
+			content = strings.ReplaceAll(content, "\n", "\r\n")
+		}
+		if err := writeEditorFileAtomic(path, []byte(content)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256([]byte(content))
+		writeViewerJSON(w, map[string]string{"version": hex.EncodeToString(sum[:])})
+	})
+
+	mux.HandleFunc(logoPath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		_, _ = w.Write(viewerLogoPNG)
+	})
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// writeEditorFileAtomic replaces path through a temporary file in the same
+// folder, keeping the existing file's permissions, so a failed save never
+// leaves a half-written note behind.
+func writeEditorFileAtomic(path string, data []byte) error {
+	return writeFileAtomic(path, data, 0o644)
+}
+
+// writeFileAtomic is writeEditorFileAtomic with the mode for a new file.
+func writeFileAtomic(path string, data []byte, newMode os.FileMode) error {
+	// Write through a symlink to its target; renaming onto the link itself
+	// would replace it with a regular file.
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := newMode
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempName)
+		return err
+	}
+	if err := temp.Chmod(mode); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempName)
+		return err
+	}
+	// Flush to disk before the rename, so a crash leaves the old file or
+	// the new one, never a truncated one.
+	if err := temp.Sync(); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	return nil
+}
+
+func renderMarkdownEditorHTML(doc viewerDocument) string {
+	editor := doc.editor
+	var b strings.Builder
+	status := "Saved"
+	if editor.missing {
+		status = "New file"
+	}
+	fmt.Fprintf(&b, `<div class="md-editor" id="md-editor" data-version="%s">`, template.HTMLEscapeString(editor.version))
+	b.WriteString(`<section class="md-editor-pane md-editor-source"><div class="md-editor-bar"><span>Markdown</span><span class="md-editor-actions">`)
+	fmt.Fprintf(&b, `<span id="md-editor-status" class="md-editor-status">%s</span>`, status)
+	b.WriteString(`<button type="button" class="md-editor-btn" id="md-editor-reload" hidden>Reload from disk</button>`)
+	b.WriteString(`<button type="button" class="md-editor-btn" id="md-editor-save" title="Save (Ctrl+S)">Save</button></span></div>`)
+	// The newline after <textarea> is dropped by the HTML parser, so a
+	// leading blank line in the file survives.
+	b.WriteString(`<textarea id="md-editor-input" class="md-editor-input" spellcheck="true">` + "\n")
+	b.WriteString(template.HTMLEscapeString(doc.content))
+	b.WriteString(`</textarea></section>`)
+	b.WriteString(`<section class="md-editor-pane"><div class="md-editor-bar"><span>Preview</span></div>`)
+	b.WriteString(`<article id="md-editor-preview" class="md-editor-preview text-frame markdown-frame">`)
+	for _, section := range editor.sections {
+		b.WriteString(`<div class="md-section">` + section + `</div>`)
+	}
+	b.WriteString(`</article></section></div>`)
+	fmt.Fprintf(&b, `<script>%s</script>`, viewerEditorScript)
+	return b.String()
+}
+
+const viewerEditorStyles = `
+    main { padding: 0; }
+    .viewer-surface { border: 0; border-radius: 0; }
+    .md-editor {
+      display: grid;
+      grid-template-columns: minmax(0, 1fr) minmax(0, 1fr);
+      height: calc(100vh - 48px);
+    }
+    .md-editor-pane { display: flex; flex-direction: column; min-height: 0; }
+    .md-editor-pane + .md-editor-pane { border-left: 0.5px solid rgba(0, 0, 0, 0.08); }
+    .md-editor-bar {
+      display: flex;
+      align-items: center;
+      justify-content: space-between;
+      gap: 10px;
+      height: 36px;
+      padding: 0 14px;
+      border-bottom: 0.5px solid rgba(0, 0, 0, 0.08);
+      font-size: 11px;
+      font-weight: 500;
+      letter-spacing: 0.05em;
+      text-transform: uppercase;
+      color: rgba(26, 26, 24, 0.38);
+      flex: none;
+    }
+    .md-editor-actions { display: flex; align-items: center; gap: 8px; text-transform: none; letter-spacing: 0; }
+    .md-editor-status { font-size: 12px; }
+    .md-editor-status.dirty { color: #b85c1a; }
+    .md-editor-status.error { color: #c0392b; }
+    .md-editor-btn {
+      font: inherit;
+      font-size: 12px;
+      padding: 3px 10px;
+      border-radius: 6px;
+      border: 0.5px solid rgba(0, 0, 0, 0.14);
+      background: white;
+      color: #1a1a18;
+      cursor: pointer;
+    }
+    .md-editor-btn:hover { background: rgba(26, 26, 24, 0.05); }
+    .md-editor-input {
+      flex: 1;
+      width: 100%;
+      min-height: 0;
+      resize: none;
+      border: 0;
+      outline: none;
+      padding: 24px 28px 60px;
+      background: transparent;
+      color: #1a1a18;
+      font-family: "SF Mono", Consolas, "Fira Mono", monospace;
+      font-size: 13px;
+      line-height: 1.7;
+      tab-size: 4;
+    }
+    .md-editor-preview { flex: 1; overflow: auto; }
+    .md-editor-preview.text-frame { max-width: none; margin: 0; padding: 28px 40px 60px; }
+    @media (max-width: 800px) {
+      .md-editor { grid-template-columns: 1fr; grid-template-rows: 1fr 1fr; }
+      .md-editor-pane + .md-editor-pane { border-left: 0; border-top: 0.5px solid rgba(0, 0, 0, 0.08); }
+    }
+    @media print {
+      .md-editor { display: block; height: auto; }
+      .md-editor-source, .md-editor-bar { display: none; }
+      .md-editor-pane + .md-editor-pane { border: 0; }
+      .md-editor-preview, .md-editor-preview.text-frame { overflow: visible; padding: 0; }
+    }
+`
+
+const viewerEditorScript = `
+(function() {
+  var root = document.getElementById('md-editor');
+  var input = document.getElementById('md-editor-input');
+  var preview = document.getElementById('md-editor-preview');
+  var status = document.getElementById('md-editor-status');
+  var reloadBtn = document.getElementById('md-editor-reload');
+  var version = root.dataset.version;
+  var saved = input.value;
+  var last = null;
+  var timer = null, inflight = false, pending = false;
+
+  function request(method, path, body, type) {
+    var headers = {};
+    if (type) headers['Content-Type'] = type;
+    return fetch(path, { method: method, headers: headers, body: body }).then(function(r) {
+      return r.text().then(function(text) {
+        var data;
+        try { data = JSON.parse(text); } catch (e) { data = { error: text.trim() || r.statusText }; }
+        data.status = r.status;
+        return data;
+      });
+    });
+  }
+  function setStatus(text, cls) {
+    status.textContent = text;
+    status.className = 'md-editor-status' + (cls ? ' ' + cls : '');
+  }
+  function dirty() { return input.value !== saved; }
+  function refreshStatus() {
+    if (dirty()) setStatus('Unsaved changes', 'dirty');
+    else setStatus('Saved');
+  }
+
+  function applySections(next) {
+    for (var i = 0; i < next.length; i++) {
+      var node = preview.children[i];
+      if (!node) {
+        node = document.createElement('div');
+        node.className = 'md-section';
+        preview.appendChild(node);
+      } else if (last && last[i] === next[i]) {
+        continue;
+      }
+      node.innerHTML = next[i];
+    }
+    while (preview.children.length > next.length) preview.removeChild(preview.lastChild);
+    last = next;
+  }
+  function renderPreview() {
+    if (inflight) { pending = true; return; }
+    inflight = true;
+    request('POST', '/preview', input.value, 'text/plain; charset=utf-8').then(function(data) {
+      inflight = false;
+      if (data.sections) applySections(data.sections);
+      if (pending) { pending = false; renderPreview(); }
+    }, function() { inflight = false; });
+  }
+
+  function save(force) {
+    var text = input.value;
+    setStatus('Saving…');
+    request('POST', '/save', JSON.stringify({ content: text, base: version, force: !!force }), 'application/json').then(function(data) {
+      if (data.status === 409) {
+        setStatus('Changed on disk', 'error');
+        reloadBtn.hidden = false;
+        if (confirm(data.error + '.\n\nOverwrite it with your version?')) save(true);
+        return;
+      }
+      if (data.error) { setStatus(data.error, 'error'); return; }
+      version = data.version;
+      saved = text;
+      reloadBtn.hidden = true;
+      refreshStatus();
+    }, function(err) { setStatus('Save failed: ' + err, 'error'); });
+  }
+  function reload() {
+    if (dirty() && !confirm('Discard your changes and load the file from disk?')) return;
+    request('GET', '/source').then(function(data) {
+      if (data.error) { setStatus(data.error, 'error'); return; }
+      input.value = data.content;
+      saved = data.content;
+      version = data.version;
+      reloadBtn.hidden = true;
+      refreshStatus();
+      renderPreview();
+    });
+  }
+
+  input.addEventListener('input', function() {
+    refreshStatus();
+    clearTimeout(timer);
+    timer = setTimeout(renderPreview, 120);
+  });
+  input.addEventListener('keydown', function(e) {
+    if (e.key === 'Tab' && !e.ctrlKey && !e.metaKey && !e.altKey) {
+      e.preventDefault();
+      if (!document.execCommand('insertText', false, '  ')) input.setRangeText('  ', input.selectionStart, input.selectionEnd, 'end');
+    }
+  });
+  input.addEventListener('scroll', function() {
+    var range = input.scrollHeight - input.clientHeight;
+    if (range > 0) preview.scrollTop = (input.scrollTop / range) * (preview.scrollHeight - preview.clientHeight);
+  });
+  document.addEventListener('keydown', function(e) {
+    if ((e.ctrlKey || e.metaKey) && (e.key === 's' || e.key === 'S')) {
+      e.preventDefault();
+      save(false);
+    }
+  });
+  document.getElementById('md-editor-save').addEventListener('click', function() { save(false); });
+  reloadBtn.addEventListener('click', reload);
+  window.addEventListener('beforeunload', function(e) {
+    if (dirty()) { e.preventDefault(); e.returnValue = ''; }
+  });
+  input.focus();
+})();
+`
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownSectionsSplitOnHeadingsOutsideFences(t *testing.T) {
+	content := "intro\n# One\ntext\n```sh\n# not a heading\n```\n## Two\n"
+	sections := markdownSections(content)
+	want := []string{"intro", "# One\ntext\n```sh\n# not a heading\n```", "## Two\n"}
+	if len(sections) != len(want) {
+		t.Fatalf("expected %d sections, got %q", len(want), sections)
+	}
+	for i := range want {
+		if sections[i] != want[i] {
+			t.Fatalf("section %d = %q, want %q", i, sections[i], want[i])
+		}
+	}
+	if strings.Join(sections, "\n") != content {
+		t.Fatal("expected sections to join back into the original content")
+	}
+}
+
+func TestEditorViewerSavesWithConflictGuard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("# Notes\r\n\r\nfirst\r\n"), 0o640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	const token = "session-token"
+	server := httptest.NewServer(newViewerSessionGuard(newEditorViewerHandler(path, func() {}), token))
+	defer server.Close()
+
+	send := func(method, route, body string, header map[string]string) (int, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+route, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, route, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var decoded map[string]any
+		_ = json.Unmarshal(data, &decoded)
+		return resp.StatusCode, decoded
+	}
+	auth := map[string]string{viewerSessionTokenHeader: token}
+
+	if status, _ := send("GET", "/", "", nil); status != http.StatusForbidden {
+		t.Fatalf("expected the page to need the token, got %d", status)
+	}
+	jar, _ := cookiejar.New(nil)
+	browser := &http.Client{Jar: jar}
+	resp, err := browser.Get(server.URL + "/?token=" + token)
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{`id="md-editor-input"`, "\n# Notes\n\nfirst\n</textarea>", `<h1 id="notes">Notes</h1>`} {
+		if !strings.Contains(string(page), want) {
+			t.Fatalf("expected editor page to contain %q", want)
+		}
+	}
+
+	if status, _ := send("POST", viewerEditorPreviewPath, "# A\n\n# B", nil); status != http.StatusForbidden {
+		t.Fatalf("expected preview without a token to be refused, got %d", status)
+	}
+	crossOrigin := map[string]string{viewerSessionTokenHeader: token, "Origin": "http://evil.example"}
+	if status, _ := send("POST", viewerEditorSavePath, `{"content":"x","force":true}`, crossOrigin); status != http.StatusForbidden {
+		t.Fatalf("expected a cross-origin save to be refused, got %d", status)
+	}
+	status, body := send("POST", viewerEditorPreviewPath, "# A\n\n# B", auth)
+	if sections, _ := body["sections"].([]any); status != http.StatusOK || len(sections) != 2 || sections[1] != `<h1 id="b">B</h1>` {
+		t.Fatalf("unexpected preview: %d %v", status, body)
+	}
+
+	_, source := send("GET", viewerEditorSourcePath, "", auth)
+	base := source["version"].(string)
+	status, body = send("POST", viewerEditorSavePath, `{"content":"# Notes\n\nsecond\n","base":"`+base+`"}`, auth)
+	if status != http.StatusOK || body["version"] == base {
+		t.Fatalf("unexpected save response: %d %v", status, body)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "# Notes\r\n\r\nsecond\r\n" {
+		t.Fatalf("expected CRLF line endings to be kept, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected file mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(path, []byte("edited elsewhere\n"), 0o640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	status, body = send("POST", viewerEditorSavePath, `{"content":"mine","base":"`+base+`"}`, auth)
+	if status != http.StatusConflict || !strings.Contains(body["error"].(string), "changed on disk") {
+		t.Fatalf("expected a conflict, got %d %v", status, body)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "edited elsewhere\n" {
+		t.Fatalf("conflicting save must not write, got %q", data)
+	}
+	if status, _ = send("POST", viewerEditorSavePath, `{"content":"mine","base":"`+base+`","force":true}`, auth); status != http.StatusOK {
+		t.Fatalf("expected a forced save to succeed, got %d", status)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "mine" {
+		t.Fatalf("expected forced save to write, got %q", data)
+	}
+}
+
+func TestJotOpenEditLaunchesTheEditor(t *testing.T) {
+	dir := t.TempDir()
+	getwd := func() (string, error) { return dir, nil }
+	var launched string
+	launch := func(path string) error {
+		launched = path
+		return nil
+	}
+
+	opts, err := parseOpenArgs([]string{"draft.md", "--edit"})
+	if err != nil || !opts.edit || opts.target != "draft.md" {
+		t.Fatalf("parseOpenArgs = %+v, %v", opts, err)
+	}
+	if _, err := parseOpenArgs([]string{"docs", "--edit", "--recursive"}); err == nil {
+		t.Fatal("expected --edit with --recursive to be rejected")
+	}
+	if err := jotOpenEditWithInput(opts.target, getwd, launch); err != nil {
+		t.Fatalf("expected a new file in an existing folder to open, got %v", err)
+	}
+	if launched != filepath.Join(dir, "draft.md") {
+		t.Fatalf("unexpected launch target %q", launched)
+	}
+	if err := jotOpenEditWithInput("data.json", getwd, launch); err == nil || !strings.Contains(err.Error(), "supports Markdown") {
+		t.Fatalf("expected non-Markdown files to be rejected, got %v", err)
+	}
+	if err := jotOpenEditWithInput("missing/notes.md", getwd, launch); err == nil {
+		t.Fatal("expected a file in a missing folder to be rejected")
+	}
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// viewerFolderMaxFiles stops a recursive scan from indexing a whole home
+	// directory by accident.
+	viewerFolderMaxFiles = 5000
+	// viewerFolderSearchPath runs a full-text search across every file the
+	// folder viewer lists.
+	viewerFolderSearchPath    = "/find"
+	viewerFolderSearchPerFile = 20
+	viewerFolderSearchMaxHits = 500
+	viewerFolderSearchPDFSize = 32 << 20
+	viewerFolderSnippetRunes  = 240
+)
+
+// folderTreeViewerLauncher opens a recursive folder viewer; tests replace it
+// so no child process is spawned.
+var folderTreeViewerLauncher = launchFolderTreeInViewer
+
+// jotOpenRecursive opens target, or the working directory, as a tree of every
+// supported file below it.
+func jotOpenRecursive(target string) error {
+	return jotOpenRecursiveWithInput(target, os.Getwd, folderTreeViewerLauncher)
+}
+
+func jotOpenRecursiveWithInput(target string, getwd func() (string, error), launch func(string) error) error {
+	target = strings.TrimSpace(target)
+	if target == "" || target == "." {
+		wd, err := getwd()
+		if err != nil {
+			return err
+		}
+		target = wd
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("--recursive needs a folder, got %s", target)
+	}
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	return launch(absPath)
+}
+
+func launchFolderTreeInViewer(dir string) error {
+	return launchViewerArgs("--recursive", dir)
+}
+
+func viewerServeArgsRequestRecursive(args []string) bool {
+	for _, arg := range args {
+		if arg == "--recursive" {
+			return true
+		}
+	}
+	return false
+}
+
+// folderTreeViewerSession handles `__viewer [--no-self-open] --recursive <dir>`.
+func folderTreeViewerSession(args []string) (viewerSession, bool, error) {
+	var rest []string
+	for _, arg := range args {
+		if arg != "--recursive" {
+			rest = append(rest, arg)
+		}
+	}
+	dir, selfOpen, err := parseViewerServeArgs(rest)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	files, err := scanFolderFilesRecursive(dir)
+	if err != nil {
+		return viewerSession{}, false, err
+	}
+	if len(files) == 0 {
+		return viewerSession{}, false, fmt.Errorf("no supported files found under %s", dir)
+	}
+	return folderFilesSession(dir, files), selfOpen, nil
+}
+
+// scanFolderFilesRecursive lists supported files below dir in path order,
+// skipping .git and anything the repository's .gitignore files exclude.
+func scanFolderFilesRecursive(dir string) ([]folderFile, error) {
+	ignore := &gitignoreMatcher{}
+	ignore.loadFile(filepath.Join(dir, ".git", "info", "exclude"), "")
+	var files []folderFile
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == "." {
+				ignore.loadFile(filepath.Join(p, ".gitignore"), "")
+				return nil
+			}
+			if d.Name() == ".git" || ignore.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			ignore.loadFile(filepath.Join(p, ".gitignore"), rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.ignored(rel, false) {
+			return nil
+		}
+		dt := viewerDocumentTypeForPath(p)
+		if dt == viewerDocumentTypeUnknown {
+			return nil
+		}
+		files = append(files, folderFile{Name: d.Name(), Rel: rel, Path: p, DocType: string(dt)})
+		if len(files) >= viewerFolderMaxFiles {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return files, err
+}
+
+// gitignoreMatcher applies .gitignore rules in the order git reads them: a
+// later match wins, and "!" re-includes a path.
+type gitignoreMatcher struct {
+	rules []gitignoreRule
+}
+
+type gitignoreRule struct {
+	base     string
+	pattern  []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// loadFile adds the rules in path; base is the slash-separated directory the
+// file lives in, relative to the scan root.
+func (m *gitignoreMatcher) loadFile(path, base string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if rule, ok := parseGitignoreLine(line, base); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+func parseGitignoreLine(line, base string) (gitignoreRule, bool) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+	rule := gitignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+	rule.pattern = strings.Split(line, "/")
+	return rule, true
+}
+
+func (m *gitignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r gitignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		return gitignoreGlob(r.pattern[0], path.Base(rel))
+	}
+	return gitignoreMatchSegments(r.pattern, strings.Split(rel, "/"))
+}
+
+func gitignoreMatchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if gitignoreMatchSegments(pattern, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 || !gitignoreGlob(pattern[0], parts[0]) {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func gitignoreGlob(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+type viewerFolderSearchResult struct {
+	Query     string                   `json:"query"`
+	Files     []viewerFolderSearchFile `json:"files"`
+	Total     int                      `json:"total"`
+	Truncated bool                     `json:"truncated"`
+}
+
+type viewerFolderSearchFile struct {
+	Index   int                     `json:"i"`
+	Path    string                  `json:"path"`
+	Matches []viewerFolderSearchHit `json:"matches"`
+}
+
+// viewerFolderSearchHit is one matching line, or one matching page of a PDF.
+type viewerFolderSearchHit struct {
+	Line int    `json:"line,omitempty"`
+	Page int    `json:"page,omitempty"`
+	Text string `json:"text"`
+}
+
+// viewerPDFTextCache keeps each PDF's extracted text until the file changes,
+// so typing a search does not re-extract every PDF on each keystroke.
+type viewerPDFTextCache struct {
+	mu    sync.Mutex
+	pages map[string]viewerPDFText
+}
+
+type viewerPDFText struct {
+	size    int64
+	modTime time.Time
+	pages   []string
+}
+
+func newViewerPDFTextCache() *viewerPDFTextCache {
+	return &viewerPDFTextCache{pages: map[string]viewerPDFText{}}
+}
+
+// text returns the pages of the PDF at path. Extraction cannot be
+// interrupted, so a cancelled ctx returns early and lets it finish into the
+// cache for the next search.
+func (c *viewerPDFTextCache) text(ctx context.Context, path string, info os.FileInfo) ([]string, error) {
+	c.mu.Lock()
+	cached, ok := c.pages[path]
+	c.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.pages, nil
+	}
+	type extracted struct {
+		pages []string
+		err   error
+	}
+	done := make(chan extracted, 1)
+	go func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			done <- extracted{err: err}
+			return
+		}
+		pages, err := readPDFText(data)
+		if err == nil {
+			c.mu.Lock()
+			c.pages[path] = viewerPDFText{size: info.Size(), modTime: info.ModTime(), pages: pages}
+			c.mu.Unlock()
+		}
+		done <- extracted{pages: pages, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case got := <-done:
+		return got.pages, got.err
+	}
+}
+
+func registerViewerFolderSearchRoute(mux *http.ServeMux, files []folderFile, touch func()) {
+	cache := newViewerPDFTextCache()
+	mux.HandleFunc(viewerFolderSearchPath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		result, err := searchFolderFiles(r.Context(), cache, files, r.URL.Query().Get("q"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeViewerJSON(w, result)
+	})
+}
+
+// searchFolderFiles looks for query, case-insensitively, in every text-like
+// file and every PDF's extracted text. .env files are skipped so a search
+// never echoes secrets the viewer masks.
+func searchFolderFiles(ctx context.Context, cache *viewerPDFTextCache, files []folderFile, query string) (viewerFolderSearchResult, error) {
+	query = strings.TrimSpace(query)
+	result := viewerFolderSearchResult{Query: query, Files: []viewerFolderSearchFile{}}
+	if query == "" {
+		return result, nil
+	}
+	for i, f := range files {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		limit := viewerFolderSearchPerFile
+		if left := viewerFolderSearchMaxHits - result.Total; left < limit {
+			limit = left
+		}
+		hits, err := searchFolderFile(ctx, cache, f, query, limit)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return result, err
+			}
+			continue
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		result.Files = append(result.Files, viewerFolderSearchFile{Index: i, Path: firstNonEmpty(f.Rel, f.Name), Matches: hits})
+		result.Total += len(hits)
+		if result.Total >= viewerFolderSearchMaxHits {
+			result.Truncated = i < len(files)-1
+			break
+		}
+	}
+	return result, nil
+}
+
+func searchFolderFile(ctx context.Context, cache *viewerPDFTextCache, f folderFile, query string, limit int) ([]viewerFolderSearchHit, error) {
+	switch viewerDocumentType(f.DocType) {
+	case viewerDocumentTypeEnv, viewerDocumentTypeImage, viewerDocumentTypeSQLite:
+		return nil, nil
+	case viewerDocumentTypePDF:
+		info, err := os.Stat(f.Path)
+		if err != nil || info.Size() > viewerFolderSearchPDFSize {
+			return nil, err
+		}
+		pages, err := cache.text(ctx, f.Path, info)
+		if err != nil {
+			return nil, err
+		}
+		needle := strings.ToLower(query)
+		var hits []viewerFolderSearchHit
+		for n, text := range pages {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for _, line := range strings.Split(text, "\n") {
+				if strings.Contains(strings.ToLower(line), needle) {
+					hits = append(hits, viewerFolderSearchHit{Page: n + 1, Text: viewerSearchSnippet(line, needle)})
+					break
+				}
+			}
+			if len(hits) >= limit {
+				break
+			}
+		}
+		return hits, nil
+	default:
+		found, err := searchViewerFile(ctx, f.Path, query, 0, limit)
+		if err != nil {
+			return nil, err
+		}
+		needle := strings.ToLower(query)
+		hits := make([]viewerFolderSearchHit, 0, len(found.Matches))
+		for _, match := range found.Matches {
+			hits = append(hits, viewerFolderSearchHit{Line: match.Line, Text: viewerSearchSnippet(match.Text, needle)})
+		}
+		return hits, nil
+	}
+}
+
+// viewerSearchSnippet trims long lines to a window around the first match so
+// minified files do not flood the results.
+func viewerSearchSnippet(line, needle string) string {
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) <= viewerFolderSnippetRunes {
+		return line
+	}
+	runes := []rune(line)
+	// Lowercasing can change a rune's byte length, so the match is found in
+	// the line itself rather than in a lowercased copy.
+	center := 0
+	if at := regexp.MustCompile("(?i)" + regexp.QuoteMeta(needle)).FindStringIndex(line); at != nil {
+		center = utf8.RuneCountInString(line[:at[0]])
+	}
+	start := center - viewerFolderSnippetRunes/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + viewerFolderSnippetRunes
+	if end > len(runes) {
+		end = len(runes)
+	}
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+const viewerFolderTreeStyles = `
+    .sidebar-search {
+      margin: 8px 10px 2px;
+      padding: 6px 9px;
+      font: inherit;
+      font-size: 12px;
+      border: 0.5px solid rgba(0,0,0,0.12);
+      border-radius: 6px;
+      background: rgba(255,255,255,0.8);
+      color: #1a1a18;
+      flex: none;
+    }
+    .sidebar-search:focus { outline: none; border-color: rgba(26,26,24,0.4); }
+    .tree-dir .item-name { color: rgba(26,26,24,0.82); font-weight: 500; }
+    .tree-caret { width: 12px; flex: none; font-size: 10px; color: rgba(26,26,24,0.4); transition: transform 0.1s; }
+    .tree-dir.collapsed .tree-caret { transform: rotate(-90deg); }
+    .finder {
+      position: fixed; inset: 0; z-index: 50;
+      background: rgba(26,26,24,0.18);
+      display: flex; justify-content: center; align-items: flex-start;
+      padding-top: 12vh;
+    }
+    .finder[hidden] { display: none; }
+    .finder-box {
+      width: min(560px, 92vw);
+      background: #fcfbf9;
+      border-radius: 12px;
+      box-shadow: 0 18px 48px rgba(0,0,0,0.18);
+      overflow: hidden;
+    }
+    .finder-box input {
+      width: 100%; padding: 13px 16px; font: inherit; font-size: 14px;
+      border: 0; border-bottom: 0.5px solid rgba(0,0,0,0.08); background: transparent;
+    }
+    .finder-box input:focus { outline: none; }
+    .finder-list { max-height: 50vh; overflow-y: auto; padding: 4px 0; }
+    .finder-item { padding: 7px 16px; cursor: pointer; display: flex; gap: 10px; align-items: baseline; }
+    .finder-item.active { background: rgba(26,26,24,0.07); }
+    .finder-name { font-size: 13px; color: #1a1a18; }
+    .finder-dir { font-size: 11.5px; color: rgba(26,26,24,0.45); overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+    .finder-item b { color: #c7370a; font-weight: 600; }
+    .search-results { padding: 18px 22px 40px; max-width: 980px; }
+    .search-summary { font-size: 12px; color: rgba(26,26,24,0.5); margin-bottom: 14px; }
+    .search-file { margin-bottom: 16px; }
+    .search-path { font-size: 12.5px; font-weight: 600; cursor: pointer; margin-bottom: 4px; }
+    .search-path:hover { text-decoration: underline; }
+    .search-hit {
+      display: flex; gap: 10px; padding: 3px 8px; border-radius: 5px; cursor: pointer;
+      font: 12px/1.5 "SF Mono", Consolas, monospace; color: rgba(26,26,24,0.78);
+    }
+    .search-hit:hover { background: rgba(26,26,24,0.05); }
+    .search-line { flex: none; width: 48px; text-align: right; color: rgba(26,26,24,0.38); }
+    .search-hit mark { background: rgba(255,196,0,0.45); color: inherit; border-radius: 2px; }
+`
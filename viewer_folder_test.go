@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFolderTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+}
+
+func TestScanFolderFilesRecursiveHonorsGitignore(t *testing.T) {
+	root := t.TempDir()
+	writeFolderTree(t, root, map[string]string{
+		".gitignore":            "build/\n*.log\n!keep.log\n/secret.md\ndocs/**/draft.md\n",
+		".git/HEAD.md":          "ref",
+		"README.md":             "# Readme",
+		"secret.md":             "hidden at the root only",
+		"build/out.md":          "generated",
+		"app.log":               "ignored",
+		"keep.log":              "re-included",
+		"docs/guide.md":         "# Guide",
+		"docs/a/b/draft.md":     "ignored draft",
+		"docs/nested/secret.md": "only the root secret.md is anchored",
+		"sub/.gitignore":        "local.txt\n",
+		"sub/local.txt":         "ignored by sub/.gitignore",
+		"sub/notes.txt":         "kept",
+		"local.txt":             "not under sub",
+		"image.bin":             "unsupported",
+	})
+
+	files, err := scanFolderFilesRecursive(root)
+	if err != nil {
+		t.Fatalf("scanFolderFilesRecursive returned error: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Rel)
+	}
+	want := "README.md,docs/guide.md,docs/nested/secret.md,keep.log,local.txt,sub/notes.txt"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected files:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+	if files[1].Name != "guide.md" || files[1].Path != filepath.Join(root, "docs", "guide.md") {
+		t.Fatalf("unexpected file entry: %+v", files[1])
+	}
+}
+
+func TestFolderViewerSearchesEveryFileOnTheServer(t *testing.T) {
+	root := t.TempDir()
+	writeFolderTree(t, root, map[string]string{
+		"README.md":           "# Project\n\nDeploy with the release script.\n",
+		"docs/ops/runbook.md": "1. Check disk\n2. Run the RELEASE job\n3. Verify\n",
+		"config.yaml":         "name: demo\n",
+		".env":                "RELEASE_TOKEN=abc123\n",
+	})
+	files, err := scanFolderFilesRecursive(root)
+	if err != nil {
+		t.Fatalf("scanFolderFilesRecursive returned error: %v", err)
+	}
+
+	server := httptest.NewServer(newFolderViewerHandler(root, files, func() {}))
+	defer server.Close()
+
+	page, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	page.Body.Close()
+
+	resp, err := http.Get(server.URL + viewerFolderSearchPath + "?q=release")
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", viewerFolderSearchPath, err)
+	}
+	defer resp.Body.Close()
+	var result viewerFolderSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if result.Total != 2 || len(result.Files) != 2 {
+		t.Fatalf("expected two matches, got %+v", result)
+	}
+	if result.Files[0].Path != "README.md" || result.Files[0].Matches[0].Line != 3 {
+		t.Fatalf("unexpected first file: %+v", result.Files[0])
+	}
+	runbook := result.Files[1]
+	if runbook.Path != "docs/ops/runbook.md" || runbook.Matches[0].Text != "2. Run the RELEASE job" || files[runbook.Index].Rel != runbook.Path {
+		t.Fatalf("unexpected runbook match: %+v", runbook)
+	}
+}
+
+func TestJotOpenRecursiveLaunchesTheFolderViewer(t *testing.T) {
+	root := t.TempDir()
+	writeFolderTree(t, root, map[string]string{"notes.md": "# Notes"})
+
+	opts, err := parseOpenArgs([]string{"--recursive", "."})
+	if err != nil || opts.target != "." || !opts.recursive {
+		t.Fatalf("parseOpenArgs = %+v, %v", opts, err)
+	}
+	target := opts.target
+	if _, err := parseOpenArgs([]string{"a", "b"}); err == nil {
+		t.Fatal("expected two targets to be rejected")
+	}
+
+	var launched string
+	launch := func(dir string) error {
+		launched = dir
+		return nil
+	}
+	getwd := func() (string, error) { return root, nil }
+	if err := jotOpenRecursiveWithInput(target, getwd, launch); err != nil {
+		t.Fatalf("jotOpenRecursiveWithInput returned error: %v", err)
+	}
+	if launched != root {
+		t.Fatalf("expected %s to be launched, got %q", root, launched)
+	}
+	err = jotOpenRecursiveWithInput(filepath.Join(root, "notes.md"), getwd, launch)
+	if err == nil || !strings.Contains(err.Error(), "needs a folder") {
+		t.Fatalf("expected a file target to be rejected, got %v", err)
+	}
+}
+
+func TestViewerSearchSnippetKeepsRunesThatChangeSizeWhenLowered(t *testing.T) {
+	// Ⱥ is two bytes but lowercases to the three-byte ⱥ.
+	line := strings.Repeat("Ⱥ", 300) + "needle"
+	snippet := viewerSearchSnippet(line, "needle")
+	if !strings.Contains(snippet, "needle") || !strings.HasPrefix(snippet, "…") {
+		t.Fatalf("expected a window around the match, got %q", snippet)
+	}
+}
+
+func TestFolderSearchCachesPDFTextUntilTheFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, samplePDF(t), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	files := []folderFile{{Name: "report.pdf", Path: path, DocType: string(viewerDocumentTypePDF)}}
+	cache := newViewerPDFTextCache()
+
+	result, err := searchFolderFiles(context.Background(), cache, files, "quarterly")
+	if err != nil || result.Total != 1 || result.Files[0].Matches[0].Page != 2 {
+		t.Fatalf("expected a match on page 2, got %+v, %v", result, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.pages[path] = viewerPDFText{size: info.Size(), modTime: info.ModTime(), pages: []string{"cached text"}}
+	if result, _ := searchFolderFiles(context.Background(), cache, files, "cached"); result.Total != 1 {
+		t.Fatalf("expected the cached text to be searched, got %+v", result)
+	}
+
+	if err := os.Chtimes(path, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := searchFolderFiles(context.Background(), cache, files, "quarterly"); result.Total != 1 {
+		t.Fatalf("expected a changed PDF to be extracted again, got %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := searchFolderFiles(ctx, newViewerPDFTextCache(), files, "quarterly"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled search to stop, got %v", err)
+	}
+}
@@ -0,0 +1,870 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const viewerDocumentTypeImage viewerDocumentType = "image"
+
+// viewerImagePath serves the image bytes in the single-file viewer.
+const viewerImagePath = "/image"
+
+// viewerImageAnalyzeLimit caps how large an image can be before jot skips
+// reading metadata and palette and only shows the picture.
+const viewerImageAnalyzeLimit = 64 << 20
+
+// viewerImagePaletteSamples is roughly how many pixels the palette looks at;
+// larger images are sampled on a grid instead of scanned pixel by pixel.
+const viewerImagePaletteSamples = 250000
+
+// viewerImagePaletteMaxPixels caps the images decoded for a palette. A small
+// file can declare huge dimensions, and decoding allocates all of them up
+// front.
+const viewerImagePaletteMaxPixels = 50 << 20
+
+var viewerImageContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+}
+
+// viewerImageView is what the image viewer shows next to the picture.
+type viewerImageView struct {
+	src     string
+	format  string
+	width   int
+	height  int
+	fields  []viewerImageField
+	palette []paletteColor
+}
+
+type viewerImageField struct {
+	label string
+	value string
+}
+
+func viewerImageContentType(name string) string {
+	return viewerImageContentTypes[strings.ToLower(filepath.Ext(name))]
+}
+
+// serveViewerImage writes image bytes with headers that keep the browser
+// from sniffing them into something else or running script inside an SVG.
+func serveViewerImage(w http.ResponseWriter, r *http.Request, name string, content io.ReadSeeker) {
+	w.Header().Set("Content-Type", viewerImageContentType(name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	http.ServeContent(w, r, filepath.Base(name), time.Time{}, content)
+}
+
+// buildViewerImageView reads dimensions, metadata, and a palette from data.
+// Anything it cannot decode is left out; the picture itself is rendered by
+// the browser either way.
+func buildViewerImageView(name string, data []byte) *viewerImageView {
+	view := &viewerImageView{src: viewerImagePath}
+	ext := strings.ToLower(filepath.Ext(name))
+	view.format = strings.ToUpper(strings.TrimPrefix(ext, "."))
+	if ext == ".jpeg" {
+		view.format = "JPG"
+	}
+	if data == nil {
+		return view
+	}
+	if ext == ".svg" {
+		view.fields = readSVGImageFields(data)
+		return view
+	}
+
+	decodable := false
+	if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		view.width, view.height = config.Width, config.Height
+		view.format = strings.ToUpper(format)
+		decodable = int64(config.Width)*int64(config.Height) <= viewerImagePaletteMaxPixels
+	}
+	switch ext {
+	case ".png":
+		view.fields = readPNGImageFields(data)
+	case ".jpg", ".jpeg":
+		view.fields = readJPEGImageFields(data)
+	case ".gif":
+		view.fields = readGIFImageFields(data)
+	}
+	if !decodable {
+		return view
+	}
+	if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		if colors, err := extractPalette(sampleViewerImage(img), paletteOptions{Count: 8, SortMode: "dominant"}); err == nil {
+			view.palette = colors
+		}
+	}
+	return view
+}
+
+// viewerSampledImage exposes every step-th pixel of an image in each
+// direction, so extractPalette stays fast on camera-sized photos.
+type viewerSampledImage struct {
+	image.Image
+	step int
+}
+
+func (s viewerSampledImage) Bounds() image.Rectangle {
+	b := s.Image.Bounds()
+	return image.Rect(0, 0, (b.Dx()+s.step-1)/s.step, (b.Dy()+s.step-1)/s.step)
+}
+
+func (s viewerSampledImage) At(x, y int) color.Color {
+	b := s.Image.Bounds()
+	return s.Image.At(b.Min.X+x*s.step, b.Min.Y+y*s.step)
+}
+
+func sampleViewerImage(img image.Image) image.Image {
+	pixels := img.Bounds().Dx() * img.Bounds().Dy()
+	if pixels <= viewerImagePaletteSamples {
+		return img
+	}
+	step := int(math.Ceil(math.Sqrt(float64(pixels) / viewerImagePaletteSamples)))
+	return viewerSampledImage{Image: img, step: step}
+}
+
+func readPNGImageFields(data []byte) []viewerImageField {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil
+	}
+	var fields []viewerImageField
+	add := func(label, value string) {
+		fields = appendViewerImageField(fields, label, value)
+	}
+	for pos := len(signature); pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		start := pos + 8
+		if length < 0 || start+length > len(data) {
+			break
+		}
+		body := data[start : start+length]
+		pos = start + length + 4
+		switch kind {
+		case "IHDR":
+			if len(body) >= 13 {
+				add("Color", fmt.Sprintf("%s, %d-bit", pngColorTypeLabel(body[9]), body[8]))
+				if body[12] == 1 {
+					add("Interlace", "Adam7")
+				}
+			}
+		case "pHYs":
+			if len(body) >= 9 && body[8] == 1 {
+				add("Resolution", fmt.Sprintf("%.0f dpi", float64(binary.BigEndian.Uint32(body))*0.0254))
+			}
+		case "gAMA":
+			if len(body) >= 4 {
+				add("Gamma", fmt.Sprintf("%.5g", float64(binary.BigEndian.Uint32(body))/100000))
+			}
+		case "sRGB":
+			add("Color profile", "sRGB")
+		case "iCCP":
+			if name, _, ok := bytes.Cut(body, []byte{0}); ok {
+				add("Color profile", latin1String(name))
+			}
+		case "acTL":
+			if len(body) >= 4 {
+				add("Frames", fmt.Sprintf("%d (animated)", binary.BigEndian.Uint32(body)))
+			}
+		case "tEXt":
+			if key, value, ok := bytes.Cut(body, []byte{0}); ok {
+				add(pngTextLabel(latin1String(key)), latin1String(value))
+			}
+		case "zTXt":
+			if key, rest, ok := bytes.Cut(body, []byte{0}); ok && len(rest) > 0 {
+				if value, err := inflateViewerImageText(rest[1:]); err == nil {
+					add(pngTextLabel(latin1String(key)), latin1String(value))
+				}
+			}
+		case "iTXt":
+			add(readPNGInternationalText(body))
+		case "eXIf":
+			fields = append(fields, readEXIFFields(body)...)
+		case "IEND":
+			return fields
+		}
+	}
+	return fields
+}
+
+func readPNGInternationalText(body []byte) (string, string) {
+	key, rest, ok := bytes.Cut(body, []byte{0})
+	if !ok || len(rest) < 2 {
+		return "", ""
+	}
+	compressed := rest[0] == 1
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0}) // language tag
+	if !ok {
+		return "", ""
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0}) // translated keyword
+	if !ok {
+		return "", ""
+	}
+	if compressed {
+		inflated, err := inflateViewerImageText(text)
+		if err != nil {
+			return "", ""
+		}
+		text = inflated
+	}
+	return pngTextLabel(string(key)), string(text)
+}
+
+func inflateViewerImageText(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(io.LimitReader(reader, 1<<20))
+}
+
+func pngTextLabel(key string) string {
+	if key == "XML:com.adobe.xmp" {
+		return "XMP"
+	}
+	return key
+}
+
+func pngColorTypeLabel(colorType byte) string {
+	switch colorType {
+	case 0:
+		return "grayscale"
+	case 2:
+		return "RGB"
+	case 3:
+		return "indexed"
+	case 4:
+		return "grayscale + alpha"
+	case 6:
+		return "RGBA"
+	default:
+		return fmt.Sprintf("type %d", colorType)
+	}
+}
+
+func readJPEGImageFields(data []byte) []viewerImageField {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	var fields []viewerImageField
+	add := func(label, value string) {
+		fields = appendViewerImageField(fields, label, value)
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA {
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		body := data[pos+4 : pos+2+length]
+		pos += 2 + length
+		switch {
+		case marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC:
+			if len(body) >= 6 {
+				encoding := "baseline"
+				if marker == 0xC2 || marker == 0xC6 || marker == 0xCA || marker == 0xCE {
+					encoding = "progressive"
+				}
+				add("Encoding", fmt.Sprintf("%s, %d-bit, %d channel(s)", encoding, body[0], body[5]))
+			}
+		case marker == 0xE0 && bytes.HasPrefix(body, []byte("JFIF\x00")) && len(body) >= 12:
+			density := float64(binary.BigEndian.Uint16(body[8:]))
+			switch body[7] {
+			case 1:
+				add("Resolution", fmt.Sprintf("%.0f dpi", density))
+			case 2:
+				add("Resolution", fmt.Sprintf("%.0f dpi", density*2.54))
+			}
+		case marker == 0xE1 && bytes.HasPrefix(body, []byte("Exif\x00\x00")):
+			fields = append(fields, readEXIFFields(body[6:])...)
+		case marker == 0xE1 && bytes.HasPrefix(body, []byte("http://ns.adobe.com/xap/1.0/\x00")):
+			add("XMP", fmt.Sprintf("embedded (%s)", formatViewerByteSize(int64(len(body)))))
+		case marker == 0xE2 && bytes.HasPrefix(body, []byte("ICC_PROFILE\x00")):
+			add("Color profile", "embedded ICC")
+		case marker == 0xFE:
+			add("Comment", string(body))
+		}
+	}
+	return fields
+}
+
+func readGIFImageFields(data []byte) []viewerImageField {
+	if len(data) < 13 || !bytes.HasPrefix(data, []byte("GIF")) {
+		return nil
+	}
+	var fields []viewerImageField
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+	frames, loop := 0, -1
+	skipBlocks := func() {
+		for pos < len(data) && data[pos] != 0 {
+			pos += int(data[pos]) + 1
+		}
+		pos++
+	}
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21:
+			if pos+2 >= len(data) {
+				pos = len(data)
+				continue
+			}
+			label := data[pos+1]
+			pos += 2
+			if label == 0xFF && pos+17 <= len(data) && string(data[pos+1:pos+12]) == "NETSCAPE2.0" && data[pos+12] >= 3 {
+				loop = int(binary.LittleEndian.Uint16(data[pos+14:]))
+			}
+			if label == 0xFE && pos < len(data) && pos+1+int(data[pos]) <= len(data) {
+				fields = appendViewerImageField(fields, "Comment", latin1String(data[pos+1:pos+1+int(data[pos])]))
+			}
+			skipBlocks()
+		case 0x2C:
+			frames++
+			if pos+10 > len(data) {
+				pos = len(data)
+				continue
+			}
+			packed := data[pos+9]
+			pos += 10
+			if packed&0x80 != 0 {
+				pos += 3 << (packed&0x07 + 1)
+			}
+			pos++ // LZW minimum code size
+			skipBlocks()
+		default:
+			pos = len(data)
+		}
+	}
+	if frames > 1 {
+		fields = append(fields, viewerImageField{label: "Frames", value: fmt.Sprintf("%d (animated)", frames)})
+		switch loop {
+		case -1:
+		case 0:
+			fields = append(fields, viewerImageField{label: "Loop", value: "forever"})
+		default:
+			fields = append(fields, viewerImageField{label: "Loop", value: fmt.Sprintf("%d time(s)", loop)})
+		}
+	}
+	return fields
+}
+
+func readSVGImageFields(data []byte) []viewerImageField {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var fields []viewerImageField
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fields
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "svg" {
+			return fields
+		}
+		var width, height string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = attr.Value
+			case "height":
+				height = attr.Value
+			case "viewBox":
+				fields = appendViewerImageField(fields, "View box", attr.Value)
+			}
+		}
+		if width != "" && height != "" {
+			fields = append([]viewerImageField{{label: "Size", value: width + " × " + height}}, fields...)
+		}
+		return fields
+	}
+}
+
+// viewerEXIFDirectory is one parsed TIFF image file directory.
+type viewerEXIFDirectory struct {
+	order   binary.ByteOrder
+	entries map[uint16]viewerEXIFValue
+}
+
+type viewerEXIFValue struct {
+	kind  uint16
+	count int
+	raw   []byte
+}
+
+// readEXIFFields parses a TIFF-structured EXIF payload and returns the tags a
+// person browsing photos cares about.
+func readEXIFFields(tiff []byte) []viewerImageField {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return nil
+	}
+	ifd0 := readEXIFDirectory(tiff, order, order.Uint32(tiff[4:]))
+	exif := readEXIFDirectory(tiff, order, ifd0.uint(0x8769))
+	gps := readEXIFDirectory(tiff, order, ifd0.uint(0x8825))
+
+	var fields []viewerImageField
+	add := func(label, value string) {
+		fields = appendViewerImageField(fields, label, value)
+	}
+	maker, model := ifd0.text(0x010F), ifd0.text(0x0110)
+	if strings.HasPrefix(model, maker) {
+		maker = ""
+	}
+	add("Camera", strings.TrimSpace(maker+" "+model))
+	add("Lens", exif.text(0xA434))
+	add("Taken", firstNonEmpty(exif.text(0x9003), ifd0.text(0x0132)))
+	if exposure, ok := exif.rational(0x829A, 0); ok && exposure > 0 {
+		if exposure < 1 {
+			add("Exposure", fmt.Sprintf("1/%.0f s", 1/exposure))
+		} else {
+			add("Exposure", fmt.Sprintf("%g s", exposure))
+		}
+	}
+	if aperture, ok := exif.rational(0x829D, 0); ok && aperture > 0 {
+		add("Aperture", fmt.Sprintf("f/%.1f", aperture))
+	}
+	if iso := exif.uint(0x8827); iso > 0 {
+		add("ISO", fmt.Sprintf("%d", iso))
+	}
+	if focal, ok := exif.rational(0x920A, 0); ok && focal > 0 {
+		add("Focal length", fmt.Sprintf("%.0f mm", focal))
+	}
+	if orientation := ifd0.uint(0x0112); orientation > 1 && orientation <= 8 {
+		add("Orientation", exifOrientationLabels[orientation])
+	}
+	if lat, ok := gps.coordinate(2, gps.text(1)); ok {
+		if lon, ok := gps.coordinate(4, gps.text(3)); ok {
+			add("Location", fmt.Sprintf("%.5f, %.5f", lat, lon))
+		}
+	}
+	add("Software", ifd0.text(0x0131))
+	add("Artist", ifd0.text(0x013B))
+	add("Copyright", ifd0.text(0x8298))
+	return fields
+}
+
+var exifOrientationLabels = map[uint32]string{
+	2: "mirrored",
+	3: "rotated 180°",
+	4: "mirrored, rotated 180°",
+	5: "mirrored, rotated 90° CW",
+	6: "rotated 90° CW",
+	7: "mirrored, rotated 90° CCW",
+	8: "rotated 90° CCW",
+}
+
+func readEXIFDirectory(tiff []byte, order binary.ByteOrder, offset uint32) viewerEXIFDirectory {
+	dir := viewerEXIFDirectory{order: order, entries: map[uint16]viewerEXIFValue{}}
+	if offset == 0 || int64(offset)+2 > int64(len(tiff)) {
+		return dir
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(tiff) {
+			break
+		}
+		entry := tiff[start : start+12]
+		kind := order.Uint16(entry[2:])
+		n := order.Uint32(entry[4:])
+		unit := exifTypeSize(kind)
+		if unit == 0 || n > uint32(len(tiff)) {
+			continue
+		}
+		size := int(n) * unit
+		var raw []byte
+		if size <= 4 {
+			raw = entry[8 : 8+size]
+		} else {
+			valueOffset := int64(order.Uint32(entry[8:]))
+			if valueOffset+int64(size) > int64(len(tiff)) {
+				continue
+			}
+			raw = tiff[valueOffset : valueOffset+int64(size)]
+		}
+		dir.entries[order.Uint16(entry)] = viewerEXIFValue{kind: kind, count: int(n), raw: raw}
+	}
+	return dir
+}
+
+func exifTypeSize(kind uint16) int {
+	switch kind {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9:
+		return 4
+	case 5, 10:
+		return 8
+	default:
+		return 0
+	}
+}
+
+func (d viewerEXIFDirectory) text(tag uint16) string {
+	value, ok := d.entries[tag]
+	if !ok || value.kind != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(value.raw), "\x00"))
+}
+
+func (d viewerEXIFDirectory) uint(tag uint16) uint32 {
+	value, ok := d.entries[tag]
+	if !ok || value.count == 0 {
+		return 0
+	}
+	switch value.kind {
+	case 3:
+		return uint32(d.order.Uint16(value.raw))
+	case 4:
+		return d.order.Uint32(value.raw)
+	default:
+		return 0
+	}
+}
+
+func (d viewerEXIFDirectory) rational(tag uint16, index int) (float64, bool) {
+	value, ok := d.entries[tag]
+	if !ok || (value.kind != 5 && value.kind != 10) || index >= value.count {
+		return 0, false
+	}
+	raw := value.raw[index*8:]
+	if value.kind == 10 {
+		num, den := int32(d.order.Uint32(raw)), int32(d.order.Uint32(raw[4:]))
+		if den == 0 {
+			return 0, false
+		}
+		return float64(num) / float64(den), true
+	}
+	num, den := d.order.Uint32(raw), d.order.Uint32(raw[4:])
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// coordinate reads a GPS degrees/minutes/seconds triple as signed degrees.
+func (d viewerEXIFDirectory) coordinate(tag uint16, ref string) (float64, bool) {
+	deg, ok1 := d.rational(tag, 0)
+	minutes, ok2 := d.rational(tag, 1)
+	seconds, ok3 := d.rational(tag, 2)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, false
+	}
+	value := deg + minutes/60 + seconds/3600
+	if ref == "S" || ref == "W" {
+		value = -value
+	}
+	return value, true
+}
+
+func appendViewerImageField(fields []viewerImageField, label, value string) []viewerImageField {
+	value = strings.TrimSpace(strings.ToValidUTF8(value, "�"))
+	if label == "" || value == "" {
+		return fields
+	}
+	if utf8.RuneCountInString(value) > 200 {
+		if label == "XMP" {
+			value = fmt.Sprintf("embedded (%s)", formatViewerByteSize(int64(len(value))))
+		} else {
+			value = string([]rune(value)[:200]) + "…"
+		}
+	}
+	return append(fields, viewerImageField{label: label, value: value})
+}
+
+func latin1String(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+func renderImageViewHTML(doc viewerDocument) string {
+	view := doc.image
+	if view == nil {
+		view = &viewerImageView{src: viewerImagePath}
+	}
+	var b strings.Builder
+	b.WriteString(`<div class="image-viewer" id="image-viewer">`)
+	fmt.Fprintf(&b, `<div class="image-stage" id="image-stage"><img id="image-el" src="%s" alt="%s" draggable="false"></div>`,
+		template.HTMLEscapeString(view.src), template.HTMLEscapeString(doc.fileName))
+	b.WriteString(`<div class="image-toolbar"><button type="button" data-zoom="out" title="Zoom out (-)">&minus;</button><span id="image-zoom">100%</span><button type="button" data-zoom="in" title="Zoom in (+)">+</button><button type="button" data-zoom="fit" title="Fit (F)">Fit</button><button type="button" data-zoom="actual" title="Actual size (0)">1:1</button></div>`)
+
+	b.WriteString(`<aside class="image-info"><h3>Details</h3><dl>`)
+	writeField := func(label, value string) {
+		fmt.Fprintf(&b, `<dt>%s</dt><dd>%s</dd>`, template.HTMLEscapeString(label), template.HTMLEscapeString(value))
+	}
+	writeField("Format", view.format)
+	if view.width > 0 && view.height > 0 {
+		writeField("Dimensions", fmt.Sprintf("%d × %d px", view.width, view.height))
+	}
+	writeField("File size", formatViewerByteSize(doc.size))
+	for _, field := range view.fields {
+		writeField(field.label, field.value)
+	}
+	b.WriteString(`</dl>`)
+	if len(view.palette) > 0 {
+		b.WriteString(`<h3>Palette</h3><div class="image-palette">`)
+		for _, c := range view.palette {
+			hex := template.HTMLEscapeString(c.Hex)
+			fmt.Fprintf(&b, `<button type="button" class="image-swatch" data-hex="%s" title="Copy %s"><span style="background:%s"></span>%s</button>`, hex, hex, hex, hex)
+		}
+		b.WriteString(`</div>`)
+	}
+	b.WriteString(`</aside></div>`)
+	fmt.Fprintf(&b, `<script>%s</script>`, viewerImageScript)
+	return b.String()
+}
+
+const viewerImageStyles = `
+    main { padding: 0; padding-right: 0; overflow: hidden; }
+    .viewer-surface { border: 0; border-radius: 0; background: transparent; height: 100%; }
+    .image-viewer {
+      display: grid;
+      grid-template-columns: 1fr 260px;
+      height: calc(100vh - 48px);
+      position: relative;
+    }
+    .image-stage {
+      position: relative;
+      overflow: hidden;
+      cursor: grab;
+      background-color: #eeece7;
+      background-image:
+        linear-gradient(45deg, rgba(0,0,0,0.05) 25%, transparent 25%, transparent 75%, rgba(0,0,0,0.05) 75%),
+        linear-gradient(45deg, rgba(0,0,0,0.05) 25%, transparent 25%, transparent 75%, rgba(0,0,0,0.05) 75%);
+      background-size: 20px 20px;
+      background-position: 0 0, 10px 10px;
+    }
+    .image-stage.dragging { cursor: grabbing; }
+    .image-stage img {
+      position: absolute;
+      left: 0;
+      top: 0;
+      transform-origin: 0 0;
+      user-select: none;
+      max-width: none;
+    }
+    .image-toolbar {
+      position: absolute;
+      left: 16px;
+      bottom: 16px;
+      display: flex;
+      align-items: center;
+      gap: 4px;
+      padding: 4px;
+      border-radius: 9px;
+      background: rgba(252, 251, 249, 0.94);
+      border: 0.5px solid rgba(0, 0, 0, 0.1);
+      box-shadow: 0 2px 10px rgba(0, 0, 0, 0.08);
+      font-size: 12px;
+    }
+    .image-toolbar button {
+      border: 0;
+      background: transparent;
+      border-radius: 6px;
+      padding: 4px 8px;
+      font: inherit;
+      cursor: pointer;
+      color: #1a1a18;
+    }
+    .image-toolbar button:hover { background: rgba(26, 26, 24, 0.07); }
+    #image-zoom { min-width: 46px; text-align: center; color: rgba(26, 26, 24, 0.55); font-variant-numeric: tabular-nums; }
+    .image-info {
+      border-left: 0.5px solid rgba(0, 0, 0, 0.08);
+      background: rgba(250, 249, 246, 0.97);
+      padding: 14px 16px;
+      overflow-y: auto;
+      font-size: 12.5px;
+    }
+    .image-info h3 {
+      font-size: 10px;
+      font-weight: 600;
+      letter-spacing: 0.08em;
+      text-transform: uppercase;
+      color: rgba(26, 26, 24, 0.35);
+      margin: 4px 0 10px;
+    }
+    .image-info h3:not(:first-child) { margin-top: 20px; }
+    .image-info dl { display: grid; grid-template-columns: auto 1fr; gap: 6px 12px; }
+    .image-info dt { color: rgba(26, 26, 24, 0.45); white-space: nowrap; }
+    .image-info dd { color: #1a1a18; word-break: break-word; }
+    .image-palette { display: grid; gap: 6px; }
+    .image-swatch {
+      display: flex;
+      align-items: center;
+      gap: 10px;
+      border: 0;
+      background: transparent;
+      padding: 3px;
+      border-radius: 6px;
+      cursor: pointer;
+      font: 12px "SF Mono", Consolas, monospace;
+      color: #1a1a18;
+      text-align: left;
+    }
+    .image-swatch:hover { background: rgba(26, 26, 24, 0.06); }
+    .image-swatch span { width: 28px; height: 20px; border-radius: 5px; border: 0.5px solid rgba(0, 0, 0, 0.12); flex: none; }
+    @media (max-width: 720px) {
+      .image-viewer { grid-template-columns: 1fr; grid-template-rows: 1fr auto; }
+      .image-info { border-left: 0; border-top: 0.5px solid rgba(0, 0, 0, 0.08); max-height: 40vh; }
+    }
+`
+
+const viewerImageScript = `
+(function() {
+  var stage = document.getElementById('image-stage');
+  var img = document.getElementById('image-el');
+  var label = document.getElementById('image-zoom');
+  var scale = 1, x = 0, y = 0, fitted = true;
+
+  function size() {
+    return {w: img.naturalWidth || 512, h: img.naturalHeight || 512};
+  }
+  function apply() {
+    img.style.transform = 'translate(' + x + 'px,' + y + 'px) scale(' + scale + ')';
+    img.style.imageRendering = scale >= 2 ? 'pixelated' : 'auto';
+    label.textContent = Math.round(scale * 100) + '%';
+  }
+  function center() {
+    var s = size();
+    x = (stage.clientWidth - s.w * scale) / 2;
+    y = (stage.clientHeight - s.h * scale) / 2;
+  }
+  function fit() {
+    var s = size();
+    scale = Math.min(1, (stage.clientWidth - 32) / s.w, (stage.clientHeight - 32) / s.h);
+    if (!(scale > 0)) scale = 1;
+    fitted = true;
+    center();
+    apply();
+  }
+  function actual() {
+    scale = 1;
+    fitted = false;
+    center();
+    apply();
+  }
+  function zoomAt(cx, cy, next) {
+    next = Math.max(0.05, Math.min(32, next));
+    x = cx - (cx - x) * (next / scale);
+    y = cy - (cy - y) * (next / scale);
+    scale = next;
+    fitted = false;
+    apply();
+  }
+  function zoomBy(factor) {
+    zoomAt(stage.clientWidth / 2, stage.clientHeight / 2, scale * factor);
+  }
+
+  if (img.complete) fit(); else img.addEventListener('load', fit);
+  window.addEventListener('resize', function() { if (fitted) fit(); });
+
+  stage.addEventListener('wheel', function(e) {
+    e.preventDefault();
+    var rect = stage.getBoundingClientRect();
+    zoomAt(e.clientX - rect.left, e.clientY - rect.top, scale * Math.exp(-e.deltaY * 0.0015));
+  }, {passive: false});
+
+  var drag = null;
+  stage.addEventListener('pointerdown', function(e) {
+    drag = {x: e.clientX - x, y: e.clientY - y};
+    stage.classList.add('dragging');
+    stage.setPointerCapture(e.pointerId);
+  });
+  stage.addEventListener('pointermove', function(e) {
+    if (!drag) return;
+    x = e.clientX - drag.x;
+    y = e.clientY - drag.y;
+    fitted = false;
+    apply();
+  });
+  stage.addEventListener('pointerup', function() {
+    drag = null;
+    stage.classList.remove('dragging');
+  });
+  stage.addEventListener('dblclick', function() { if (fitted) actual(); else fit(); });
+
+  document.querySelectorAll('[data-zoom]').forEach(function(button) {
+    button.addEventListener('click', function() {
+      var action = button.getAttribute('data-zoom');
+      if (action === 'in') zoomBy(1.25);
+      else if (action === 'out') zoomBy(0.8);
+      else if (action === 'fit') fit();
+      else actual();
+    });
+  });
+  document.addEventListener('keydown', function(e) {
+    if (e.metaKey || e.ctrlKey || e.altKey) return;
+    if (e.key === '+' || e.key === '=') zoomBy(1.25);
+    else if (e.key === '-') zoomBy(0.8);
+    else if (e.key === '0') actual();
+    else if (e.key === 'f' || e.key === 'F') fit();
+  });
+
+  document.querySelectorAll('.image-swatch').forEach(function(swatch) {
+    swatch.addEventListener('click', function() {
+      var hex = swatch.getAttribute('data-hex');
+      if (navigator.clipboard) navigator.clipboard.writeText(hex);
+      swatch.lastChild.textContent = 'copied';
+      setTimeout(function() { swatch.lastChild.textContent = hex; }, 900);
+    });
+  });
+})();
+`
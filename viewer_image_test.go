@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildTestEXIF returns a little-endian TIFF payload with a camera model, a
+// capture time, and an aperture in the EXIF sub-directory.
+func buildTestEXIF() []byte {
+	le := binary.LittleEndian
+	var b bytes.Buffer
+	b.WriteString("II")
+	_ = binary.Write(&b, le, uint16(42))
+	_ = binary.Write(&b, le, uint32(8))
+
+	model := "Canon EOS R5\x00"
+	taken := "2026:03:19 08:15:00\x00"
+	// IFD0: 3 entries at offset 8, then the next-IFD pointer.
+	ifd0Size := 2 + 3*12 + 4
+	makeOffset := 8 + ifd0Size
+	modelOffset := makeOffset + 6
+	exifOffset := modelOffset + len(model)
+	exifSize := 2 + 2*12 + 4
+	takenOffset := exifOffset + exifSize
+	fnumberOffset := takenOffset + len(taken)
+
+	entry := func(tag, kind uint16, count, value uint32) {
+		_ = binary.Write(&b, le, tag)
+		_ = binary.Write(&b, le, kind)
+		_ = binary.Write(&b, le, count)
+		_ = binary.Write(&b, le, value)
+	}
+	_ = binary.Write(&b, le, uint16(3))
+	entry(0x010F, 2, 6, uint32(makeOffset))
+	entry(0x0110, 2, uint32(len(model)), uint32(modelOffset))
+	entry(0x8769, 4, 1, uint32(exifOffset))
+	_ = binary.Write(&b, le, uint32(0))
+	b.WriteString("Canon\x00")
+	b.WriteString(model)
+
+	_ = binary.Write(&b, le, uint16(2))
+	entry(0x9003, 2, uint32(len(taken)), uint32(takenOffset))
+	entry(0x829D, 5, 1, uint32(fnumberOffset))
+	_ = binary.Write(&b, le, uint32(0))
+	b.WriteString(taken)
+	_ = binary.Write(&b, le, uint32(28))
+	_ = binary.Write(&b, le, uint32(10))
+	return b.Bytes()
+}
+
+func insertTestPNGChunks(t *testing.T, data []byte, chunks map[string][]byte, order ...string) []byte {
+	t.Helper()
+
+	// IHDR always follows the 8-byte signature and is 25 bytes long.
+	head, tail := data[:33], data[33:]
+	var out bytes.Buffer
+	out.Write(head)
+	for _, kind := range order {
+		body := chunks[kind]
+		_ = binary.Write(&out, binary.BigEndian, uint32(len(body)))
+		out.WriteString(kind)
+		out.Write(body)
+		_ = binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(kind), body...)))
+	}
+	out.Write(tail)
+	return out.Bytes()
+}
+
+func testTwoToneImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 30 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func viewerImageFieldMap(view *viewerImageView) map[string]string {
+	fields := map[string]string{}
+	for _, field := range view.fields {
+		fields[field.label] = field.value
+	}
+	return fields
+}
+
+func TestBuildViewerImageViewReadsPNGChunksAndPalette(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testTwoToneImage()); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	data := insertTestPNGChunks(t, encoded.Bytes(), map[string][]byte{
+		"tEXt": []byte("Title\x00Hero banner"),
+		"pHYs": {0, 0, 0x0B, 0x13, 0, 0, 0x0B, 0x13, 1},
+		"eXIf": buildTestEXIF(),
+	}, "tEXt", "pHYs", "eXIf")
+
+	view := buildViewerImageView("banner.png", data)
+	if view.width != 40 || view.height != 20 || view.format != "PNG" {
+		t.Fatalf("unexpected image basics: %+v", view)
+	}
+	fields := viewerImageFieldMap(view)
+	want := map[string]string{
+		"Title":      "Hero banner",
+		"Resolution": "72 dpi",
+		"Camera":     "Canon EOS R5",
+		"Taken":      "2026:03:19 08:15:00",
+		"Aperture":   "f/2.8",
+		"Color":      "RGB, 8-bit",
+	}
+	for label, value := range want {
+		if fields[label] != value {
+			t.Fatalf("field %q = %q, want %q (all: %v)", label, fields[label], value, fields)
+		}
+	}
+	if len(view.palette) != 2 || view.palette[0].Hex != "#ff0000" || view.palette[1].Hex != "#0000ff" {
+		t.Fatalf("unexpected palette: %+v", view.palette)
+	}
+}
+
+func TestBuildViewerImageViewSkipsThePaletteForHugeDimensions(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testTwoToneImage()); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	// Rewrite the IHDR so a few hundred bytes claim 100000x100000 pixels.
+	data := encoded.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], 100000)
+	binary.BigEndian.PutUint32(data[20:24], 100000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	view := buildViewerImageView("huge.png", data)
+	if view.width != 100000 || view.height != 100000 {
+		t.Fatalf("expected the declared size to be reported, got %dx%d", view.width, view.height)
+	}
+	if view.palette != nil {
+		t.Fatalf("expected no palette above the pixel cap, got %+v", view.palette)
+	}
+}
+
+func TestBuildViewerImageViewReadsJPEGExifAndToleratesJunk(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, testTwoToneImage(), nil); err != nil {
+		t.Fatalf("jpeg.Encode failed: %v", err)
+	}
+	payload := append([]byte("Exif\x00\x00"), buildTestEXIF()...)
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	data := append(append(append([]byte{}, encoded.Bytes()[:2]...), append(segment, payload...)...), encoded.Bytes()[2:]...)
+
+	fields := viewerImageFieldMap(buildViewerImageView("photo.jpg", data))
+	if fields["Camera"] != "Canon EOS R5" || !strings.HasPrefix(fields["Encoding"], "baseline, 8-bit") {
+		t.Fatalf("unexpected JPEG fields: %v", fields)
+	}
+
+	junk := append([]byte("Exif\x00\x00"), bytes.Repeat([]byte("jot"), 20)...)
+	if got := readEXIFFields(junk[6:]); len(got) != 0 {
+		t.Fatalf("expected no fields from junk EXIF, got %v", got)
+	}
+}
+
+func TestFolderViewerServesImagesAndGallery(t *testing.T) {
+	dir := t.TempDir()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testTwoToneImage()); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), encoded.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	writeTestFile(t, filepath.Join(dir, "icon.svg"), `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><script>alert(1)</script></svg>`)
+	writeTestFile(t, filepath.Join(dir, "notes.md"), "# notes\n")
+
+	files, err := scanFolderFiles(dir)
+	if err != nil {
+		t.Fatalf("scanFolderFiles returned error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected png, svg, and md to be listed, got %+v", files)
+	}
+
+	server := httptest.NewServer(newFolderViewerHandler(dir, files, func() {}))
+	defer server.Close()
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, page := get("/")
+	if !strings.Contains(page, "showGallery") || !strings.Contains(page, `"docType":"image"`) {
+		t.Fatal("expected folder page to offer a gallery for images")
+	}
+
+	_, viewer := get("/file?i=0")
+	for _, want := range []string{`src="/image?i=0"`, "40 × 20 px", "#ff0000", "image-stage"} {
+		if !strings.Contains(viewer, want) {
+			t.Fatalf("expected image viewer page to contain %q", want)
+		}
+	}
+
+	resp, _ := get("/image?i=1")
+	if resp.Header.Get("Content-Type") != "image/svg+xml" || !strings.Contains(resp.Header.Get("Content-Security-Policy"), "sandbox") {
+		t.Fatalf("expected sandboxed SVG response, got %v", resp.Header)
+	}
+	if resp, _ := get("/image?i=2"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected /image to refuse non-image files, got %d", resp.StatusCode)
+	}
+}
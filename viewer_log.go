@@ -0,0 +1,714 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	viewerLogMaxEntries = 5000
+	viewerLogMaxSources = 12
+	viewerLogTailLimit  = 1 << 20
+)
+
+type viewerLogFormat string
+
+const (
+	viewerLogFormatPlain  viewerLogFormat = "plain"
+	viewerLogFormatJSON   viewerLogFormat = "json"
+	viewerLogFormatLogfmt viewerLogFormat = "logfmt"
+	viewerLogFormatSyslog viewerLogFormat = "syslog"
+	viewerLogFormatGo     viewerLogFormat = "go"
+)
+
+type viewerLogEntry struct {
+	Line    int    `json:"line"`
+	Time    string `json:"time,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+	Raw     string `json:"raw"`
+}
+
+type viewerLogCount struct {
+	Name  string
+	Count int
+}
+
+type viewerLogView struct {
+	Format    viewerLogFormat
+	Entries   []viewerLogEntry
+	Skipped   int
+	Levels    []viewerLogCount
+	Sources   []viewerLogCount
+	EndOffset int64
+}
+
+var (
+	viewerLogLevelOrder = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+	viewerLogGoPrefix   = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2})(?:\.\d+)? (?:([\w./-]+\.go:\d+): )?(.*)$`)
+	viewerLogSyslog3164 = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^:\[\s]+)(?:\[\d+\])?: ?(.*)$`)
+	viewerLogSyslog5424 = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) \S+ \S+ (?:-|\[.*?\]) ?(.*)$`)
+	viewerLogLeadingTS  = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)\]?\s+(.*)$`)
+	viewerLogLevelWord  = regexp.MustCompile(`^\[?(TRACE|DEBUG|DBG|INFO|INF|NOTICE|WARN|WARNING|WRN|ERROR|ERR|FATAL|CRITICAL|CRIT|PANIC)\]?:?\s+(.*)$`)
+	viewerLogBareLevel  = regexp.MustCompile(`(?i)\b(trace|debug|info|warn|warning|error|fatal|panic|critical)\b`)
+)
+
+func buildViewerLogView(content string, endOffset int64) *viewerLogView {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	view := &viewerLogView{Format: detectViewerLogFormat(lines), EndOffset: endOffset}
+	first := 0
+	if len(lines) > viewerLogMaxEntries {
+		first = len(lines) - viewerLogMaxEntries
+		view.Skipped = first
+	}
+	now := time.Now()
+	for i := first; i < len(lines); i++ {
+		view.Entries = append(view.Entries, parseViewerLogLine(lines[i], i+1, now))
+	}
+	view.Levels, view.Sources = countViewerLogFacets(view.Entries)
+	return view
+}
+
+// detectViewerLogFormat picks the format most of the first non-empty lines
+// agree on, so a stray banner line does not flip the whole file.
+func detectViewerLogFormat(lines []string) viewerLogFormat {
+	votes := map[viewerLogFormat]int{}
+	sampled := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		votes[classifyViewerLogLine(line)]++
+		sampled++
+		if sampled >= 50 {
+			break
+		}
+	}
+	best := viewerLogFormatPlain
+	for _, format := range []viewerLogFormat{viewerLogFormatJSON, viewerLogFormatLogfmt, viewerLogFormatSyslog, viewerLogFormatGo} {
+		if votes[format] > votes[best] {
+			best = format
+		}
+	}
+	return best
+}
+
+func classifyViewerLogLine(line string) viewerLogFormat {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)):
+		return viewerLogFormatJSON
+	case viewerLogSyslog5424.MatchString(trimmed) || viewerLogSyslog3164.MatchString(trimmed):
+		return viewerLogFormatSyslog
+	case viewerLogGoPrefix.MatchString(trimmed):
+		return viewerLogFormatGo
+	}
+	pairs := parseLogfmtPairs(trimmed)
+	if _, ok := pairs["level"]; ok && len(pairs) >= 2 {
+		return viewerLogFormatLogfmt
+	}
+	if _, ok := pairs["msg"]; ok && len(pairs) >= 2 {
+		return viewerLogFormatLogfmt
+	}
+	return viewerLogFormatPlain
+}
+
+// parseViewerLogLine classifies each line on its own, so files that mix
+// formats (a plain banner ahead of JSON lines) still read well.
+func parseViewerLogLine(line string, lineNo int, now time.Time) viewerLogEntry {
+	entry := viewerLogEntry{Line: lineNo, Raw: line, Message: line}
+	trimmed := strings.TrimSpace(line)
+	parsed := false
+	switch classifyViewerLogLine(trimmed) {
+	case viewerLogFormatJSON:
+		parsed = parseViewerLogJSON(trimmed, &entry)
+	case viewerLogFormatLogfmt:
+		parsed = parseViewerLogfmt(trimmed, &entry)
+	case viewerLogFormatSyslog:
+		parsed = parseViewerLogSyslog(trimmed, &entry, now)
+	case viewerLogFormatGo:
+		parsed = parseViewerLogGo(trimmed, &entry)
+	}
+	if !parsed {
+		parseViewerLogPlain(trimmed, &entry)
+	}
+	if entry.Level == "" {
+		if match := viewerLogBareLevel.FindStringSubmatch(entry.Message); match != nil {
+			entry.Level = normalizeViewerLogLevel(match[1])
+		}
+	}
+	return entry
+}
+
+func parseViewerLogJSON(line string, entry *viewerLogEntry) bool {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return false
+	}
+	entry.Level = normalizeViewerLogLevel(firstViewerLogField(fields, "level", "lvl", "severity", "levelname", "log.level"))
+	entry.Source = firstViewerLogField(fields, "logger", "source", "component", "service", "name", "module", "caller")
+	if msg := firstViewerLogField(fields, "msg", "message", "event"); msg != "" {
+		entry.Message = msg
+	}
+	if raw, ok := firstViewerLogValue(fields, "time", "ts", "timestamp", "@timestamp", "t"); ok {
+		entry.Time = viewerLogTimeValue(raw)
+	}
+	return true
+}
+
+func firstViewerLogValue(fields map[string]any, keys ...string) (any, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func firstViewerLogField(fields map[string]any, keys ...string) string {
+	value, ok := firstViewerLogValue(fields, keys...)
+	if !ok {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func viewerLogTimeValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		sec := int64(v)
+		switch {
+		case v > 1e17:
+			return time.Unix(0, int64(v)).UTC().Format(time.RFC3339Nano)
+		case v > 1e11:
+			return time.UnixMilli(int64(v)).UTC().Format(time.RFC3339Nano)
+		default:
+			return time.Unix(sec, int64((v-float64(sec))*1e9)).UTC().Format(time.RFC3339Nano)
+		}
+	case string:
+		return parseViewerLogTime(v, time.Now())
+	default:
+		return ""
+	}
+}
+
+func parseViewerLogfmt(line string, entry *viewerLogEntry) bool {
+	pairs := parseLogfmtPairs(line)
+	if len(pairs) == 0 {
+		return false
+	}
+	entry.Level = normalizeViewerLogLevel(firstNonEmpty(pairs["level"], pairs["lvl"], pairs["severity"]))
+	entry.Source = firstNonEmpty(pairs["logger"], pairs["source"], pairs["component"], pairs["module"], pairs["caller"])
+	if msg := firstNonEmpty(pairs["msg"], pairs["message"]); msg != "" {
+		entry.Message = msg
+	}
+	if ts := firstNonEmpty(pairs["time"], pairs["ts"], pairs["timestamp"], pairs["t"]); ts != "" {
+		entry.Time = parseViewerLogTime(ts, time.Now())
+	}
+	return true
+}
+
+// parseLogfmtPairs reads key=value and key="quoted value" pairs. Bare words
+// without '=' are ignored rather than rejected so prefixed lines still parse.
+func parseLogfmtPairs(line string) map[string]string {
+	pairs := map[string]string{}
+	s := line
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t")
+		eq := strings.IndexByte(s, '=')
+		space := strings.IndexAny(s, " \t")
+		if eq <= 0 || (space >= 0 && space < eq) {
+			if space < 0 {
+				break
+			}
+			s = s[space:]
+			continue
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				end = len(s) - 1
+			}
+			if unquoted, err := strconv.Unquote(s[:end+1]); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(s[:end+1], `"`)
+			}
+			s = s[end+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		pairs[key] = value
+	}
+	return pairs
+}
+
+func parseViewerLogSyslog(line string, entry *viewerLogEntry, now time.Time) bool {
+	if m := viewerLogSyslog5424.FindStringSubmatch(line); m != nil {
+		entry.Level = viewerLogSyslogSeverity(m[1])
+		entry.Time = parseViewerLogTime(m[2], now)
+		entry.Source = m[4]
+		entry.Message = m[5]
+		return true
+	}
+	if m := viewerLogSyslog3164.FindStringSubmatch(line); m != nil {
+		entry.Level = viewerLogSyslogSeverity(m[1])
+		entry.Time = parseViewerLogTime(m[2], now)
+		entry.Source = m[4]
+		entry.Message = m[5]
+		return true
+	}
+	return false
+}
+
+func viewerLogSyslogSeverity(pri string) string {
+	n, err := strconv.Atoi(pri)
+	if err != nil {
+		return ""
+	}
+	switch severity := n % 8; {
+	case severity <= 2:
+		return "fatal"
+	case severity == 3:
+		return "error"
+	case severity == 4:
+		return "warn"
+	case severity <= 6:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+func parseViewerLogGo(line string, entry *viewerLogEntry) bool {
+	m := viewerLogGoPrefix.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	entry.Time = parseViewerLogTime(m[1], time.Now())
+	entry.Source = m[2]
+	entry.Message = m[3]
+	if lm := viewerLogLevelWord.FindStringSubmatch(entry.Message); lm != nil {
+		entry.Level = normalizeViewerLogLevel(lm[1])
+		entry.Message = lm[2]
+	}
+	return true
+}
+
+func parseViewerLogPlain(line string, entry *viewerLogEntry) {
+	rest := line
+	if m := viewerLogLeadingTS.FindStringSubmatch(rest); m != nil {
+		if ts := parseViewerLogTime(m[1], time.Now()); ts != "" {
+			entry.Time = ts
+			rest = m[2]
+		}
+	}
+	if m := viewerLogLevelWord.FindStringSubmatch(rest); m != nil {
+		entry.Level = normalizeViewerLogLevel(m[1])
+		rest = m[2]
+	}
+	entry.Message = rest
+}
+
+// parseViewerLogTime normalizes the timestamp shapes common in logs into
+// RFC 3339, leaning on parseHumanTimestamp for everything it already knows.
+func parseViewerLogTime(text string, now time.Time) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	candidates := []string{text}
+	// 2026-03-24 09:00:00,123 (Python) and 2026/03/24 09:00:00 (Go log).
+	normalized := strings.Replace(text, ",", ".", 1)
+	if len(normalized) >= 10 && normalized[4] == '/' && normalized[7] == '/' {
+		normalized = strings.ReplaceAll(normalized[:10], "/", "-") + normalized[10:]
+	}
+	if normalized != text {
+		candidates = append(candidates, normalized)
+	}
+	if dot := strings.IndexByte(normalized, '.'); dot > 0 && !strings.ContainsAny(normalized[dot:], "Z+-") {
+		candidates = append(candidates, normalized[:dot])
+	}
+	// Syslog stamps carry no year: "Mar 24 09:00:00".
+	if fields := strings.Fields(text); len(fields) == 3 && len(fields[0]) == 3 {
+		candidates = append(candidates, fmt.Sprintf("%s %s %d %s", fields[0], fields[1], now.Year(), fields[2]))
+	}
+	for _, candidate := range candidates {
+		if parsed, _, err := parseHumanTimestamp(candidate, time.Local); err == nil {
+			return parsed.Format(time.RFC3339Nano)
+		}
+	}
+	return ""
+}
+
+func normalizeViewerLogLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace", "trc":
+		return "trace"
+	case "debug", "dbg", "d":
+		return "debug"
+	case "info", "inf", "information", "notice", "i":
+		return "info"
+	case "warn", "warning", "wrn", "w":
+		return "warn"
+	case "error", "err", "e":
+		return "error"
+	case "fatal", "critical", "crit", "panic", "emerg", "alert", "dpanic":
+		return "fatal"
+	default:
+		return ""
+	}
+}
+
+func countViewerLogFacets(entries []viewerLogEntry) ([]viewerLogCount, []viewerLogCount) {
+	levelCounts := map[string]int{}
+	sourceCounts := map[string]int{}
+	for _, entry := range entries {
+		if entry.Level != "" {
+			levelCounts[entry.Level]++
+		}
+		if entry.Source != "" {
+			sourceCounts[entry.Source]++
+		}
+	}
+	var levels []viewerLogCount
+	for _, level := range viewerLogLevelOrder {
+		if levelCounts[level] > 0 {
+			levels = append(levels, viewerLogCount{Name: level, Count: levelCounts[level]})
+		}
+	}
+	var sources []viewerLogCount
+	for name, count := range sourceCounts {
+		sources = append(sources, viewerLogCount{Name: name, Count: count})
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Count != sources[j].Count {
+			return sources[i].Count > sources[j].Count
+		}
+		return sources[i].Name < sources[j].Name
+	})
+	if len(sources) > viewerLogMaxSources {
+		sources = sources[:viewerLogMaxSources]
+	}
+	return levels, sources
+}
+
+// readViewerLogTail returns complete lines appended after offset. A file that
+// shrank was truncated or rotated, so reading restarts from the top. A line
+// longer than viewerLogTailLimit is cut short with "…" and the rest of it is
+// skipped, so the tail keeps moving.
+func readViewerLogTail(path string, offset int64, firstLine int) ([]viewerLogEntry, int64, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, offset, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, offset, false, err
+	}
+	reset := false
+	if info.Size() < offset {
+		offset, firstLine, reset = 0, 1, true
+	}
+	if info.Size() == offset {
+		return nil, offset, reset, nil
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, reset, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, viewerLogTailLimit))
+	if err != nil {
+		return nil, offset, reset, err
+	}
+	end := strings.LastIndexByte(string(data), '\n')
+	if end < 0 {
+		if len(data) < viewerLogTailLimit {
+			return nil, offset, reset, nil
+		}
+		skipped, err := skipViewerLogLine(file)
+		if err != nil {
+			return nil, offset, reset, err
+		}
+		line := strings.ToValidUTF8(strings.TrimRight(string(data), "\r"), "") + "…"
+		entry := parseViewerLogLine(line, firstLine, time.Now())
+		return []viewerLogEntry{entry}, offset + int64(len(data)) + skipped, reset, nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data[:end]), "\r\n", "\n"), "\n")
+	now := time.Now()
+	entries := make([]viewerLogEntry, 0, len(lines))
+	for i, line := range lines {
+		entries = append(entries, parseViewerLogLine(line, firstLine+i, now))
+	}
+	return entries, offset + int64(end+1), reset, nil
+}
+
+// skipViewerLogLine reads r up to and including the next newline, or to its
+// end, and reports how many bytes that was.
+func skipViewerLogLine(r io.Reader) (int64, error) {
+	reader := bufio.NewReader(r)
+	var skipped int64
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		skipped += int64(len(chunk))
+		switch err {
+		case nil, io.EOF:
+			return skipped, nil
+		case bufio.ErrBufferFull:
+		default:
+			return skipped, err
+		}
+	}
+}
+
+func registerViewerLogRoutes(mux *http.ServeMux, target viewerStreamTarget, touch func()) {
+	mux.HandleFunc("/tail", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		path, docType, ok := target(r)
+		if !ok || docType != viewerDocumentTypeLog {
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			return
+		}
+		offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		if err != nil || offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		line, err := strconv.Atoi(r.URL.Query().Get("line"))
+		if err != nil || line < 1 {
+			line = 1
+		}
+		entries, next, reset, err := readViewerLogTail(path, offset, line)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []viewerLogEntry{}
+		}
+		writeViewerJSON(w, map[string]any{"offset": next, "reset": reset, "entries": entries})
+	})
+}
+
+func renderLogViewHTML(view viewerLogView) string {
+	var b strings.Builder
+	nextLine := 1
+	if n := len(view.Entries); n > 0 {
+		nextLine = view.Entries[n-1].Line + 1
+	}
+	fmt.Fprintf(&b, `<div class="code-frame log-frame" id="log-root" data-offset="%d" data-line="%d">`, view.EndOffset, nextLine)
+	b.WriteString(`<div class="log-bar"><span class="viewer-meta">`)
+	fmt.Fprintf(&b, "%s · %d entries", template.HTMLEscapeString(viewerLogFormatLabel(view.Format)), len(view.Entries))
+	if view.Skipped > 0 {
+		fmt.Fprintf(&b, " · last %d lines shown", len(view.Entries))
+	}
+	b.WriteString(`</span><span class="log-chips" id="log-levels">`)
+	for _, level := range view.Levels {
+		fmt.Fprintf(&b, `<button type="button" class="log-chip lvl-%s active" data-level="%s">%s <span>%d</span></button>`, level.Name, level.Name, level.Name, level.Count)
+	}
+	b.WriteString(`</span>`)
+	if len(view.Sources) > 0 {
+		b.WriteString(`<span class="log-chips" id="log-sources">`)
+		for _, source := range view.Sources {
+			name := template.HTMLEscapeString(source.Name)
+			fmt.Fprintf(&b, `<button type="button" class="log-chip active" data-source="%s">%s <span>%d</span></button>`, name, name, source.Count)
+		}
+		b.WriteString(`</span>`)
+	}
+	b.WriteString(`<input type="search" id="log-filter" placeholder="Filter"><label class="log-follow"><input type="checkbox" id="log-follow"> Follow</label></div>`)
+	b.WriteString(`<table class="line-table log-table"><tbody id="log-body">`)
+	for _, entry := range view.Entries {
+		b.WriteString(renderLogEntryRow(entry))
+	}
+	b.WriteString(`</tbody></table></div>`)
+	fmt.Fprintf(&b, `<script>%s</script>`, viewerLogScript)
+	return b.String()
+}
+
+func renderLogEntryRow(entry viewerLogEntry) string {
+	level := entry.Level
+	if level == "" {
+		level = "none"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<tr class="lvl-%s" data-level="%s" data-source="%s"><td class="ln">%d</td>`, level, level, template.HTMLEscapeString(entry.Source), entry.Line)
+	b.WriteString(`<td class="log-ts">`)
+	if entry.Time != "" {
+		fmt.Fprintf(&b, `<time datetime="%s">%s</time>`, template.HTMLEscapeString(entry.Time), template.HTMLEscapeString(viewerLogShortTime(entry.Time)))
+	}
+	b.WriteString(`</td><td class="log-lvl">`)
+	if entry.Level != "" {
+		b.WriteString(strings.ToUpper(entry.Level))
+	}
+	b.WriteString(`</td><td class="lc">`)
+	if entry.Source != "" {
+		fmt.Fprintf(&b, `<span class="log-src">%s</span> `, template.HTMLEscapeString(entry.Source))
+	}
+	fmt.Fprintf(&b, `<span class="log-msg" title="%s">%s</span></td></tr>`, template.HTMLEscapeString(entry.Raw), template.HTMLEscapeString(entry.Message))
+	return b.String()
+}
+
+func viewerLogShortTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Format("2006-01-02 15:04:05")
+}
+
+func viewerLogFormatLabel(format viewerLogFormat) string {
+	switch format {
+	case viewerLogFormatJSON:
+		return "JSON lines"
+	case viewerLogFormatLogfmt:
+		return "logfmt"
+	case viewerLogFormatSyslog:
+		return "syslog"
+	case viewerLogFormatGo:
+		return "Go log"
+	default:
+		return "plain log"
+	}
+}
+
+const viewerLogStyles = `
+.log-bar {
+  display: flex; align-items: center; gap: 10px; flex-wrap: wrap;
+  padding: 10px 16px; border-bottom: 0.5px solid rgba(0,0,0,0.07);
+  position: sticky; top: 0; background: rgba(252,251,249,0.97); z-index: 2;
+  font-family: -apple-system, BlinkMacSystemFont, "Inter", "Segoe UI", sans-serif;
+}
+.log-bar .viewer-meta { margin-right: auto; }
+.log-chips { display: inline-flex; gap: 4px; flex-wrap: wrap; }
+.log-chip {
+  border: 0.5px solid rgba(0,0,0,0.12); background: white; border-radius: 999px;
+  padding: 2px 9px; font-size: 11px; cursor: pointer; color: rgba(26,26,24,0.4);
+  text-transform: lowercase;
+}
+.log-chip span { opacity: 0.6; margin-left: 2px; }
+.log-chip.active { color: #1a1a18; background: rgba(26,26,24,0.06); }
+#log-filter { border: 0.5px solid rgba(0,0,0,0.12); border-radius: 6px; padding: 3px 8px; font-size: 12px; width: 160px; }
+.log-follow { font-size: 12px; color: rgba(26,26,24,0.6); display: inline-flex; gap: 4px; align-items: center; }
+.log-table td { vertical-align: top; }
+.log-table .log-ts { white-space: nowrap; padding: 0 12px; color: rgba(26,26,24,0.38); font-variant-numeric: tabular-nums; }
+.log-table .log-lvl { white-space: nowrap; padding: 0 8px; font-size: 11px; font-weight: 600; letter-spacing: 0.04em; }
+.log-table .lc { white-space: pre-wrap; word-break: break-word; }
+.log-src { color: #8b3ab8; }
+.lvl-trace .log-lvl, .log-chip.lvl-trace.active { color: rgba(26,26,24,0.35); }
+.lvl-debug .log-lvl, .log-chip.lvl-debug.active { color: #6b7f99; }
+.lvl-info .log-lvl, .log-chip.lvl-info.active { color: #1a6fb8; }
+.lvl-warn .log-lvl, .log-chip.lvl-warn.active { color: #b85c1a; }
+.lvl-error .log-lvl, .log-chip.lvl-error.active { color: #c0392b; }
+.lvl-fatal .log-lvl, .log-chip.lvl-fatal.active { color: #fff; background: #c0392b; }
+tr.lvl-error .lc { background: rgba(192,57,43,0.05); }
+tr.lvl-fatal .lc { background: rgba(192,57,43,0.1); }
+tr.lvl-warn .lc { background: rgba(184,92,26,0.04); }
+tr.log-new .lc { animation: log-flash 1.2s ease-out; }
+@keyframes log-flash { from { background: rgba(232,201,122,0.45); } }
+`
+
+const viewerLogScript = `
+(function() {
+  var root = document.getElementById('log-root');
+  if (!root) return;
+  var qs = location.search ? location.search + '&' : '?';
+  var body = document.getElementById('log-body');
+  var filter = document.getElementById('log-filter');
+  var follow = document.getElementById('log-follow');
+  var hiddenLevels = {}, hiddenSources = {};
+  var offset = parseInt(root.getAttribute('data-offset'), 10) || 0;
+  var nextLine = parseInt(root.getAttribute('data-line'), 10) || 1;
+  var timer = null;
+
+  function esc(s) {
+    return String(s == null ? '' : s).replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/>/g,'&gt;').replace(/"/g,'&quot;');
+  }
+  function visible(tr) {
+    if (hiddenLevels[tr.getAttribute('data-level')]) return false;
+    var src = tr.getAttribute('data-source');
+    if (src && hiddenSources[src]) return false;
+    var q = filter.value.trim().toLowerCase();
+    return !q || tr.textContent.toLowerCase().indexOf(q) >= 0;
+  }
+  function apply() {
+    Array.prototype.forEach.call(body.rows, function(tr) { tr.style.display = visible(tr) ? '' : 'none'; });
+  }
+  root.querySelectorAll('.log-chip').forEach(function(chip) {
+    chip.addEventListener('click', function() {
+      chip.classList.toggle('active');
+      var off = !chip.classList.contains('active');
+      if (chip.hasAttribute('data-level')) hiddenLevels[chip.getAttribute('data-level')] = off;
+      else hiddenSources[chip.getAttribute('data-source')] = off;
+      apply();
+    });
+  });
+  filter.addEventListener('input', apply);
+
+  function shortTime(t) {
+    var d = new Date(t);
+    if (isNaN(d)) return t;
+    function p(n) { return (n < 10 ? '0' : '') + n; }
+    return d.getFullYear() + '-' + p(d.getMonth() + 1) + '-' + p(d.getDate()) + ' ' + p(d.getHours()) + ':' + p(d.getMinutes()) + ':' + p(d.getSeconds());
+  }
+  function row(e) {
+    var lvl = e.level || 'none';
+    return '<tr class="lvl-' + lvl + ' log-new" data-level="' + lvl + '" data-source="' + esc(e.source) + '"><td class="ln">' + e.line + '</td>' +
+      '<td class="log-ts">' + (e.time ? '<time datetime="' + esc(e.time) + '">' + esc(shortTime(e.time)) + '</time>' : '') + '</td>' +
+      '<td class="log-lvl">' + (e.level ? e.level.toUpperCase() : '') + '</td>' +
+      '<td class="lc">' + (e.source ? '<span class="log-src">' + esc(e.source) + '</span> ' : '') + '<span class="log-msg" title="' + esc(e.raw) + '">' + esc(e.message) + '</span></td></tr>';
+  }
+  function poll() {
+    fetch('/tail' + qs + 'offset=' + offset + '&line=' + nextLine).then(function(r) { return r.json(); }).then(function(data) {
+      if (data.reset) body.innerHTML = '';
+      offset = data.offset;
+      if (!data.entries.length) return;
+      var html = '';
+      data.entries.forEach(function(e) { html += row(e); nextLine = e.line + 1; });
+      body.insertAdjacentHTML('beforeend', html);
+      apply();
+      window.scrollTo(0, document.body.scrollHeight);
+      var main = document.querySelector('main');
+      if (main) main.scrollTop = main.scrollHeight;
+    }).catch(function() {});
+  }
+  follow.addEventListener('change', function() {
+    if (timer) { clearInterval(timer); timer = null; }
+    if (follow.checked) { poll(); timer = setInterval(poll, 1000); }
+  });
+})();
+`
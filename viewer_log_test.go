@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectViewerLogFormatRecognizesCommonShapes(t *testing.T) {
+	cases := map[viewerLogFormat][]string{
+		viewerLogFormatJSON: {
+			`{"time":"2026-03-24T09:00:00Z","level":"info","msg":"booted","logger":"api"}`,
+			`{"time":"2026-03-24T09:00:01Z","level":"error","msg":"failed"}`,
+		},
+		viewerLogFormatLogfmt: {
+			`time=2026-03-24T09:00:00Z level=info msg="server started" component=http`,
+			`time=2026-03-24T09:00:02Z level=warn msg=slow component=db`,
+		},
+		viewerLogFormatSyslog: {
+			`Mar 24 09:00:00 web-1 sshd[412]: Accepted publickey for deploy`,
+			`<11>Mar 24 09:00:03 web-1 cron[88]: job failed`,
+		},
+		viewerLogFormatGo: {
+			`2026/03/24 09:00:00 listening on :8080`,
+			`2026/03/24 09:00:01 server.go:42: ERROR handler panicked`,
+		},
+		viewerLogFormatPlain: {
+			`2026-03-24T09:00:00Z INFO  jot viewer booted`,
+			`2026-03-24T09:00:04Z WARN  sample.env values masked before render`,
+		},
+	}
+	for want, lines := range cases {
+		if got := detectViewerLogFormat(lines); got != want {
+			t.Fatalf("detectViewerLogFormat(%q) = %q, want %q", lines, got, want)
+		}
+	}
+}
+
+func TestParseViewerLogLineExtractsLevelSourceAndTime(t *testing.T) {
+	now := time.Date(2026, 3, 24, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		line    string
+		level   string
+		source  string
+		message string
+		hasTime bool
+	}{
+		{`{"ts":1774342800,"level":"WARN","msg":"disk low","logger":"agent"}`, "warn", "agent", "disk low", true},
+		{`time=2026-03-24T09:00:00Z level=error msg="db timeout" component=store`, "error", "store", "db timeout", true},
+		{`<11>Mar 24 09:00:03 web-1 cron[88]: job failed`, "error", "cron", "job failed", true},
+		{`2026/03/24 09:00:01 server.go:42: ERROR handler panicked`, "error", "server.go:42", "handler panicked", true},
+		{`2026-03-24 09:00:00,512 DEBUG cache warmed`, "debug", "", "cache warmed", true},
+		{`plain line mentioning an error somewhere`, "error", "", "plain line mentioning an error somewhere", false},
+	}
+	for _, tc := range cases {
+		entry := parseViewerLogLine(tc.line, 7, now)
+		if entry.Level != tc.level || entry.Source != tc.source || entry.Message != tc.message {
+			t.Fatalf("parseViewerLogLine(%q) = %#v", tc.line, entry)
+		}
+		if (entry.Time != "") != tc.hasTime {
+			t.Fatalf("parseViewerLogLine(%q) time = %q, want present=%v", tc.line, entry.Time, tc.hasTime)
+		}
+		if entry.Line != 7 || entry.Raw != tc.line {
+			t.Fatalf("expected line number and raw text to be kept, got %#v", entry)
+		}
+	}
+}
+
+func TestLogViewerRendersLevelChipsAndFollowsAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	initial := "time=2026-03-24T09:00:00Z level=info msg=boot component=http\n" +
+		"time=2026-03-24T09:00:01Z level=error msg=<boom> component=db\n"
+	if err := os.WriteFile(path, []byte(initial), 0o600); err != nil {
+		t.Fatalf("write log failed: %v", err)
+	}
+	doc, err := loadViewerDocument(path)
+	if err != nil {
+		t.Fatalf("loadViewerDocument returned error: %v", err)
+	}
+	if doc.docType != viewerDocumentTypeLog || doc.logView == nil {
+		t.Fatalf("expected log document, got %#v", doc)
+	}
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+
+	page := fetchViewerBody(t, server.URL+"/")
+	for _, snippet := range []string{`id="log-root"`, `data-level="error"`, `data-source="db"`, `id="log-follow"`, "&lt;boom&gt;", "logfmt"} {
+		if !strings.Contains(page, snippet) {
+			t.Fatalf("expected log page to contain %q, got %q", snippet, page)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open for append failed: %v", err)
+	}
+	if _, err := file.WriteString("time=2026-03-24T09:00:02Z level=warn msg=late component=http\npartial"); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	_ = file.Close()
+
+	var tail struct {
+		Offset  int64            `json:"offset"`
+		Reset   bool             `json:"reset"`
+		Entries []viewerLogEntry `json:"entries"`
+	}
+	body := fetchViewerBody(t, server.URL+"/tail?offset="+strconv.FormatInt(doc.logView.EndOffset, 10)+"&line=3")
+	if err := json.Unmarshal([]byte(body), &tail); err != nil {
+		t.Fatalf("decode tail failed: %v", err)
+	}
+	if tail.Reset || len(tail.Entries) != 1 || tail.Entries[0].Level != "warn" || tail.Entries[0].Line != 3 {
+		t.Fatalf("expected one complete appended entry, got %#v", tail)
+	}
+
+	if err := os.WriteFile(path, []byte("level=info msg=rotated\n"), 0o600); err != nil {
+		t.Fatalf("rotate failed: %v", err)
+	}
+	body = fetchViewerBody(t, server.URL+"/tail?offset="+strconv.FormatInt(tail.Offset, 10)+"&line=4")
+	if err := json.Unmarshal([]byte(body), &tail); err != nil {
+		t.Fatalf("decode tail after rotate failed: %v", err)
+	}
+	if !tail.Reset || len(tail.Entries) != 1 || tail.Entries[0].Line != 1 {
+		t.Fatalf("expected reset after truncation, got %#v", tail)
+	}
+}
+
+func TestReadViewerLogTailSkipsPastALineLongerThanTheLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	long := strings.Repeat("x", viewerLogTailLimit+100)
+	if err := os.WriteFile(path, []byte(long+"\nlevel=info msg=next\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	entries, offset, _, err := readViewerLogTail(path, 0, 1)
+	if err != nil {
+		t.Fatalf("readViewerLogTail returned error: %v", err)
+	}
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Raw, "…") || len(entries[0].Raw) > viewerLogTailLimit+len("…") {
+		t.Fatalf("expected the long line cut short, got %d entries", len(entries))
+	}
+	if offset != int64(len(long)+1) {
+		t.Fatalf("expected the offset past the long line, got %d", offset)
+	}
+
+	entries, _, _, err = readViewerLogTail(path, offset, 2)
+	if err != nil || len(entries) != 1 || entries[0].Message != "next" || entries[0].Line != 2 {
+		t.Fatalf("expected the following line next, got %#v, %v", entries, err)
+	}
+}
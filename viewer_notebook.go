@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+const viewerDocumentTypeNotebook viewerDocumentType = "notebook"
+
+// viewerNotebookExportPath downloads the notebook as a standalone HTML page
+// in the single-file viewer.
+const viewerNotebookExportPath = "/export.html"
+
+var viewerANSIEscapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// viewerNotebook is a Jupyter notebook reduced to what the viewer renders.
+type viewerNotebook struct {
+	language   string
+	kernel     string
+	cells      []viewerNotebookCell
+	exportHref string
+}
+
+type viewerNotebookCell struct {
+	kind           string
+	source         string
+	executionCount *int
+	outputs        []viewerNotebookOutput
+}
+
+// viewerNotebookOutput is one stored output. Kind is "stream", "error",
+// "html", "markdown", "image", or "text".
+type viewerNotebookOutput struct {
+	kind   string
+	name   string
+	text   string
+	mime   string
+	base64 string
+}
+
+// notebookText accepts the two shapes nbformat uses for multi-line strings:
+// a single string or a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = notebookText(text)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+type notebookFile struct {
+	NBFormat int `json:"nbformat"`
+	Metadata struct {
+		Kernelspec struct {
+			DisplayName string `json:"display_name"`
+			Language    string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []struct {
+		CellType       string       `json:"cell_type"`
+		Source         notebookText `json:"source"`
+		ExecutionCount *int         `json:"execution_count"`
+		Outputs        []struct {
+			OutputType string                     `json:"output_type"`
+			Name       string                     `json:"name"`
+			Text       notebookText               `json:"text"`
+			Data       map[string]json.RawMessage `json:"data"`
+			EName      string                     `json:"ename"`
+			EValue     string                     `json:"evalue"`
+			Traceback  []string                   `json:"traceback"`
+		} `json:"outputs"`
+	} `json:"cells"`
+}
+
+// parseViewerNotebook reads an nbformat 4 notebook.
+func parseViewerNotebook(content []byte) (*viewerNotebook, error) {
+	var file notebookFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	if file.NBFormat < 4 {
+		return nil, errors.New("only nbformat 4 notebooks can be rendered")
+	}
+	nb := &viewerNotebook{
+		language:   firstNonEmpty(file.Metadata.LanguageInfo.Name, file.Metadata.Kernelspec.Language),
+		kernel:     file.Metadata.Kernelspec.DisplayName,
+		exportHref: viewerNotebookExportPath,
+	}
+	for _, raw := range file.Cells {
+		cell := viewerNotebookCell{
+			kind:           raw.CellType,
+			source:         string(raw.Source),
+			executionCount: raw.ExecutionCount,
+		}
+		for _, out := range raw.Outputs {
+			switch out.OutputType {
+			case "stream":
+				cell.outputs = append(cell.outputs, viewerNotebookOutput{kind: "stream", name: out.Name, text: stripNotebookANSI(string(out.Text))})
+			case "error":
+				text := out.EName + ": " + out.EValue
+				if len(out.Traceback) > 0 {
+					text = strings.Join(out.Traceback, "\n")
+				}
+				cell.outputs = append(cell.outputs, viewerNotebookOutput{kind: "error", text: stripNotebookANSI(text)})
+			case "execute_result", "display_data":
+				if output, ok := pickNotebookOutput(out.Data); ok {
+					cell.outputs = append(cell.outputs, output)
+				}
+			}
+		}
+		nb.cells = append(nb.cells, cell)
+	}
+	return nb, nil
+}
+
+// pickNotebookOutput chooses the richest representation the viewer can show
+// safely, in the same order Jupyter itself prefers them.
+func pickNotebookOutput(data map[string]json.RawMessage) (viewerNotebookOutput, bool) {
+	text := func(mime string) (string, bool) {
+		raw, ok := data[mime]
+		if !ok {
+			return "", false
+		}
+		var value notebookText
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", false
+		}
+		return string(value), true
+	}
+	for _, mime := range []string{"image/png", "image/jpeg"} {
+		if value, ok := text(mime); ok {
+			encoded := strings.Join(strings.Fields(value), "")
+			if _, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+				return viewerNotebookOutput{kind: "image", mime: mime, base64: encoded}, true
+			}
+		}
+	}
+	if value, ok := text("image/svg+xml"); ok {
+		return viewerNotebookOutput{kind: "image", mime: "image/svg+xml", base64: base64.StdEncoding.EncodeToString([]byte(value))}, true
+	}
+	if value, ok := text("text/html"); ok {
+		return viewerNotebookOutput{kind: "html", text: value}, true
+	}
+	if value, ok := text("text/markdown"); ok {
+		return viewerNotebookOutput{kind: "markdown", text: value}, true
+	}
+	if value, ok := text("text/plain"); ok {
+		return viewerNotebookOutput{kind: "text", text: stripNotebookANSI(value)}, true
+	}
+	return viewerNotebookOutput{}, false
+}
+
+func stripNotebookANSI(text string) string {
+	return viewerANSIEscapePattern.ReplaceAllString(text, "")
+}
+
+func renderNotebookHTML(nb viewerNotebook, fileName string) string {
+	var b strings.Builder
+	b.WriteString(`<div class="nb-frame"><div class="nb-bar"><span class="viewer-meta">`)
+	var meta []string
+	if label := firstNonEmpty(nb.kernel, nb.language); label != "" {
+		meta = append(meta, label)
+	}
+	meta = append(meta, fmt.Sprintf("%d cells", len(nb.cells)))
+	b.WriteString(template.HTMLEscapeString(strings.Join(meta, " · ")))
+	b.WriteString(`</span>`)
+	if nb.exportHref != "" {
+		fmt.Fprintf(&b, `<a class="nb-export" href="%s" download="%s">Export HTML</a>`,
+			template.HTMLEscapeString(nb.exportHref), template.HTMLEscapeString(notebookExportName(fileName)))
+	}
+	b.WriteString(`</div>`)
+
+	for _, cell := range nb.cells {
+		switch cell.kind {
+		case "markdown":
+			b.WriteString(`<section class="nb-cell nb-markdown"><div class="nb-prompt"></div><div class="nb-body text-frame">`)
+			b.WriteString(renderMarkdownHTML(cell.source))
+			b.WriteString(`</div></section>`)
+		case "code":
+			prompt := "In [ ]:"
+			if cell.executionCount != nil {
+				prompt = fmt.Sprintf("In [%d]:", *cell.executionCount)
+			}
+			fmt.Fprintf(&b, `<section class="nb-cell nb-code"><div class="nb-prompt">%s</div><div class="nb-body">`, prompt)
+			b.WriteString(`<div class="code-frame">` + renderCodeWithLineNumbers(cell.source, "") + `</div>`)
+			if len(cell.outputs) > 0 {
+				b.WriteString(`<div class="nb-outputs">`)
+				for _, output := range cell.outputs {
+					b.WriteString(renderNotebookOutput(output))
+				}
+				b.WriteString(`</div>`)
+			}
+			b.WriteString(`</div></section>`)
+		default:
+			b.WriteString(`<section class="nb-cell nb-raw"><div class="nb-prompt"></div><div class="nb-body"><pre class="nb-output">`)
+			b.WriteString(template.HTMLEscapeString(cell.source))
+			b.WriteString(`</pre></div></section>`)
+		}
+	}
+	b.WriteString(`</div>`)
+	fmt.Fprintf(&b, `<script>%s</script>`, viewerNotebookScript)
+	return b.String()
+}
+
+func renderNotebookOutput(output viewerNotebookOutput) string {
+	switch output.kind {
+	case "image":
+		return fmt.Sprintf(`<img class="nb-image" alt="output" src="data:%s;base64,%s">`, output.mime, template.HTMLEscapeString(output.base64))
+	case "html":
+		// Outputs are rendered in a sandbox without scripts so a notebook
+		// cannot run code on the viewer's origin.
+		return fmt.Sprintf(`<iframe class="nb-html" sandbox="allow-same-origin" srcdoc="%s"></iframe>`, template.HTMLEscapeString(output.text))
+	case "markdown":
+		return `<div class="nb-output text-frame">` + renderMarkdownHTML(output.text) + `</div>`
+	case "error":
+		return `<pre class="nb-output nb-error">` + template.HTMLEscapeString(output.text) + `</pre>`
+	case "stream":
+		class := "nb-output"
+		if output.name == "stderr" {
+			class += " nb-stderr"
+		}
+		return fmt.Sprintf(`<pre class="%s">%s</pre>`, class, template.HTMLEscapeString(output.text))
+	default:
+		return `<pre class="nb-output">` + template.HTMLEscapeString(output.text) + `</pre>`
+	}
+}
+
+func notebookExportName(fileName string) string {
+	return strings.TrimSuffix(fileName, ".ipynb") + ".html"
+}
+
+const viewerNotebookStyles = `
+    .nb-frame { max-width: 980px; margin: 0 auto; padding: 8px 0 60px; }
+    .nb-bar {
+      display: flex;
+      align-items: center;
+      justify-content: space-between;
+      gap: 12px;
+      margin: 0 0 14px 86px;
+    }
+    .nb-bar .viewer-meta { padding: 0; }
+    .nb-export {
+      font-size: 12px;
+      font-weight: 500;
+      padding: 4px 10px;
+      border-radius: 6px;
+      color: #1a1a18;
+      text-decoration: none;
+      border: 0.5px solid rgba(0, 0, 0, 0.14);
+      background: rgba(252, 251, 249, 0.97);
+    }
+    .nb-export:hover { background: rgba(26, 26, 24, 0.06); }
+    .nb-cell { display: grid; grid-template-columns: 76px 1fr; gap: 10px; margin-bottom: 14px; }
+    .nb-prompt {
+      font: 11.5px "SF Mono", Consolas, monospace;
+      color: rgba(26, 26, 24, 0.4);
+      text-align: right;
+      padding-top: 10px;
+      white-space: nowrap;
+    }
+    .nb-body { min-width: 0; }
+    .nb-markdown .text-frame, .nb-outputs .text-frame { max-width: none; margin: 0; padding: 4px 0; }
+    .nb-code .code-frame {
+      border: 0.5px solid rgba(0, 0, 0, 0.08);
+      border-radius: 8px;
+      background: rgba(252, 251, 249, 0.97);
+      overflow-x: auto;
+    }
+    .nb-outputs { padding: 8px 0 0; display: grid; gap: 8px; }
+    .nb-output {
+      font: 12.5px/1.55 "SF Mono", Consolas, monospace;
+      white-space: pre-wrap;
+      word-break: break-word;
+      color: rgba(26, 26, 24, 0.82);
+      margin: 0;
+    }
+    .nb-stderr { background: rgba(184, 92, 26, 0.07); padding: 6px 8px; border-radius: 6px; }
+    .nb-error { background: rgba(180, 30, 30, 0.07); color: #8c1d1d; padding: 8px 10px; border-radius: 6px; }
+    .nb-image { max-width: 100%; display: block; background: white; border-radius: 4px; }
+    iframe.nb-html { width: 100%; height: 80px; border: 0; background: transparent; }
+`
+
+const viewerNotebookScript = `
+(function() {
+  document.querySelectorAll('iframe.nb-html').forEach(function(frame) {
+    function fit() {
+      try {
+        frame.style.height = (frame.contentDocument.documentElement.scrollHeight + 4) + 'px';
+      } catch (e) {}
+    }
+    frame.addEventListener('load', fit);
+    fit();
+  });
+})();
+`
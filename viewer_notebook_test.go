@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {"kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}},
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Churn\n", "Reading **monthly** numbers."]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "source": "import pandas as pd\ndf.head()", "outputs": [
+   {"output_type": "stream", "name": "stdout", "text": ["loaded 12 rows\n"]},
+   {"output_type": "execute_result", "execution_count": 3, "metadata": {}, "data": {
+     "text/html": ["<table><tr><td>42</td></tr></table>"],
+     "text/plain": ["   a\n0  42"]
+   }},
+   {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0KGgo=\n", "text/plain": ["<Figure>"]}},
+   {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[0;31mValueError\u001b[0m: bad"]}
+  ]}
+ ]
+}`
+
+func TestParseViewerNotebookCollectsCellsAndOutputs(t *testing.T) {
+	nb, err := parseViewerNotebook([]byte(testNotebook))
+	if err != nil {
+		t.Fatalf("parseViewerNotebook returned error: %v", err)
+	}
+	if nb.kernel != "Python 3" || len(nb.cells) != 2 {
+		t.Fatalf("unexpected notebook: %+v", nb)
+	}
+	outputs := nb.cells[1].outputs
+	kinds := make([]string, len(outputs))
+	for i, output := range outputs {
+		kinds[i] = output.kind
+	}
+	if strings.Join(kinds, ",") != "stream,html,image,error" {
+		t.Fatalf("unexpected output kinds: %v", kinds)
+	}
+	if outputs[2].base64 != "iVBORw0KGgo=" || outputs[3].text != "ValueError: bad" {
+		t.Fatalf("expected cleaned image data and traceback, got %+v", outputs)
+	}
+
+	if _, err := parseViewerNotebook([]byte(`{"nbformat": 3, "worksheets": []}`)); err == nil {
+		t.Fatal("expected nbformat 3 to be rejected")
+	}
+}
+
+func TestNotebookViewerRendersAndExportsStandaloneHTML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "churn.ipynb")
+	if err := os.WriteFile(path, []byte(testNotebook), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	doc, err := loadViewerDocument(path)
+	if err != nil {
+		t.Fatalf("loadViewerDocument returned error: %v", err)
+	}
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, page := get("/")
+	for _, want := range []string{
+		"<strong>monthly</strong>",
+		"In [3]:",
+		`<td class="ln">2</td>`,
+		"loaded 12 rows",
+		`sandbox="allow-same-origin" srcdoc="&lt;table&gt;`,
+		`src="data:image/png;base64,iVBORw0KGgo="`,
+		"ValueError: bad",
+		`href="/export.html" download="churn.html"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected notebook page to contain %q", want)
+		}
+	}
+
+	resp, export := get("/export.html")
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), "churn.html") {
+		t.Fatalf("expected export to download as churn.html, got %v", resp.Header)
+	}
+	if strings.Contains(export, "/logo.png") || strings.Contains(export, "Export HTML") {
+		t.Fatal("expected standalone export to inline the logo and drop the export link")
+	}
+	if !strings.Contains(export, "data:image/png;base64,") || !strings.Contains(export, "In [3]:") {
+		t.Fatal("expected standalone export to carry the rendered notebook")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// viewerSessionTokenHeader lets scripts that are not a browser pass the
+	// session token without a cookie.
+	viewerSessionTokenHeader = "X-Jot-Token"
+
+	// viewerContentSecurityPolicy keeps pages from loading scripts, styles,
+	// or frames from anywhere but the session itself and from being framed
+	// by other sites. Inline scripts stay allowed because every page ships
+	// its script inline. Remote images are allowed so Markdown that embeds
+	// them still renders.
+	viewerContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data: blob: https:; media-src 'self' data: blob:; connect-src 'self'; frame-src 'self'; object-src 'self'; " +
+		"base-uri 'none'; form-action 'self'; frame-ancestors 'self'"
+)
+
+func newViewerSessionToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// viewerSessionCookieName is unique per session because cookies are shared
+// across every port on 127.0.0.1.
+func viewerSessionCookieName(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "jot_session_" + hex.EncodeToString(sum[:6])
+}
+
+// newViewerSessionGuard wraps a viewer handler so that only the window opened
+// from the printed URL can use it.
+//
+// The first request carries the token as ?token=; the guard swaps it for an
+// HttpOnly cookie and redirects to the same URL without it, so frames,
+// images, and fetches from the page are authorized by the cookie. Requests
+// whose Host is not a loopback name are refused, which stops a DNS
+// rebinding page from reaching the port under its own origin, and so are
+// requests that a browser marks as coming from another origin.
+func newViewerSessionGuard(next http.Handler, token string) http.Handler {
+	return newFramedViewerSessionGuard(next, token, "")
+}
+
+// newFramedViewerSessionGuard is newViewerSessionGuard for a session that is
+// shown in a frame of the page at ancestor, the way the viewer daemon shows
+// each tab. The ancestor may frame the session, and the frame's own
+// navigations are let through even though they come from another port.
+func newFramedViewerSessionGuard(next http.Handler, token, ancestor string) http.Handler {
+	cookieName := viewerSessionCookieName(token)
+	policy := viewerContentSecurityPolicy
+	if ancestor != "" {
+		policy += " " + ancestor
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Content-Security-Policy", policy)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+
+		if !viewerLoopbackHost(r.Host) {
+			http.Error(w, "unexpected Host header", http.StatusMisdirectedRequest)
+			return
+		}
+		if !viewerSameOriginRequest(r) && !(ancestor != "" && viewerFrameNavigation(r)) {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+
+		query := r.URL.Query()
+		if query.Has("token") {
+			if !viewerTokenMatches(query.Get("token"), token) {
+				http.Error(w, "invalid session token", http.StatusForbidden)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     cookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				query.Del("token")
+				target := r.URL.Path
+				if encoded := query.Encode(); encoded != "" {
+					target += "?" + encoded
+				}
+				http.Redirect(w, r, target, http.StatusSeeOther)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if cookie, err := r.Cookie(cookieName); err == nil && viewerTokenMatches(cookie.Value, token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if viewerTokenMatches(r.Header.Get(viewerSessionTokenHeader), token) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "this viewer session only opens from the URL jot printed when it started", http.StatusForbidden)
+	})
+}
+
+func viewerTokenMatches(got, token string) bool {
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// viewerLoopbackHost reports whether a Host header names this machine. The
+// port is not checked; only the listener's own port reaches the handler.
+func viewerLoopbackHost(host string) bool {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	if strings.EqualFold(name, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(name)
+	return ip != nil && ip.IsLoopback()
+}
+
+// viewerSameOriginRequest refuses requests a browser sends on behalf of
+// another origin. Origin is checked when present; Sec-Fetch-Site also
+// covers plain image, script, and frame loads, which carry no Origin, and
+// pages on another port of the same host, which count as the same site.
+// Requests from tools other than browsers carry neither header.
+func viewerSameOriginRequest(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+		return true
+	default:
+		return false
+	}
+}
+
+// viewerFrameNavigation reports whether r loads a document into a frame. A
+// navigation cannot read the response from the framing page, and
+// frame-ancestors decides which pages may show it.
+func viewerFrameNavigation(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return r.Header.Get("Sec-Fetch-Site") == "same-site" &&
+		r.Header.Get("Sec-Fetch-Mode") == "navigate" &&
+		r.Header.Get("Sec-Fetch-Dest") == "iframe" &&
+		r.Header.Get("Origin") == ""
+}
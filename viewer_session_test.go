@@ -0,0 +1,138 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestViewerSessionGuardNeedsTheToken(t *testing.T) {
+	token, err := newViewerSessionToken()
+	if err != nil || len(token) != 32 {
+		t.Fatalf("newViewerSessionToken = %q, %v", token, err)
+	}
+	handler := newViewerSessionGuard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok "+r.URL.RawQuery)
+	}), token)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(httptest.NewRequest("GET", "http://127.0.0.1:4000/", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a request without the token to be refused, got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Security-Policy") != viewerContentSecurityPolicy {
+		t.Fatal("expected the CSP on refused responses too")
+	}
+	if rec := serve(httptest.NewRequest("GET", "http://127.0.0.1:4000/?token=wrong", nil)); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a wrong token to be refused, got %d", rec.Code)
+	}
+
+	rec = serve(httptest.NewRequest("GET", "http://127.0.0.1:4000/raw?token="+token+"&line=3", nil))
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/raw?line=3" {
+		t.Fatalf("expected a redirect that drops the token, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].Value != token {
+		t.Fatalf("expected an HttpOnly session cookie, got %+v", cookies)
+	}
+
+	req := httptest.NewRequest("GET", "http://127.0.0.1:4000/raw?line=3", nil)
+	req.AddCookie(cookies[0])
+	rec = serve(req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok line=3" {
+		t.Fatalf("expected the cookie to authorize, got %d %q", rec.Code, rec.Body.String())
+	}
+	for _, header := range []string{"Content-Security-Policy", "X-Content-Type-Options", "Referrer-Policy"} {
+		if rec.Header().Get(header) == "" {
+			t.Fatalf("expected %s on every response", header)
+		}
+	}
+
+	req = httptest.NewRequest("POST", "http://localhost:4000/save", strings.NewReader("{}"))
+	req.Header.Set(viewerSessionTokenHeader, token)
+	if rec := serve(req); rec.Code != http.StatusOK {
+		t.Fatalf("expected the token header to authorize, got %d", rec.Code)
+	}
+}
+
+func TestViewerSessionGuardRefusesCrossOriginRequests(t *testing.T) {
+	const token = "session-token"
+	handler := newViewerSessionGuard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), token)
+
+	cases := []struct {
+		name   string
+		host   string
+		header map[string]string
+	}{
+		{name: "rebound host", host: "evil.example:4000"},
+		{name: "other origin", host: "127.0.0.1:4000", header: map[string]string{"Origin": "http://evil.example"}},
+		{name: "other port", host: "127.0.0.1:4000", header: map[string]string{"Origin": "http://127.0.0.1:5000"}},
+		{name: "opaque origin", host: "127.0.0.1:4000", header: map[string]string{"Origin": "null"}},
+		{name: "cross-site fetch", host: "127.0.0.1:4000", header: map[string]string{"Sec-Fetch-Site": "cross-site"}},
+		{name: "same-site fetch", host: "127.0.0.1:4000", header: map[string]string{"Sec-Fetch-Site": "same-site"}},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("POST", "http://"+tc.host+"/save", strings.NewReader("{}"))
+		req.Header.Set(viewerSessionTokenHeader, token)
+		for key, value := range tc.header {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK {
+			t.Fatalf("%s: expected the request to be refused", tc.name)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "http://[::1]:4000/save", strings.NewReader("{}"))
+	req.Header.Set(viewerSessionTokenHeader, token)
+	req.Header.Set("Origin", "http://[::1]:4000")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a same-origin request to pass, got %d", rec.Code)
+	}
+}
+
+func TestFramedViewerSessionGuardLetsTheAncestorFrameTheSession(t *testing.T) {
+	const token = "session-token"
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	frameLoad := func(method string) *http.Request {
+		req := httptest.NewRequest(method, "http://127.0.0.1:4000/?token="+token, nil)
+		req.Header.Set("Sec-Fetch-Site", "same-site")
+		req.Header.Set("Sec-Fetch-Mode", "navigate")
+		req.Header.Set("Sec-Fetch-Dest", "iframe")
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	newViewerSessionGuard(next, token).ServeHTTP(rec, frameLoad("GET"))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a plain session to refuse frames from another port, got %d", rec.Code)
+	}
+
+	framed := newFramedViewerSessionGuard(next, token, "http://127.0.0.1:5000")
+	rec = httptest.NewRecorder()
+	framed.ServeHTTP(rec, frameLoad("GET"))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected the frame load to be let through, got %d", rec.Code)
+	}
+	if policy := rec.Header().Get("Content-Security-Policy"); !strings.HasSuffix(policy, "frame-ancestors 'self' http://127.0.0.1:5000") {
+		t.Fatalf("unexpected policy %q", policy)
+	}
+	rec = httptest.NewRecorder()
+	framed.ServeHTTP(rec, frameLoad("POST"))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected only navigations to pass, got %d", rec.Code)
+	}
+}
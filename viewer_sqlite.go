@@ -0,0 +1,516 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const viewerDocumentTypeSQLite viewerDocumentType = "sqlite"
+
+// viewerSQLitePath serves table pages and query results as JSON.
+const viewerSQLitePath = "/sqlite"
+
+const (
+	viewerSQLitePageRows = 100
+	// viewerSQLiteCountLimit is the largest database whose tables are counted
+	// up front; counting reads every leaf page.
+	viewerSQLiteCountLimit = 1 << 30
+)
+
+// viewerSQLiteView is what the SQLite browser renders on first load. live is
+// false for databases that only exist in memory (archive entries), which
+// have no route to page through or query.
+type viewerSQLiteView struct {
+	tables   []viewerSQLiteTable
+	objects  []sqliteSchemaObject
+	first    sqliteResult
+	current  string
+	previews map[string]sqliteResult
+	pageSize int
+	live     bool
+	wal      bool
+	err      string
+}
+
+type viewerSQLiteTable struct {
+	name    string
+	rows    int
+	columns []sqliteColumn
+	sql     string
+	note    string
+}
+
+func loadViewerSQLiteFile(path string) *viewerSQLiteView {
+	file, err := os.Open(path)
+	if err != nil {
+		return &viewerSQLiteView{err: err.Error()}
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return &viewerSQLiteView{err: err.Error()}
+	}
+	view := buildViewerSQLiteView(file, info.Size(), true)
+	if wal, err := os.Stat(path + "-wal"); err == nil && wal.Size() > 0 {
+		view.wal = true
+	}
+	return view
+}
+
+func buildViewerSQLiteView(r io.ReaderAt, size int64, live bool) *viewerSQLiteView {
+	view := &viewerSQLiteView{live: live}
+	db, err := openSQLiteDB(r, size)
+	if err != nil {
+		view.err = err.Error()
+		return view
+	}
+	view.pageSize = db.pageSize
+	objects, err := db.schema()
+	if err != nil {
+		view.err = err.Error()
+		return view
+	}
+	view.objects = objects
+	for _, object := range objects {
+		if object.kind != "table" {
+			continue
+		}
+		table, err := lookupSQLiteTable(db, object.name)
+		entry := viewerSQLiteTable{name: object.name, rows: -1, columns: table.columns, sql: object.sql}
+		switch {
+		case err != nil:
+			entry.note = err.Error()
+		case table.withoutRowid:
+			entry.note = "WITHOUT ROWID tables cannot be browsed yet"
+		case size <= viewerSQLiteCountLimit:
+			if entry.rows, err = db.countRows(table.root); err != nil {
+				entry.note = err.Error()
+				entry.rows = -1
+			}
+		}
+		view.tables = append(view.tables, entry)
+	}
+	if !live {
+		view.previews = map[string]sqliteResult{}
+	}
+	for i, entry := range view.tables {
+		if entry.note != "" {
+			continue
+		}
+		table, _ := lookupSQLiteTable(db, entry.name)
+		page, err := sqliteTablePage(db, table, 0)
+		if err != nil {
+			view.tables[i].note = err.Error()
+			continue
+		}
+		if view.current == "" {
+			view.first, view.current = page, entry.name
+		}
+		if live {
+			break
+		}
+		view.previews[entry.name] = page
+	}
+	return view
+}
+
+// sqliteTablePage reads one page of rows in rowid order. Total is the row
+// count of the whole table, or -1 when the database is too large to count.
+func sqliteTablePage(db *sqliteDB, table sqliteTable, page int) (sqliteResult, error) {
+	// Every row takes at least a byte of the file, so a page past size rows
+	// is out of range however the table is laid out.
+	if page < 0 || int64(page) > db.size/viewerSQLitePageRows {
+		return sqliteResult{}, fmt.Errorf("page %d is out of range", page)
+	}
+	result := sqliteResult{Columns: []string{}, Rows: [][]any{}}
+	for _, column := range table.columns {
+		result.Columns = append(result.Columns, column.name)
+	}
+	result.Total = -1
+	if db.size <= viewerSQLiteCountLimit {
+		total, err := db.countRows(table.root)
+		if err != nil {
+			return sqliteResult{}, err
+		}
+		result.Total = total
+	}
+	err := db.tableRows(table, page*viewerSQLitePageRows, func(_ int64, values []sqliteValue) (bool, error) {
+		for len(result.Columns) < len(values) {
+			result.Columns = append(result.Columns, fmt.Sprintf("column%d", len(result.Columns)+1))
+		}
+		result.Rows = append(result.Rows, sqliteDisplayRow(values))
+		return len(result.Rows) < viewerSQLitePageRows, nil
+	})
+	return result, err
+}
+
+func registerViewerSQLiteRoutes(mux *http.ServeMux, target viewerStreamTarget, touch func()) {
+	mux.HandleFunc(viewerSQLitePath, func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		path, docType, ok := target(r)
+		if !ok || docType != viewerDocumentTypeSQLite {
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db, err := openSQLiteDB(file, info.Size())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		query := r.URL.Query()
+		var result sqliteResult
+		page := 0
+		if text := query.Get("q"); text != "" {
+			result, err = runSQLiteQuery(db, text)
+		} else {
+			page, _ = strconv.Atoi(query.Get("page"))
+			if page < 0 {
+				page = 0
+			}
+			var table sqliteTable
+			if table, err = lookupSQLiteTable(db, query.Get("table")); err == nil {
+				result, err = sqliteTablePage(db, table, page)
+			}
+		}
+		if err != nil {
+			writeViewerJSON(w, map[string]any{"error": err.Error()})
+			return
+		}
+		writeViewerJSON(w, map[string]any{
+			"columns":   result.Columns,
+			"rows":      result.Rows,
+			"total":     result.Total,
+			"truncated": result.Truncated,
+			"page":      page,
+			"pageRows":  viewerSQLitePageRows,
+		})
+	})
+}
+
+func renderSQLiteViewHTML(view viewerSQLiteView) string {
+	var b strings.Builder
+	if view.err != "" {
+		b.WriteString(`<div class="text-frame"><p>Could not read this database: `)
+		b.WriteString(template.HTMLEscapeString(view.err))
+		b.WriteString(`</p></div>`)
+		return b.String()
+	}
+	live := ""
+	if view.live {
+		live = "1"
+	}
+	fmt.Fprintf(&b, `<div class="text-frame csv-frame sqlite-frame" id="sqlite-root" data-live="%s" data-page-rows="%d">`, live, viewerSQLitePageRows)
+	b.WriteString(`<div class="viewer-meta">`)
+	meta := []string{fmt.Sprintf("%d tables", len(view.tables)), fmt.Sprintf("%d-byte pages", view.pageSize), "read-only"}
+	b.WriteString(template.HTMLEscapeString(strings.Join(meta, " · ")))
+	b.WriteString(`</div>`)
+	if view.wal {
+		b.WriteString(`<div class="sqlite-note">This database has a write-ahead log. Changes that are still in the -wal file are not shown until SQLite checkpoints them.</div>`)
+	}
+	if !view.live {
+		b.WriteString(`<div class="sqlite-note">Showing the first rows of each table. Open the database directly to page through rows and run queries.</div>`)
+	}
+
+	b.WriteString(`<div class="sqlite-layout"><nav class="sqlite-tables">`)
+	for _, table := range view.tables {
+		class := "sqlite-table"
+		if table.name == view.current {
+			class += " active"
+		}
+		count := ""
+		if table.rows >= 0 {
+			count = strconv.Itoa(table.rows)
+		}
+		fmt.Fprintf(&b, `<button type="button" class="%s" data-table="%s" data-rows="%d"><span>%s</span><span class="sqlite-count">%s</span></button>`,
+			class, template.HTMLEscapeString(table.name), table.rows, template.HTMLEscapeString(table.name), count)
+	}
+	if len(view.tables) == 0 {
+		b.WriteString(`<div class="viewer-meta">No tables</div>`)
+	}
+	b.WriteString(`</nav><div class="sqlite-main">`)
+
+	if view.live {
+		b.WriteString(`<div class="sqlite-query"><textarea id="sqlite-sql" rows="2" spellcheck="false" placeholder="SELECT * FROM table WHERE … ORDER BY … LIMIT 50"></textarea>`)
+		b.WriteString(`<button type="button" id="sqlite-run">Run</button></div>`)
+	}
+	b.WriteString(`<div class="sqlite-tabs"><button type="button" class="active" data-tab="rows">Rows</button><button type="button" data-tab="schema">Schema</button></div>`)
+
+	b.WriteString(`<div class="sqlite-pane" data-pane="rows"><div class="viewer-meta" id="sqlite-status"></div><div id="sqlite-rows">`)
+	b.WriteString(renderSQLiteResultTable(view.first))
+	b.WriteString(`</div><div class="sqlite-pager" id="sqlite-pager"><button type="button" data-step="-1">‹ Prev</button><span id="sqlite-page"></span><button type="button" data-step="1">Next ›</button></div></div>`)
+
+	b.WriteString(`<div class="sqlite-pane" data-pane="schema" hidden>`)
+	for _, table := range view.tables {
+		fmt.Fprintf(&b, `<section class="sqlite-schema" data-table="%s">`, template.HTMLEscapeString(table.name))
+		if table.note != "" {
+			fmt.Fprintf(&b, `<div class="sqlite-note">%s</div>`, template.HTMLEscapeString(table.note))
+		}
+		if len(table.columns) > 0 {
+			b.WriteString(`<div class="table-wrap"><table><thead><tr><th>Column</th><th>Type</th><th>Key</th></tr></thead><tbody>`)
+			for _, column := range table.columns {
+				key := ""
+				if column.primaryKey {
+					key = "PRIMARY KEY"
+				}
+				fmt.Fprintf(&b, `<tr><td>%s</td><td>%s</td><td>%s</td></tr>`,
+					template.HTMLEscapeString(column.name), template.HTMLEscapeString(column.typ), key)
+			}
+			b.WriteString(`</tbody></table></div>`)
+		}
+		b.WriteString(`<pre class="sqlite-sql">` + template.HTMLEscapeString(table.sql) + `</pre>`)
+		for _, object := range view.objects {
+			if object.kind == "table" || object.table != table.name || object.sql == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<div class="viewer-meta">%s %s</div><pre class="sqlite-sql">%s</pre>`,
+				template.HTMLEscapeString(object.kind), template.HTMLEscapeString(object.name), template.HTMLEscapeString(object.sql))
+		}
+		b.WriteString(`</section>`)
+	}
+	b.WriteString(`</div></div></div>`)
+
+	if view.previews != nil {
+		payload, _ := json.Marshal(view.previews)
+		fmt.Fprintf(&b, `<script type="application/json" id="sqlite-previews">%s</script>`, payload)
+	}
+	b.WriteString(`</div>`)
+	fmt.Fprintf(&b, `<script>%s</script>`, viewerSQLiteScript)
+	return b.String()
+}
+
+// renderSQLiteResultTable uses the same markup as renderCSVTableHTML so both
+// previews share one table style; NULL cells are marked so they read
+// differently from empty text.
+func renderSQLiteResultTable(result sqliteResult) string {
+	var b strings.Builder
+	b.WriteString(`<div class="table-wrap"><table><thead><tr>`)
+	for _, column := range result.Columns {
+		b.WriteString(`<th>`)
+		b.WriteString(template.HTMLEscapeString(column))
+		b.WriteString(`</th>`)
+	}
+	b.WriteString(`</tr></thead><tbody>`)
+	for _, row := range result.Rows {
+		b.WriteString(`<tr>`)
+		for _, cell := range row {
+			text, ok := cell.(string)
+			if !ok {
+				b.WriteString(`<td class="sqlite-null">NULL</td>`)
+				continue
+			}
+			b.WriteString(`<td>`)
+			b.WriteString(template.HTMLEscapeString(text))
+			b.WriteString(`</td>`)
+		}
+		b.WriteString(`</tr>`)
+	}
+	b.WriteString(`</tbody></table></div>`)
+	return b.String()
+}
+
+const viewerSQLiteStyles = `
+    .sqlite-frame { max-width: none; }
+    .sqlite-layout { display: grid; grid-template-columns: 200px 1fr; gap: 18px; margin-top: 12px; }
+    .sqlite-tables { display: flex; flex-direction: column; gap: 2px; }
+    .sqlite-table {
+      display: flex;
+      justify-content: space-between;
+      gap: 8px;
+      font: inherit;
+      font-size: 13px;
+      text-align: left;
+      padding: 6px 10px;
+      border: 0;
+      border-radius: 6px;
+      background: transparent;
+      color: #1a1a18;
+      cursor: pointer;
+      overflow: hidden;
+    }
+    .sqlite-table span:first-child { overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+    .sqlite-table:hover { background: rgba(26, 26, 24, 0.05); }
+    .sqlite-table.active { background: rgba(26, 26, 24, 0.09); font-weight: 600; }
+    .sqlite-count { color: rgba(26, 26, 24, 0.42); font-size: 11px; font-weight: 400; }
+    .sqlite-main { min-width: 0; }
+    .sqlite-query { display: flex; gap: 8px; align-items: stretch; margin-bottom: 10px; }
+    .sqlite-query textarea {
+      flex: 1;
+      resize: vertical;
+      font: 12.5px/1.5 "SF Mono", Consolas, monospace;
+      padding: 8px 10px;
+      border: 0.5px solid rgba(0, 0, 0, 0.14);
+      border-radius: 8px;
+      background: rgba(255, 255, 255, 0.8);
+    }
+    .sqlite-query button, .sqlite-tabs button, .sqlite-pager button {
+      font: inherit;
+      font-size: 12px;
+      padding: 4px 12px;
+      border: 0.5px solid rgba(0, 0, 0, 0.14);
+      border-radius: 6px;
+      background: rgba(252, 251, 249, 0.97);
+      color: #1a1a18;
+      cursor: pointer;
+    }
+    .sqlite-query button { font-weight: 600; }
+    .sqlite-tabs { display: flex; gap: 6px; margin-bottom: 8px; }
+    .sqlite-tabs button.active { background: #1a1a18; color: #fcfbf9; }
+    .sqlite-pager { display: flex; align-items: center; gap: 10px; margin-top: 10px; font-size: 12px; color: rgba(26, 26, 24, 0.6); }
+    .sqlite-pager button:disabled { opacity: 0.4; cursor: default; }
+    .sqlite-note {
+      margin: 8px 0;
+      padding: 8px 12px;
+      border-radius: 8px;
+      font-size: 12.5px;
+      background: rgba(184, 92, 26, 0.08);
+      color: #7a4a16;
+    }
+    .sqlite-error { color: #8c1d1d; }
+    .sqlite-null { color: rgba(26, 26, 24, 0.35); font-style: italic; }
+    .sqlite-frame td { font-family: "SF Mono", Consolas, monospace; font-size: 12.5px; white-space: pre-wrap; }
+    .sqlite-sql {
+      font: 12px/1.5 "SF Mono", Consolas, monospace;
+      white-space: pre-wrap;
+      padding: 10px 12px;
+      border-radius: 8px;
+      background: rgba(26, 26, 24, 0.04);
+    }
+`
+
+const viewerSQLiteScript = `
+(function() {
+  var root = document.getElementById('sqlite-root');
+  if (!root) return;
+  var live = root.getAttribute('data-live') === '1';
+  var pageRows = parseInt(root.getAttribute('data-page-rows'), 10);
+  var qs = location.search ? location.search + '&' : '?';
+  var rowsBox = document.getElementById('sqlite-rows');
+  var status = document.getElementById('sqlite-status');
+  var pager = document.getElementById('sqlite-pager');
+  var pageLabel = document.getElementById('sqlite-page');
+  var previews = {};
+  var previewNode = document.getElementById('sqlite-previews');
+  if (previewNode) previews = JSON.parse(previewNode.textContent);
+  var buttons = root.querySelectorAll('.sqlite-table');
+  var active = root.querySelector('.sqlite-table.active');
+  var current = active ? active.getAttribute('data-table') : '';
+  var page = 0;
+  var total = active ? parseInt(active.getAttribute('data-rows'), 10) : 0;
+  var shown = rowsBox.querySelectorAll('tbody tr').length;
+
+  function esc(s) {
+    return String(s).replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/"/g, '&quot;');
+  }
+  function renderTable(data) {
+    var html = '<div class="table-wrap"><table><thead><tr>';
+    data.columns.forEach(function(c) { html += '<th>' + esc(c) + '</th>'; });
+    html += '</tr></thead><tbody>';
+    data.rows.forEach(function(row) {
+      html += '<tr>';
+      row.forEach(function(cell) {
+        html += cell === null ? '<td class="sqlite-null">NULL</td>' : '<td>' + esc(cell) + '</td>';
+      });
+      html += '</tr>';
+    });
+    rowsBox.innerHTML = html + '</tbody></table></div>';
+  }
+  function updatePager() {
+    pager.hidden = !live || !current;
+    var pages = total >= 0 ? Math.max(1, Math.ceil(total / pageRows)) : 0;
+    pageLabel.textContent = 'Page ' + (page + 1) + (pages ? ' of ' + pages : '');
+    pager.querySelector('[data-step="-1"]').disabled = page === 0;
+    pager.querySelector('[data-step="1"]').disabled = pages ? page + 1 >= pages : shown < pageRows;
+    status.className = 'viewer-meta';
+    if (!current) status.textContent = '';
+    else if (total >= 0) status.textContent = current + ' · ' + total + (total === 1 ? ' row' : ' rows');
+    else status.textContent = current;
+  }
+  function load(table, n) {
+    current = table;
+    page = n;
+    if (!live) {
+      var data = previews[table];
+      if (data) { renderTable(data); total = data.total; shown = data.rows.length; }
+      else rowsBox.innerHTML = '';
+      updatePager();
+      if (data && data.total > data.rows.length) status.textContent = table + ' · first ' + data.rows.length + ' of ' + data.total + ' rows';
+      return;
+    }
+    fetch('/sqlite' + qs + 'table=' + encodeURIComponent(table) + '&page=' + n).then(function(r) { return r.json(); }).then(function(data) {
+      if (data.error) { showError(data.error); return; }
+      total = data.total;
+      shown = data.rows.length;
+      renderTable(data);
+      updatePager();
+    });
+  }
+  function showError(message) {
+    status.className = 'viewer-meta sqlite-error';
+    status.textContent = message;
+  }
+  function selectTab(name) {
+    root.querySelectorAll('.sqlite-tabs button').forEach(function(b) { b.classList.toggle('active', b.getAttribute('data-tab') === name); });
+    root.querySelectorAll('.sqlite-pane').forEach(function(p) { p.hidden = p.getAttribute('data-pane') !== name; });
+  }
+  function showSchema() {
+    root.querySelectorAll('.sqlite-schema').forEach(function(s) { s.hidden = s.getAttribute('data-table') !== current; });
+  }
+
+  buttons.forEach(function(button) {
+    button.addEventListener('click', function() {
+      buttons.forEach(function(b) { b.classList.toggle('active', b === button); });
+      load(button.getAttribute('data-table'), 0);
+      showSchema();
+    });
+  });
+  root.querySelectorAll('.sqlite-tabs button').forEach(function(b) {
+    b.addEventListener('click', function() { selectTab(b.getAttribute('data-tab')); });
+  });
+  pager.querySelectorAll('button').forEach(function(b) {
+    b.addEventListener('click', function() { load(current, page + parseInt(b.getAttribute('data-step'), 10)); });
+  });
+
+  var sql = document.getElementById('sqlite-sql');
+  function run() {
+    var text = sql.value.trim();
+    if (!text) return;
+    selectTab('rows');
+    fetch('/sqlite' + qs + 'q=' + encodeURIComponent(text)).then(function(r) { return r.json(); }).then(function(data) {
+      if (data.error) { showError(data.error); return; }
+      buttons.forEach(function(b) { b.classList.remove('active'); });
+      current = '';
+      renderTable(data);
+      updatePager();
+      status.textContent = data.truncated ? 'Showing the first ' + data.rows.length + ' rows' : data.total + (data.total === 1 ? ' row' : ' rows');
+    });
+  }
+  if (sql) {
+    document.getElementById('sqlite-run').addEventListener('click', run);
+    sql.addEventListener('keydown', function(e) {
+      if (e.key === 'Enter' && (e.ctrlKey || e.metaKey)) { e.preventDefault(); run(); }
+    });
+    if (current) sql.value = 'SELECT * FROM "' + current.replace(/"/g, '""') + '" LIMIT 50';
+  }
+  showSchema();
+  updatePager();
+  if (!live && current) load(current, 0);
+})();
+`
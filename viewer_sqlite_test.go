@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testSQLiteTable struct {
+	sql  string
+	name string
+	rows [][]any
+}
+
+// buildTestSQLite writes a database the way SQLite lays one out: page 1 holds
+// the schema, each table gets one leaf page, and payloads that do not fit
+// spill onto overflow pages.
+func buildTestSQLite(t *testing.T, tables []testSQLiteTable) []byte {
+	t.Helper()
+	const pageSize = 1024
+	var pages [][]byte
+	newPage := func() int {
+		pages = append(pages, make([]byte, pageSize))
+		return len(pages)
+	}
+	newPage()
+	roots := make([]int, len(tables))
+	for i := range tables {
+		roots[i] = newPage()
+	}
+
+	writeLeaf := func(pageNo int, rows [][]any) {
+		page := pages[pageNo-1]
+		hdr := 0
+		if pageNo == 1 {
+			hdr = 100
+		}
+		page[hdr] = 0x0d
+		binary.BigEndian.PutUint16(page[hdr+3:], uint16(len(rows)))
+		content := pageSize
+		for i, row := range rows {
+			payload := encodeTestSQLiteRecord(row)
+			cell := appendTestSQLiteVarint(nil, uint64(len(payload)))
+			cell = appendTestSQLiteVarint(cell, uint64(i+1))
+			maxLocal, minLocal := pageSize-35, (pageSize-12)*32/255-23
+			if len(payload) <= maxLocal {
+				cell = append(cell, payload...)
+			} else {
+				local := minLocal + (len(payload)-minLocal)%(pageSize-4)
+				if local > maxLocal {
+					local = minLocal
+				}
+				cell = append(cell, payload[:local]...)
+				rest := payload[local:]
+				first := newPage()
+				cell = binary.BigEndian.AppendUint32(cell, uint32(first))
+				for overflow := first; len(rest) > 0; {
+					n := copy(pages[overflow-1][4:], rest)
+					rest = rest[n:]
+					if len(rest) > 0 {
+						next := newPage()
+						binary.BigEndian.PutUint32(pages[overflow-1], uint32(next))
+						overflow = next
+					}
+				}
+			}
+			content -= len(cell)
+			copy(page[content:], cell)
+			binary.BigEndian.PutUint16(page[hdr+8+2*i:], uint16(content))
+		}
+		binary.BigEndian.PutUint16(page[hdr+5:], uint16(content))
+	}
+
+	var schema [][]any
+	for i, table := range tables {
+		schema = append(schema, []any{"table", table.name, table.name, int64(roots[i]), table.sql})
+	}
+	writeLeaf(1, schema)
+	for i, table := range tables {
+		writeLeaf(roots[i], table.rows)
+	}
+
+	header := pages[0]
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], pageSize)
+	header[18], header[19], header[21], header[22], header[23] = 1, 1, 64, 32, 32
+	binary.BigEndian.PutUint32(header[28:], uint32(len(pages)))
+	binary.BigEndian.PutUint32(header[44:], 4)
+	binary.BigEndian.PutUint32(header[56:], 1)
+	return bytes.Join(pages, nil)
+}
+
+func encodeTestSQLiteRecord(values []any) []byte {
+	var types, body []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			types = appendTestSQLiteVarint(types, 0)
+		case int64:
+			types = appendTestSQLiteVarint(types, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(v))
+		case float64:
+			types = appendTestSQLiteVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types = appendTestSQLiteVarint(types, uint64(13+2*len(v)))
+			body = append(body, v...)
+		case []byte:
+			types = appendTestSQLiteVarint(types, uint64(12+2*len(v)))
+			body = append(body, v...)
+		}
+	}
+	headerSize := len(types) + 1
+	if headerSize > 127 {
+		headerSize++
+	}
+	record := appendTestSQLiteVarint(nil, uint64(headerSize))
+	record = append(record, types...)
+	return append(record, body...)
+}
+
+func appendTestSQLiteVarint(b []byte, v uint64) []byte {
+	var groups []byte
+	for {
+		groups = append([]byte{byte(v & 0x7f)}, groups...)
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := 0; i < len(groups)-1; i++ {
+		groups[i] |= 0x80
+	}
+	return append(b, groups...)
+}
+
+func testSQLiteFixture(t *testing.T) []byte {
+	t.Helper()
+	return buildTestSQLite(t, []testSQLiteTable{
+		{
+			name: "people",
+			sql:  "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT NOT NULL, age INT, bio TEXT, active INT DEFAULT 1)",
+			rows: [][]any{
+				{nil, "Ada", int64(36), "mathematician"},
+				{nil, "Grace", int64(45), strings.Repeat("compilers ", 250)},
+				{nil, "Linus", int64(21), nil},
+				{nil, "Margaret", nil, []byte{0xde, 0xad}},
+			},
+		},
+		{
+			name: "tags",
+			sql:  `CREATE TABLE "tags" ([label] TEXT, weight REAL)`,
+			rows: [][]any{{"go", 1.5}, {"sql", 2.0}},
+		},
+	})
+}
+
+func TestSQLiteReaderDecodesTablesAndOverflowPages(t *testing.T) {
+	data := testSQLiteFixture(t)
+	db, err := openSQLiteDB(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("openSQLiteDB returned error: %v", err)
+	}
+	tables, err := db.tables()
+	if err != nil || len(tables) != 2 {
+		t.Fatalf("tables() = %+v, %v", tables, err)
+	}
+	people := tables[0]
+	if people.rowidColumn != 0 || people.columns[4].defaultValue != int64(1) {
+		t.Fatalf("unexpected people table: %+v", people)
+	}
+
+	var rows [][]sqliteValue
+	err = db.tableRows(people, 0, func(_ int64, row []sqliteValue) (bool, error) {
+		rows = append(rows, row)
+		return true, nil
+	})
+	if err != nil || len(rows) != 4 {
+		t.Fatalf("tableRows = %d rows, %v", len(rows), err)
+	}
+	if rows[0][0] != int64(1) || rows[0][1] != "Ada" || rows[0][4] != int64(1) {
+		t.Fatalf("expected rowid alias and default to be filled in, got %v", rows[0])
+	}
+	if bio := rows[1][3].(string); bio != strings.Repeat("compilers ", 250) {
+		t.Fatalf("overflow payload was not reassembled: %d bytes", len(bio))
+	}
+	if !bytes.Equal(rows[3][3].([]byte), []byte{0xde, 0xad}) || rows[2][3] != nil {
+		t.Fatalf("unexpected blob or NULL decoding: %v", rows[2:])
+	}
+
+	if _, err := openSQLiteDB(strings.NewReader("not a database"), 14); err != errSQLiteNotDatabase {
+		t.Fatalf("expected errSQLiteNotDatabase, got %v", err)
+	}
+}
+
+func TestRunSQLiteQueryOnlyRunsSelect(t *testing.T) {
+	data := testSQLiteFixture(t)
+	db, err := openSQLiteDB(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("openSQLiteDB returned error: %v", err)
+	}
+
+	result, err := runSQLiteQuery(db, "select name, age * 2 AS doubled from people where age is not null and name like '%a%' order by age desc limit 2;")
+	if err != nil {
+		t.Fatalf("runSQLiteQuery returned error: %v", err)
+	}
+	got, _ := json.Marshal(result)
+	want := `{"columns":["name","doubled"],"rows":[["Grace","90"],["Ada","72"]],"total":2,"truncated":false}`
+	if string(got) != want {
+		t.Fatalf("unexpected result:\n got %s\nwant %s", got, want)
+	}
+
+	result, err = runSQLiteQuery(db, `SELECT count(*) FROM "tags" WHERE weight >= 2`)
+	if err != nil || result.Rows[0][0] != "1" {
+		t.Fatalf("count(*) = %v, %v", result.Rows, err)
+	}
+	result, err = runSQLiteQuery(db, "SELECT id, age FROM people WHERE id IN (3, 4) ORDER BY 2")
+	if err != nil || len(result.Rows) != 2 || result.Rows[0][1] != nil || result.Rows[1][0] != "3" {
+		t.Fatalf("expected NULL to sort first, got %v, %v", result.Rows, err)
+	}
+
+	for query, message := range map[string]string{
+		"DELETE FROM people":                      "only SELECT statements are allowed",
+		"SELECT 1 FROM people; DROP TABLE people": "one statement at a time",
+		"SELECT * FROM people JOIN tags":          "unsupported clause",
+		"SELECT * FROM missing":                   "no such table",
+		"SELECT nope FROM people":                 "no such column",
+	} {
+		if _, err := runSQLiteQuery(db, query); err == nil || !strings.Contains(err.Error(), message) {
+			t.Fatalf("%q: expected error containing %q, got %v", query, message, err)
+		}
+	}
+}
+
+func TestSQLiteViewerServesPagesAndQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	if err := os.WriteFile(path, testSQLiteFixture(t), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	doc, err := loadViewerDocument(path)
+	if err != nil {
+		t.Fatalf("loadViewerDocument returned error: %v", err)
+	}
+	if doc.docType != viewerDocumentTypeSQLite || doc.sqlite == nil || !doc.sqlite.live {
+		t.Fatalf("expected a live SQLite view, got %+v", doc)
+	}
+	page := renderViewerPage(doc, "/document.pdf", "/logo.png")
+	for _, want := range []string{`class="text-frame csv-frame sqlite-frame"`, `data-table="people"`, `<td>Ada</td>`, `id="sqlite-sql"`, "CREATE TABLE people"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected page to contain %q", want)
+		}
+	}
+
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+	fetch := func(query url.Values) map[string]any {
+		t.Helper()
+		resp, err := http.Get(server.URL + viewerSQLitePath + "?" + query.Encode())
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return body
+	}
+
+	body := fetch(url.Values{"table": {"tags"}, "page": {"0"}})
+	if body["total"] != float64(2) || len(body["rows"].([]any)) != 2 {
+		t.Fatalf("unexpected table page: %v", body)
+	}
+	for _, far := range []string{"92233720368547759", strconv.Itoa(math.MaxInt)} {
+		if body := fetch(url.Values{"table": {"tags"}, "page": {far}}); body["error"] == nil {
+			t.Fatalf("expected page %s to be out of range, got %v", far, body)
+		}
+	}
+	body = fetch(url.Values{"q": {"SELECT label FROM tags WHERE weight > 1.5"}})
+	if rows := body["rows"].([]any); len(rows) != 1 || rows[0].([]any)[0] != "sql" {
+		t.Fatalf("unexpected query result: %v", body)
+	}
+	body = fetch(url.Values{"q": {"UPDATE tags SET weight = 0"}})
+	if body["error"] != errSQLiteSelectOnly.Error() {
+		t.Fatalf("expected UPDATE to be refused, got %v", body)
+	}
+}
@@ -0,0 +1,699 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Files above viewerStreamThreshold are never read into memory by the viewer.
+// The page ships an empty shell and the browser pulls line or row pages from
+// the handler on demand.
+const (
+	viewerStreamThreshold   = 8 << 20
+	viewerStreamIndexStride = 1024
+	viewerStreamPageSize    = 500
+	viewerStreamMaxPage     = 5000
+	viewerStreamMaxLineLen  = 16 << 10
+	viewerSearchMaxResults  = 200
+	viewerCSVPreviewRows    = 500
+)
+
+type viewerLineIndex struct {
+	size    int64
+	modTime time.Time
+	total   int
+	offsets []int64
+}
+
+type viewerRecordIndex struct {
+	size    int64
+	modTime time.Time
+	headers []string
+	width   int
+	total   int
+	offsets []int64
+}
+
+type viewerSearchMatch struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type viewerSearchResult struct {
+	Query   string              `json:"query"`
+	Matches []viewerSearchMatch `json:"matches"`
+	Next    int                 `json:"next"`
+	Done    bool                `json:"done"`
+}
+
+type viewerStreamCache struct {
+	mu      sync.Mutex
+	lines   map[string]*viewerLineIndex
+	records map[string]*viewerRecordIndex
+}
+
+func newViewerStreamCache() *viewerStreamCache {
+	return &viewerStreamCache{
+		lines:   map[string]*viewerLineIndex{},
+		records: map[string]*viewerRecordIndex{},
+	}
+}
+
+func (c *viewerStreamCache) lineIndex(path string) (*viewerLineIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	idx, ok := c.lines[path]
+	c.mu.Unlock()
+	if ok && idx.size == info.Size() && idx.modTime.Equal(info.ModTime()) {
+		return idx, nil
+	}
+	idx, err = buildViewerLineIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.lines[path] = idx
+	c.mu.Unlock()
+	return idx, nil
+}
+
+func (c *viewerStreamCache) recordIndex(path string) (*viewerRecordIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	idx, ok := c.records[path]
+	c.mu.Unlock()
+	if ok && idx.size == info.Size() && idx.modTime.Equal(info.ModTime()) {
+		return idx, nil
+	}
+	idx, err = buildViewerRecordIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.records[path] = idx
+	c.mu.Unlock()
+	return idx, nil
+}
+
+// viewerShouldStream reports whether a document is too large to render inline.
+func viewerShouldStream(docType viewerDocumentType, size int64) bool {
+	if docType == viewerDocumentTypePDF || docType == viewerDocumentTypeNotebook {
+		return false
+	}
+	return size > viewerStreamThreshold
+}
+
+// buildViewerLineIndex scans the file once and keeps the byte offset of every
+// viewerStreamIndexStride-th line, so a page read only has to skip at most one
+// stride of lines from the nearest checkpoint.
+func buildViewerLineIndex(path string) (*viewerLineIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &viewerLineIndex{size: info.Size(), modTime: info.ModTime(), offsets: []int64{0}}
+	buf := make([]byte, 1<<20)
+	var offset int64
+	lastByte := byte('\n')
+	for {
+		n, readErr := file.Read(buf)
+		chunk := buf[:n]
+		for len(chunk) > 0 {
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				offset += int64(len(chunk))
+				break
+			}
+			offset += int64(i + 1)
+			chunk = chunk[i+1:]
+			idx.total++
+			if idx.total%viewerStreamIndexStride == 0 {
+				idx.offsets = append(idx.offsets, offset)
+			}
+		}
+		if n > 0 {
+			lastByte = buf[n-1]
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+	if offset > 0 && lastByte != '\n' {
+		idx.total++
+	}
+	return idx, nil
+}
+
+func (idx *viewerLineIndex) readLines(path string, start, count int) ([]string, error) {
+	if start < 0 || start >= idx.total || count <= 0 {
+		return nil, nil
+	}
+	if start+count > idx.total {
+		count = idx.total - start
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checkpoint := start / viewerStreamIndexStride
+	if checkpoint >= len(idx.offsets) {
+		checkpoint = len(idx.offsets) - 1
+	}
+	if _, err := file.Seek(idx.offsets[checkpoint], io.SeekStart); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReaderSize(file, 256<<10)
+	for line := checkpoint * viewerStreamIndexStride; line < start; line++ {
+		if _, err := readViewerLine(reader); err != nil {
+			return nil, err
+		}
+	}
+	lines := make([]string, 0, count)
+	for len(lines) < count {
+		line, err := readViewerLine(reader)
+		if errors.Is(err, io.EOF) {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// readViewerLine returns one line without its terminator. Lines longer than
+// viewerStreamMaxLineLen are consumed in full but clipped in the result.
+func readViewerLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	clipped := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(line)+len(chunk) > viewerStreamMaxLineLen {
+			if room := viewerStreamMaxLineLen - len(line); room > 0 {
+				line = append(line, chunk[:room]...)
+			}
+			clipped = true
+		} else {
+			line = append(line, chunk...)
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		text := strings.TrimRight(string(line), "\r\n")
+		if clipped {
+			text += " …"
+		}
+		if err != nil {
+			return text, err
+		}
+		return text, nil
+	}
+}
+
+func searchViewerFile(ctx context.Context, path string, query string, from int, limit int) (viewerSearchResult, error) {
+	result := viewerSearchResult{Query: query, Matches: []viewerSearchMatch{}}
+	needle := strings.ToLower(query)
+	if needle == "" {
+		result.Done = true
+		return result, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 1<<20)
+	for lineNo := 0; ; lineNo++ {
+		if lineNo%65536 == 0 && ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		line, err := readViewerLine(reader)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return result, err
+		}
+		if errors.Is(err, io.EOF) && line == "" {
+			result.Done = true
+			return result, nil
+		}
+		if lineNo >= from && strings.Contains(strings.ToLower(line), needle) {
+			result.Matches = append(result.Matches, viewerSearchMatch{Line: lineNo + 1, Text: line})
+			if len(result.Matches) >= limit {
+				result.Next = lineNo + 1
+				return result, nil
+			}
+		}
+		if err != nil {
+			result.Done = true
+			return result, nil
+		}
+	}
+}
+
+// buildViewerRecordIndex walks the CSV once. Records can span lines when a
+// field is quoted, so checkpoints come from the reader's input offset instead
+// of the line index.
+func buildViewerRecordIndex(path string) (*viewerRecordIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newViewerCSVReader(bufio.NewReaderSize(file, 1<<20))
+	idx := &viewerRecordIndex{size: info.Size(), modTime: info.ModTime()}
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		idx.headers = []string{"Column 1"}
+		idx.width = 1
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	header = append([]string(nil), header...)
+	if len(header) > 0 {
+		header[0] = stripUTF8BOM(header[0])
+	}
+	idx.width = len(header)
+	idx.offsets = append(idx.offsets, reader.InputOffset())
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > idx.width {
+			idx.width = len(record)
+		}
+		idx.total++
+		if idx.total%viewerStreamIndexStride == 0 {
+			idx.offsets = append(idx.offsets, reader.InputOffset())
+		}
+	}
+	idx.headers = padCSVRow(header, idx.width)
+	for i := range idx.headers {
+		if strings.TrimSpace(idx.headers[i]) == "" {
+			idx.headers[i] = fmt.Sprintf("Column %d", i+1)
+		}
+	}
+	return idx, nil
+}
+
+func (idx *viewerRecordIndex) readRows(path string, start, count int) ([][]string, error) {
+	if start < 0 || start >= idx.total || count <= 0 {
+		return nil, nil
+	}
+	if start+count > idx.total {
+		count = idx.total - start
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checkpoint := start / viewerStreamIndexStride
+	if checkpoint >= len(idx.offsets) {
+		checkpoint = len(idx.offsets) - 1
+	}
+	if _, err := file.Seek(idx.offsets[checkpoint], io.SeekStart); err != nil {
+		return nil, err
+	}
+	reader := newViewerCSVReader(bufio.NewReaderSize(file, 256<<10))
+	for row := checkpoint * viewerStreamIndexStride; row < start; row++ {
+		if _, err := reader.Read(); err != nil {
+			return nil, err
+		}
+	}
+	rows := make([][]string, 0, count)
+	for len(rows) < count {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, padCSVRow(record, idx.width))
+	}
+	return rows, nil
+}
+
+func newViewerCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
+
+// viewerStreamTarget resolves the file a stream request refers to. The single
+// file viewer ignores the request; the folder viewer reads its `i` parameter.
+type viewerStreamTarget func(r *http.Request) (path string, docType viewerDocumentType, ok bool)
+
+func registerViewerStreamRoutes(mux *http.ServeMux, cache *viewerStreamCache, target viewerStreamTarget, touch func()) {
+	mux.HandleFunc("/lines", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		path, _, ok := target(r)
+		if !ok {
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			return
+		}
+		start, count := viewerStreamPageParams(r)
+		idx, err := cache.lineIndex(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		lines, err := idx.readLines(path, start, count)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if lines == nil {
+			lines = []string{}
+		}
+		writeViewerJSON(w, map[string]any{"start": start, "total": idx.total, "lines": lines})
+	})
+	mux.HandleFunc("/rows", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		path, docType, ok := target(r)
+		if !ok || docType != viewerDocumentTypeCSV {
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			return
+		}
+		start, count := viewerStreamPageParams(r)
+		idx, err := cache.recordIndex(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rows, err := idx.readRows(path, start, count)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if rows == nil {
+			rows = [][]string{}
+		}
+		writeViewerJSON(w, map[string]any{"start": start, "total": idx.total, "headers": idx.headers, "rows": rows})
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		touch()
+		path, _, ok := target(r)
+		if !ok {
+			http.Error(w, "invalid file", http.StatusBadRequest)
+			return
+		}
+		from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		if from < 0 {
+			from = 0
+		}
+		result, err := searchViewerFile(r.Context(), path, r.URL.Query().Get("q"), from, viewerSearchMaxResults)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeViewerJSON(w, result)
+	})
+}
+
+func viewerStreamPageParams(r *http.Request) (int, int) {
+	start, err := strconv.Atoi(r.URL.Query().Get("start"))
+	if err != nil || start < 0 {
+		start = 0
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count <= 0 {
+		count = viewerStreamPageSize
+	}
+	if count > viewerStreamMaxPage {
+		count = viewerStreamMaxPage
+	}
+	return start, count
+}
+
+func writeViewerJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func renderViewerStreamContent(doc viewerDocument) string {
+	kind := "lines"
+	if doc.docType == viewerDocumentTypeCSV {
+		kind = "rows"
+	}
+	return fmt.Sprintf(`<div class="code-frame stream-frame" id="stream-root" data-kind="%s">
+<div class="stream-bar">
+  <span class="viewer-meta" id="stream-meta">%s · indexing…</span>
+  <span class="stream-nav">
+    <button type="button" data-page="first">&#x21E4;</button>
+    <button type="button" data-page="prev">&#x2190;</button>
+    <input type="number" id="stream-goto" min="1" placeholder="line">
+    <button type="button" data-page="next">&#x2192;</button>
+    <button type="button" data-page="last">&#x21E5;</button>
+  </span>
+  <input type="search" id="stream-search" placeholder="Search whole file">
+</div>
+<div class="stream-results" id="stream-results" hidden></div>
+<div id="stream-body"></div>
+</div>
+<script>%s</script>`, kind, template.HTMLEscapeString(formatViewerByteSize(doc.size)), viewerStreamScript)
+}
+
+func renderCSVLoadMore(table viewerCSVTable) string {
+	if !table.Truncated {
+		return ""
+	}
+	return fmt.Sprintf(`<button type="button" class="stream-more" id="csv-more" data-next="%d" data-total="%d">Load %d more rows</button><script>%s</script>`,
+		len(table.Rows), table.TotalRows, viewerCSVPreviewRows, viewerCSVMoreScript)
+}
+
+func formatViewerByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+const viewerStreamStyles = `
+.stream-frame { padding-bottom: 12px; }
+.stream-bar {
+  display: flex; align-items: center; gap: 10px; flex-wrap: wrap;
+  padding: 10px 16px; border-bottom: 0.5px solid rgba(0,0,0,0.07);
+  position: sticky; top: 0; background: rgba(252,251,249,0.97); z-index: 2;
+  font-family: -apple-system, BlinkMacSystemFont, "Inter", "Segoe UI", sans-serif;
+}
+.stream-bar .viewer-meta { flex: 1; }
+.stream-nav { display: inline-flex; gap: 4px; align-items: center; }
+.stream-bar button, .stream-more {
+  border: 0.5px solid rgba(0,0,0,0.12); background: white; border-radius: 6px;
+  padding: 3px 8px; font-size: 12px; cursor: pointer; color: rgba(26,26,24,0.7);
+}
+.stream-bar button:hover, .stream-more:hover { background: rgba(26,26,24,0.05); }
+.stream-bar input {
+  border: 0.5px solid rgba(0,0,0,0.12); border-radius: 6px; padding: 3px 8px;
+  font-size: 12px; background: white; color: #1a1a18;
+}
+#stream-goto { width: 90px; }
+#stream-search { width: 220px; }
+.stream-results {
+  max-height: 220px; overflow: auto; padding: 6px 0;
+  border-bottom: 0.5px solid rgba(0,0,0,0.07); background: rgba(26,26,24,0.025);
+}
+.stream-hit { display: block; padding: 2px 16px; cursor: pointer; white-space: pre; overflow: hidden; text-overflow: ellipsis; }
+.stream-hit:hover { background: rgba(26,26,24,0.05); }
+.stream-hit b { color: #b85c1a; font-weight: 600; margin-right: 10px; }
+.stream-frame tr.hit .lc { background: rgba(232,201,122,0.28); }
+.stream-more { display: block; margin: 12px auto 0; }
+.stream-frame table.rows td, .stream-frame table.rows th { padding: 4px 12px; border-bottom: 0.5px solid rgba(0,0,0,0.06); text-align: left; white-space: pre; }
+.stream-frame table.rows th { position: sticky; top: 46px; background: rgba(244,243,240,0.98); font-weight: 600; }
+`
+
+const viewerStreamScript = `
+(function() {
+  var root = document.getElementById('stream-root');
+  if (!root) return;
+  var kind = root.getAttribute('data-kind');
+  var qs = location.search ? location.search + '&' : '?';
+  var meta = document.getElementById('stream-meta');
+  var body = document.getElementById('stream-body');
+  var results = document.getElementById('stream-results');
+  var gotoBox = document.getElementById('stream-goto');
+  var searchBox = document.getElementById('stream-search');
+  var baseMeta = meta.textContent.replace(/ · .*$/, '');
+  var page = 500, start = 0, total = 0, headers = null;
+
+  function esc(s) {
+    return String(s).replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/>/g,'&gt;');
+  }
+
+  function load(at, mark) {
+    if (at < 0) at = 0;
+    fetch('/' + kind + qs + 'start=' + at + '&count=' + page).then(function(r) { return r.json(); }).then(function(data) {
+      start = data.start; total = data.total;
+      var h = '';
+      if (kind === 'rows') {
+        headers = data.headers;
+        h += '<table class="rows"><thead><tr><th class="ln">#</th>';
+        headers.forEach(function(c) { h += '<th>' + esc(c) + '</th>'; });
+        h += '</tr></thead><tbody>';
+        data.rows.forEach(function(row, i) {
+          h += '<tr><td class="ln">' + (start + i + 1) + '</td>';
+          row.forEach(function(c) { h += '<td>' + esc(c) + '</td>'; });
+          h += '</tr>';
+        });
+        h += '</tbody></table>';
+      } else {
+        h += '<table class="line-table">';
+        data.lines.forEach(function(line, i) {
+          var n = start + i + 1;
+          h += '<tr id="L' + n + '"' + (n === mark ? ' class="hit"' : '') + '><td class="ln">' + n + '</td><td class="lc">' + esc(line) + '</td></tr>';
+        });
+        h += '</table>';
+      }
+      body.innerHTML = h;
+      var end = Math.min(start + page, total);
+      meta.textContent = baseMeta + ' · ' + (total ? (start + 1) + '–' + end : 0) + ' of ' + total + (kind === 'rows' ? ' rows' : ' lines');
+      var target = mark ? document.getElementById('L' + mark) : null;
+      if (target) target.scrollIntoView({block: 'center'}); else root.scrollIntoView({block: 'start'});
+    }).catch(function(err) { meta.textContent = baseMeta + ' · ' + err.message; });
+  }
+
+  root.querySelectorAll('[data-page]').forEach(function(btn) {
+    btn.addEventListener('click', function() {
+      var p = btn.getAttribute('data-page');
+      if (p === 'first') load(0);
+      if (p === 'prev') load(start - page);
+      if (p === 'next' && start + page < total) load(start + page);
+      if (p === 'last') load(Math.max(0, Math.floor((total - 1) / page) * page));
+    });
+  });
+  gotoBox.addEventListener('keydown', function(ev) {
+    if (ev.key !== 'Enter') return;
+    var n = parseInt(gotoBox.value, 10);
+    if (n > 0) load(Math.floor((n - 1) / page) * page, n);
+  });
+
+  var searchSeq = 0;
+  function search(q, from, append) {
+    var seq = ++searchSeq;
+    if (!append) { results.innerHTML = '<div class="viewer-meta stream-hit">Searching…</div>'; }
+    results.hidden = false;
+    fetch('/search' + qs + 'q=' + encodeURIComponent(q) + '&from=' + from).then(function(r) { return r.json(); }).then(function(data) {
+      if (seq !== searchSeq) return;
+      var more = results.querySelector('.stream-more');
+      if (more) more.remove();
+      if (!append) results.innerHTML = '';
+      if (!append && data.matches.length === 0) {
+        results.innerHTML = '<div class="viewer-meta stream-hit">No matches</div>';
+        return;
+      }
+      data.matches.forEach(function(m) {
+        var el = document.createElement('span');
+        el.className = 'stream-hit';
+        el.innerHTML = '<b>' + m.line + '</b>' + esc(m.text);
+        el.addEventListener('click', function() {
+          if (kind === 'rows') load(Math.max(0, Math.floor((m.line - 2) / page) * page));
+          else load(Math.floor((m.line - 1) / page) * page, m.line);
+        });
+        results.appendChild(el);
+      });
+      if (!data.done) {
+        var btn = document.createElement('button');
+        btn.type = 'button';
+        btn.className = 'stream-more';
+        btn.textContent = 'More matches';
+        btn.addEventListener('click', function() { search(q, data.next, true); });
+        results.appendChild(btn);
+      }
+    });
+  }
+  searchBox.addEventListener('keydown', function(ev) {
+    if (ev.key !== 'Enter') return;
+    var q = searchBox.value.trim();
+    if (!q) { results.hidden = true; return; }
+    search(q, 0, false);
+  });
+
+  load(0);
+})();
+`
+
+const viewerCSVMoreScript = `
+(function() {
+  var btn = document.getElementById('csv-more');
+  if (!btn) return;
+  var qs = location.search ? location.search + '&' : '?';
+  var body = document.querySelector('.csv-frame tbody');
+  var meta = document.querySelector('.csv-frame .viewer-meta');
+  function esc(s) {
+    return String(s).replace(/&/g,'&amp;').replace(/</g,'&lt;').replace(/>/g,'&gt;');
+  }
+  btn.addEventListener('click', function() {
+    var next = parseInt(btn.getAttribute('data-next'), 10);
+    btn.disabled = true;
+    fetch('/rows' + qs + 'start=' + next + '&count=500').then(function(r) { return r.json(); }).then(function(data) {
+      var h = '';
+      data.rows.forEach(function(row) {
+        h += '<tr>';
+        row.forEach(function(c) { h += '<td>' + esc(c) + '</td>'; });
+        h += '</tr>';
+      });
+      body.insertAdjacentHTML('beforeend', h);
+      next += data.rows.length;
+      btn.setAttribute('data-next', next);
+      if (meta) meta.textContent = 'Showing first ' + next + ' of ' + data.total + ' rows';
+      btn.disabled = false;
+      if (next >= data.total || data.rows.length === 0) btn.remove();
+    });
+  });
+})();
+`
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeNumberedLog(t *testing.T, path string, lines int, trailingNewline bool) {
+	t.Helper()
+	var b strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&b, "line %d level=info", i)
+		if i < lines || trailingNewline {
+			b.WriteByte('\n')
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("write log failed: %v", err)
+	}
+}
+
+func TestViewerLineIndexReadsPagesAcrossCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	writeNumberedLog(t, path, 5000, false)
+
+	idx, err := buildViewerLineIndex(path)
+	if err != nil {
+		t.Fatalf("buildViewerLineIndex returned error: %v", err)
+	}
+	if idx.total != 5000 {
+		t.Fatalf("expected 5000 lines, got %d", idx.total)
+	}
+	if len(idx.offsets) != 5 {
+		t.Fatalf("expected 5 checkpoints, got %d", len(idx.offsets))
+	}
+
+	lines, err := idx.readLines(path, 3070, 3)
+	if err != nil {
+		t.Fatalf("readLines returned error: %v", err)
+	}
+	want := []string{"line 3071 level=info", "line 3072 level=info", "line 3073 level=info"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %q, got %q", want, lines)
+	}
+
+	tail, err := idx.readLines(path, 4998, 50)
+	if err != nil {
+		t.Fatalf("readLines tail returned error: %v", err)
+	}
+	if len(tail) != 2 || tail[1] != "line 5000 level=info" {
+		t.Fatalf("expected final two lines, got %q", tail)
+	}
+}
+
+func TestSearchViewerFileResumesFromNextLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	writeNumberedLog(t, path, 400, true)
+
+	first, err := searchViewerFile(context.Background(), path, "LINE 1", 0, 3)
+	if err != nil {
+		t.Fatalf("searchViewerFile returned error: %v", err)
+	}
+	if first.Done || len(first.Matches) != 3 || first.Matches[0].Line != 1 || first.Matches[2].Line != 11 {
+		t.Fatalf("unexpected first page: %#v", first)
+	}
+	rest, err := searchViewerFile(context.Background(), path, "line 39", first.Next, 50)
+	if err != nil {
+		t.Fatalf("searchViewerFile returned error: %v", err)
+	}
+	if !rest.Done || len(rest.Matches) != 11 {
+		t.Fatalf("expected 11 matches for line 39x, got %#v", rest)
+	}
+}
+
+func TestViewerRecordIndexPagesQuotedMultilineRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	var b strings.Builder
+	b.WriteString("\ufeffid,note\n")
+	for i := 1; i <= 1200; i++ {
+		if i == 1100 {
+			fmt.Fprintf(&b, "%d,\"spans\ntwo lines\"\n", i)
+			continue
+		}
+		fmt.Fprintf(&b, "%d,row %d\n", i, i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("write csv failed: %v", err)
+	}
+
+	idx, err := buildViewerRecordIndex(path)
+	if err != nil {
+		t.Fatalf("buildViewerRecordIndex returned error: %v", err)
+	}
+	if idx.total != 1200 || idx.headers[0] != "id" {
+		t.Fatalf("unexpected index: total=%d headers=%q", idx.total, idx.headers)
+	}
+	rows, err := idx.readRows(path, 1098, 3)
+	if err != nil {
+		t.Fatalf("readRows returned error: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "1099" || rows[1][1] != "spans\ntwo lines" || rows[2][0] != "1101" {
+		t.Fatalf("unexpected rows: %q", rows)
+	}
+}
+
+func TestStreamedViewerHandlerServesShellLinesAndSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeNumberedLog(t, path, 1500, true)
+	doc := viewerDocument{path: path, fileName: "app.log", docType: viewerDocumentTypeText, size: 1, streamed: true}
+
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+
+	page := fetchViewerBody(t, server.URL+"/")
+	if !strings.Contains(page, `id="stream-root"`) || strings.Contains(page, "line 1 level=info") {
+		t.Fatalf("expected streaming shell without inline content, got %q", page)
+	}
+
+	var lines struct {
+		Start int      `json:"start"`
+		Total int      `json:"total"`
+		Lines []string `json:"lines"`
+	}
+	if err := json.Unmarshal([]byte(fetchViewerBody(t, server.URL+"/lines?start=1200&count=2")), &lines); err != nil {
+		t.Fatalf("decode lines failed: %v", err)
+	}
+	if lines.Total != 1500 || len(lines.Lines) != 2 || lines.Lines[0] != "line 1201 level=info" {
+		t.Fatalf("unexpected lines payload: %#v", lines)
+	}
+
+	var search viewerSearchResult
+	if err := json.Unmarshal([]byte(fetchViewerBody(t, server.URL+"/search?q=line+1499")), &search); err != nil {
+		t.Fatalf("decode search failed: %v", err)
+	}
+	if len(search.Matches) != 1 || search.Matches[0].Line != 1499 {
+		t.Fatalf("unexpected search payload: %#v", search)
+	}
+}
+
+func TestCSVViewerOffersPagingPastPreviewCutoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	var b strings.Builder
+	b.WriteString("id\n")
+	for i := 1; i <= viewerCSVPreviewRows+20; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("write csv failed: %v", err)
+	}
+	doc, err := loadViewerDocument(path)
+	if err != nil {
+		t.Fatalf("loadViewerDocument returned error: %v", err)
+	}
+	server := httptest.NewServer(newFileViewerHandler(doc, func() {}))
+	defer server.Close()
+
+	page := fetchViewerBody(t, server.URL+"/")
+	if !strings.Contains(page, `id="csv-more"`) {
+		t.Fatalf("expected load-more control, got %q", page)
+	}
+	var rows struct {
+		Total int        `json:"total"`
+		Rows  [][]string `json:"rows"`
+	}
+	if err := json.Unmarshal([]byte(fetchViewerBody(t, fmt.Sprintf("%s/rows?start=%d", server.URL, viewerCSVPreviewRows))), &rows); err != nil {
+		t.Fatalf("decode rows failed: %v", err)
+	}
+	if rows.Total != viewerCSVPreviewRows+20 || len(rows.Rows) != 20 || rows.Rows[0][0] != "501" {
+		t.Fatalf("unexpected rows payload: %#v", rows)
+	}
+}
+
+func fetchViewerBody(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s failed: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from %s, got %d: %s", url, resp.StatusCode, body)
+	}
+	return string(body)
+}
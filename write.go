@@ -2,11 +2,15 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
@@ -17,7 +21,35 @@ func jotWrite(w io.Writer, args []string) error {
 	if len(args) == 0 || (len(args) == 1 && isHelpFlag(args[0])) {
 		return writeHelp(w, "write")
 	}
-	if len(args) > 1 {
+	var opts wEditorOptions
+	entry := false
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "--wrap":
+			opts.wrap = true
+			continue
+		case "--preview":
+			opts.preview = true
+			continue
+		case "--entry":
+			entry = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	args = rest
+	if entry {
+		if len(args) > 1 {
+			return fmt.Errorf("jot write --entry takes at most one entry id - try: jot write --entry <entry-id>")
+		}
+		id := ""
+		if len(args) == 1 {
+			id = strings.TrimSpace(args[0])
+		}
+		return jotWriteEntry(w, id, opts)
+	}
+	if len(args) != 1 {
 		return fmt.Errorf("jot write takes one file - try: jot write <file.md>")
 	}
 	path := strings.TrimSpace(args[0])
@@ -29,18 +61,30 @@ func jotWrite(w io.Writer, args []string) error {
 		initial = string(data)
 	} else if !os.IsNotExist(err) {
 		return err
+	} else if _, ok := wLookupEntry(path); ok {
+		// No such file, but a journal entry has this id.
+		return jotWriteEntry(w, path, opts)
 	}
 	if !term.IsTerminal(int(os.Stdin.Fd())) {
 		return fmt.Errorf("jot write requires an interactive terminal")
 	}
-	saved, err := runInlineEditor(path, initial)
+	// One reader serves the recovery prompt and the editor, so keys typed
+	// during the prompt are not stranded in a second buffer.
+	in := bufio.NewReader(os.Stdin)
+	initial, recovered, err := offerSwapRecovery(in, w, path, initial, time.Now())
+	if err != nil {
+		return err
+	}
+	opts.recovered = recovered
+	saved, err := runInlineEditor(in, path, initial, opts)
 	if err != nil {
 		return err
 	}
 	if saved != nil {
-		if err := os.WriteFile(path, []byte(*saved), 0o644); err != nil {
+		if err := writeEditorFileAtomic(path, []byte(*saved)); err != nil {
 			return err
 		}
+		wRemoveSwaps(path)
 		fmt.Fprintln(w, fg(wColSaved)+"  saved"+wrst+" -> "+path)
 	} else {
 		fmt.Fprintln(w, fg(wColMuted)+"  quit without saving"+wrst)
@@ -52,24 +96,27 @@ func jotWrite(w io.Writer, args []string) error {
 // Palette — warm, minimal, Notion-adjacent
 // ---------------------------------------------------------------------------
 const (
-	wColBg      = "#0f0f0d"
-	wColBarBg   = "#161614"
-	wColCurBg   = "#1a1a17"
-	wColAccent  = "#c4a882"
-	wColMuted   = "#44443c"
-	wColSubtle  = "#585850"
-	wColDimMrk  = "#252521"
-	wColText    = "#d4d0c6"
-	wColH1      = "#e8c97a"
-	wColH2      = "#c4a882"
-	wColH3      = "#9a7e5e"
-	wColCode    = "#5aaf59"
-	wColBold    = "#ece6d6"
-	wColItalic  = "#968676"
-	wColBullet  = "#b89a72"
-	wColSaved   = "#5aaf59"
-	wColUnsaved = "#d4896a"
-	wrst        = "\x1b[0m"
+	wColBg       = "#0f0f0d"
+	wColBarBg    = "#161614"
+	wColCurBg    = "#1a1a17"
+	wColAccent   = "#c4a882"
+	wColMuted    = "#44443c"
+	wColSubtle   = "#585850"
+	wColDimMrk   = "#252521"
+	wColText     = "#d4d0c6"
+	wColH1       = "#e8c97a"
+	wColH2       = "#c4a882"
+	wColH3       = "#9a7e5e"
+	wColCode     = "#5aaf59"
+	wColBold     = "#ece6d6"
+	wColItalic   = "#968676"
+	wColBullet   = "#b89a72"
+	wColSaved    = "#5aaf59"
+	wColUnsaved  = "#d4896a"
+	wColMatch    = "#33301f"
+	wColMatchCur = "#6b5a2e"
+	wColSelect   = "#2f3437"
+	wrst         = "\x1b[0m"
 )
 
 func fg(h string) string { return "\x1b[38;2;" + wRGB(h) + "m" }
@@ -122,7 +169,7 @@ func wStripANSI(s string) string {
 	return b.String()
 }
 
-func wVisLen(s string) int { return utf8.RuneCountInString(wStripANSI(s)) }
+func wVisLen(s string) int { return wStrWidth(wStripANSI(s)) }
 
 func wTruncANSI(s string, max int) string {
 	if max <= 0 {
@@ -131,6 +178,7 @@ func wTruncANSI(s string, max int) string {
 	var b strings.Builder
 	r := []rune(s)
 	i, count := 0, 0
+	var prev rune
 	for i < len(r) && count < max {
 		if r[i] == '\x1b' && i+1 < len(r) && r[i+1] == '[' {
 			b.WriteRune(r[i])
@@ -145,9 +193,14 @@ func wTruncANSI(s string, max int) string {
 			}
 			continue
 		}
+		w := wWidthAfter(prev, r[i])
+		if count+w > max {
+			break
+		}
 		b.WriteRune(r[i])
+		prev = r[i]
 		i++
-		count++
+		count += w
 	}
 	b.WriteString(wrst)
 	return b.String()
@@ -167,13 +220,32 @@ func wRow(content, bgCol string, width int) string {
 // ---------------------------------------------------------------------------
 
 type wEditor struct {
-	path    string
+	path string
+	// label replaces the file name in the header, for journal entries.
+	label   string
 	lines   []string
 	cx, cy  int
 	scrollY int
+	// scrollSeg is the first row of line scrollY on screen when wrapping.
+	scrollSeg int
+	// wrap soft-wraps long lines at word boundaries instead of cutting them.
+	wrap bool
+	// preview shows the rendered document beside the source.
+	preview bool
 	saved   bool
+	// rev counts changes to lines, so autosave can tell when there is
+	// something new to write.
+	rev     int
 	W, H    int
 	visH    int
+	history wHistory
+	search  wSearch
+	// The selection runs from anchor to the cursor while selecting is set.
+	anchor    wPos
+	selecting bool
+	clip      wClipboard
+	// message replaces the word count in the status bar until the next key.
+	message string
 }
 
 func wNewEditor(path, content string) *wEditor {
@@ -219,13 +291,22 @@ func (e *wEditor) gutW() int {
 // contentW: text columns = W minus gutter minus left padding minus right padding
 func (e *wEditor) contentW() int {
 	// gutter(gutW) + "  " padding left + text + "  " padding right
-	w := e.W - e.gutW() - 4
+	w := e.paneW() - e.gutW() - 4
 	if w < 8 {
 		w = 8
 	}
 	return w
 }
 
+// paneW is the width of the editing pane: the whole terminal, or the left
+// half while the preview is open.
+func (e *wEditor) paneW() int {
+	if e.preview {
+		return e.W / 2
+	}
+	return e.W
+}
+
 func (e *wEditor) line() string {
 	if e.cy >= len(e.lines) {
 		return ""
@@ -246,18 +327,98 @@ func (e *wEditor) scroll() {
 	if e.cy >= e.scrollY+e.visH {
 		e.scrollY = e.cy - e.visH + 1
 	}
+	if !e.wrap {
+		e.scrollSeg = 0
+		return
+	}
+	e.scrollSeg = wMin(e.scrollSeg, len(e.segments(e.scrollY))-1)
+	seg := wSegOf(e.segments(e.cy), e.cx)
+	if e.cy == e.scrollY && seg < e.scrollSeg {
+		e.scrollSeg = seg
+	}
+	for e.rowsToCursor() >= e.visH {
+		e.scrollSeg++
+		if e.scrollSeg >= len(e.segments(e.scrollY)) {
+			e.scrollY++
+			e.scrollSeg = 0
+		}
+	}
+}
+
+// segments returns where each screen row of line i starts: one row unless
+// soft wrap is on.
+func (e *wEditor) segments(i int) []int {
+	if !e.wrap || i < 0 || i >= len(e.lines) {
+		return []int{0}
+	}
+	return wWrapLine([]rune(e.lines[i]), e.contentW())
+}
+
+// rowsToCursor counts the screen rows from the top of the view to the
+// cursor's row.
+func (e *wEditor) rowsToCursor() int {
+	seg := wSegOf(e.segments(e.cy), e.cx)
+	if e.cy == e.scrollY {
+		return seg - e.scrollSeg
+	}
+	n := len(e.segments(e.scrollY)) - e.scrollSeg
+	for i := e.scrollY + 1; i < e.cy; i++ {
+		n += len(e.segments(i))
+	}
+	return n + seg
+}
+
+// wRowRef is one screen row: a row of a line, or past the end when line
+// is -1.
+type wRowRef struct {
+	line, start, end int
+	cont             bool
+}
+
+// layoutRows lists the rows on screen from the scroll position down.
+func (e *wEditor) layoutRows() []wRowRef {
+	rows := make([]wRowRef, 0, e.visH)
+	line, seg := e.scrollY, e.scrollSeg
+	for len(rows) < e.visH {
+		if line >= len(e.lines) {
+			rows = append(rows, wRowRef{line: -1})
+			continue
+		}
+		segs := e.segments(line)
+		for ; seg < len(segs) && len(rows) < e.visH; seg++ {
+			end := utf8.RuneCountInString(e.lines[line])
+			if seg+1 < len(segs) {
+				end = segs[seg+1]
+			}
+			rows = append(rows, wRowRef{line: line, start: segs[seg], end: end, cont: seg > 0})
+		}
+		line, seg = line+1, 0
+	}
+	return rows
 }
 
 // ---------------------------------------------------------------------------
 // Editing
 // ---------------------------------------------------------------------------
 
+// replaceLines swaps lines[start:end] for with. Every edit goes through
+// here so the undo history sees it.
+func (e *wEditor) replaceLines(start, end int, with ...string) {
+	removed := append([]string(nil), e.lines[start:end]...)
+	inserted := append([]string(nil), with...)
+	e.lines = wSplice(e.lines, start, end-start, inserted)
+	e.history.record(wOp{start: start, removed: removed, inserted: inserted})
+	e.saved = false
+	e.rev++
+}
+
+func (e *wEditor) setLine(text string) { e.replaceLines(e.cy, e.cy+1, text) }
+
 func (e *wEditor) insert(r rune) {
 	line := []rune(e.line())
 	cx := wMin(e.cx, len(line))
-	e.lines[e.cy] = string(append(line[:cx:cx], append([]rune{r}, line[cx:]...)...))
+	e.setLine(string(line[:cx]) + string(r) + string(line[cx:]))
 	e.cx++
-	e.saved = false
 }
 
 func (e *wEditor) newline() {
@@ -265,12 +426,9 @@ func (e *wEditor) newline() {
 	cx := wMin(e.cx, len(line))
 	before, after := string(line[:cx]), string(line[cx:])
 	prefix := wListPrefix(before)
-	e.lines[e.cy] = before
-	rest := append([]string{prefix + after}, e.lines[e.cy+1:]...)
-	e.lines = append(e.lines[:e.cy+1], rest...)
+	e.replaceLines(e.cy, e.cy+1, before, prefix+after)
 	e.cy++
 	e.cx = utf8.RuneCountInString(prefix)
-	e.saved = false
 }
 
 func wListPrefix(s string) string {
@@ -289,59 +447,50 @@ func (e *wEditor) backspace() {
 		}
 		prev := e.lines[e.cy-1]
 		e.cx = utf8.RuneCountInString(prev)
-		e.lines[e.cy-1] = prev + e.lines[e.cy]
-		e.lines = append(e.lines[:e.cy], e.lines[e.cy+1:]...)
+		e.replaceLines(e.cy-1, e.cy+1, prev+e.lines[e.cy])
 		e.cy--
 	} else {
 		r := []rune(e.line())
 		cx := wMin(e.cx, len(r))
-		e.lines[e.cy] = string(append(r[:cx-1:cx-1], r[cx:]...))
-		e.cx--
+		prev := wPrevBoundary(r, cx)
+		e.setLine(string(r[:prev]) + string(r[cx:]))
+		e.cx = prev
 	}
-	e.saved = false
 }
 
 func (e *wEditor) deleteForward() {
 	r := []rune(e.line())
 	if e.cx < len(r) {
-		e.lines[e.cy] = string(append(r[:e.cx:e.cx], r[e.cx+1:]...))
-		e.saved = false
+		e.setLine(string(r[:e.cx]) + string(r[wNextBoundary(r, e.cx):]))
 	} else if e.cy < len(e.lines)-1 {
-		e.lines[e.cy] = e.line() + e.lines[e.cy+1]
-		e.lines = append(e.lines[:e.cy+1], e.lines[e.cy+2:]...)
-		e.saved = false
+		e.replaceLines(e.cy, e.cy+2, e.line()+e.lines[e.cy+1])
 	}
 }
 
 func (e *wEditor) insertHeading(level int) {
 	prefix := strings.Repeat("#", level) + " "
 	stripped := strings.TrimLeft(e.line(), "# ")
-	e.lines[e.cy] = prefix + stripped
+	e.setLine(prefix + stripped)
 	e.cx = utf8.RuneCountInString(e.lines[e.cy])
-	e.saved = false
 }
 
 func (e *wEditor) wrapWord(open, close string) {
 	r := []rune(e.line())
 	cx := wMin(e.cx, len(r))
-	e.lines[e.cy] = string(r[:cx]) + open + close + string(r[cx:])
+	e.setLine(string(r[:cx]) + open + close + string(r[cx:]))
 	e.cx = cx + utf8.RuneCountInString(open)
-	e.saved = false
 }
 
 func (e *wEditor) insertBelow(text string) {
-	rest := append([]string{text}, e.lines[e.cy+1:]...)
-	e.lines = append(e.lines[:e.cy+1], rest...)
+	e.replaceLines(e.cy+1, e.cy+1, text)
 	e.cy++
 	e.cx = 0
-	e.saved = false
 }
 
 func (e *wEditor) prependLine(prefix string) {
 	if !strings.HasPrefix(e.line(), prefix) {
-		e.lines[e.cy] = prefix + e.line()
+		e.setLine(prefix + e.line())
 		e.cx += utf8.RuneCountInString(prefix)
-		e.saved = false
 	}
 }
 
@@ -356,7 +505,11 @@ func wMin(a, b int) int {
 // Rendering — no box, Notion-style
 // ---------------------------------------------------------------------------
 
-func (e *wEditor) render() {
+func (e *wEditor) render() { fmt.Print(e.frame()) }
+
+// frame builds one full redraw, ending with the moves that park the
+// terminal cursor.
+func (e *wEditor) frame() string {
 	var sb strings.Builder
 	W := e.W
 	gw := e.gutW()
@@ -365,6 +518,9 @@ func (e *wEditor) render() {
 	// ── Header ────────────────────────────────────────────────────────────
 	// Minimal: "jot  filename  ● unsaved        ctrl+s  ctrl+q"
 	fname := filepath.Base(e.path)
+	if e.label != "" {
+		fname = e.label
+	}
 	var saveMark string
 	if e.saved {
 		saveMark = fg(wColSaved) + "✓" + wrst + bg(wColBarBg) + fg(wColMuted) + " saved"
@@ -395,8 +551,17 @@ func (e *wEditor) render() {
 	// Each row: [gutter][  ][content padded to cw][  ]
 	// No box lines. Just clean background fill.
 
-	for rowIdx := 0; rowIdx < e.visH; rowIdx++ {
-		lineIdx := e.scrollY + rowIdx
+	curRow, curCol := 0, gw+2
+	paneW := e.paneW()
+	var pv []wPreviewLine
+	pvTop, pvW := 0, W-paneW-1
+	if e.preview {
+		pv = wRenderPreview(e.lines, pvW-2)
+		pvTop = e.previewTop(pv)
+	}
+	highlighted := map[int]string{}
+	for rowIdx, row := range e.layoutRows() {
+		lineIdx := row.line
 		isCur := lineIdx == e.cy
 		lineBg := wColBg
 		if isCur {
@@ -405,36 +570,54 @@ func (e *wEditor) render() {
 
 		var rowBuf strings.Builder
 
-		// Gutter — subtle line numbers flush right
-		if lineIdx < len(e.lines) {
-			numStr := fmt.Sprintf("%*d", gw-1, lineIdx+1)
-			if isCur {
-				rowBuf.WriteString(bg(lineBg) + fg(wColAccent) + numStr + " " + wrst)
-			} else {
-				rowBuf.WriteString(bg(wColBg) + fg(wColMuted) + numStr + " " + wrst)
-			}
-		} else {
+		// Gutter — subtle line numbers flush right, a hook on wrapped rows
+		switch {
+		case lineIdx < 0:
 			// Empty line marker — very dim, just a dot
 			rowBuf.WriteString(bg(wColBg) + fg(wColDimMrk) + fmt.Sprintf("%*s ", gw-1, "·") + wrst)
+		case row.cont:
+			rowBuf.WriteString(bg(lineBg) + fg(wColMuted) + fmt.Sprintf("%*s ", gw-1, "↪") + wrst)
+		case isCur:
+			rowBuf.WriteString(bg(lineBg) + fg(wColAccent) + fmt.Sprintf("%*d", gw-1, lineIdx+1) + " " + wrst)
+		default:
+			rowBuf.WriteString(bg(wColBg) + fg(wColMuted) + fmt.Sprintf("%*d", gw-1, lineIdx+1) + " " + wrst)
 		}
 
 		// Left padding (2 spaces) — creates the comfortable Notion-like margin
 		rowBuf.WriteString(bg(lineBg) + "  ")
 
 		// Content
-		if lineIdx < len(e.lines) {
-			highlighted := wHighlightLine(e.lines[lineIdx], lineBg)
-			rawLen := wVisLen(highlighted)
+		if lineIdx >= 0 {
+			full, ok := highlighted[lineIdx]
+			if !ok {
+				full = wOverlay(wHighlightLine(e.lines[lineIdx], lineBg), append(e.searchSpans(lineIdx), e.selectionSpans(lineIdx)...), lineBg)
+				highlighted[lineIdx] = full
+			}
+			content := full
+			if e.wrap {
+				content = wSliceANSI(full, row.start, row.end)
+			}
+			rawLen := wVisLen(content)
 			if rawLen > cw {
-				// Long line — truncate with › indicator, no hard wrap
-				highlighted = wTruncANSI(highlighted, cw-1) +
-					wrst + bg(wColBarBg) + fg(wColMuted) + "›"
+				if e.wrap {
+					// Only hanging spaces overflow a wrapped row.
+					content = wTruncANSI(content, cw)
+				} else {
+					// Long line — truncate with › indicator
+					content = wTruncANSI(content, cw-1) +
+						wrst + bg(wColBarBg) + fg(wColMuted) + "›"
+				}
 				rawLen = cw
 			}
-			rowBuf.WriteString(highlighted)
+			rowBuf.WriteString(content + wrst)
 			if rawLen < cw {
 				rowBuf.WriteString(bg(lineBg) + strings.Repeat(" ", cw-rawLen))
 			}
+			if isCur && e.cx >= row.start && (e.cx < row.end || e.cx == utf8.RuneCountInString(e.lines[lineIdx]) || !e.wrap) {
+				curRow = rowIdx
+				r := []rune(e.lines[lineIdx])
+				curCol = gw + 2 + wStrWidth(string(r[row.start:wMin(e.cx, len(r))]))
+			}
 		} else {
 			rowBuf.WriteString(bg(wColBg) + strings.Repeat(" ", cw))
 		}
@@ -442,7 +625,13 @@ func (e *wEditor) render() {
 		// Right padding
 		rowBuf.WriteString(bg(lineBg) + "  " + wrst)
 
-		sb.WriteString(wRow(rowBuf.String(), wColBg, W) + "\r\n")
+		if !e.preview {
+			sb.WriteString(wRow(rowBuf.String(), wColBg, W) + "\r\n")
+			continue
+		}
+		sb.WriteString(wRow(rowBuf.String(), wColBg, paneW) +
+			bg(wColBarBg) + fg(wColDimMrk) + "│" + wrst +
+			e.previewRow(pv, pvTop+rowIdx, pvW) + "\r\n")
 	}
 
 	// ── Status bar ────────────────────────────────────────────────────────
@@ -453,8 +642,18 @@ func (e *wEditor) render() {
 	}
 	sLeft := bg(wColBarBg) + fg(wColMuted) +
 		fmt.Sprintf("  %d words · %d lines", words, len(e.lines))
+	if e.message != "" {
+		sLeft = bg(wColBarBg) + fg(wColAccent) + "  " + e.message
+	}
+	wrapMark := ""
+	if e.wrap {
+		wrapMark = "wrap  "
+	}
+	if e.preview {
+		wrapMark += "preview  "
+	}
 	sRight := bg(wColBarBg) + fg(wColMuted) +
-		fmt.Sprintf("  %d%%  ln %d  col %d  ", pct, e.cy+1, e.cx+1)
+		fmt.Sprintf("  %s%d%%  ln %d  col %d  ", wrapMark, pct, e.cy+1, e.cx+1)
 	slw := wVisLen(sLeft)
 	srw := wVisLen(sRight)
 	sGap := W - slw - srw
@@ -462,21 +661,30 @@ func (e *wEditor) render() {
 		sGap = 0
 	}
 	statusRow := sLeft + bg(wColBarBg) + strings.Repeat(" ", sGap) + sRight
+	promptCol := -1
+	if e.search.prompt != wPromptNone {
+		statusRow, promptCol = e.promptRow(W)
+	}
 	sb.WriteString(wRow(statusRow, wColBarBg, W) + "\r\n")
 
-	fmt.Print(sb.String())
+	// While a prompt takes input the cursor sits at the end of it.
+	if promptCol >= 0 {
+		sb.WriteString(fmt.Sprintf("\x1b[1A\r\x1b[%dC", wMin(promptCol, W-1)))
+		return sb.String()
+	}
 
 	// After printing, cursor is at the end of the status bar (bottom of render).
 	// Move up to the correct text row, then to the correct column.
 	// Rows from bottom: status(1) + rows below cursor in visible area
-	rowsFromBottom := 1 + (e.visH - 1 - (e.cy - e.scrollY))
-	fmt.Printf("\x1b[%dA", rowsFromBottom) // move up to cursor line
+	rowsFromBottom := 1 + (e.visH - 1 - curRow)
+	sb.WriteString(fmt.Sprintf("\x1b[%dA", rowsFromBottom)) // move up to cursor line
 	// Now move to correct column using carriage return + forward
-	curC := gw + 2 + e.cx // 0-indexed
-	fmt.Print("\r")       // go to column 0
+	curC := wMin(curCol, W-1) // 0-indexed
+	sb.WriteString("\r")      // go to column 0
 	if curC > 0 {
-		fmt.Printf("\x1b[%dC", curC) // move right curC columns
+		sb.WriteString(fmt.Sprintf("\x1b[%dC", curC)) // move right curC columns
 	}
+	return sb.String()
 }
 
 // ---------------------------------------------------------------------------
@@ -544,7 +752,19 @@ func wHighlightInline(s, bgCol string) string {
 // Main loop with resize detection
 // ---------------------------------------------------------------------------
 
-func runInlineEditor(path, initial string) (*string, error) {
+var errWriteInterrupted = errors.New("jot write was interrupted; unsaved changes are kept for recovery")
+
+// wEditorOptions carry what jot write decided before the editor opened.
+type wEditorOptions struct {
+	wrap    bool
+	preview bool
+	// recovered means initial came from a swap file and differs from disk.
+	recovered bool
+	// label names the document in the header instead of the file name.
+	label string
+}
+
+func runInlineEditor(in *bufio.Reader, path, initial string, opts wEditorOptions) (*string, error) {
 	fd := int(os.Stdin.Fd())
 	old, err := term.MakeRaw(fd)
 	if err != nil {
@@ -553,6 +773,28 @@ func runInlineEditor(path, initial string) (*string, error) {
 	defer term.Restore(fd, old)
 
 	e := wNewEditor(path, initial)
+	e.label = opts.label
+	e.wrap = opts.wrap
+	if opts.preview {
+		e.togglePreview()
+	}
+	swap := newWSwap(path)
+	swapRev := e.rev
+	if opts.recovered {
+		e.saved = false
+		e.history.savedSeq = -1
+		e.message = "recovered unsaved changes · ctrl+s to keep them"
+		swapRev = -1
+	}
+	autosave := time.NewTicker(wSwapInterval)
+	defer autosave.Stop()
+	// A closed terminal sends SIGHUP; flush the swap file before going.
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP, syscall.SIGTERM)
+	defer signal.Stop(hangup)
+	// Bracketed paste lets a paste arrive as one key instead of typed lines.
+	fmt.Print("\x1b[?2004h")
+	defer fmt.Print("\x1b[?2004l")
 	// Reserve exactly visH+2 rows (header + text + status)
 	totalRows := e.visH + 2
 	for i := 0; i < totalRows; i++ {
@@ -592,10 +834,10 @@ func runInlineEditor(path, initial string) (*string, error) {
 	}()
 
 	keysCh := make(chan []byte, 32)
+	keys := newWKeyReader(in)
 	go func() {
-		reader := bufio.NewReader(os.Stdin)
 		for {
-			b, err := wReadKey(reader)
+			b, err := wReadKey(keys)
 			if err != nil {
 				close(keysCh)
 				return
@@ -625,136 +867,342 @@ func runInlineEditor(path, initial string) (*string, error) {
 				redraw()
 			}
 
+		case <-hangup:
+			if !e.saved {
+				_ = swap.write(strings.Join(e.lines, "\n"), time.Now())
+			}
+			term.Restore(fd, old)
+			return nil, errWriteInterrupted
+
+		case <-autosave.C:
+			if e.rev == swapRev {
+				continue
+			}
+			swapRev = e.rev
+			if e.saved {
+				swap.remove()
+				continue
+			}
+			if err := swap.write(strings.Join(e.lines, "\n"), time.Now()); err != nil {
+				e.message = "autosave failed: " + err.Error()
+				redraw()
+			}
+
 		case b, ok := <-keysCh:
 			if !ok {
-				return nil, nil
+				// The terminal went away; keep what was typed for recovery.
+				if !e.saved {
+					_ = swap.write(strings.Join(e.lines, "\n"), time.Now())
+				}
+				return nil, errWriteInterrupted
 			}
 
-			switch {
-			case b[0] == 19: // ctrl+s
+			result := e.handleKey(b)
+			if seq := e.takeOSC(); seq != "" {
+				fmt.Print(seq)
+			}
+			switch result {
+			case wKeySave:
 				term.Restore(fd, old)
 				fmt.Print("\x1b[?25h")
 				fmt.Printf("\x1b[%d;0H", totalRows+1)
 				c := strings.Join(e.lines, "\n")
 				return &c, nil
-
-			case b[0] == 17: // ctrl+q
+			case wKeyQuit:
 				term.Restore(fd, old)
 				fmt.Print("\x1b[?25h")
 				fmt.Printf("\x1b[%d;0H", totalRows+1)
+				wRemoveSwaps(path)
 				return nil, nil
+			}
 
-			case len(b) >= 3 && b[0] == 27 && b[1] == '[':
-				switch b[2] {
-				case 'A':
-					if e.cy > 0 {
-						e.cy--
-						e.clampCx()
-					}
-				case 'B':
-					if e.cy < len(e.lines)-1 {
-						e.cy++
-						e.clampCx()
-					}
-				case 'C':
-					if e.cx < e.lineLen() {
-						e.cx++
-					} else if e.cy < len(e.lines)-1 {
-						e.cy++
-						e.cx = 0
-					}
-				case 'D':
-					if e.cx > 0 {
-						e.cx--
-					} else if e.cy > 0 {
-						e.cy--
-						e.cx = e.lineLen()
-					}
-				case 'H':
-					e.cx = 0
-				case 'F':
-					e.cx = e.lineLen()
-				case '3':
-					e.deleteForward()
-				case '5':
-					for i := 0; i < e.visH && e.cy > 0; i++ {
-						e.cy--
-					}
-					e.clampCx()
-				case '6':
-					for i := 0; i < e.visH && e.cy < len(e.lines)-1; i++ {
-						e.cy++
-					}
-					e.clampCx()
-				}
+			redraw()
+		}
+	}
+}
 
-			case b[0] == 13:
-				e.newline()
-			case b[0] == 127 || b[0] == 8:
-				e.backspace()
-			case b[0] == 9:
-				e.insert(' ')
-				e.insert(' ')
-			case b[0] == 2:
-				e.wrapWord("**", "**")
-			case b[0] == 5:
-				e.wrapWord("_", "_")
-			case b[0] == 11:
-				e.wrapWord("`", "`")
-			case b[0] == 7:
-				e.insertBelow("```")
-				e.insertBelow("")
-				e.insertBelow("```")
-				e.cy -= 2
-			case b[0] == 12:
-				e.prependLine("- ")
-			case b[0] == 18:
-				e.insertBelow("---")
-			case b[0] == 28:
-				e.insertHeading(1)
-			case b[0] == 29:
-				e.insertHeading(2)
-			case b[0] == 30:
-				e.insertHeading(3)
-			default:
-				if b[0] >= 32 {
-					if r, _ := utf8.DecodeRune(b); r != utf8.RuneError {
-						e.insert(r)
-					}
-				}
+// move applies a navigation key; with word, left and right go by word.
+func (e *wEditor) move(key byte, word bool) {
+	switch key {
+	case 'A':
+		e.moveRow(-1)
+	case 'B':
+		e.moveRow(1)
+	case 'C':
+		if word {
+			e.wordRight()
+		} else if e.cx < e.lineLen() {
+			e.cx = wNextBoundary([]rune(e.line()), e.cx)
+		} else if e.cy < len(e.lines)-1 {
+			e.cy++
+			e.cx = 0
+		}
+	case 'D':
+		if word {
+			e.wordLeft()
+		} else if e.cx > 0 {
+			e.cx = wPrevBoundary([]rune(e.line()), e.cx)
+		} else if e.cy > 0 {
+			e.cy--
+			e.cx = e.lineLen()
+		}
+	case 'H':
+		e.cx = 0
+	case 'F':
+		e.cx = e.lineLen()
+	case '5':
+		for i := 0; i < e.visH && e.cy > 0; i++ {
+			e.cy--
+		}
+		e.clampCx()
+	case '6':
+		for i := 0; i < e.visH && e.cy < len(e.lines)-1; i++ {
+			e.cy++
+		}
+		e.clampCx()
+	}
+}
+
+// moveRow moves the cursor one screen row up or down, keeping its display
+// column. With soft wrap a row can be part of the same line.
+func (e *wEditor) moveRow(dir int) {
+	line := []rune(e.line())
+	segs := e.segments(e.cy)
+	seg := wSegOf(segs, e.cx)
+	col := wStrWidth(string(line[segs[seg]:wMin(e.cx, len(line))]))
+	seg += dir
+	if seg < 0 || seg >= len(segs) {
+		if e.cy+dir < 0 || e.cy+dir >= len(e.lines) {
+			return
+		}
+		e.cy += dir
+		line = []rune(e.line())
+		segs = e.segments(e.cy)
+		seg = 0
+		if dir < 0 {
+			seg = len(segs) - 1
+		}
+	}
+	end := len(line)
+	if seg+1 < len(segs) {
+		end = segs[seg+1]
+	}
+	e.cx = wColToIndex(line, segs[seg], end, col)
+	if seg+1 < len(segs) && e.cx == end {
+		e.cx = wPrevBoundary(line, end)
+	}
+}
+
+// wKeyResult tells the main loop what a key asked for beyond editing.
+type wKeyResult int
+
+const (
+	wKeyNone wKeyResult = iota
+	wKeySave
+	wKeyQuit
+)
+
+// handleKey applies one key read by wReadKey. It never touches the terminal,
+// so tests can drive the editor with scripted key sequences.
+func (e *wEditor) handleKey(b []byte) wKeyResult {
+	e.message = ""
+	if e.search.prompt == wPromptNone && e.handleSelectionKey(b) {
+		return wKeyNone
+	}
+	switch {
+	case b[0] == 19: // ctrl+s
+		return wKeySave
+
+	case b[0] == 17: // ctrl+q
+		return wKeyQuit
+
+	case e.search.prompt != wPromptNone:
+		e.handlePromptKey(b)
+
+	case len(b) == 2 && b[0] == 27 && b[1] == 'z': // alt+z
+		e.wrap = !e.wrap
+		e.scrollSeg = 0
+	case b[0] == 16: // ctrl+p
+		e.togglePreview()
+	case b[0] == 6: // ctrl+f
+		e.openPrompt(wPromptFind)
+	case len(b) == 2 && b[0] == 27 && b[1] == 'r': // alt+r
+		e.openPrompt(wPromptReplaceFind)
+	case b[0] == 7: // ctrl+g
+		e.openPrompt(wPromptGoto)
+
+	case b[0] == 26: // ctrl+z
+		e.selecting = false
+		e.undo()
+	case b[0] == 25: // ctrl+y
+		e.selecting = false
+		e.redo()
+
+	case len(b) >= 3 && b[0] == 27 && (b[1] == '[' || b[1] == 'O'):
+		if key, mod := wCSIKey(b); key == '3' {
+			e.edit(wEditDelete, 0, e.deleteForward)
+		} else {
+			e.moveKey(key, mod)
+		}
+
+	case b[0] == 13:
+		e.edit(wEditOther, 0, e.newline)
+	case b[0] == 127 || b[0] == 8: // some terminals send ctrl+h for backspace
+		e.edit(wEditDelete, 0, e.backspace)
+	case b[0] == 9:
+		e.edit(wEditType, ' ', func() {
+			e.insert(' ')
+			e.insert(' ')
+		})
+	case b[0] == 2:
+		e.edit(wEditOther, 0, func() { e.wrapWord("**", "**") })
+	case b[0] == 5:
+		e.edit(wEditOther, 0, func() { e.wrapWord("_", "_") })
+	case b[0] == 11:
+		e.edit(wEditOther, 0, func() { e.wrapWord("`", "`") })
+	case b[0] == 20:
+		e.edit(wEditOther, 0, func() {
+			e.insertBelow("```")
+			e.insertBelow("")
+			e.insertBelow("```")
+			e.cy -= 2
+		})
+	case b[0] == 12:
+		e.edit(wEditOther, 0, func() { e.prependLine("- ") })
+	case b[0] == 18:
+		e.edit(wEditOther, 0, func() { e.insertBelow("---") })
+	case b[0] == 28:
+		e.edit(wEditOther, 0, func() { e.insertHeading(1) })
+	case b[0] == 29:
+		e.edit(wEditOther, 0, func() { e.insertHeading(2) })
+	case b[0] == 30:
+		e.edit(wEditOther, 0, func() { e.insertHeading(3) })
+	default:
+		if b[0] >= 32 {
+			if r, _ := utf8.DecodeRune(b); r != utf8.RuneError {
+				e.edit(wEditType, r, func() { e.insert(r) })
 			}
+		}
+	}
+	return wKeyNone
+}
 
-			redraw()
+// wEscWait is how long wReadKey waits after an esc for the rest of an
+// escape sequence. Over a slow link a sequence can arrive in pieces.
+const wEscWait = 100 * time.Millisecond
+
+// wKeyReader hands input to wReadKey byte by byte from a goroutine, so a
+// lone esc can be told from the start of a sequence by whether another
+// byte follows soon.
+type wKeyReader struct {
+	bytes chan byte
+	// err is why input ended; it is set before bytes is closed.
+	err error
+}
+
+func newWKeyReader(r io.Reader) *wKeyReader {
+	k := &wKeyReader{bytes: make(chan byte, 4096)}
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := r.Read(buf)
+			for _, c := range buf[:n] {
+				k.bytes <- c
+			}
+			if err != nil {
+				k.err = err
+				close(k.bytes)
+				return
+			}
 		}
+	}()
+	return k
+}
+
+func (k *wKeyReader) ReadByte() (byte, error) {
+	c, ok := <-k.bytes
+	if !ok {
+		return 0, k.err
 	}
+	return c, nil
 }
 
-func wReadKey(r *bufio.Reader) ([]byte, error) {
-	b := make([]byte, 1)
-	if _, err := r.Read(b); err != nil {
+// readByteWithin is ReadByte giving up after d.
+func (k *wKeyReader) readByteWithin(d time.Duration) (byte, bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case c, ok := <-k.bytes:
+		return c, ok
+	case <-timer.C:
+		return 0, false
+	}
+}
+
+// wReadKey reads one key: a byte, a UTF-8 rune, an escape sequence, or a
+// whole bracketed paste.
+func wReadKey(r *wKeyReader) ([]byte, error) {
+	c, err := r.ReadByte()
+	if err != nil {
 		return nil, err
 	}
+	b := []byte{c}
+	if b[0] >= 0xC0 {
+		n := 2
+		if b[0] >= 0xF0 {
+			n = 4
+		} else if b[0] >= 0xE0 {
+			n = 3
+		}
+		for len(b) < n {
+			c, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			b = append(b, c)
+		}
+		return b, nil
+	}
 	if b[0] != 27 {
 		return b, nil
 	}
 	buf := []byte{27}
-	next, err := r.ReadByte()
-	if err != nil {
+	// A lone esc is not followed by anything for a while.
+	next, ok := r.readByteWithin(wEscWait)
+	if !ok {
 		return buf, nil
 	}
 	buf = append(buf, next)
-	if next != '[' {
+	switch next {
+	case 'O':
+		if c, err := r.ReadByte(); err == nil {
+			buf = append(buf, c)
+		}
+		return buf, nil
+	case '[':
+	default:
 		return buf, nil
 	}
-	c, err := r.ReadByte()
-	if err != nil {
+	// CSI: parameter bytes up to a final byte in @..~.
+	for len(buf) < 32 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return buf, nil
+		}
+		buf = append(buf, c)
+		if c >= '@' && c <= '~' {
+			break
+		}
+	}
+	if string(buf) != wPasteStart {
 		return buf, nil
 	}
-	buf = append(buf, c)
-	if c >= '0' && c <= '9' {
-		if final, err := r.ReadByte(); err == nil {
-			buf = append(buf, final)
+	for !bytes.HasSuffix(buf, []byte(wPasteEnd)) {
+		c, err := r.ReadByte()
+		if err != nil {
+			return buf, nil
 		}
+		buf = append(buf, c)
 	}
-	return buf, nil
+	return buf[:len(buf)-len(wPasteEnd)], nil
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// ---------------------------------------------------------------------------
+// Journal entries — jot write --entry opens the editor on a new journal
+// entry, and jot write <entry-id> on an existing one. Saving stores the text
+// in the journal: the first heading becomes the title and inline #hashtags
+// become the tags.
+// ---------------------------------------------------------------------------
+
+var (
+	wHashtagPattern    = regexp.MustCompile(`(^|[\s(\[,;])#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+	wInlineCodePattern = regexp.MustCompile("`[^`]*`")
+)
+
+// wEntryFromText splits editor text into an entry's title, content, and
+// tags. A heading on the first line is the title and is left out of the
+// content; otherwise the first heading anywhere names the entry.
+func wEntryFromText(text string) (title, content string, tags []string) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	first := -1
+	drop := -1
+	seen := map[string]bool{}
+	inCode := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if first < 0 && trimmed != "" {
+			first = i
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if level := assistantMarkdownHeadingLevel(trimmed); level > 0 && title == "" {
+			title = strings.TrimSpace(strings.TrimRight(trimmed[level:], "# "))
+			if i == first {
+				drop = i
+			}
+		}
+		for _, m := range wHashtagPattern.FindAllStringSubmatch(wInlineCodePattern.ReplaceAllString(line, ""), -1) {
+			tag := strings.TrimRight(m[2], "/-")
+			// #1 and #42 are issue numbers, not tags.
+			if strings.IndexFunc(tag, unicode.IsLetter) < 0 || seen[strings.ToLower(tag)] {
+				continue
+			}
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+	}
+	if drop >= 0 {
+		lines = append(lines[:drop], lines[drop+1:]...)
+	}
+	content = strings.TrimRight(strings.TrimLeft(strings.Join(lines, "\n"), "\n"), " \t\n")
+	return title, content, tags
+}
+
+// wEntryText is the editor text for an entry: its title as a heading, then
+// its content. Tags the content does not mention are added as a line of
+// hashtags, so saving keeps them.
+func wEntryText(entry journalEntry) string {
+	var b strings.Builder
+	if title := strings.TrimSpace(entry.Title); title != "" {
+		b.WriteString("# " + title + "\n\n")
+	}
+	content := strings.TrimRight(entry.Content, "\r\n")
+	b.WriteString(content)
+	_, _, present := wEntryFromText(content)
+	have := map[string]bool{}
+	for _, tag := range present {
+		have[strings.ToLower(tag)] = true
+	}
+	var missing []string
+	for _, tag := range entry.Tags {
+		tag = strings.Join(strings.Fields(tag), "-")
+		if tag == "" || have[strings.ToLower(tag)] {
+			continue
+		}
+		have[strings.ToLower(tag)] = true
+		missing = append(missing, "#"+tag)
+	}
+	if len(missing) > 0 {
+		if content != "" {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(strings.Join(missing, " "))
+	}
+	return b.String()
+}
+
+// saveWriteEntry stores text as the entry with id, or as a new entry when
+// id is empty. loaded is the text the editor opened with. It reports false
+// when there was nothing to store: an empty new entry, or text the same as
+// loaded, which is left alone even where reading it back would differ from
+// the stored entry.
+func saveWriteEntry(journalPath, id, loaded, text string, now time.Time) (journalEntry, bool, error) {
+	if text == loaded {
+		return journalEntry{ID: id}, false, nil
+	}
+	title, content, tags := wEntryFromText(text)
+	if id != "" {
+		entries, err := loadJournalEntries(journalPath)
+		if err != nil {
+			return journalEntry{}, false, err
+		}
+		for i := range entries {
+			entry := &entries[i]
+			if entry.ID != id {
+				continue
+			}
+			entry.Title, entry.Content, entry.Tags = title, content, tags
+			at := now
+			entry.UpdatedAt = &at
+			return *entry, true, writeJournalEntries(journalPath, entries)
+		}
+	}
+	if title == "" && content == "" {
+		return journalEntry{}, false, nil
+	}
+	if id == "" {
+		id = newEntryID(now, 0)
+	}
+	entry := journalEntry{
+		ID:        id,
+		CreatedAt: now,
+		Content:   content,
+		Title:     title,
+		Tags:      tags,
+		Source:    "write",
+	}
+	return entry, true, appendJournalEntry(journalPath, entry)
+}
+
+// wLookupEntry finds the journal entry with id without creating a journal.
+func wLookupEntry(id string) (journalEntry, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return journalEntry{}, false
+	}
+	_, _, journalPath := journalPaths(home)
+	entries, err := loadJournalEntries(journalPath)
+	if err != nil {
+		return journalEntry{}, false
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return journalEntry{}, false
+}
+
+// jotWriteEntry edits the journal entry with id, or a new one when id is
+// empty, and stores it on save.
+func jotWriteEntry(w io.Writer, id string, opts wEditorOptions) error {
+	journalPath, err := ensureJournalJSONL()
+	if err != nil {
+		return err
+	}
+	var initial, loaded string
+	name, label := "entry-new.md", "new entry"
+	if id != "" {
+		entry, ok := wLookupEntry(id)
+		if !ok {
+			return fmt.Errorf("no journal entry with id %q - try: jot list", id)
+		}
+		initial = wEntryText(entry)
+		loaded = initial
+		name, label = "entry-"+id+".md", "entry "+id
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("jot write requires an interactive terminal")
+	}
+	// The entry has no file of its own; this path beside the journal names
+	// its swap file.
+	path := filepath.Join(filepath.Dir(journalPath), name)
+	in := bufio.NewReader(os.Stdin)
+	initial, opts.recovered, err = offerSwapRecovery(in, w, path, initial, time.Now())
+	if err != nil {
+		return err
+	}
+	opts.label = label
+	saved, err := runInlineEditor(in, path, initial, opts)
+	if err != nil {
+		return err
+	}
+	if saved == nil {
+		fmt.Fprintln(w, fg(wColMuted)+"  quit without saving"+wrst)
+		return nil
+	}
+	entry, stored, err := saveWriteEntry(journalPath, id, loaded, *saved, time.Now())
+	if err != nil {
+		return err
+	}
+	wRemoveSwaps(path)
+	if !stored {
+		fmt.Fprintln(w, fg(wColMuted)+"  nothing to save"+wrst)
+		return nil
+	}
+	fmt.Fprintln(w, fg(wColSaved)+"  saved"+wrst+" -> jot open "+entry.ID)
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteEntryFromText(t *testing.T) {
+	text := strings.Join([]string{
+		"",
+		"# Trip to #Lisbon",
+		"",
+		"Booked the train with #travel in mind, see #42.",
+		"Also #Travel and `#notatag` here.",
+		"```",
+		"#include <stdio.h>",
+		"```",
+		"(#food/pastries) ## not a heading",
+	}, "\n")
+	title, content, tags := wEntryFromText(text)
+	if title != "Trip to #Lisbon" {
+		t.Fatalf("expected the first heading as title, got %q", title)
+	}
+	if strings.HasPrefix(content, "#") || !strings.HasPrefix(content, "Booked the train") {
+		t.Fatalf("expected the title heading left out of the content, got %q", content)
+	}
+	if got, want := strings.Join(tags, ","), "Lisbon,travel,food/pastries"; got != want {
+		t.Fatalf("expected tags %q, got %q", want, got)
+	}
+
+	title, content, _ = wEntryFromText("intro line\n\n## Later section\nbody")
+	if title != "Later section" || !strings.Contains(content, "## Later section") {
+		t.Fatalf("expected a heading further down to name the entry and stay in the content, got %q / %q", title, content)
+	}
+}
+
+func TestWriteEntryTextRoundTrip(t *testing.T) {
+	entry := journalEntry{
+		Title:   "Standup",
+		Content: "Talked about #release timing.",
+		Tags:    []string{"release", "deep work"},
+	}
+	text := wEntryText(entry)
+	if text != "# Standup\n\nTalked about #release timing.\n\n#deep-work" {
+		t.Fatalf("unexpected editor text %q", text)
+	}
+	title, _, tags := wEntryFromText(text)
+	if title != "Standup" || strings.Join(tags, ",") != "release,deep-work" {
+		t.Fatalf("expected the title and tags to survive a round trip, got %q %q", title, tags)
+	}
+}
+
+func TestWriteEntrySaveCreatesAndUpdates(t *testing.T) {
+	withTempHome(t)
+	journalPath, err := ensureJournalJSONL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := journalEntry{ID: "other", CreatedAt: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), Content: "keep me"}
+	if err := appendJournalEntry(journalPath, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, stored, err := saveWriteEntry(journalPath, "", "", "\n  \n", time.Now()); err != nil || stored {
+		t.Fatalf("expected an empty new entry not to be stored, got %v, %v", stored, err)
+	}
+
+	created := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	entry, stored, err := saveWriteEntry(journalPath, "", "", "# Ideas\n\nA thought about #jot.", created)
+	if err != nil || !stored {
+		t.Fatalf("expected the new entry to be stored, got %v, %v", stored, err)
+	}
+	if entry.Title != "Ideas" || entry.Content != "A thought about #jot." || strings.Join(entry.Tags, ",") != "jot" || entry.Source != "write" {
+		t.Fatalf("unexpected new entry %+v", entry)
+	}
+	found, ok := wLookupEntry(entry.ID)
+	if !ok || found.Title != "Ideas" {
+		t.Fatalf("expected to find the new entry by id, got %+v", found)
+	}
+
+	if _, stored, err := saveWriteEntry(journalPath, entry.ID, wEntryText(found), wEntryText(found), created.Add(time.Hour)); err != nil || stored {
+		t.Fatalf("expected an unchanged entry not to be rewritten, got %v, %v", stored, err)
+	}
+	updated := created.Add(2 * time.Hour)
+	if _, stored, err := saveWriteEntry(journalPath, entry.ID, wEntryText(found), "# Better ideas\n\nNow about #notes.", updated); err != nil || !stored {
+		t.Fatalf("expected the edit to be stored, got %v, %v", stored, err)
+	}
+	entries, err := loadJournalEntries(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Content != "keep me" {
+		t.Fatalf("expected the other entry untouched and no duplicate, got %+v", entries)
+	}
+	got := entries[1]
+	if got.ID != entry.ID || got.Title != "Better ideas" || strings.Join(got.Tags, ",") != "notes" || !got.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected updated entry %+v", got)
+	}
+	if got.UpdatedAt == nil || !got.UpdatedAt.Equal(updated) {
+		t.Fatalf("expected updated_at to be set, got %v", got.UpdatedAt)
+	}
+	if _, ok := wLookupEntry("missing"); ok {
+		t.Fatal("expected no entry for an unknown id")
+	}
+}
+
+func TestWriteEntryUnchangedSaveLeavesTheJournalAlone(t *testing.T) {
+	withTempHome(t)
+	journalPath, err := ensureJournalJSONL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured := journalEntry{
+		ID:        "captured",
+		CreatedAt: time.Date(2026, 2, 3, 8, 0, 0, 0, time.UTC),
+		Content:   "# Agenda\nplan the week",
+		Tags:      []string{"deep work", "planning"},
+		Source:    "capture",
+	}
+	if err := appendJournalEntry(journalPath, captured); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, ok := wLookupEntry("captured")
+	if !ok {
+		t.Fatal("expected to find the captured entry")
+	}
+	loaded := wEntryText(found)
+	if !strings.HasSuffix(loaded, "#deep-work #planning") {
+		t.Fatalf("expected the tags missing from the content as hashtags, got %q", loaded)
+	}
+	if _, stored, err := saveWriteEntry(journalPath, "captured", loaded, loaded, time.Now()); err != nil || stored {
+		t.Fatalf("expected an unedited save not to store, got %v, %v", stored, err)
+	}
+	after, err := os.ReadFile(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("expected the journal untouched, got %s", after)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import "unicode"
+
+// ---------------------------------------------------------------------------
+// Undo history — edits are recorded as line-range operations and grouped so
+// one ctrl+z takes back a typed word, a run of deletes, or a whole command.
+// ---------------------------------------------------------------------------
+
+const (
+	wHistoryMaxGroups = 1000
+	wHistoryMaxBytes  = 4 << 20
+)
+
+type wEditKind int
+
+const (
+	wEditType wEditKind = iota + 1
+	wEditDelete
+	wEditOther
+)
+
+type wPos struct{ cx, cy int }
+
+// wOp replaced lines[start:start+len(removed)] with inserted.
+type wOp struct {
+	start    int
+	removed  []string
+	inserted []string
+}
+
+func (op wOp) size() int {
+	n := 0
+	for _, line := range op.removed {
+		n += len(line)
+	}
+	for _, line := range op.inserted {
+		n += len(line)
+	}
+	return n
+}
+
+// wGroup is one undo step: the operations of one or more keys and the
+// cursor on either side of them.
+type wGroup struct {
+	ops    []wOp
+	kind   wEditKind
+	before wPos
+	after  wPos
+	last   rune
+	word   bool
+	seq    int
+}
+
+func (g wGroup) size() int {
+	n := 0
+	for _, op := range g.ops {
+		n += op.size()
+	}
+	return n
+}
+
+// wHistory holds the undo and redo stacks. Only the undo stack counts
+// against the limits; the oldest groups are dropped first.
+type wHistory struct {
+	undo, redo []wGroup
+	bytes      int
+	open       bool
+	changed    bool
+	nextSeq    int
+	baseSeq    int
+	savedSeq   int
+	maxGroups  int
+	maxBytes   int
+}
+
+func (e *wEditor) cursor() wPos { return wPos{cx: e.cx, cy: e.cy} }
+
+// edit runs fn as one key's worth of changes. Typed runes extend the
+// previous group until the next word starts, so a word undoes together with
+// the spaces after it. Deletes extend a run of deletes, and
+// anything else gets a group of its own. Moving the cursor in between
+// always starts a new group.
+func (e *wEditor) edit(kind wEditKind, r rune, fn func()) {
+	h := &e.history
+	pos := e.cursor()
+	if !h.continues(kind, r, pos) {
+		h.nextSeq++
+		h.undo = append(h.undo, wGroup{kind: kind, before: pos, seq: h.nextSeq})
+	}
+	h.changed = false
+	fn()
+	top := &h.undo[len(h.undo)-1]
+	if !h.changed && len(top.ops) == 0 {
+		h.undo = h.undo[:len(h.undo)-1]
+		return
+	}
+	top.after = e.cursor()
+	top.last = r
+	top.word = top.word || (r != 0 && !unicode.IsSpace(r))
+	h.open = true
+	h.redo = nil
+	h.trim()
+	e.saved = h.current() == h.savedSeq
+}
+
+func (h *wHistory) continues(kind wEditKind, r rune, pos wPos) bool {
+	if !h.open || len(h.undo) == 0 || kind == wEditOther {
+		return false
+	}
+	top := h.undo[len(h.undo)-1]
+	if top.kind != kind || top.after != pos {
+		return false
+	}
+	if kind == wEditType && top.word && unicode.IsSpace(top.last) && !unicode.IsSpace(r) {
+		return false
+	}
+	return true
+}
+
+// record adds op to the open group. Repeated edits of the same single line
+// collapse into one operation, so typing a word stores the line twice
+// rather than once per key.
+func (h *wHistory) record(op wOp) {
+	if len(h.undo) == 0 {
+		return
+	}
+	h.changed = true
+	top := &h.undo[len(h.undo)-1]
+	if n := len(top.ops); n > 0 {
+		last := &top.ops[n-1]
+		if last.start == op.start && len(last.inserted) == 1 && len(op.removed) == 1 && len(op.inserted) == 1 {
+			h.bytes += len(op.inserted[0]) - len(last.inserted[0])
+			last.inserted = op.inserted
+			return
+		}
+	}
+	top.ops = append(top.ops, op)
+	h.bytes += op.size()
+}
+
+func (h *wHistory) trim() {
+	maxGroups, maxBytes := h.maxGroups, h.maxBytes
+	if maxGroups <= 0 {
+		maxGroups = wHistoryMaxGroups
+	}
+	if maxBytes <= 0 {
+		maxBytes = wHistoryMaxBytes
+	}
+	for len(h.undo) > maxGroups || (h.bytes > maxBytes && len(h.undo) > 1) {
+		h.bytes -= h.undo[0].size()
+		h.baseSeq = h.undo[0].seq
+		h.undo = h.undo[1:]
+	}
+}
+
+// current identifies the state the buffer is in: the newest group still on
+// the undo stack, or the oldest state the history can reach.
+func (h *wHistory) current() int {
+	if len(h.undo) == 0 {
+		return h.baseSeq
+	}
+	return h.undo[len(h.undo)-1].seq
+}
+
+func (e *wEditor) undo() {
+	h := &e.history
+	if len(h.undo) == 0 {
+		return
+	}
+	g := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.bytes -= g.size()
+	for i := len(g.ops) - 1; i >= 0; i-- {
+		op := g.ops[i]
+		e.lines = wSplice(e.lines, op.start, len(op.inserted), op.removed)
+	}
+	e.cx, e.cy = g.before.cx, g.before.cy
+	e.rev++
+	h.redo = append(h.redo, g)
+	h.open = false
+	e.saved = h.current() == h.savedSeq
+}
+
+func (e *wEditor) redo() {
+	h := &e.history
+	if len(h.redo) == 0 {
+		return
+	}
+	g := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	for _, op := range g.ops {
+		e.lines = wSplice(e.lines, op.start, len(op.removed), op.inserted)
+	}
+	e.cx, e.cy = g.after.cx, g.after.cy
+	e.rev++
+	h.undo = append(h.undo, g)
+	h.bytes += g.size()
+	h.open = false
+	h.trim()
+	e.saved = h.current() == h.savedSeq
+}
+
+// wSplice returns lines with the n lines at start replaced by with.
+func wSplice(lines []string, start, n int, with []string) []string {
+	out := make([]string, 0, len(lines)-n+len(with))
+	out = append(out, lines[:start]...)
+	out = append(out, with...)
+	return append(out, lines[start+n:]...)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	wKeyUndo  = "\x1a"
+	wKeyRedo  = "\x19"
+	wKeyLeft  = "\x1b[D"
+	wKeyUp    = "\x1b[A"
+	wKeyEnter = "\r"
+	wKeyBack  = "\x7f"
+)
+
+func newTestWriteEditor(content string) *wEditor {
+	return &wEditor{path: "notes.md", lines: strings.Split(content, "\n"), saved: true, W: 80, H: 24, visH: 22}
+}
+
+// feedKeys drives e with a scripted key sequence, parsed by wReadKey the same
+// way the terminal loop reads stdin.
+func feedKeys(t *testing.T, e *wEditor, keys string) {
+	t.Helper()
+	reader := newWKeyReader(strings.NewReader(keys))
+	for {
+		b, err := wReadKey(reader)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("wReadKey returned error: %v", err)
+		}
+		e.handleKey(b)
+	}
+}
+
+func editorText(e *wEditor) string { return strings.Join(e.lines, "\n") }
+
+func TestWriteUndoGroupsTypingByWord(t *testing.T) {
+	e := newTestWriteEditor("")
+	feedKeys(t, e, "hello world")
+	if got := editorText(e); got != "hello world" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "hello " || e.cx != 6 {
+		t.Fatalf("expected one undo to remove the last word, got %q at %d", got, e.cx)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "" || !e.saved {
+		t.Fatalf("expected the original buffer back and marked saved, got %q saved=%v", got, e.saved)
+	}
+	feedKeys(t, e, wKeyUndo)
+	feedKeys(t, e, wKeyRedo+wKeyRedo)
+	if got := editorText(e); got != "hello world" || e.cx != 11 || e.saved {
+		t.Fatalf("expected redo to restore both words, got %q at %d", got, e.cx)
+	}
+	feedKeys(t, e, wKeyRedo)
+	if got := editorText(e); got != "hello world" {
+		t.Fatalf("expected an empty redo stack to be a no-op, got %q", got)
+	}
+}
+
+func TestWriteUndoSeparatesCommandsAndCursorMoves(t *testing.T) {
+	e := newTestWriteEditor("- one")
+	e.cx = 5
+	feedKeys(t, e, wKeyEnter+"two"+wKeyUp+" more")
+	if got := editorText(e); got != "- one more\n- two" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- one\n- two" || e.cy != 0 || e.cx != 5 {
+		t.Fatalf("expected typing after a cursor move to undo on its own, got %q at %d,%d", got, e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- one\n- " {
+		t.Fatalf("expected the typed word to undo next, got %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- one" || e.cx != 5 {
+		t.Fatalf("expected the list continuation to undo as one step, got %q", got)
+	}
+
+	feedKeys(t, e, wKeyBack+wKeyBack+wKeyBack+"\x02")
+	if got := editorText(e); got != "- ****" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- " {
+		t.Fatalf("expected ctrl+b to undo on its own, got %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- one" {
+		t.Fatalf("expected the run of backspaces to undo together, got %q", got)
+	}
+	feedKeys(t, e, wKeyRedo+"x")
+	if feedKeys(t, e, wKeyRedo); editorText(e) != "- x" {
+		t.Fatalf("expected a new edit to clear the redo stack, got %q", editorText(e))
+	}
+}
+
+func TestWriteUndoJoinsAndSplitsLines(t *testing.T) {
+	e := newTestWriteEditor("alpha\nbeta")
+	e.cy = 1
+	feedKeys(t, e, wKeyBack)
+	if got := editorText(e); got != "alphabeta" || e.cx != 5 {
+		t.Fatalf("unexpected join %q at %d", got, e.cx)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "alpha\nbeta" || e.cy != 1 || e.cx != 0 {
+		t.Fatalf("expected the join undone, got %q at %d,%d", got, e.cy, e.cx)
+	}
+	feedKeys(t, e, "\x14")
+	if got := editorText(e); got != "alpha\nbeta\n```\n\n```" {
+		t.Fatalf("unexpected code block %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "alpha\nbeta" {
+		t.Fatalf("expected the code block to undo in one step, got %q", got)
+	}
+}
+
+func TestWriteUndoHistoryIsBounded(t *testing.T) {
+	e := newTestWriteEditor("")
+	e.history.maxGroups = 3
+	feedKeys(t, e, "a b c d e")
+	if len(e.history.undo) != 3 {
+		t.Fatalf("expected 3 undo groups, got %d", len(e.history.undo))
+	}
+	feedKeys(t, e, strings.Repeat(wKeyUndo, 5))
+	if got := editorText(e); got != "a b " || e.saved {
+		t.Fatalf("expected undo to stop at the oldest kept group, got %q saved=%v", got, e.saved)
+	}
+
+	e = newTestWriteEditor("")
+	e.history.maxBytes = 64
+	long := strings.Repeat("word ", 40)
+	feedKeys(t, e, long)
+	if len(e.history.undo) != 1 {
+		t.Fatalf("expected the byte limit to keep only the newest group, got %d bytes in %d groups", e.history.bytes, len(e.history.undo))
+	}
+	feedKeys(t, e, wKeyUndo+wKeyUndo)
+	if got := editorText(e); got != strings.Repeat("word ", 39) {
+		t.Fatalf("expected one step of undo, got %q", got)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// Preview — ctrl+p splits the editor and renders the document on the right
+// with the assistant's terminal Markdown styles. The preview scrolls so the
+// cursor's line sits level with the cursor.
+// ---------------------------------------------------------------------------
+
+// wPreviewMinW is the narrowest terminal the preview opens in.
+const wPreviewMinW = 60
+
+// wPreviewLine is one rendered row and the source line it came from.
+type wPreviewLine struct {
+	text string
+	src  int
+}
+
+var wLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)(\s+"[^"]*")?\)`)
+
+// wShortenLinks rewrites link targets to a short form, so a long URL does
+// not push the text it belongs to off the pane.
+func wShortenLinks(text string) string {
+	return wLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := wLinkPattern.FindStringSubmatch(link)
+		return "[" + m[1] + "](" + wShortURL(m[2]) + ")"
+	})
+}
+
+// wShortURL keeps the host of a URL and the last part of its path.
+func wShortURL(raw string) string {
+	const limit = 28
+	if utf8.RuneCountInString(raw) <= limit {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "…/" + path.Base(raw)
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+	trimmed := strings.Trim(u.Path, "/")
+	switch {
+	case trimmed == "":
+		return host
+	case !strings.Contains(trimmed, "/"):
+		return host + "/" + trimmed
+	}
+	return host + "/…/" + path.Base(trimmed)
+}
+
+func wInline(text string) string { return assistantRenderInlineMarkdown(wShortenLinks(text)) }
+
+// wRenderPreview renders lines to rows of at most width columns.
+func wRenderPreview(lines []string, width int) []wPreviewLine {
+	var out []wPreviewLine
+	emit := func(src int, indent int, styled string) {
+		pad := strings.Repeat(" ", indent)
+		visible := []rune(wStripANSI(styled))
+		starts := wWrapLine(visible, max(width-indent, 8))
+		for k, start := range starts {
+			end := len(visible)
+			if k+1 < len(starts) {
+				end = starts[k+1]
+			}
+			out = append(out, wPreviewLine{text: pad + wSliceANSI(styled, start, end) + wrst, src: src})
+		}
+	}
+	blankBefore := func(src int) {
+		if n := len(out); n > 0 && strings.TrimSpace(wStripANSI(out[n-1].text)) != "" {
+			out = append(out, wPreviewLine{src: src})
+		}
+	}
+	inCode := false
+	for i := 0; i < len(lines); i++ {
+		raw := lines[i]
+		trimmed := strings.TrimSpace(raw)
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			if lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```")); inCode && lang != "" {
+				emit(i, 2, assistantTerminalDim(lang))
+			}
+			continue
+		}
+		if inCode {
+			emit(i, 2, fg(wColCode)+strings.ReplaceAll(raw, "\t", "    "))
+			continue
+		}
+		switch {
+		case trimmed == "":
+			if n := len(out); n > 0 && out[n-1].text != "" {
+				out = append(out, wPreviewLine{src: i})
+			}
+		case strings.HasPrefix(trimmed, "|") && strings.Count(trimmed, "|") >= 2:
+			j := i
+			for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|") {
+				j++
+			}
+			blankBefore(i)
+			for k, row := range wRenderTable(lines[i:j], width) {
+				out = append(out, wPreviewLine{text: row, src: wMin(i+k, j-1)})
+			}
+			i = j - 1
+		case assistantMarkdownIsRule(trimmed):
+			emit(i, 0, assistantTerminalDim(strings.Repeat("─", width)))
+		case assistantMarkdownHeadingLevel(trimmed) > 0:
+			level := assistantMarkdownHeadingLevel(trimmed)
+			blankBefore(i)
+			emit(i, 0, assistantStyleMarkdownHeading(level, wShortenLinks(strings.TrimSpace(trimmed[level:]))))
+		case strings.HasPrefix(trimmed, ">"):
+			emit(i, indent, assistantTerminalDim("│ ")+wrst+wItal()+wInline(strings.TrimSpace(strings.TrimLeft(trimmed, "> "))))
+		default:
+			if number, content := assistantMarkdownOrderedContent(trimmed); content != "" {
+				emit(i, indent, assistantTerminalDim(number+".")+wrst+" "+wInline(content))
+				continue
+			}
+			if content := assistantMarkdownBulletContent(trimmed); content != "" {
+				marker := assistantTerminalAccent("•")
+				switch {
+				case strings.HasPrefix(content, "[ ] "):
+					marker, content = assistantTerminalAccent("☐"), content[4:]
+				case strings.HasPrefix(content, "[x] "), strings.HasPrefix(content, "[X] "):
+					marker, content = assistantTerminalAccent("☑"), content[4:]
+				}
+				emit(i, indent, marker+wrst+" "+wInline(content))
+				continue
+			}
+			emit(i, indent, wInline(trimmed))
+		}
+	}
+	return out
+}
+
+// wRenderTable lays out a Markdown table with aligned columns, honoring
+// the :--, :-:, and --: markers of the divider row.
+func wRenderTable(rows []string, width int) []string {
+	var cells [][]string
+	var aligns []string
+	header := false
+	for _, row := range rows {
+		t := strings.TrimSpace(row)
+		if assistantMarkdownIsTableDivider(t) {
+			aligns = wSplitTableRow(t)
+			header = len(cells) == 1
+			continue
+		}
+		parts := wSplitTableRow(t)
+		for k := range parts {
+			parts[k] = wInline(parts[k])
+		}
+		cells = append(cells, parts)
+	}
+	cols := 0
+	for _, row := range cells {
+		cols = max(cols, len(row))
+	}
+	widths := make([]int, cols)
+	for _, row := range cells {
+		for k, cell := range row {
+			widths[k] = max(widths[k], wVisLen(cell))
+		}
+	}
+	sep := assistantTerminalDim(" │ ") + wrst
+	var out []string
+	for r, row := range cells {
+		var b strings.Builder
+		for k := 0; k < cols; k++ {
+			cell := ""
+			if k < len(row) {
+				cell = row[k]
+			}
+			if header && r == 0 {
+				cell = wBold() + cell + wrst
+			}
+			gap := widths[k] - wVisLen(cell)
+			align := ""
+			if k < len(aligns) {
+				align = aligns[k]
+			}
+			if k > 0 {
+				b.WriteString(sep)
+			}
+			switch {
+			case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+				b.WriteString(strings.Repeat(" ", gap/2) + cell + wrst + strings.Repeat(" ", gap-gap/2))
+			case strings.HasSuffix(align, ":"):
+				b.WriteString(strings.Repeat(" ", gap) + cell + wrst)
+			default:
+				b.WriteString(cell + wrst + strings.Repeat(" ", gap))
+			}
+		}
+		out = append(out, wFitANSI(b.String(), width))
+		if header && r == 0 {
+			parts := make([]string, cols)
+			for k := range parts {
+				parts[k] = strings.Repeat("─", widths[k])
+			}
+			out = append(out, wFitANSI(assistantTerminalDim(strings.Join(parts, "─┼─"))+wrst, width))
+		}
+	}
+	return out
+}
+
+// wSplitTableRow returns the trimmed cells of a |-separated row.
+func wSplitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+	parts := strings.Split(row, "|")
+	for k := range parts {
+		parts[k] = strings.TrimSpace(parts[k])
+	}
+	return parts
+}
+
+// wFitANSI cuts s to width columns, marking the cut with an ellipsis.
+func wFitANSI(s string, width int) string {
+	if wVisLen(s) <= width {
+		return s
+	}
+	return wTruncANSI(s, width-1) + assistantTerminalDim("…") + wrst
+}
+
+// wKeepBg re-applies the pane's colors after each reset, since the
+// Markdown styles reset everything when they end.
+func wKeepBg(s, bgCol string) string {
+	base := bg(bgCol) + fg(wColText)
+	return base + strings.ReplaceAll(s, wrst, wrst+base)
+}
+
+// previewTop is the first preview row on screen: the cursor's line is kept
+// on the cursor's row, within the bounds of the rendered document.
+func (e *wEditor) previewTop(pv []wPreviewLine) int {
+	at := len(pv)
+	for k, line := range pv {
+		if line.src >= e.cy {
+			at = k
+			break
+		}
+	}
+	top := at - max(e.rowsToCursor(), 0)
+	top = wMin(top, len(pv)-e.visH)
+	return max(top, 0)
+}
+
+// previewRow renders row k of the preview to width columns.
+func (e *wEditor) previewRow(pv []wPreviewLine, k, width int) string {
+	if k < 0 || k >= len(pv) {
+		return bg(wColBg) + strings.Repeat(" ", width) + wrst
+	}
+	bgCol := wColBg
+	if pv[k].src == e.cy {
+		bgCol = wColCurBg
+	}
+	return wRow(wKeepBg(" "+pv[k].text, bgCol), bgCol, width)
+}
+
+// togglePreview opens or closes the preview pane.
+func (e *wEditor) togglePreview() {
+	if !e.preview && e.W < wPreviewMinW {
+		e.message = "widen the terminal to open the preview"
+		return
+	}
+	e.preview = !e.preview
+	e.scrollSeg = 0
+}
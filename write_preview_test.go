@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteShortURL(t *testing.T) {
+	cases := map[string]string{
+		"https://go.dev":                                          "https://go.dev",
+		"https://www.example-company.com/":                        "example-company.com",
+		"https://github.com/Intina47/jot/blob/main/docs/guide.md": "github.com/…/guide.md",
+		"https://example.com/a-fairly-long-page":                  "example.com/a-fairly-long-page",
+		"docs/some/deeply/nested/folder/notes.md":                 "…/notes.md",
+	}
+	for in, want := range cases {
+		if got := wShortURL(in); got != want {
+			t.Errorf("wShortURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+	got := wStripANSI(wInline("see [the guide](https://github.com/Intina47/jot/blob/main/docs/guide.md)"))
+	if got != "see the guide (github.com/…/guide.md)" {
+		t.Fatalf("unexpected inline rendering %q", got)
+	}
+}
+
+func TestWriteRenderTableAlignsColumns(t *testing.T) {
+	rows := wRenderTable([]string{
+		"| Name | Count | Note |",
+		"|:-----|------:|:----:|",
+		"| alpha | 1 | x |",
+		"| beta | 200 | **long** note |",
+	}, 60)
+	var plain []string
+	for _, row := range rows {
+		plain = append(plain, wStripANSI(row))
+	}
+	want := []string{
+		"Name  │ Count │   Note",
+		"──────┼───────┼──────────",
+		"alpha │     1 │     x",
+		"beta  │   200 │ long note",
+	}
+	for k := range want {
+		if strings.TrimRight(plain[k], " ") != want[k] {
+			t.Fatalf("row %d = %q, want %q", k, plain[k], want[k])
+		}
+	}
+	if narrow := wRenderTable([]string{"| a long cell | another long cell |"}, 12); wVisLen(narrow[0]) != 12 || !strings.HasSuffix(wStripANSI(narrow[0]), "…") {
+		t.Fatalf("expected a wide table to be cut to the pane, got %q", wStripANSI(narrow[0]))
+	}
+}
+
+func TestWriteRenderPreviewBlocks(t *testing.T) {
+	pv := wRenderPreview([]string{
+		"# Title",
+		"intro with `code` and *emphasis*",
+		"## Section",
+		"- [ ] open task",
+		"```sh",
+		"echo **not bold**",
+		"```",
+		"",
+		"",
+		"1. first",
+	}, 40)
+	var plain []string
+	for _, line := range pv {
+		plain = append(plain, wStripANSI(line.text))
+	}
+	got := strings.Join(plain, "\n")
+	want := "Title\nintro with code and emphasis\n\nSection\n☐ open task\n  sh\n  echo **not bold**\n\n1. first"
+	if got != want {
+		t.Fatalf("unexpected preview:\n%s\nwant:\n%s", got, want)
+	}
+	if pv[3].src != 2 || pv[6].src != 5 || pv[len(pv)-1].src != 9 {
+		t.Fatalf("expected rows to remember their source lines, got %+v", pv)
+	}
+	if !strings.Contains(pv[0].text, "\x1b[1m") {
+		t.Fatal("expected the h1 to be styled")
+	}
+
+	wrapped := wRenderPreview([]string{strings.Repeat("word ", 20)}, 30)
+	if len(wrapped) < 3 {
+		t.Fatalf("expected a long paragraph to wrap, got %d rows", len(wrapped))
+	}
+	for _, line := range wrapped {
+		if wVisLen(line.text) > 30 || line.src != 0 {
+			t.Fatalf("unexpected wrapped row %q src=%d", wStripANSI(line.text), line.src)
+		}
+	}
+}
+
+func TestWritePreviewPaneFollowsTheCursor(t *testing.T) {
+	var doc []string
+	for i := 1; i <= 60; i++ {
+		doc = append(doc, fmt.Sprintf("line %d", i))
+	}
+	e := newTestWriteEditor(strings.Join(doc, "\n"))
+	e.W, e.visH = 100, 10
+	feedKeys(t, e, "\x10")
+	if !e.preview || e.paneW() != 50 || e.contentW() != 42 {
+		t.Fatalf("expected ctrl+p to split the screen, got preview=%v paneW=%d", e.preview, e.paneW())
+	}
+	e.cy = 30
+	e.scroll()
+	rows := strings.Split(e.frame(), "\r\n")
+	cursorRow := e.rowsToCursor() + 1
+	if plain := wStripANSI(rows[cursorRow]); !strings.Contains(plain, "line 31") || strings.Count(plain, "line 31") != 2 {
+		t.Fatalf("expected the source and preview of the cursor line on one row, got %q", plain)
+	}
+	if !strings.Contains(rows[cursorRow], "│"+wrst+bg(wColCurBg)) {
+		t.Fatal("expected the preview row of the cursor line to be highlighted")
+	}
+
+	feedKeys(t, e, "\x10")
+	if e.preview || e.paneW() != 100 {
+		t.Fatal("expected ctrl+p to close the preview")
+	}
+	e.W = 40
+	feedKeys(t, e, "\x10")
+	if e.preview || e.message == "" {
+		t.Fatal("expected the preview to refuse a narrow terminal")
+	}
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// Search, replace, and go-to-line — ctrl+f, alt+r, and ctrl+g open a prompt
+// in the status bar. Search is incremental: the cursor jumps to the first
+// match as the query is typed, and every match on screen is highlighted.
+// ---------------------------------------------------------------------------
+
+type wPromptKind int
+
+const (
+	wPromptNone wPromptKind = iota
+	wPromptFind
+	wPromptReplaceFind
+	wPromptReplaceWith
+	wPromptConfirm
+	wPromptGoto
+)
+
+type wSearch struct {
+	prompt   wPromptKind
+	input    string
+	query    string
+	replace  string
+	regex    bool
+	re       *regexp.Regexp
+	err      string
+	origin   wPos
+	wrapped  bool
+	replaced int
+}
+
+// wMatch is one match in rune columns of a line.
+type wMatch struct{ line, start, end int }
+
+func (m wMatch) pos() wPos { return wPos{cx: m.start, cy: m.line} }
+
+func wBefore(a, b wPos) bool { return a.cy < b.cy || (a.cy == b.cy && a.cx < b.cx) }
+
+// compile turns the query into a pattern. Plain queries match literally;
+// both kinds ignore case unless the query has an upper-case letter.
+func (s *wSearch) compile() {
+	s.re, s.err = nil, ""
+	if s.query == "" {
+		return
+	}
+	pattern := s.query
+	if !s.regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !strings.ContainsFunc(s.query, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		s.err = "invalid pattern"
+		return
+	}
+	s.re = re
+}
+
+// highlighting reports whether matches should be painted on screen.
+func (s *wSearch) highlighting() bool {
+	switch s.prompt {
+	case wPromptFind, wPromptReplaceFind, wPromptReplaceWith, wPromptConfirm:
+		return s.re != nil
+	}
+	return false
+}
+
+// lineMatches returns the non-empty matches on line i.
+func (e *wEditor) lineMatches(i int) []wMatch {
+	re := e.search.re
+	if re == nil || i < 0 || i >= len(e.lines) {
+		return nil
+	}
+	line := e.lines[i]
+	var out []wMatch
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		start := utf8.RuneCountInString(line[:loc[0]])
+		out = append(out, wMatch{line: i, start: start, end: start + utf8.RuneCountInString(line[loc[0]:loc[1]])})
+	}
+	return out
+}
+
+// findFrom returns the first match at or after from, or with backward the
+// last match before it. With wrap the search continues past the end of the
+// document.
+func (e *wEditor) findFrom(from wPos, backward, wrap bool) (wMatch, bool) {
+	n := len(e.lines)
+	// With wrap the last step comes back round to from's own line, where
+	// matches on the far side of the cursor still count.
+	steps := n
+	if wrap {
+		steps = n + 1
+	}
+	for step := 0; step < steps; step++ {
+		i := from.cy + step
+		if backward {
+			i = from.cy - step
+		}
+		if !wrap && (i < 0 || i >= n) {
+			break
+		}
+		i = ((i % n) + n) % n
+		matches := e.lineMatches(i)
+		if backward {
+			for j := len(matches) - 1; j >= 0; j-- {
+				if step > 0 || matches[j].start < from.cx {
+					return matches[j], true
+				}
+			}
+		} else {
+			for _, m := range matches {
+				if step > 0 || m.start >= from.cx {
+					return m, true
+				}
+			}
+		}
+	}
+	return wMatch{}, false
+}
+
+func (e *wEditor) jumpTo(m wMatch) { e.cx, e.cy = m.start, m.line }
+
+// jumpFromOrigin moves to the first match at or after where the search
+// started, or back to the start when nothing matches.
+func (e *wEditor) jumpFromOrigin() {
+	if m, ok := e.findFrom(e.search.origin, false, true); ok {
+		e.jumpTo(m)
+		return
+	}
+	e.cx, e.cy = e.search.origin.cx, e.search.origin.cy
+}
+
+func (e *wEditor) openPrompt(kind wPromptKind) {
+	s := &e.search
+	s.prompt = kind
+	s.origin = e.cursor()
+	e.message = ""
+	switch kind {
+	case wPromptFind, wPromptReplaceFind:
+		s.input = s.query
+		s.compile()
+		e.jumpFromOrigin()
+	default:
+		s.input = ""
+	}
+}
+
+func (e *wEditor) closePrompt() {
+	e.search.prompt = wPromptNone
+	e.search.input = ""
+}
+
+// handlePromptKey handles a key while a prompt is open.
+func (e *wEditor) handlePromptKey(b []byte) {
+	s := &e.search
+	if s.prompt == wPromptConfirm {
+		e.handleConfirmKey(b)
+		return
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte(wPasteStart)):
+		text, _, _ := strings.Cut(wSanitizePaste(string(b[len(wPasteStart):])), "\n")
+		e.setPromptInput(s.input + text)
+	case len(b) == 1 && b[0] == 27: // esc
+		if s.prompt == wPromptGoto {
+			e.cx, e.cy = s.origin.cx, s.origin.cy
+		}
+		e.closePrompt()
+	case b[0] == 13:
+		e.submitPrompt()
+	case b[0] == 127 || b[0] == 8:
+		if r := []rune(s.input); len(r) > 0 {
+			e.setPromptInput(string(r[:len(r)-1]))
+		}
+	case b[0] == 18 && s.prompt != wPromptGoto && s.prompt != wPromptReplaceWith: // ctrl+r
+		s.regex = !s.regex
+		s.compile()
+		e.jumpFromOrigin()
+	case b[0] == 14 || (len(b) >= 3 && b[0] == 27 && b[2] == 'B'): // ctrl+n, down
+		if s.prompt == wPromptFind {
+			e.findNext(false)
+		}
+	case b[0] == 16 || (len(b) >= 3 && b[0] == 27 && b[2] == 'A'): // ctrl+p, up
+		if s.prompt == wPromptFind {
+			e.findNext(true)
+		}
+	case b[0] >= 32:
+		if r, _ := utf8.DecodeRune(b); r != utf8.RuneError {
+			e.setPromptInput(s.input + string(r))
+		}
+	}
+}
+
+func (e *wEditor) setPromptInput(input string) {
+	s := &e.search
+	s.input = input
+	switch s.prompt {
+	case wPromptFind, wPromptReplaceFind:
+		s.query = input
+		s.compile()
+		e.jumpFromOrigin()
+	case wPromptGoto:
+		e.gotoLine(input)
+	}
+}
+
+func (e *wEditor) findNext(backward bool) {
+	from := e.cursor()
+	if !backward {
+		from.cx++
+	}
+	if m, ok := e.findFrom(from, backward, true); ok {
+		e.jumpTo(m)
+	}
+}
+
+func (e *wEditor) submitPrompt() {
+	s := &e.search
+	switch s.prompt {
+	case wPromptFind:
+		e.findNext(false)
+	case wPromptReplaceFind:
+		if s.re == nil {
+			return
+		}
+		s.prompt = wPromptReplaceWith
+		s.input = s.replace
+	case wPromptReplaceWith:
+		s.replace = s.input
+		s.input = ""
+		s.wrapped = false
+		s.replaced = 0
+		m, ok := e.nextReplaceMatch(s.origin)
+		if !ok {
+			e.closePrompt()
+			e.message = "no matches"
+			return
+		}
+		e.jumpTo(m)
+		s.prompt = wPromptConfirm
+	case wPromptGoto:
+		e.gotoLine(s.input)
+		e.closePrompt()
+	}
+}
+
+// gotoLine moves to "line" or "line:col", both counted from 1.
+func (e *wEditor) gotoLine(input string) {
+	lineText, colText, _ := strings.Cut(strings.TrimSpace(input), ":")
+	line, err := strconv.Atoi(lineText)
+	if err != nil {
+		return
+	}
+	e.cy = max(0, wMin(line, len(e.lines))-1)
+	e.cx = 0
+	if col, err := strconv.Atoi(colText); err == nil && col > 0 {
+		e.cx = col - 1
+	}
+	e.clampCx()
+}
+
+// nextReplaceMatch walks from the origin to the end of the document, then
+// from the top back to the origin, so each match is offered once.
+func (e *wEditor) nextReplaceMatch(from wPos) (wMatch, bool) {
+	s := &e.search
+	if !s.wrapped {
+		if m, ok := e.findFrom(from, false, false); ok {
+			return m, true
+		}
+		s.wrapped = true
+		from = wPos{}
+	}
+	if m, ok := e.findFrom(from, false, false); ok && wBefore(m.pos(), s.origin) {
+		return m, true
+	}
+	return wMatch{}, false
+}
+
+func (e *wEditor) handleConfirmKey(b []byte) {
+	s := &e.search
+	current, ok := e.matchAtCursor()
+	if !ok {
+		e.finishReplace()
+		return
+	}
+	switch {
+	case b[0] == 'y' || b[0] == 'Y':
+		e.edit(wEditOther, 0, func() { e.replaceMatch(current) })
+		e.advanceReplace()
+	case b[0] == 'n' || b[0] == 'N':
+		e.cx = current.end
+		e.advanceReplace()
+	case b[0] == 'a' || b[0] == 'A':
+		e.edit(wEditOther, 0, func() {
+			for s.prompt == wPromptConfirm {
+				m, ok := e.matchAtCursor()
+				if !ok {
+					break
+				}
+				e.replaceMatch(m)
+				e.advanceReplace()
+			}
+		})
+	case b[0] == 27 || b[0] == 'q' || b[0] == 'Q':
+		e.finishReplace()
+	}
+}
+
+func (e *wEditor) matchAtCursor() (wMatch, bool) {
+	for _, m := range e.lineMatches(e.cy) {
+		if m.start == e.cx {
+			return m, true
+		}
+	}
+	return wMatch{}, false
+}
+
+// replaceMatch swaps m for the replacement, expanding $1-style references
+// in regex mode, and leaves the cursor after the inserted text.
+func (e *wEditor) replaceMatch(m wMatch) {
+	s := &e.search
+	line := e.lines[m.line]
+	runes := []rune(line)
+	startByte := len(string(runes[:m.start]))
+	endByte := len(string(runes[:m.end]))
+	replacement := s.replace
+	if s.regex {
+		for _, loc := range s.re.FindAllStringSubmatchIndex(line, -1) {
+			if loc[0] == startByte {
+				replacement = string(s.re.ExpandString(nil, s.replace, line, loc))
+				break
+			}
+		}
+	}
+	e.replaceLines(m.line, m.line+1, line[:startByte]+replacement+line[endByte:])
+	e.cy = m.line
+	e.cx = m.start + utf8.RuneCountInString(replacement)
+	if m.line == s.origin.cy && m.start < s.origin.cx {
+		s.origin.cx += utf8.RuneCountInString(replacement) - (m.end - m.start)
+	}
+	s.replaced++
+}
+
+func (e *wEditor) advanceReplace() {
+	if m, ok := e.nextReplaceMatch(e.cursor()); ok {
+		e.jumpTo(m)
+		return
+	}
+	e.finishReplace()
+}
+
+func (e *wEditor) finishReplace() {
+	s := &e.search
+	e.closePrompt()
+	if s.replaced == 1 {
+		e.message = "1 replacement"
+	} else {
+		e.message = fmt.Sprintf("%d replacements", s.replaced)
+	}
+}
+
+// matchCount returns how many matches the document has and the 1-based
+// index of the one under the cursor, or 0 when the cursor is not on one.
+func (e *wEditor) matchCount() (int, int) {
+	total, current := 0, 0
+	for i := range e.lines {
+		for _, m := range e.lineMatches(i) {
+			total++
+			if m.line == e.cy && m.start == e.cx {
+				current = total
+			}
+		}
+	}
+	return total, current
+}
+
+// searchSpans returns the background spans that paint matches on line i.
+func (e *wEditor) searchSpans(i int) []wSpan {
+	if !e.search.highlighting() {
+		return nil
+	}
+	var spans []wSpan
+	for _, m := range e.lineMatches(i) {
+		col := wColMatch
+		if m.line == e.cy && m.start == e.cx {
+			col = wColMatchCur
+		}
+		spans = append(spans, wSpan{start: m.start, end: m.end, bg: col})
+	}
+	return spans
+}
+
+// wSpan paints visible columns [start, end) of a line with bg.
+type wSpan struct {
+	start, end int
+	bg         string
+}
+
+// wOverlay paints spans over an already highlighted line. Escape codes
+// inside a span are followed by the span's background again, since the
+// highlighter resets colors between tokens.
+func wOverlay(s string, spans []wSpan, baseBg string) string {
+	if len(spans) == 0 {
+		return s
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	r := []rune(s)
+	col, k := 0, 0
+	inSpan := false
+	for i := 0; i < len(r); {
+		if r[i] == '\x1b' && i+1 < len(r) && r[i+1] == '[' {
+			j := i + 2
+			for j < len(r) && (r[j] < '@' || r[j] > '~') {
+				j++
+			}
+			b.WriteString(string(r[i:wMin(j+1, len(r))]))
+			if inSpan {
+				b.WriteString(bg(spans[k].bg))
+			}
+			i = j + 1
+			continue
+		}
+		if inSpan && col == spans[k].end {
+			inSpan = false
+			k++
+			b.WriteString(bg(baseBg))
+		}
+		for k < len(spans) && spans[k].end <= col {
+			k++
+		}
+		if !inSpan && k < len(spans) && col >= spans[k].start {
+			inSpan = true
+			b.WriteString(bg(spans[k].bg))
+		}
+		b.WriteRune(r[i])
+		col++
+		i++
+	}
+	if inSpan {
+		b.WriteString(bg(baseBg))
+	}
+	return b.String()
+}
+
+// promptRow renders the status bar while a prompt is open and returns the
+// column the terminal cursor belongs in, or -1 to leave it in the text.
+func (e *wEditor) promptRow(W int) (string, int) {
+	s := &e.search
+	label := map[wPromptKind]string{
+		wPromptFind:        "find",
+		wPromptReplaceFind: "replace",
+		wPromptReplaceWith: "with",
+		wPromptConfirm:     "replace",
+		wPromptGoto:        "go to line",
+	}[s.prompt]
+	left := bg(wColBarBg) + fg(wColAccent) + "  " + label + "  " + fg(wColText) + s.input
+	cursorCol := wVisLen(left)
+	var hints []string
+	switch s.prompt {
+	case wPromptFind, wPromptReplaceFind:
+		switch {
+		case s.err != "":
+			hints = append(hints, fg(wColUnsaved)+s.err+fg(wColMuted))
+		case s.re != nil:
+			total, current := e.matchCount()
+			if total == 0 {
+				hints = append(hints, fg(wColUnsaved)+"no matches"+fg(wColMuted))
+			} else {
+				hints = append(hints, fmt.Sprintf("%d of %d", current, total))
+			}
+		}
+		mode := "ctrl+r regex"
+		if s.regex {
+			mode = fg(wColAccent) + "regex" + fg(wColMuted) + " ctrl+r"
+		}
+		hints = append(hints, mode)
+		if s.prompt == wPromptFind {
+			hints = append(hints, "↵ ↓ next", "↑ prev", "esc done")
+		} else {
+			hints = append(hints, "↵ continue", "esc cancel")
+		}
+	case wPromptReplaceWith:
+		if s.regex {
+			hints = append(hints, "$1 inserts a group")
+		}
+		hints = append(hints, "↵ start", "esc cancel")
+	case wPromptConfirm:
+		left = bg(wColBarBg) + fg(wColAccent) + "  replace " + fg(wColText) + s.query + fg(wColMuted) + " with " + fg(wColText) + s.replace + fg(wColMuted) + "?"
+		cursorCol = -1
+		hints = append(hints, "y yes", "n skip", "a all", "esc stop")
+	case wPromptGoto:
+		hints = append(hints, fmt.Sprintf("1–%d", len(e.lines)), "line:col", "↵ go", "esc cancel")
+	}
+	right := bg(wColBarBg) + fg(wColMuted) + strings.Join(hints, "  ·  ") + "  "
+	gap := W - wVisLen(left) - wVisLen(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return left + bg(wColBarBg) + strings.Repeat(" ", gap) + right, cursorCol
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	wKeyFind    = "\x06"
+	wKeyReplace = "\x1br"
+	wKeyGoto    = "\x07"
+	wKeyEsc     = "\x1b"
+	wKeyDown    = "\x1b[B"
+)
+
+func TestWriteSearchIsIncrementalAndWraps(t *testing.T) {
+	e := newTestWriteEditor("one fish\ntwo Fish\nred fish\nblue fish")
+	e.cy = 1
+	feedKeys(t, e, wKeyFind+"fi")
+	if e.cy != 1 || e.cx != 4 {
+		t.Fatalf("expected a lower-case query to match Fish on the cursor line, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, "sh"+wKeyEnter+wKeyDown)
+	if e.cy != 3 || e.cx != 5 {
+		t.Fatalf("expected two steps forward to reach the last line, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyEnter)
+	if e.cy != 0 || e.cx != 4 {
+		t.Fatalf("expected the search to wrap to the top, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyUp)
+	if e.cy != 3 {
+		t.Fatalf("expected up to go back round to the last match, got %d,%d", e.cy, e.cx)
+	}
+	if total, current := e.matchCount(); total != 4 || current != 4 {
+		t.Fatalf("expected match 4 of 4, got %d of %d", current, total)
+	}
+	feedKeys(t, e, wKeyEsc)
+	if e.search.prompt != wPromptNone || e.cy != 3 || e.cx != 5 {
+		t.Fatalf("expected esc to close the prompt and keep the match, got prompt=%d at %d,%d", e.search.prompt, e.cy, e.cx)
+	}
+
+	feedKeys(t, e, wKeyFind+strings.Repeat(wKeyBack, 4)+"Fish")
+	if e.cy != 1 || e.cx != 4 {
+		t.Fatalf("expected an upper-case query to match case, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyEsc)
+	feedKeys(t, e, "x")
+	if got := e.lines[1]; got != "two xFish" {
+		t.Fatalf("expected typing after the search to edit the text, got %q", got)
+	}
+}
+
+func TestWriteSearchRegexMode(t *testing.T) {
+	e := newTestWriteEditor("v1.2\nv1x2\nv10")
+	feedKeys(t, e, wKeyFind+"v1.2")
+	if total, _ := e.matchCount(); total != 1 {
+		t.Fatalf("expected a plain query to match the dot literally, got %d matches", total)
+	}
+	feedKeys(t, e, "\x12")
+	if total, _ := e.matchCount(); total != 2 || !e.search.regex {
+		t.Fatalf("expected ctrl+r to switch to regex matching, got %d matches", total)
+	}
+	feedKeys(t, e, "(")
+	if e.search.err == "" || e.search.re != nil {
+		t.Fatal("expected an invalid pattern to be reported")
+	}
+	if frame := wStripANSI(e.frame()); !strings.Contains(frame, "invalid pattern") {
+		t.Fatalf("expected the status bar to show the error, got %q", frame)
+	}
+}
+
+func TestWriteSearchHighlightsMatches(t *testing.T) {
+	e := newTestWriteEditor("say hello\nhello again")
+	feedKeys(t, e, wKeyFind+"hello")
+	frame := e.frame()
+	if !strings.Contains(frame, bg(wColMatchCur)+"hello") {
+		t.Fatal("expected the current match to be highlighted")
+	}
+	if !strings.Contains(frame, bg(wColMatch)) {
+		t.Fatal("expected the other match to be highlighted")
+	}
+	if plain := wStripANSI(frame); !strings.Contains(plain, "1 of 2") || !strings.Contains(plain, "say hello") {
+		t.Fatalf("unexpected frame %q", plain)
+	}
+	feedKeys(t, e, wKeyEsc)
+	if strings.Contains(e.frame(), bg(wColMatch)) {
+		t.Fatal("expected highlights to clear with the prompt")
+	}
+}
+
+func TestWriteReplaceConfirmsEachMatch(t *testing.T) {
+	e := newTestWriteEditor("cat and cat\ncat")
+	e.cx = 4
+	feedKeys(t, e, wKeyReplace+"cat"+wKeyEnter+"dog"+wKeyEnter)
+	if e.search.prompt != wPromptConfirm || e.cy != 0 || e.cx != 8 {
+		t.Fatalf("expected to confirm the first match after the cursor, got prompt=%d at %d,%d", e.search.prompt, e.cy, e.cx)
+	}
+	feedKeys(t, e, "y")
+	feedKeys(t, e, "n")
+	if e.cy != 0 || e.cx != 0 {
+		t.Fatalf("expected the walk to wrap to the top, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, "y")
+	if got := editorText(e); got != "dog and dog\ncat" || e.search.prompt != wPromptNone {
+		t.Fatalf("unexpected text %q prompt=%d", got, e.search.prompt)
+	}
+	if e.message != "2 replacements" {
+		t.Fatalf("unexpected message %q", e.message)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "cat and dog\ncat" {
+		t.Fatalf("expected each confirmed replacement to undo on its own, got %q", got)
+	}
+}
+
+func TestWriteReplaceAllWithGroups(t *testing.T) {
+	e := newTestWriteEditor("- [x] a\n- [ ] b\n- [x] c")
+	feedKeys(t, e, wKeyReplace+"\x12"+`\[(x| )\] (\w)`+wKeyEnter+"$2 ($1)"+wKeyEnter+"a")
+	if got := editorText(e); got != "- a (x)\n- b ( )\n- c (x)" {
+		t.Fatalf("unexpected text %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- [x] a\n- [ ] b\n- [x] c" {
+		t.Fatalf("expected replace-all to undo in one step, got %q", got)
+	}
+
+	e = newTestWriteEditor("nothing here")
+	feedKeys(t, e, wKeyReplace+"zzz"+wKeyEnter+"y"+wKeyEnter)
+	if e.search.prompt != wPromptNone || e.message != "no matches" || editorText(e) != "nothing here" {
+		t.Fatalf("expected nothing to change, got %q prompt=%d", editorText(e), e.search.prompt)
+	}
+}
+
+func TestWriteGotoLine(t *testing.T) {
+	e := newTestWriteEditor("a\nbb\nccc\ndddd")
+	feedKeys(t, e, wKeyGoto+"3"+wKeyEnter)
+	if e.cy != 2 || e.cx != 0 {
+		t.Fatalf("expected line 3, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyGoto+"4:3"+wKeyEnter)
+	if e.cy != 3 || e.cx != 2 {
+		t.Fatalf("expected line 4 col 3, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyGoto+"99:99"+wKeyEnter)
+	if e.cy != 3 || e.cx != 4 {
+		t.Fatalf("expected the jump to clamp to the end, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyGoto+"1"+wKeyEsc)
+	if e.cy != 3 || e.cx != 4 {
+		t.Fatalf("expected esc to return to where the cursor was, got %d,%d", e.cy, e.cx)
+	}
+}
+
+func TestWriteCtrlHDeletesLikeBackspace(t *testing.T) {
+	e := newTestWriteEditor("abc")
+	e.cx = 3
+	feedKeys(t, e, "\x08")
+	if got := editorText(e); got != "ab" || e.search.prompt != wPromptNone {
+		t.Fatalf("expected ctrl+h to delete a character, got %q with prompt %v", got, e.search.prompt)
+	}
+}
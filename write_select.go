@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ---------------------------------------------------------------------------
+// Selection and clipboard — shift+arrows select, ctrl+c/x/v copy, cut, and
+// paste through a kill ring. Copies also go to the system clipboard as an
+// OSC 52 sequence, which the terminal applies even over SSH. Pastes from
+// the terminal arrive bracketed and are inserted verbatim.
+// ---------------------------------------------------------------------------
+
+const wKillRingSize = 16
+
+// wPasteStart and wPasteEnd bracket text pasted into the terminal once
+// bracketed paste mode is on. wReadKey returns a paste as wPasteStart
+// followed by the pasted text.
+const (
+	wPasteStart = "\x1b[200~"
+	wPasteEnd   = "\x1b[201~"
+)
+
+// wClip is one kill ring entry. A line clip was copied without a selection
+// and pastes as a whole line above the cursor.
+type wClip struct {
+	text string
+	line bool
+}
+
+type wClipboard struct {
+	ring []wClip // newest last
+	// The span of the last paste, so alt+y can swap it for an older entry.
+	yankFrom, yankTo, yankCursor wPos
+	yankIdx                      int
+	yanked                       bool
+	// osc is a pending OSC 52 sequence for the main loop to print.
+	osc string
+}
+
+func (c *wClipboard) push(clip wClip) {
+	if n := len(c.ring); n > 0 && c.ring[n-1] == clip {
+		return
+	}
+	c.ring = append(c.ring, clip)
+	if len(c.ring) > wKillRingSize {
+		c.ring = c.ring[len(c.ring)-wKillRingSize:]
+	}
+	text := clip.text
+	if clip.line {
+		text += "\n"
+	}
+	c.osc = wOSC52(text)
+}
+
+// wOSC52 asks the terminal to put text on the system clipboard.
+func wOSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+// takeOSC returns the pending clipboard sequence, once.
+func (e *wEditor) takeOSC() string {
+	seq := e.clip.osc
+	e.clip.osc = ""
+	return seq
+}
+
+// selection returns the selected range in document order.
+func (e *wEditor) selection() (wPos, wPos) {
+	a, b := e.anchor, e.cursor()
+	if wBefore(b, a) {
+		return b, a
+	}
+	return a, b
+}
+
+func (e *wEditor) textBetween(a, b wPos) string {
+	if a.cy == b.cy {
+		r := []rune(e.lines[a.cy])
+		return string(r[a.cx:b.cx])
+	}
+	parts := []string{string([]rune(e.lines[a.cy])[a.cx:])}
+	parts = append(parts, e.lines[a.cy+1:b.cy]...)
+	parts = append(parts, string([]rune(e.lines[b.cy])[:b.cx]))
+	return strings.Join(parts, "\n")
+}
+
+func (e *wEditor) deleteBetween(a, b wPos) {
+	head := string([]rune(e.lines[a.cy])[:a.cx])
+	tail := string([]rune(e.lines[b.cy])[b.cx:])
+	e.replaceLines(a.cy, b.cy+1, head+tail)
+	e.cx, e.cy = a.cx, a.cy
+}
+
+func (e *wEditor) deleteSelection() {
+	a, b := e.selection()
+	e.selecting = false
+	e.deleteBetween(a, b)
+}
+
+// insertText inserts text at the cursor as is: no list continuation and
+// no indentation, however many lines it has.
+func (e *wEditor) insertText(text string) {
+	r := []rune(e.line())
+	cx := wMin(e.cx, len(r))
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+	e.cx = utf8.RuneCountInString(lines[last])
+	if last == 0 {
+		e.cx += cx
+	}
+	lines[0] = string(r[:cx]) + lines[0]
+	lines[last] += string(r[cx:])
+	e.replaceLines(e.cy, e.cy+1, lines...)
+	e.cy += last
+}
+
+// pasteClip inserts clip at the cursor and remembers where it went.
+func (e *wEditor) pasteClip(clip wClip) {
+	from := e.cursor()
+	if clip.line {
+		from.cx = 0
+		e.replaceLines(e.cy, e.cy, strings.Split(clip.text, "\n")...)
+		e.cy += strings.Count(clip.text, "\n") + 1
+		e.clip.yankFrom, e.clip.yankTo = from, wPos{cy: e.cy}
+	} else {
+		e.insertText(clip.text)
+		e.clip.yankFrom, e.clip.yankTo = from, e.cursor()
+	}
+	e.clip.yankCursor = e.cursor()
+	e.clip.yanked = true
+}
+
+// wSanitizePaste normalizes line endings and drops control characters a
+// terminal should never have passed through, keeping tabs.
+func wSanitizePaste(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// wordBounds returns the word around column cx of line, or the run of
+// spaces or punctuation there when it is not on a word.
+func wordBounds(line []rune, cx int) (int, int) {
+	if len(line) == 0 {
+		return 0, 0
+	}
+	cx = wMin(cx, len(line)-1)
+	if wRuneClass(line[cx]) != 1 && cx > 0 && wRuneClass(line[cx-1]) == 1 {
+		cx--
+	}
+	class := wRuneClass(line[cx])
+	start, end := cx, cx+1
+	for start > 0 && wRuneClass(line[start-1]) == class {
+		start--
+	}
+	for end < len(line) && wRuneClass(line[end]) == class {
+		end++
+	}
+	return start, end
+}
+
+func wIsWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+
+// wRuneClass is 0 for spaces, 1 for word runes, and 2 for the rest.
+func wRuneClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case wIsWordRune(r):
+		return 1
+	}
+	return 2
+}
+
+// wordLeft and wordRight move by word the way ctrl+arrows do in most
+// editors, crossing line ends.
+func (e *wEditor) wordLeft() {
+	if e.cx == 0 {
+		if e.cy > 0 {
+			e.cy--
+			e.cx = e.lineLen()
+		}
+		return
+	}
+	r := []rune(e.line())
+	for e.cx > 0 && !wIsWordRune(r[e.cx-1]) {
+		e.cx--
+	}
+	for e.cx > 0 && wIsWordRune(r[e.cx-1]) {
+		e.cx--
+	}
+}
+
+func (e *wEditor) wordRight() {
+	r := []rune(e.line())
+	if e.cx >= len(r) {
+		if e.cy < len(e.lines)-1 {
+			e.cy++
+			e.cx = 0
+		}
+		return
+	}
+	for e.cx < len(r) && !wIsWordRune(r[e.cx]) {
+		e.cx++
+	}
+	for e.cx < len(r) && wIsWordRune(r[e.cx]) {
+		e.cx++
+	}
+}
+
+// selectWord selects the word under the cursor, or extends an existing
+// selection to the end of the next word.
+func (e *wEditor) selectWord() {
+	if e.selecting {
+		e.wordRight()
+		return
+	}
+	start, end := wordBounds([]rune(e.line()), e.cx)
+	e.anchor = wPos{cx: start, cy: e.cy}
+	e.cx = end
+	e.selecting = start != end
+}
+
+// selectLine selects the cursor's line, or extends the selection to the
+// start of the next line.
+func (e *wEditor) selectLine() {
+	if !e.selecting {
+		e.anchor = wPos{cy: e.cy}
+		e.selecting = true
+	}
+	if e.cy < len(e.lines)-1 {
+		e.cy++
+		e.cx = 0
+	} else {
+		e.cx = e.lineLen()
+	}
+}
+
+// wCSIKey decodes an escape sequence into its final key and a modifier
+// mask (1 shift, 2 alt, 4 ctrl). Keys sent as ESC[n~ come back as the
+// digit n, so delete is '3' and page up '5'.
+func wCSIKey(b []byte) (byte, int) {
+	body := b[2:]
+	final := body[len(body)-1]
+	params := strings.Split(string(body[:len(body)-1]), ";")
+	mod := 0
+	if len(params) > 1 {
+		if m, err := strconv.Atoi(params[1]); err == nil && m > 0 {
+			mod = m - 1
+		}
+	}
+	if final != '~' {
+		return final, mod
+	}
+	switch params[0] {
+	case "1", "7":
+		return 'H', mod
+	case "4", "8":
+		return 'F', mod
+	}
+	if len(params[0]) == 1 {
+		return params[0][0], mod
+	}
+	return 0, mod
+}
+
+// moveKey applies a navigation key. Shift extends the selection, ctrl
+// moves by word, and a plain left or right collapses a selection to the
+// matching end.
+func (e *wEditor) moveKey(key byte, mod int) {
+	if mod&1 != 0 {
+		if !e.selecting {
+			e.anchor = e.cursor()
+			e.selecting = true
+		}
+		e.move(key, mod&4 != 0)
+		e.selecting = e.anchor != e.cursor()
+		return
+	}
+	if e.selecting {
+		e.selecting = false
+		start, end := e.selection()
+		switch key {
+		case 'D':
+			e.cx, e.cy = start.cx, start.cy
+			return
+		case 'C':
+			e.cx, e.cy = end.cx, end.cy
+			return
+		}
+	}
+	e.move(key, mod&4 != 0)
+}
+
+// handleSelectionKey handles the keys that work on the selection and the
+// clipboard, and reports whether it used b. Any other key that is not
+// navigation drops the selection, after it has replaced the selection
+// when the key types or deletes.
+func (e *wEditor) handleSelectionKey(b []byte) bool {
+	if bytes.HasPrefix(b, []byte(wPasteStart)) {
+		text := wSanitizePaste(string(b[len(wPasteStart):]))
+		e.edit(wEditOther, 0, func() {
+			if e.selecting {
+				e.deleteSelection()
+			}
+			e.insertText(text)
+		})
+		e.clip.yanked = false
+		return true
+	}
+	if len(b) == 2 && b[0] == 27 && b[1] == 'y' { // alt+y
+		e.yankPop()
+		return true
+	}
+	if len(b) >= 3 && b[0] == 27 && (b[1] == '[' || b[1] == 'O') {
+		if key, _ := wCSIKey(b); key == '3' && e.selecting {
+			e.edit(wEditOther, 0, e.deleteSelection)
+			return true
+		}
+		return false
+	}
+	switch b[0] {
+	case 23: // ctrl+w
+		e.selectWord()
+		return true
+	case 1: // ctrl+a
+		e.selectLine()
+		return true
+	case 3: // ctrl+c
+		e.copySelection(false)
+		return true
+	case 24: // ctrl+x
+		e.copySelection(true)
+		return true
+	case 22: // ctrl+v
+		if len(e.clip.ring) == 0 {
+			e.message = "kill ring is empty · paste from the terminal to use the system clipboard"
+			return true
+		}
+		clip := e.clip.ring[len(e.clip.ring)-1]
+		e.edit(wEditOther, 0, func() {
+			if e.selecting {
+				e.deleteSelection()
+			}
+			e.pasteClip(clip)
+		})
+		e.clip.yankIdx = len(e.clip.ring) - 1
+		return true
+	}
+	e.clip.yanked = false
+	if !e.selecting {
+		return false
+	}
+	switch {
+	case b[0] == 127 || b[0] == 8:
+		e.edit(wEditOther, 0, e.deleteSelection)
+		return true
+	case b[0] == 13:
+		e.edit(wEditOther, 0, e.deleteSelection)
+		return false
+	case b[0] == 9 || b[0] >= 32:
+		// Typing over a selection undoes together with the deletion.
+		e.edit(wEditType, 0, e.deleteSelection)
+		return false
+	}
+	e.selecting = false
+	return false
+}
+
+// copySelection copies the selection, or the cursor's line when nothing is
+// selected, and with cut removes it.
+func (e *wEditor) copySelection(cut bool) {
+	if e.selecting {
+		a, b := e.selection()
+		e.clip.push(wClip{text: e.textBetween(a, b)})
+		if cut {
+			e.edit(wEditOther, 0, e.deleteSelection)
+		}
+		e.selecting = false
+		return
+	}
+	e.clip.push(wClip{text: e.line(), line: true})
+	if !cut {
+		return
+	}
+	e.edit(wEditOther, 0, func() {
+		if len(e.lines) == 1 {
+			e.setLine("")
+		} else {
+			e.replaceLines(e.cy, e.cy+1)
+			e.cy = wMin(e.cy, len(e.lines)-1)
+		}
+		e.cx = 0
+	})
+}
+
+// yankPop replaces the text just pasted with the next older ring entry.
+func (e *wEditor) yankPop() {
+	c := &e.clip
+	if !c.yanked || c.yankCursor != e.cursor() || len(c.ring) < 2 {
+		return
+	}
+	c.yankIdx = (c.yankIdx - 1 + len(c.ring)) % len(c.ring)
+	clip := c.ring[c.yankIdx]
+	e.edit(wEditOther, 0, func() {
+		e.deleteBetween(c.yankFrom, c.yankTo)
+		e.pasteClip(clip)
+	})
+}
+
+// selectionSpans returns the background spans that paint the selection on
+// line i.
+func (e *wEditor) selectionSpans(i int) []wSpan {
+	if !e.selecting {
+		return nil
+	}
+	a, b := e.selection()
+	if i < a.cy || i > b.cy {
+		return nil
+	}
+	start, end := 0, utf8.RuneCountInString(e.lines[i])
+	if i == a.cy {
+		start = a.cx
+	}
+	if i == b.cy {
+		end = b.cx
+	}
+	if start >= end {
+		return nil
+	}
+	return []wSpan{{start: start, end: end, bg: wColSelect}}
+}
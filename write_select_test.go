@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	wKeyShiftLeft      = "\x1b[1;2D"
+	wKeyShiftRight     = "\x1b[1;2C"
+	wKeyShiftDown      = "\x1b[1;2B"
+	wKeyCtrlShiftRight = "\x1b[1;6C"
+	wKeyCopy           = "\x03"
+	wKeyCut            = "\x18"
+	wKeyPaste          = "\x16"
+)
+
+func TestWriteReadKeyParsesSequences(t *testing.T) {
+	e := newTestWriteEditor("")
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: wKeyShiftLeft, want: wKeyShiftLeft},
+		{in: "\x1b[3~", want: "\x1b[3~"},
+		{in: "\x1bOH", want: "\x1bOH"},
+		{in: "é", want: "é"},
+		{in: wPasteStart + "a\x1b[Ab" + wPasteEnd, want: wPasteStart + "a\x1b[Ab"},
+	}
+	for _, tc := range cases {
+		var keys []string
+		reader := newWKeyReader(strings.NewReader(tc.in))
+		for {
+			b, err := wReadKey(reader)
+			if err != nil {
+				break
+			}
+			keys = append(keys, string(b))
+		}
+		if len(keys) != 1 || keys[0] != tc.want {
+			t.Fatalf("wReadKey(%q) = %q", tc.in, keys)
+		}
+	}
+	if key, mod := wCSIKey([]byte(wKeyCtrlShiftRight)); key != 'C' || mod != 5 {
+		t.Fatalf("unexpected key %q mod %d", key, mod)
+	}
+	if key, _ := wCSIKey([]byte("\x1b[4~")); key != 'F' {
+		t.Fatalf("expected ESC[4~ to be end, got %q", key)
+	}
+	feedKeys(t, e, "é")
+	if editorText(e) != "é" {
+		t.Fatalf("expected a multi-byte rune to be typed, got %q", editorText(e))
+	}
+}
+
+func TestWriteReadKeyWaitsForSplitSequences(t *testing.T) {
+	read := func(chunks []string, pause time.Duration) []string {
+		pr, pw := io.Pipe()
+		go func() {
+			for i, chunk := range chunks {
+				if i > 0 {
+					time.Sleep(pause)
+				}
+				_, _ = pw.Write([]byte(chunk))
+			}
+			pw.Close()
+		}()
+		reader := newWKeyReader(pr)
+		var keys []string
+		for {
+			b, err := wReadKey(reader)
+			if err != nil {
+				return keys
+			}
+			keys = append(keys, string(b))
+		}
+	}
+	if got := read([]string{"\x1b", "[A"}, wEscWait/5); len(got) != 1 || got[0] != "\x1b[A" {
+		t.Fatalf("expected a sequence split after esc to read as one key, got %q", got)
+	}
+	if got := read([]string{"\x1b", "x"}, 2*wEscWait); len(got) != 2 || got[0] != "\x1b" || got[1] != "x" {
+		t.Fatalf("expected a lone esc once nothing follows it, got %q", got)
+	}
+}
+
+func TestWriteShiftArrowsSelectAndTypeOver(t *testing.T) {
+	e := newTestWriteEditor("hello brave world")
+	e.cx = 6
+	feedKeys(t, e, wKeyCtrlShiftRight)
+	if !e.selecting {
+		t.Fatal("expected a selection")
+	}
+	a, b := e.selection()
+	if got := e.textBetween(a, b); got != "brave" {
+		t.Fatalf("expected ctrl+shift+right to select a word, got %q", got)
+	}
+	if !strings.Contains(e.frame(), bg(wColSelect)+"brave") {
+		t.Fatal("expected the selection to be painted")
+	}
+	feedKeys(t, e, "new")
+	if got := editorText(e); got != "hello new world" || e.selecting {
+		t.Fatalf("expected typing to replace the selection, got %q", got)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "hello brave world" {
+		t.Fatalf("expected typing over a selection to undo in one step, got %q", got)
+	}
+
+	e = newTestWriteEditor("one\ntwo\nthree")
+	e.cx = 1
+	feedKeys(t, e, wKeyShiftDown+wKeyShiftRight+wKeyBack)
+	if got := editorText(e); got != "oo\nthree" {
+		t.Fatalf("expected backspace to delete a multi-line selection, got %q", got)
+	}
+	e = newTestWriteEditor("abc")
+	e.cx = 3
+	feedKeys(t, e, wKeyShiftLeft+wKeyShiftLeft+wKeyLeft)
+	if e.selecting || e.cx != 1 {
+		t.Fatalf("expected left to collapse to the start of the selection, got %d selecting=%v", e.cx, e.selecting)
+	}
+}
+
+func TestWriteCopyCutPasteAndKillRing(t *testing.T) {
+	e := newTestWriteEditor("alpha beta\ngamma")
+	e.cx = 7
+	feedKeys(t, e, "\x17"+wKeyCopy)
+	if len(e.clip.ring) != 1 || e.clip.ring[0].text != "beta" {
+		t.Fatalf("expected ctrl+w then ctrl+c to copy the word, got %+v", e.clip.ring)
+	}
+	if want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("beta")) + "\x07"; e.takeOSC() != want {
+		t.Fatal("expected the copy to reach the system clipboard through OSC 52")
+	}
+	if e.takeOSC() != "" {
+		t.Fatal("expected the OSC sequence to be sent once")
+	}
+
+	e.cy, e.cx = 1, 0
+	feedKeys(t, e, wKeyCut)
+	if got := editorText(e); got != "alpha beta" {
+		t.Fatalf("expected ctrl+x without a selection to cut the line, got %q", got)
+	}
+	feedKeys(t, e, wKeyPaste)
+	if got := editorText(e); got != "gamma\nalpha beta" || e.cy != 1 {
+		t.Fatalf("expected the cut line to paste above the cursor, got %q at line %d", got, e.cy)
+	}
+	feedKeys(t, e, "\x1by")
+	if got := editorText(e); got != "betaalpha beta" {
+		t.Fatalf("expected alt+y to swap in the older entry, got %q", got)
+	}
+	feedKeys(t, e, "\x1by")
+	if got := editorText(e); got != "gamma\nalpha beta" {
+		t.Fatalf("expected alt+y to cycle back round the ring, got %q", got)
+	}
+	feedKeys(t, e, wKeyUndo+wKeyUndo+wKeyUndo)
+	if got := editorText(e); got != "alpha beta" {
+		t.Fatalf("unexpected text after undo %q", got)
+	}
+
+	e = newTestWriteEditor("x")
+	feedKeys(t, e, wKeyPaste)
+	if editorText(e) != "x" || e.message == "" {
+		t.Fatal("expected an empty kill ring to say so")
+	}
+	feedKeys(t, e, "\x01\x01"+wKeyCopy)
+	if e.clip.ring[0].text != "x" {
+		t.Fatalf("expected ctrl+a to select the line, got %q", e.clip.ring[0].text)
+	}
+}
+
+func TestWriteBracketedPasteIsVerbatim(t *testing.T) {
+	e := newTestWriteEditor("- item")
+	e.cx = 6
+	feedKeys(t, e, wPasteStart+"\r\n- next\r\n\tcode\x07"+wPasteEnd)
+	if got := editorText(e); got != "- item\n- next\n\tcode" {
+		t.Fatalf("expected the paste without list continuation, got %q", got)
+	}
+	if e.cy != 2 || e.cx != 5 {
+		t.Fatalf("expected the cursor after the pasted text, got %d,%d", e.cy, e.cx)
+	}
+	feedKeys(t, e, wKeyUndo)
+	if got := editorText(e); got != "- item" {
+		t.Fatalf("expected the paste to undo in one step, got %q", got)
+	}
+
+	feedKeys(t, e, wKeyFind+wPasteStart+"item\nrest"+wPasteEnd)
+	if e.search.input != "item" {
+		t.Fatalf("expected a paste into the prompt to keep its first line, got %q", e.search.input)
+	}
+}
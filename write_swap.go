@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Swap files — while jot write is open, unsaved changes are written every
+// few seconds to a hidden swap file beside the document, or under
+// ~/.jot/swap when that folder is read-only. Saving or quitting removes
+// it, so a swap file left behind means the editor died, and the next
+// jot write of the same path offers to bring the changes back.
+// ---------------------------------------------------------------------------
+
+const wSwapInterval = 3 * time.Second
+
+type wSwapFile struct {
+	Path    string    `json:"path"`
+	PID     int       `json:"pid"`
+	Updated time.Time `json:"updated"`
+	Content string    `json:"content"`
+}
+
+// wSwapDir is where swap files go when the document's folder is not
+// writable. Tests point it elsewhere.
+var wSwapDir = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".jot", "swap"), nil
+}
+
+// wSwapPaths returns the places a swap file for path may live, beside it
+// first.
+func wSwapPaths(path string) []string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	paths := []string{filepath.Join(filepath.Dir(abs), "."+filepath.Base(abs)+".jot-swp")}
+	if dir, err := wSwapDir(); err == nil {
+		sum := sha256.Sum256([]byte(abs))
+		paths = append(paths, filepath.Join(dir, hex.EncodeToString(sum[:8])+"-"+filepath.Base(abs)+".jot-swp"))
+	}
+	return paths
+}
+
+// wSwap writes the swap file for one editing session.
+type wSwap struct {
+	path  string
+	paths []string
+	// at is the swap file written last, so it can be removed.
+	at string
+}
+
+func newWSwap(path string) *wSwap {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return &wSwap{path: abs, paths: wSwapPaths(path)}
+}
+
+// write saves content to the first swap location that accepts it.
+func (s *wSwap) write(content string, now time.Time) error {
+	data, err := json.Marshal(wSwapFile{Path: s.path, PID: os.Getpid(), Updated: now, Content: content})
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for i, candidate := range s.paths {
+		// Only the fallback folder is created; the document's own folder
+		// has to exist for it to be saved anyway.
+		if i > 0 {
+			if err := os.MkdirAll(filepath.Dir(candidate), 0o700); err != nil {
+				firstErr = wFirstErr(firstErr, err)
+				continue
+			}
+		}
+		if err := writeFileAtomic(candidate, data, 0o600); err != nil {
+			firstErr = wFirstErr(firstErr, err)
+			continue
+		}
+		if s.at != "" && s.at != candidate {
+			_ = os.Remove(s.at)
+		}
+		s.at = candidate
+		return nil
+	}
+	return firstErr
+}
+
+func wFirstErr(first, err error) error {
+	if first != nil {
+		return first
+	}
+	return err
+}
+
+// remove deletes the swap file once the document is saved or discarded.
+func (s *wSwap) remove() {
+	if s.at != "" {
+		_ = os.Remove(s.at)
+		s.at = ""
+	}
+}
+
+// wFindSwap returns the newest swap file left for path.
+func wFindSwap(path string) *wSwapFile {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	var found *wSwapFile
+	for _, candidate := range wSwapPaths(path) {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		var swap wSwapFile
+		if err := json.Unmarshal(data, &swap); err != nil || swap.Path != abs {
+			continue
+		}
+		if found == nil || swap.Updated.After(found.Updated) {
+			found = &swap
+		}
+	}
+	return found
+}
+
+// offerSwapRecovery asks whether to restore the changes in a swap file left
+// for path. It returns the content to edit and whether it came from the
+// swap file. Declining deletes the swap file unless another jot is still
+// writing it.
+func offerSwapRecovery(in *bufio.Reader, w io.Writer, path, current string, now time.Time) (string, bool, error) {
+	swap := wFindSwap(path)
+	if swap == nil {
+		return current, false, nil
+	}
+	if swap.Content == current {
+		wRemoveSwaps(path)
+		return current, false, nil
+	}
+	live := swap.PID != os.Getpid() && daemonProcessAlive(swap.PID)
+	fmt.Fprintln(w, fg(wColUnsaved)+"  unsaved changes found"+wrst+" for "+filepath.Base(path)+
+		fg(wColMuted)+" · autosaved "+humanDuration(now.Sub(swap.Updated))+" ago"+wrst)
+	if live {
+		fmt.Fprintln(w, fg(wColMuted)+fmt.Sprintf("  another jot write (pid %d) still has this file open", swap.PID)+wrst)
+	}
+	restore, err := promptYesNo(in, w, "  recover them? [y/N] ")
+	if err != nil {
+		return "", false, err
+	}
+	if restore {
+		return swap.Content, true, nil
+	}
+	if !live {
+		wRemoveSwaps(path)
+	}
+	return current, false, nil
+}
+
+func wRemoveSwaps(path string) {
+	for _, candidate := range wSwapPaths(path) {
+		_ = os.Remove(candidate)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withTestSwapDir(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "swap")
+	previous := wSwapDir
+	wSwapDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { wSwapDir = previous })
+	return dir
+}
+
+func TestWriteSwapFileBesideTheDocument(t *testing.T) {
+	withTestSwapDir(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	swap := newWSwap(path)
+	if err := swap.write("draft one", now); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	beside := filepath.Join(dir, ".notes.md.jot-swp")
+	if swap.at != beside {
+		t.Fatalf("expected the swap file beside the document, got %q", swap.at)
+	}
+	info, err := os.Stat(beside)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a private swap file, got %v, %v", info, err)
+	}
+	found := wFindSwap(path)
+	if found == nil || found.Content != "draft one" || !found.Updated.Equal(now) || found.PID != os.Getpid() {
+		t.Fatalf("unexpected swap %+v", found)
+	}
+	if wFindSwap(filepath.Join(dir, "other.md")) != nil {
+		t.Fatal("expected no swap file for another document")
+	}
+	swap.remove()
+	if _, err := os.Stat(beside); !os.IsNotExist(err) {
+		t.Fatalf("expected remove to delete the swap file, got %v", err)
+	}
+}
+
+func TestWriteSwapFallsBackToTheSwapFolder(t *testing.T) {
+	swapDir := withTestSwapDir(t)
+	path := filepath.Join(t.TempDir(), "missing", "notes.md")
+	swap := newWSwap(path)
+	if err := swap.write("draft", time.Now()); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	if filepath.Dir(swap.at) != swapDir || !strings.HasSuffix(swap.at, "-notes.md.jot-swp") {
+		t.Fatalf("expected the swap file under the swap folder, got %q", swap.at)
+	}
+	if found := wFindSwap(path); found == nil || found.Content != "draft" {
+		t.Fatalf("expected to find the fallback swap file, got %+v", found)
+	}
+}
+
+func TestWriteOfferSwapRecovery(t *testing.T) {
+	withTestSwapDir(t)
+	path := filepath.Join(t.TempDir(), "notes.md")
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	writeSwap := func(content string) {
+		t.Helper()
+		swap := newWSwap(path)
+		if err := swap.write(content, now.Add(-2*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offer := func(answer, current string) (string, bool, string) {
+		t.Helper()
+		var out strings.Builder
+		content, recovered, err := offerSwapRecovery(bufio.NewReader(strings.NewReader(answer)), &out, path, current, now)
+		if err != nil {
+			t.Fatalf("offerSwapRecovery returned error: %v", err)
+		}
+		return content, recovered, wStripANSI(out.String())
+	}
+
+	writeSwap("saved text\nplus unsaved lines")
+	content, recovered, out := offer("y\n", "saved text")
+	if !recovered || content != "saved text\nplus unsaved lines" {
+		t.Fatalf("expected the swap content back, got %q recovered=%v", content, recovered)
+	}
+	if !strings.Contains(out, "unsaved changes found for notes.md · autosaved 2m ago") || !strings.Contains(out, "recover them? [y/N]") {
+		t.Fatalf("unexpected prompt %q", out)
+	}
+
+	content, recovered, _ = offer("\n", "saved text")
+	if recovered || content != "saved text" {
+		t.Fatalf("expected declining to keep the file on disk, got %q", content)
+	}
+	if wFindSwap(path) != nil {
+		t.Fatal("expected declining to delete the swap file")
+	}
+
+	writeSwap("same")
+	if _, recovered, out := offer("", "same"); recovered || out != "" || wFindSwap(path) != nil {
+		t.Fatalf("expected a swap file matching the document to be dropped quietly, got %q", out)
+	}
+}
+
+func TestWriteRecoveredEditorStaysUnsavedThroughUndo(t *testing.T) {
+	e := newTestWriteEditor("recovered")
+	e.saved = false
+	e.history.savedSeq = -1
+	feedKeys(t, e, "x"+wKeyUndo)
+	if e.saved {
+		t.Fatal("expected undo back to the recovered text to stay unsaved")
+	}
+	if e.rev != 2 {
+		t.Fatalf("expected the edit and the undo to bump rev, got %d", e.rev)
+	}
+}
+
+func TestWriteSaveFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real.md")
+	link := filepath.Join(dir, "link.md")
+	if err := os.WriteFile(real, []byte("old"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := writeEditorFileAtomic(link, []byte("new")); err != nil {
+		t.Fatalf("save returned error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the link to survive the save, got %v, %v", info, err)
+	}
+	data, err := os.ReadFile(real)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("expected the target to hold the new text, got %q, %v", data, err)
+	}
+	if info, err := os.Stat(real); err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected the target to keep its mode, got %v, %v", info, err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"sort"
+	"unicode"
+)
+
+// ---------------------------------------------------------------------------
+// Display width — terminals give East Asian wide characters and most emoji
+// two columns and combining marks none. The cursor steps over whole
+// grapheme clusters, so an accented letter, a flag, or a joined emoji moves
+// and deletes as one character.
+// ---------------------------------------------------------------------------
+
+// wWideRanges lists the East Asian Wide and Fullwidth blocks and the emoji
+// blocks terminals draw two columns wide, sorted by start.
+var wWideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// wRuneWidth is the number of columns r takes on its own.
+func wRuneWidth(r rune) int {
+	switch {
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf),
+		r >= 0x1160 && r <= 0x11FF, // Hangul vowels and finals join the syllable
+		r >= 0xFE00 && r <= 0xFE0F,
+		r >= 0xE0100 && r <= 0xE01EF:
+		return 0
+	}
+	i := sort.Search(len(wWideRanges), func(i int) bool { return wWideRanges[i][1] >= r })
+	if i < len(wWideRanges) && wWideRanges[i][0] <= r {
+		return 2
+	}
+	return 1
+}
+
+func wIsRegional(r rune) bool { return r >= 0x1F1E6 && r <= 0x1F1FF }
+
+// wExtends reports whether r continues the grapheme cluster that prev is
+// part of: combining marks, variation selectors, skin tones, emoji tags,
+// and anything joined with a zero-width joiner.
+func wExtends(prev, r rune) bool {
+	switch {
+	case prev == 0x200D, r == 0x200D:
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xE0020 && r <= 0xE007F:
+		return true
+	case r >= 0x1160 && r <= 0x11FF:
+		return prev >= 0x1100 && prev <= 0x11FF
+	}
+	return false
+}
+
+// wWidthAfter is the columns r adds after prev. Runes that continue a
+// cluster add nothing, except the emoji variation selector, which widens a
+// narrow symbol like ❤ to emoji width.
+func wWidthAfter(prev, r rune) int {
+	if prev != 0 && wExtends(prev, r) {
+		if r == 0xFE0F && wRuneWidth(prev) == 1 {
+			return 1
+		}
+		return 0
+	}
+	return wRuneWidth(r)
+}
+
+// wStrWidth is the number of columns s takes in a terminal.
+func wStrWidth(s string) int {
+	n := 0
+	var prev rune
+	for _, r := range s {
+		n += wWidthAfter(prev, r)
+		prev = r
+	}
+	return n
+}
+
+// wNextBoundary returns the start of the grapheme cluster after the one
+// at i. A pair of regional indicators makes one flag.
+func wNextBoundary(line []rune, i int) int {
+	if i >= len(line) {
+		return len(line)
+	}
+	j := i + 1
+	if wIsRegional(line[i]) && j < len(line) && wIsRegional(line[j]) {
+		j++
+	}
+	for j < len(line) && wExtends(line[j-1], line[j]) {
+		j++
+	}
+	return j
+}
+
+// wPrevBoundary returns the start of the grapheme cluster before i.
+func wPrevBoundary(line []rune, i int) int {
+	prev := 0
+	for j := 0; j < i && j < len(line); {
+		prev = j
+		j = wNextBoundary(line, j)
+	}
+	return prev
+}
+
+// wColToIndex returns the rune index in line[start:end] whose cluster
+// covers display column col, counted from start.
+func wColToIndex(line []rune, start, end, col int) int {
+	i, acc := start, 0
+	for i < end {
+		j := wNextBoundary(line, i)
+		w := wStrWidth(string(line[i:j]))
+		if acc+w > col {
+			break
+		}
+		acc += w
+		i = j
+	}
+	return i
+}
+
+// wWrapLine returns the rune offsets where each row of line starts when it
+// is wrapped to width columns. Rows break after the last space that fits;
+// a word longer than a row breaks where it reaches the edge. Spaces may
+// hang past the edge instead of starting the next row.
+func wWrapLine(line []rune, width int) []int {
+	starts := []int{0}
+	start, col, lastBreak := 0, 0, -1
+	for i := 0; i < len(line); {
+		j := wNextBoundary(line, i)
+		w := wStrWidth(string(line[i:j]))
+		space := unicode.IsSpace(line[i])
+		if !space && col+w > width && i > start {
+			brk := i
+			if lastBreak > start {
+				brk = lastBreak
+			}
+			starts = append(starts, brk)
+			start, lastBreak = brk, -1
+			col = wStrWidth(string(line[brk:i]))
+		}
+		col += w
+		if space {
+			lastBreak = j
+		}
+		i = j
+	}
+	return starts
+}
+
+// wSegOf returns which row of a wrapped line holds rune offset cx.
+func wSegOf(starts []int, cx int) int {
+	k := 0
+	for k+1 < len(starts) && starts[k+1] <= cx {
+		k++
+	}
+	return k
+}
+
+// wSliceANSI keeps the visible runes of s with index in [from, to) and
+// every escape code before to, so the slice starts in the right colors.
+func wSliceANSI(s string, from, to int) string {
+	var b []rune
+	r := []rune(s)
+	idx := 0
+	for i := 0; i < len(r) && idx < to; {
+		if r[i] == '\x1b' && i+1 < len(r) && r[i+1] == '[' {
+			j := i + 2
+			for j < len(r) && (r[j] < '@' || r[j] > '~') {
+				j++
+			}
+			b = append(b, r[i:wMin(j+1, len(r))]...)
+			i = j + 1
+			continue
+		}
+		if idx >= from {
+			b = append(b, r[i])
+		}
+		idx++
+		i++
+	}
+	return string(b)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteStrWidth(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{in: "hello", want: 5},
+		{in: "日本語", want: 6},
+		{in: "ｆｕｌｌ", want: 8},
+		{in: "한국어", want: 6},
+		{in: "é", want: 1},
+		{in: "👍🏽", want: 2},
+		{in: "👨‍👩‍👧", want: 2},
+		{in: "🇬🇧", want: 2},
+		{in: "❤️", want: 2},
+		{in: "a​b", want: 2},
+	}
+	for _, tc := range cases {
+		if got := wStrWidth(tc.in); got != tc.want {
+			t.Errorf("wStrWidth(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+	if got := wVisLen(fg(wColText) + "日本" + wrst); got != 4 {
+		t.Fatalf("expected wVisLen to skip escapes and count wide runes, got %d", got)
+	}
+	if got := wStripANSI(wTruncANSI("ab日本", 3)); got != "ab" {
+		t.Fatalf("expected truncation not to split a wide rune, got %q", got)
+	}
+}
+
+func TestWriteCursorMovesByGraphemeCluster(t *testing.T) {
+	flag := "\U0001F1EC\U0001F1E7"
+	family := "\U0001F468\u200D\U0001F469\u200D\U0001F467"
+	e := newTestWriteEditor("a" + "e\u0301" + flag + family + "z")
+	e.cx = e.lineLen()
+	var stops []int
+	for e.cx > 0 {
+		feedKeys(t, e, wKeyLeft)
+		stops = append(stops, e.cx)
+	}
+	if got, want := stops, []int{10, 5, 3, 1, 0}; !equalInts(got, want) {
+		t.Fatalf("expected left to stop at cluster starts %v, got %v", want, got)
+	}
+	e.cx = 5
+	feedKeys(t, e, wKeyBack)
+	if got := editorText(e); got != "ae\u0301"+family+"z" {
+		t.Fatalf("expected backspace to delete the whole flag, got %q", got)
+	}
+	e.cx = 1
+	feedKeys(t, e, "\x1b[3~")
+	if got := editorText(e); got != "a"+family+"z" {
+		t.Fatalf("expected delete to remove the letter and its accent, got %q", got)
+	}
+
+	e = newTestWriteEditor("日本語のテキスト\nabcdef")
+	e.cx = 2
+	feedKeys(t, e, "\x1b[B")
+	if e.cx != 4 {
+		t.Fatalf("expected down to keep the display column, got cx %d", e.cx)
+	}
+	feedKeys(t, e, wKeyUp)
+	if e.cx != 2 {
+		t.Fatalf("expected up to land on the wide rune at that column, got cx %d", e.cx)
+	}
+	if frame := e.frame(); !strings.HasSuffix(frame, "\r\x1b[10C") {
+		t.Fatalf("expected the terminal cursor after two wide runes, got %q", frame[len(frame)-12:])
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWriteWrapLineBreaksAtWords(t *testing.T) {
+	cases := []struct {
+		in    string
+		width int
+		want  []string
+	}{
+		{in: "the quick brown fox", width: 10, want: []string{"the quick ", "brown fox"}},
+		{in: "abcdefghij", width: 4, want: []string{"abcd", "efgh", "ij"}},
+		{in: "go   far", width: 3, want: []string{"go   ", "far"}},
+		{in: "日本語のテキスト", width: 7, want: []string{"日本語", "のテキ", "スト"}},
+		{in: "", width: 5, want: []string{""}},
+	}
+	for _, tc := range cases {
+		r := []rune(tc.in)
+		starts := wWrapLine(r, tc.width)
+		var got []string
+		for i, start := range starts {
+			end := len(r)
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			got = append(got, string(r[start:end]))
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("wWrapLine(%q, %d) = %q, want %q", tc.in, tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestWriteSoftWrapRendersContinuationRows(t *testing.T) {
+	long := strings.Repeat("word ", 60)
+	e := newTestWriteEditor("# Title\n" + long + "\nlast")
+	e.W, e.visH = 40, 6
+	feedKeys(t, e, "\x1bz")
+	if !e.wrap {
+		t.Fatal("expected alt+z to turn on soft wrap")
+	}
+	rows := e.layoutRows()
+	if rows[1].line != 1 || rows[1].cont || !rows[2].cont || rows[2].start == 0 {
+		t.Fatalf("expected the long line to span rows, got %+v", rows)
+	}
+	plain := wStripANSI(e.frame())
+	if !strings.Contains(plain, "  2   word word") || !strings.Contains(plain, "  ↪   word") {
+		t.Fatalf("expected a numbered first row and marked continuation rows, got %q", plain)
+	}
+	if strings.Contains(plain, "›") {
+		t.Fatal("expected no truncation marker when wrapping")
+	}
+
+	e.cy, e.cx = 1, 0
+	feedKeys(t, e, "\x1b[B")
+	if e.cy != 1 || e.cx == 0 {
+		t.Fatalf("expected down to move within the wrapped line, got %d,%d", e.cy, e.cx)
+	}
+	e.cy, e.cx = 2, 0
+	e.scroll()
+	if got := e.rowsToCursor(); got != e.visH-1 {
+		t.Fatalf("expected scrolling to keep the cursor on the last row, got row %d", got)
+	}
+	if e.scrollY != 1 || e.scrollSeg == 0 {
+		t.Fatalf("expected the view to start partway through the wrapped line, got %d/%d", e.scrollY, e.scrollSeg)
+	}
+
+	feedKeys(t, e, "\x1bz")
+	e.scroll()
+	if e.wrap || e.scrollSeg != 0 || !strings.Contains(wStripANSI(e.frame()), "›") {
+		t.Fatal("expected alt+z to switch back to truncated lines")
+	}
+}